@@ -9,33 +9,24 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"path"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
-	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+	"github.com/hyperledger/fabric-samples/client-go/submit"
 	"google.golang.org/grpc/status"
 )
 
 const (
-	mspID         = "Org1MSP"
-	cryptoPath    = "../../test-network/organizations/peerOrganizations/org1.example.com"
-	certPath      = cryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem"
-	keyPath       = cryptoPath + "/users/User1@org1.example.com/msp/keystore/"
-	tlsCertPath   = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
-	peerEndpoint  = "localhost:7051"
-	gatewayPeer   = "peer0.org1.example.com"
 	channelName   = "mychannel"
 	chaincodeName = "basic"
+	// clientProfile names the client identity and gateway peer, which can be overridden by HLF_* environment variables.
+	clientProfile = "clientProfile.yaml"
 )
 
 var now = time.Now()
@@ -44,12 +35,27 @@ var assetId = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
 func main() {
 	log.Println("============ application-golang starts ============")
 
+	p, err := profile.Load(clientProfile)
+	if err != nil {
+		panic(err)
+	}
+
 	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	clientConnection := newGrpcConnection()
+	clientConnection, err := p.NewConnection()
+	if err != nil {
+		panic(err)
+	}
 	defer clientConnection.Close()
 
-	id := newIdentity()
-	sign := newSign()
+	id, err := p.NewIdentity()
+	if err != nil {
+		panic(err)
+	}
+	sign, closeSign, err := p.NewSign(profile.WithAuditLog(log.Default()))
+	if err != nil {
+		panic(err)
+	}
+	defer closeSign()
 
 	// Create a Gateway connection for a specific client identity
 	gateway, err := client.Connect(
@@ -88,74 +94,10 @@ func main() {
 	fmt.Println("exampleErrorHandling:")
 	exampleErrorHandling(contract)
 
-	log.Println("============ application-golang ends ============")
-}
-
-// newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection() *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
-	if err != nil {
-		panic(err)
-	}
-
-	certPool := x509.NewCertPool()
-	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
-
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
-	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
-	}
+	fmt.Println("exampleRetryingSubmit:")
+	exampleRetryingSubmit(contract)
 
-	return connection
-}
-
-// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity() *identity.X509Identity {
-	certificate, err := loadCertificate(certPath)
-	if err != nil {
-		panic(err)
-	}
-
-	id, err := identity.NewX509Identity(mspID, certificate)
-	if err != nil {
-		panic(err)
-	}
-
-	return id
-}
-
-func loadCertificate(filename string) (*x509.Certificate, error) {
-	certificatePEM, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read certificate file: %w", err)
-	}
-	return identity.CertificateFromPEM(certificatePEM)
-}
-
-// newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign() identity.Sign {
-	files, err := ioutil.ReadDir(keyPath)
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
-	}
-	privateKeyPEM, err := ioutil.ReadFile(path.Join(keyPath, files[0].Name()))
-
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
-	}
-
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
-	if err != nil {
-		panic(err)
-	}
-
-	sign, err := identity.NewPrivateKeySign(privateKey)
-	if err != nil {
-		panic(err)
-	}
-
-	return sign
+	log.Println("============ application-golang ends ============")
 }
 
 // This type of transaction would typically only be run once by an application the first time it was started after its
@@ -264,6 +206,20 @@ func exampleErrorHandling(contract *client.Contract) {
 	}
 }
 
+// Submit a transaction with a helper that classifies failures, and retries the retryable ones with a new endorsement.
+// A transaction that might already be committed is never submitted again.
+func exampleRetryingSubmit(contract *client.Contract) {
+	fmt.Println("Submit Transaction: UpdateAsset asset70, asset70 does not exist and should return a chaincode error that is not retried")
+
+	submitter := submit.New(submit.NewContract(contract), submit.WithAttempts(3))
+	_, err := submitter.Submit(context.Background(), "UpdateAsset", client.WithArguments("asset70", "blue", "5", "Tomoko", "300"))
+
+	var submitErr *submit.Error
+	if errors.As(err, &submitErr) {
+		fmt.Printf("Submit failed with a %s after %d attempts, transaction %s may be committed: %t\n", submitErr.Kind, submitErr.Attempts, submitErr.TransactionID, submitErr.MayBeCommitted)
+	}
+}
+
 // Format JSON data
 func formatJSON(data []byte) string {
 	var prettyJSON bytes.Buffer
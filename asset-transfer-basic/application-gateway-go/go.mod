@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/hyperledger/fabric-gateway v1.1.0
 	github.com/hyperledger/fabric-protos-go-apiv2 v0.0.0-20220615102044-467be1c7b2e7
+	github.com/hyperledger/fabric-samples/client-go v0.0.0
 	google.golang.org/grpc v1.47.0
 )
 
@@ -16,4 +17,7 @@ require (
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/genproto v0.0.0-20220527130721-00d5c0f3be58 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/hyperledger/fabric-samples/client-go => ../../client-go
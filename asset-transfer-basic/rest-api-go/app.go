@@ -0,0 +1,104 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+)
+
+// shutdownTimeout bounds the time to finish the HTTP requests in progress when the server stops.
+const shutdownTimeout = 10 * time.Second
+
+func init() {
+	expvar.Publish("hlf_sign_latency", profile.SignLatency)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "\nUnexpected application error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	orgs := map[string]evaluator{}
+	submitters := map[string]submitter{}
+	apiKeys := map[string]string{}
+	for _, orgCfg := range cfg.orgs {
+		org, err := connectOrg(cfg, orgCfg)
+		if err != nil {
+			return fmt.Errorf("failed to connect to the gateway peers of %s: %w", orgCfg.profile.MSPID, err)
+		}
+		defer org.close()
+
+		orgs[org.mspID] = org
+		submitters[org.mspID] = org
+		apiKeys[orgCfg.apiKey] = org.mspID
+	}
+
+	store, err := newJobStore(cfg.jobStoreType, cfg.jobStoreFile)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	queue := newJobQueue(store, submitters, cfg)
+	queueDone := make(chan error, 1)
+	go func() {
+		queueDone <- queue.run(ctx)
+	}()
+
+	s := &server{orgs: orgs, apiKeys: apiKeys, jobs: queue}
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.port),
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverDone := make(chan error, 1)
+	go func() {
+		log.Printf("REST server listening on port %d", cfg.port)
+		serverDone <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverDone:
+		stop()
+		<-queueDone
+		return err
+	case err := <-queueDone:
+		httpServer.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Failed to shut down HTTP server: %v", err)
+	}
+	return <-queueDone
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+)
+
+// config holds the settings of the REST server, which are read from environment variables. The defaults connect to
+// the Fabric test network.
+type config struct {
+	port          int
+	channelName   string
+	chaincodeName string
+	orgs          []orgConfig
+
+	evaluateTimeout time.Duration
+	endorseTimeout  time.Duration
+	submitTimeout   time.Duration
+	commitTimeout   time.Duration
+
+	// Attempts to submit the transaction of a job before the job fails.
+	submitJobAttempts int
+	// Backoff between attempts, either fixed or exponential.
+	submitJobBackoffType  string
+	submitJobBackoffDelay time.Duration
+	// Number of jobs processed in parallel.
+	submitJobConcurrency int
+
+	// Job store backend, either memory or sqlite.
+	jobStoreType string
+	jobStoreFile string
+}
+
+// orgConfig is the client profile used for the requests of an organization, which names its identity and gateway
+// peers. Clients are mapped to the organization by their API key.
+type orgConfig struct {
+	apiKey  string
+	profile *profile.Profile
+}
+
+func loadConfig() (*config, error) {
+	org1, err := loadOrgConfig("Org1")
+	if err != nil {
+		return nil, err
+	}
+	org2, err := loadOrgConfig("Org2")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{
+		channelName:          envOrDefault("HLF_CHANNEL_NAME", "mychannel"),
+		chaincodeName:        envOrDefault("HLF_CHAINCODE_NAME", "basic"),
+		orgs:                 []orgConfig{*org1, *org2},
+		submitJobBackoffType: envOrDefault("SUBMIT_JOB_BACKOFF_TYPE", "fixed"),
+		jobStoreType:         envOrDefault("JOB_STORE", "memory"),
+		jobStoreFile:         envOrDefault("JOB_STORE_FILE", "jobs.sqlite"),
+	}
+	if cfg.submitJobBackoffType != "fixed" && cfg.submitJobBackoffType != "exponential" {
+		return nil, fmt.Errorf("invalid SUBMIT_JOB_BACKOFF_TYPE value: %s", cfg.submitJobBackoffType)
+	}
+
+	ints := []struct {
+		key          string
+		defaultValue int
+		value        *int
+	}{
+		{"PORT", 3000, &cfg.port},
+		{"SUBMIT_JOB_ATTEMPTS", 5, &cfg.submitJobAttempts},
+		{"SUBMIT_JOB_CONCURRENCY", 5, &cfg.submitJobConcurrency},
+	}
+	for _, setting := range ints {
+		if *setting.value, err = positiveIntFromEnv(setting.key, setting.defaultValue); err != nil {
+			return nil, err
+		}
+	}
+
+	durations := []struct {
+		key          string
+		defaultValue int
+		unit         time.Duration
+		value        *time.Duration
+	}{
+		{"SUBMIT_JOB_BACKOFF_DELAY", 3000, time.Millisecond, &cfg.submitJobBackoffDelay},
+		{"HLF_QUERY_TIMEOUT", 3, time.Second, &cfg.evaluateTimeout},
+		{"HLF_ENDORSE_TIMEOUT", 30, time.Second, &cfg.endorseTimeout},
+		{"HLF_SUBMIT_TIMEOUT", 5, time.Second, &cfg.submitTimeout},
+		{"HLF_COMMIT_TIMEOUT", 300, time.Second, &cfg.commitTimeout},
+	}
+	for _, setting := range durations {
+		value, err := positiveIntFromEnv(setting.key, setting.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*setting.value = time.Duration(value) * setting.unit
+	}
+
+	return cfg, nil
+}
+
+// loadOrgConfig reads the API key and client profile of an organization, for instance ORG1_APIKEY and
+// org1Profile.yaml for Org1. The API key has no default and must be set. The settings of the profile can be overridden
+// by the HLF_* environment variables of the profile package with the organization as suffix, such as
+// HLF_PEER_ENDPOINT_ORG1.
+func loadOrgConfig(org string) (*orgConfig, error) {
+	apiKeyName := strings.ToUpper(org) + "_APIKEY"
+	apiKey := os.Getenv(apiKeyName)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s must be set", apiKeyName)
+	}
+
+	p, err := profile.Load(strings.ToLower(org)+"Profile.yaml", profile.WithEnvSuffix("_"+strings.ToUpper(org)))
+	if err != nil {
+		return nil, err
+	}
+	return &orgConfig{apiKey: apiKey, profile: p}, nil
+}
+
+func positiveIntFromEnv(key string, defaultValue int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result <= 0 {
+		return 0, fmt.Errorf("invalid %s value, must be a positive integer: %s", key, value)
+	}
+	return result, nil
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
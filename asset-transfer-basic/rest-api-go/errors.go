@@ -0,0 +1,72 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/client-go/submit"
+)
+
+// retryAction is the way the transaction of a job is retried after an error.
+type retryAction int
+
+const (
+	// retryWithNewTransactionID retries with a new proposal, for transactions that could not be committed.
+	retryWithNewTransactionID retryAction = iota
+	// retryWithExistingTransactionID retries with the saved proposal, for transactions that may have been sent to
+	// the orderer, so that the transaction is never committed twice. A transaction whose commit was saved is only
+	// checked again for its commit status.
+	retryWithExistingTransactionID
+	// noRetry fails the job, for errors from the smart contract, duplicate transactions and jobs of unknown
+	// organizations.
+	noRetry
+)
+
+// retryActionOf returns the way to retry a transaction after an error. Transactions are retried after any error
+// except errors from the smart contract and duplicate transaction errors, as classified by submit.Classify. You might
+// prefer to only retry transactions which fail with specific errors instead, for example MVCC_READ_CONFLICT,
+// PHANTOM_READ_CONFLICT or ENDORSEMENT_POLICY_FAILURE.
+func retryActionOf(err error) retryAction {
+	var commitErr *commitError
+	if errors.As(err, &commitErr) {
+		if commitErr.code == peer.TxValidationCode_DUPLICATE_TXID {
+			return noRetry
+		}
+		return retryWithNewTransactionID
+	}
+
+	var unknownOrgErr *unknownOrgError
+	if errors.As(err, &unknownOrgErr) {
+		return noRetry
+	}
+	switch submit.Classify(err) {
+	case submit.ChaincodeError, submit.DuplicateTransaction:
+		return noRetry
+	}
+
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	if errors.As(err, &submitErr) || errors.As(err, &commitStatusErr) {
+		return retryWithExistingTransactionID
+	}
+
+	return retryWithNewTransactionID
+}
+
+// The smart contract implementations of the basic asset transfer sample report missing assets with messages such as
+// "the asset %s does not exist", "The asset ${id} does not exist" or "Asset %s does not exist".
+var assetDoesNotExistRegexp = regexp.MustCompile(`([tT]he )?[aA]sset \S* does not exist`)
+
+// isAssetNotFoundError reports whether the smart contract failed because an asset does not exist.
+func isAssetNotFoundError(err error) bool {
+	message, ok := submit.ChaincodeMessage(err)
+	return ok && assetDoesNotExistRegexp.MatchString(message)
+}
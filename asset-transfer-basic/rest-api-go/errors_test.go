@@ -0,0 +1,103 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newEndorseError returns a gRPC status error like the one returned when peers fail to endorse a transaction.
+func newEndorseError(t *testing.T, message string, peerMessages ...string) error {
+	statusErr := status.New(codes.Aborted, message)
+	for _, peerMessage := range peerMessages {
+		var err error
+		statusErr, err = statusErr.WithDetails(&gateway.ErrorDetail{
+			Address: "peer0.org1.example.com:7051",
+			MspId:   "Org1MSP",
+			Message: peerMessage,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return statusErr.Err()
+}
+
+func TestRetryActionOf(t *testing.T) {
+	for name, test := range map[string]struct {
+		err      error
+		expected retryAction
+	}{
+		"contract error": {
+			err:      newEndorseError(t, "failed to endorse transaction", "chaincode response 500, the asset asset1 already exists"),
+			expected: noRetry,
+		},
+		"duplicate transaction": {
+			err:      newEndorseError(t, "failed to endorse transaction", "duplicate transaction found [tx1]. Creator [0a07]"),
+			expected: noRetry,
+		},
+		"duplicate transaction ID committed": {
+			err:      &commitError{transactionID: "tx1", code: peer.TxValidationCode_DUPLICATE_TXID},
+			expected: noRetry,
+		},
+		"unknown organization": {
+			err:      &unknownOrgError{mspID: "Org3MSP"},
+			expected: noRetry,
+		},
+		"invalid transaction": {
+			err:      &commitError{transactionID: "tx1", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+			expected: retryWithNewTransactionID,
+		},
+		"unavailable peer": {
+			err:      status.Error(codes.Unavailable, "connection refused"),
+			expected: retryWithNewTransactionID,
+		},
+		"timeout": {
+			err:      fmt.Errorf("failed: %w", context.DeadlineExceeded),
+			expected: retryWithNewTransactionID,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := retryActionOf(test.err); actual != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsAssetNotFoundError(t *testing.T) {
+	for _, message := range []string{
+		"chaincode response 500, the asset asset1 does not exist",
+		"chaincode response 500, The asset asset1 does not exist",
+		"chaincode response 500, Asset asset1 does not exist",
+	} {
+		if err := newEndorseError(t, "evaluate call to endorser returned error: "+message); !isAssetNotFoundError(err) {
+			t.Errorf("expected asset not found error for %q", message)
+		}
+		if err := newEndorseError(t, "failed to evaluate transaction", message); !isAssetNotFoundError(err) {
+			t.Errorf("expected asset not found error for detail %q", message)
+		}
+	}
+
+	for _, err := range []error{
+		newEndorseError(t, "failed to evaluate transaction", "chaincode response 500, the asset asset1 already exists"),
+		errors.New("the asset asset1 does not exist"),
+		nil,
+	} {
+		if isAssetNotFoundError(err) {
+			t.Errorf("expected %v not to be an asset not found error", err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+	"google.golang.org/grpc"
+)
+
+// orgGateway is the long-lived Gateway connection of an organization, which is used for all the requests of its
+// clients. Connections are not created per request.
+type orgGateway struct {
+	mspID      string
+	connection *grpc.ClientConn
+	closeSign  func() error
+	gateway    *client.Gateway
+	contract   *client.Contract
+	qscc       *client.Contract
+	channel    string
+}
+
+// connectOrg connects to the gateway peers of an organization using the client identity of its profile.
+func connectOrg(cfg *config, org orgConfig) (*orgGateway, error) {
+	connection, err := org.profile.NewConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	sign, closeSign, err := org.profile.NewSign(profile.WithAuditLog(log.Default()))
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	gateway, err := newGateway(cfg, org, sign, connection)
+	if err != nil {
+		closeSign()
+		connection.Close()
+		return nil, err
+	}
+
+	network := gateway.GetNetwork(cfg.channelName)
+	return &orgGateway{
+		mspID:      org.profile.MSPID,
+		connection: connection,
+		closeSign:  closeSign,
+		gateway:    gateway,
+		contract:   network.GetContract(cfg.chaincodeName),
+		qscc:       network.GetContract("qscc"),
+		channel:    cfg.channelName,
+	}, nil
+}
+
+func (o *orgGateway) close() {
+	o.gateway.Close()
+	o.closeSign()
+	o.connection.Close()
+}
+
+// evaluate evaluates a transaction of the asset contract.
+func (o *orgGateway) evaluate(ctx context.Context, transactionName string, args ...string) ([]byte, error) {
+	proposal, err := o.contract.NewProposal(transactionName, client.WithArguments(args...))
+	if err != nil {
+		return nil, err
+	}
+	return proposal.EvaluateWithContext(ctx)
+}
+
+// newProposal creates a proposal for a transaction of the asset contract, serialized so that it can be saved with a
+// job and submitted again with the same transaction ID.
+func (o *orgGateway) newProposal(transactionName string, args []string) ([]byte, string, error) {
+	proposal, err := o.contract.NewProposal(transactionName, client.WithArguments(args...))
+	if err != nil {
+		return nil, "", err
+	}
+	proposalBytes, err := proposal.Bytes()
+	if err != nil {
+		return nil, "", err
+	}
+	return proposalBytes, proposal.TransactionID(), nil
+}
+
+// endorseAndSubmit endorses a serialized proposal and submits the endorsed transaction to the orderer. It returns the
+// transaction result and the serialized commit, which is used to wait for the transaction to commit.
+func (o *orgGateway) endorseAndSubmit(ctx context.Context, proposalBytes []byte) ([]byte, []byte, error) {
+	proposal, err := o.gateway.NewProposal(proposalBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commit, err := transaction.SubmitWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commitBytes, err := commit.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return transaction.Result(), commitBytes, nil
+}
+
+// commitStatus waits for a submitted transaction to commit, and returns a *commitError if it is invalid.
+func (o *orgGateway) commitStatus(ctx context.Context, commitBytes []byte) error {
+	commit, err := o.gateway.NewCommit(commitBytes)
+	if err != nil {
+		return err
+	}
+
+	status, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !status.Successful {
+		return &commitError{transactionID: status.TransactionID, code: status.Code}
+	}
+	return nil
+}
+
+// ping checks the connection to the gateway peer by getting the height of the channel ledger.
+func (o *orgGateway) ping(ctx context.Context) error {
+	proposal, err := o.qscc.NewProposal("GetChainInfo", client.WithArguments(o.channel))
+	if err != nil {
+		return err
+	}
+	_, err = proposal.EvaluateWithContext(ctx)
+	return err
+}
+
+// commitError reports a transaction that was committed to the ledger as invalid.
+type commitError struct {
+	transactionID string
+	code          peer.TxValidationCode
+}
+
+func (e *commitError) Error() string {
+	return fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", e.transactionID, int32(e.code), e.code)
+}
+
+// newGateway connects to the Gateway server using the client identity of an organization and the configured timeouts
+// for the different gRPC calls.
+func newGateway(cfg *config, org orgConfig, sign identity.Sign, clientConnection *grpc.ClientConn) (*client.Gateway, error) {
+	id, err := org.profile.NewIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(cfg.evaluateTimeout),
+		client.WithEndorseTimeout(cfg.endorseTimeout),
+		client.WithSubmitTimeout(cfg.submitTimeout),
+		client.WithCommitStatusTimeout(cfg.commitTimeout),
+	)
+}
@@ -0,0 +1,24 @@
+module restApi
+
+go 1.18
+
+require (
+	github.com/hyperledger/fabric-gateway v1.1.0
+	github.com/hyperledger/fabric-protos-go-apiv2 v0.0.0-20220615102044-467be1c7b2e7
+	github.com/hyperledger/fabric-samples/client-go v0.0.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	google.golang.org/grpc v1.47.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/miekg/pkcs11 v1.1.1 // indirect
+	golang.org/x/net v0.0.0-20220526153639-5463443f8c37 // indirect
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+	golang.org/x/text v0.3.7 // indirect
+	google.golang.org/genproto v0.0.0-20220527130721-00d5c0f3be58 // indirect
+	google.golang.org/protobuf v1.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/hyperledger/fabric-samples/client-go => ../../client-go
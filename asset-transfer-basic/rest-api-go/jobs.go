@@ -0,0 +1,427 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job statuses. A job is waiting until a worker processes it, and delayed while it waits for a retry. Completed and
+// failed jobs are finished.
+const (
+	jobWaiting   = "waiting"
+	jobActive    = "active"
+	jobDelayed   = "delayed"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+// job is a request to submit a transaction, processed asynchronously by the job queue.
+type job struct {
+	ID              string   `json:"id"`
+	MSPID           string   `json:"mspId"`
+	TransactionName string   `json:"transactionName"`
+	TransactionArgs []string `json:"transactionArgs"`
+	Status          string   `json:"status"`
+	Attempts        int      `json:"attempts"`
+	// TransactionIDs of all the proposals created for the job, one per retry with a new transaction ID.
+	TransactionIDs []string `json:"transactionIds"`
+	// Proposal and Commit are the serialized proposal and commit of the current transaction, which are reused when
+	// the transaction is retried with its existing transaction ID.
+	Proposal []byte `json:"proposal,omitempty"`
+	Commit   []byte `json:"commit,omitempty"`
+	Result   []byte `json:"result,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (j *job) finished() bool {
+	return j.Status == jobCompleted || j.Status == jobFailed
+}
+
+// jobSummary is the representation of a job returned by the jobs endpoint.
+type jobSummary struct {
+	JobID              string   `json:"jobId"`
+	Status             string   `json:"status"`
+	Attempts           int      `json:"attempts"`
+	TransactionIDs     []string `json:"transactionIds"`
+	TransactionPayload *string  `json:"transactionPayload,omitempty"`
+	TransactionError   string   `json:"transactionError,omitempty"`
+}
+
+func (j *job) summary() *jobSummary {
+	summary := &jobSummary{
+		JobID:            j.ID,
+		Status:           j.Status,
+		Attempts:         j.Attempts,
+		TransactionIDs:   j.TransactionIDs,
+		TransactionError: j.Error,
+	}
+	if summary.TransactionIDs == nil {
+		summary.TransactionIDs = []string{}
+	}
+	if j.Status == jobCompleted {
+		payload := string(j.Result)
+		summary.TransactionPayload = &payload
+	}
+	return summary
+}
+
+var errJobNotFound = errors.New("job not found")
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// jobStore persists jobs. A store must be safe for concurrent use.
+type jobStore interface {
+	// add saves a new job and assigns it a random ID, so that job IDs cannot be guessed.
+	add(j *job) error
+	// get returns the job with given ID, or errJobNotFound.
+	get(id string) (*job, error)
+	update(j *job) error
+	// unfinished returns the jobs that are not completed or failed, in the order they were added.
+	unfinished() ([]*job, error)
+	close() error
+}
+
+// newJobStore returns the job store of given type: memory, or sqlite to keep jobs across restarts.
+func newJobStore(storeType string, fileName string) (jobStore, error) {
+	switch storeType {
+	case "memory":
+		return newMemoryJobStore(), nil
+	case "sqlite":
+		return newSQLiteJobStore(fileName)
+	default:
+		return nil, fmt.Errorf("invalid JOB_STORE value: %s", storeType)
+	}
+}
+
+// submitter submits the transactions of the jobs of an organization. It is implemented by *orgGateway.
+type submitter interface {
+	newProposal(transactionName string, args []string) (proposal []byte, transactionID string, err error)
+	endorseAndSubmit(ctx context.Context, proposal []byte) (result []byte, commit []byte, err error)
+	commitStatus(ctx context.Context, commit []byte) error
+}
+
+// jobQueue processes submit jobs with a pool of workers, retrying failed transactions with a backoff. Jobs that are
+// unfinished when the queue starts, for instance because the server stopped, are processed again.
+type jobQueue struct {
+	store       jobStore
+	submitters  map[string]submitter
+	attempts    int
+	backoff     func(attempt int) time.Duration
+	concurrency int
+
+	ready   chan string
+	stopped chan struct{}
+	stop    sync.Once
+
+	// queued are the IDs of the jobs waiting for or being processed by a worker, so that a job is never processed by
+	// two workers at once.
+	mutex  sync.Mutex
+	queued map[string]bool
+}
+
+func newJobQueue(store jobStore, submitters map[string]submitter, cfg *config) *jobQueue {
+	delay := cfg.submitJobBackoffDelay
+	backoff := func(attempt int) time.Duration {
+		return delay
+	}
+	if cfg.submitJobBackoffType == "exponential" {
+		backoff = func(attempt int) time.Duration {
+			return delay << (attempt - 1)
+		}
+	}
+
+	return &jobQueue{
+		store:       store,
+		submitters:  submitters,
+		attempts:    cfg.submitJobAttempts,
+		backoff:     backoff,
+		concurrency: cfg.submitJobConcurrency,
+		ready:       make(chan string),
+		stopped:     make(chan struct{}),
+		queued:      map[string]bool{},
+	}
+}
+
+// add queues a job to submit a transaction for an organization and returns the job ID.
+func (q *jobQueue) add(mspID string, transactionName string, args ...string) (string, error) {
+	j := &job{
+		MSPID:           mspID,
+		TransactionName: transactionName,
+		TransactionArgs: args,
+		Status:          jobWaiting,
+	}
+	if err := q.store.add(j); err != nil {
+		return "", fmt.Errorf("failed to add job: %w", err)
+	}
+
+	q.enqueue(j.ID, 0)
+	return j.ID, nil
+}
+
+// get returns the job with given ID, or errJobNotFound.
+func (q *jobQueue) get(id string) (*job, error) {
+	return q.store.get(id)
+}
+
+// enqueue hands a job to the workers after a delay, unless the job is already queued. Jobs still queued when the queue
+// stops are left unfinished in the store.
+func (q *jobQueue) enqueue(id string, delay time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.queued[id] {
+		return
+	}
+	q.queued[id] = true
+	q.schedule(id, delay)
+}
+
+func (q *jobQueue) schedule(id string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case q.ready <- id:
+		case <-q.stopped:
+		}
+	})
+}
+
+// run processes jobs until the context is cancelled, and waits for the jobs in progress to finish.
+func (q *jobQueue) run(ctx context.Context) error {
+	defer q.stop.Do(func() { close(q.stopped) })
+
+	unfinished, err := q.store.unfinished()
+	if err != nil {
+		return fmt.Errorf("failed to read unfinished jobs: %w", err)
+	}
+	for _, j := range unfinished {
+		q.enqueue(j.ID, 0)
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < q.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-q.ready:
+					if delay, retry := q.process(ctx, id); retry {
+						q.schedule(id, delay)
+					} else {
+						q.release(id)
+					}
+				}
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	workers.Wait()
+	return nil
+}
+
+func (q *jobQueue) release(id string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.queued, id)
+}
+
+// process makes an attempt to submit the transaction of a job, and either finishes the job or returns the delay before
+// it is retried.
+func (q *jobQueue) process(ctx context.Context, id string) (time.Duration, bool) {
+	j, err := q.store.get(id)
+	if err != nil {
+		log.Printf("Failed to read job %s: %v", id, err)
+		return 0, false
+	}
+	if j.finished() {
+		return 0, false
+	}
+
+	j.Status = jobActive
+	j.Attempts++
+	if err := q.store.update(j); err != nil {
+		log.Printf("Failed to update job %s: %v", id, err)
+		return 0, false
+	}
+
+	result, err := q.submit(ctx, j)
+	switch {
+	case err == nil:
+		j.Status = jobCompleted
+		j.Result = result
+		j.Proposal, j.Commit = nil, nil
+		j.Error = ""
+
+	case ctx.Err() != nil:
+		// Stopped while submitting, so the job is attempted again after a restart
+		j.Status = jobWaiting
+		j.Attempts--
+
+	default:
+		action := retryActionOf(err)
+		j.Error = err.Error()
+		if action == noRetry || j.Attempts >= q.attempts {
+			log.Printf("Job %s failed after %d attempts: %v", id, j.Attempts, err)
+			j.Status = jobFailed
+			j.Proposal, j.Commit = nil, nil
+			break
+		}
+
+		log.Printf("Job %s attempt %d failed, retrying: %v", id, j.Attempts, err)
+		if action == retryWithNewTransactionID {
+			j.Proposal, j.Commit, j.Result = nil, nil, nil
+		}
+		j.Status = jobDelayed
+	}
+
+	if err := q.store.update(j); err != nil {
+		log.Printf("Failed to update job %s: %v", id, err)
+		return 0, false
+	}
+	if j.Status != jobDelayed {
+		return 0, false
+	}
+	return q.backoff(j.Attempts), true
+}
+
+// submit submits the transaction of a job and waits for it to commit. The proposal and the commit of the transaction
+// are saved with the job before they are used, so that a retry with the existing transaction ID can resume from them.
+func (q *jobQueue) submit(ctx context.Context, j *job) ([]byte, error) {
+	submitter, ok := q.submitters[j.MSPID]
+	if !ok {
+		return nil, &unknownOrgError{mspID: j.MSPID}
+	}
+
+	if j.Proposal == nil {
+		proposal, transactionID, err := submitter.newProposal(j.TransactionName, j.TransactionArgs)
+		if err != nil {
+			return nil, err
+		}
+		j.Proposal = proposal
+		j.TransactionIDs = append(j.TransactionIDs, transactionID)
+		if err := q.store.update(j); err != nil {
+			return nil, err
+		}
+	}
+
+	if j.Commit == nil {
+		result, commit, err := submitter.endorseAndSubmit(ctx, j.Proposal)
+		if err != nil {
+			return nil, err
+		}
+		j.Result, j.Commit = result, commit
+		if err := q.store.update(j); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := submitter.commitStatus(ctx, j.Commit); err != nil {
+		return nil, err
+	}
+	return j.Result, nil
+}
+
+// unknownOrgError reports a job for an organization without a gateway connection, which can never succeed.
+type unknownOrgError struct {
+	mspID string
+}
+
+func (e *unknownOrgError) Error() string {
+	return fmt.Sprintf("no gateway connection for MSP ID %s", e.mspID)
+}
+
+// memoryJobStore keeps jobs in memory, so they are lost when the server stops.
+type memoryJobStore struct {
+	mutex sync.Mutex
+	jobs  map[string]*job
+	order []string
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{jobs: map[string]*job{}}
+}
+
+func (s *memoryJobStore) add(j *job) error {
+	id, err := newJobID()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	j.ID = id
+	s.jobs[j.ID] = copyJob(j)
+	s.order = append(s.order, j.ID)
+	return nil
+}
+
+func (s *memoryJobStore) get(id string) (*job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, errJobNotFound
+	}
+	return copyJob(j), nil
+}
+
+func (s *memoryJobStore) update(j *job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.jobs[j.ID]; !ok {
+		return errJobNotFound
+	}
+	s.jobs[j.ID] = copyJob(j)
+	return nil
+}
+
+func (s *memoryJobStore) unfinished() ([]*job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := []*job{}
+	for _, id := range s.order {
+		if j := s.jobs[id]; !j.finished() {
+			result = append(result, copyJob(j))
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryJobStore) close() error {
+	return nil
+}
+
+// copyJob copies a job, so that the store and the workers never share the slices of a job.
+func copyJob(j *job) *job {
+	result := *j
+	result.TransactionArgs = append([]string(nil), j.TransactionArgs...)
+	result.TransactionIDs = append([]string(nil), j.TransactionIDs...)
+	return &result
+}
@@ -0,0 +1,377 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// fakeSubmitter records the calls of the job queue, and fails the calls for which an error is queued.
+type fakeSubmitter struct {
+	mutex          sync.Mutex
+	proposals      int
+	endorsed       []string
+	statusChecks   []string
+	endorseErrors  []error
+	statusErrors   []error
+	blockEndorsing bool
+}
+
+func (s *fakeSubmitter) newProposal(transactionName string, args []string) ([]byte, string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.proposals++
+	transactionID := fmt.Sprintf("tx%d", s.proposals)
+	return []byte(transactionID), transactionID, nil
+}
+
+func (s *fakeSubmitter) endorseAndSubmit(ctx context.Context, proposal []byte) ([]byte, []byte, error) {
+	if s.blockEndorsing {
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.endorsed = append(s.endorsed, string(proposal))
+	if err := pop(&s.endorseErrors); err != nil {
+		return nil, nil, err
+	}
+	return []byte("result of " + string(proposal)), []byte("commit of " + string(proposal)), nil
+}
+
+func (s *fakeSubmitter) commitStatus(ctx context.Context, commit []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.statusChecks = append(s.statusChecks, string(commit))
+	return pop(&s.statusErrors)
+}
+
+func pop(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+func newTestJobQueue(store jobStore, orgSubmitter submitter) *jobQueue {
+	return newJobQueue(store, map[string]submitter{"Org1MSP": orgSubmitter}, &config{
+		submitJobAttempts:     3,
+		submitJobBackoffType:  "fixed",
+		submitJobBackoffDelay: time.Millisecond,
+		submitJobConcurrency:  2,
+	})
+}
+
+// runJob runs a queue until the job with given ID is finished.
+func runJob(t *testing.T, queue *jobQueue, addJob func() string) *jobSummary {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- queue.run(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	id := addJob()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := queue.get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.finished() {
+			return j.summary()
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func addTestJob(t *testing.T, queue *jobQueue, mspID string) func() string {
+	return func() string {
+		id, err := queue.add(mspID, "CreateAsset", "asset1", "blue", "5", "Tom", "100")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	submitErr := errors.New("submit failed")
+	contractErr := fmt.Errorf("chaincode response 500, the asset asset1 already exists")
+
+	for name, test := range map[string]struct {
+		mspID                  string
+		endorseErrors          []error
+		statusErrors           []error
+		expectedStatus         string
+		expectedTransactionIDs []string
+		expectedEndorsed       []string
+		expectedStatusChecks   []string
+	}{
+		"completes": {
+			mspID:                  "Org1MSP",
+			expectedStatus:         jobCompleted,
+			expectedTransactionIDs: []string{"tx1"},
+			expectedEndorsed:       []string{"tx1"},
+			expectedStatusChecks:   []string{"commit of tx1"},
+		},
+		"retries with new transaction ID": {
+			mspID:                  "Org1MSP",
+			endorseErrors:          []error{submitErr},
+			expectedStatus:         jobCompleted,
+			expectedTransactionIDs: []string{"tx1", "tx2"},
+			expectedEndorsed:       []string{"tx1", "tx2"},
+			expectedStatusChecks:   []string{"commit of tx2"},
+		},
+		"retries invalid transaction with new transaction ID": {
+			mspID:                  "Org1MSP",
+			statusErrors:           []error{&commitError{transactionID: "tx1", code: peer.TxValidationCode_MVCC_READ_CONFLICT}},
+			expectedStatus:         jobCompleted,
+			expectedTransactionIDs: []string{"tx1", "tx2"},
+			expectedEndorsed:       []string{"tx1", "tx2"},
+			expectedStatusChecks:   []string{"commit of tx1", "commit of tx2"},
+		},
+		"does not retry contract errors": {
+			mspID:                  "Org1MSP",
+			endorseErrors:          []error{contractErr},
+			expectedStatus:         jobFailed,
+			expectedTransactionIDs: []string{"tx1"},
+			expectedEndorsed:       []string{"tx1"},
+		},
+		"does not retry duplicate transactions": {
+			mspID:                  "Org1MSP",
+			statusErrors:           []error{&commitError{transactionID: "tx1", code: peer.TxValidationCode_DUPLICATE_TXID}},
+			expectedStatus:         jobFailed,
+			expectedTransactionIDs: []string{"tx1"},
+			expectedEndorsed:       []string{"tx1"},
+			expectedStatusChecks:   []string{"commit of tx1"},
+		},
+		"fails after all attempts": {
+			mspID:                  "Org1MSP",
+			endorseErrors:          []error{submitErr, submitErr, submitErr},
+			expectedStatus:         jobFailed,
+			expectedTransactionIDs: []string{"tx1", "tx2", "tx3"},
+			expectedEndorsed:       []string{"tx1", "tx2", "tx3"},
+		},
+		"fails for unknown organization": {
+			mspID:          "Org3MSP",
+			expectedStatus: jobFailed,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			submitter := &fakeSubmitter{endorseErrors: test.endorseErrors, statusErrors: test.statusErrors}
+			queue := newTestJobQueue(newMemoryJobStore(), submitter)
+
+			summary := runJob(t, queue, addTestJob(t, queue, test.mspID))
+
+			if summary.Status != test.expectedStatus {
+				t.Errorf("expected status %s, got %s (%s)", test.expectedStatus, summary.Status, summary.TransactionError)
+			}
+			if test.expectedTransactionIDs == nil {
+				test.expectedTransactionIDs = []string{}
+			}
+			if !reflect.DeepEqual(summary.TransactionIDs, test.expectedTransactionIDs) {
+				t.Errorf("expected transaction IDs %v, got %v", test.expectedTransactionIDs, summary.TransactionIDs)
+			}
+			if !reflect.DeepEqual(submitter.endorsed, test.expectedEndorsed) {
+				t.Errorf("expected endorsed proposals %v, got %v", test.expectedEndorsed, submitter.endorsed)
+			}
+			if !reflect.DeepEqual(submitter.statusChecks, test.expectedStatusChecks) {
+				t.Errorf("expected status checks %v, got %v", test.expectedStatusChecks, submitter.statusChecks)
+			}
+			if test.expectedStatus == jobCompleted {
+				expected := "result of " + test.expectedTransactionIDs[len(test.expectedTransactionIDs)-1]
+				if summary.TransactionPayload == nil || *summary.TransactionPayload != expected {
+					t.Errorf("expected payload %q, got %v", expected, summary.TransactionPayload)
+				}
+			} else if summary.TransactionPayload != nil {
+				t.Errorf("expected no payload, got %q", *summary.TransactionPayload)
+			}
+		})
+	}
+}
+
+func TestJobQueueChecksSavedCommitWithoutResubmitting(t *testing.T) {
+	submitter := &fakeSubmitter{}
+	queue := newTestJobQueue(newMemoryJobStore(), submitter)
+
+	// A job whose transaction was submitted, but whose commit status could not be read
+	summary := runJob(t, queue, func() string {
+		j := &job{
+			MSPID:           "Org1MSP",
+			TransactionName: "CreateAsset",
+			Status:          jobDelayed,
+			Attempts:        1,
+			TransactionIDs:  []string{"tx1"},
+			Proposal:        []byte("tx1"),
+			Commit:          []byte("commit of tx1"),
+			Result:          []byte("result of tx1"),
+		}
+		if err := queue.store.add(j); err != nil {
+			t.Fatal(err)
+		}
+		queue.enqueue(j.ID, 0)
+		return j.ID
+	})
+
+	if summary.Status != jobCompleted {
+		t.Fatalf("expected job to complete, got %s (%s)", summary.Status, summary.TransactionError)
+	}
+	if submitter.proposals != 0 || len(submitter.endorsed) != 0 {
+		t.Errorf("expected no new proposal or endorsement, got %d proposals and endorsed %v", submitter.proposals, submitter.endorsed)
+	}
+	if !reflect.DeepEqual(submitter.statusChecks, []string{"commit of tx1"}) {
+		t.Errorf("expected status check of saved commit, got %v", submitter.statusChecks)
+	}
+	if !reflect.DeepEqual(summary.TransactionIDs, []string{"tx1"}) {
+		t.Errorf("expected transaction IDs [tx1], got %v", summary.TransactionIDs)
+	}
+}
+
+func TestJobQueueResumesUnfinishedJobsAfterRestart(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "jobs.sqlite")
+	store, err := newSQLiteJobStore(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop the queue while the job is being endorsed
+	blocked := &fakeSubmitter{blockEndorsing: true}
+	queue := newTestJobQueue(store, blocked)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- queue.run(ctx)
+	}()
+	id := addTestJob(t, queue, "Org1MSP")()
+	for {
+		if j, err := store.get(id); err != nil {
+			t.Fatal(err)
+		} else if j.Proposal != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := store.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = newSQLiteJobStore(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+	submitter := &fakeSubmitter{}
+	queue = newTestJobQueue(store, submitter)
+
+	summary := runJob(t, queue, func() string { return id })
+
+	if summary.Status != jobCompleted {
+		t.Fatalf("expected job to complete, got %s (%s)", summary.Status, summary.TransactionError)
+	}
+	if summary.Attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", summary.Attempts)
+	}
+	// The saved proposal is endorsed again, rather than a new one
+	if !reflect.DeepEqual(submitter.endorsed, []string{"tx1"}) || submitter.proposals != 0 {
+		t.Errorf("expected saved proposal tx1 to be endorsed, got %d new proposals and endorsed %v", submitter.proposals, submitter.endorsed)
+	}
+}
+
+func TestJobStores(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) jobStore{
+		"memory": func(t *testing.T) jobStore {
+			return newMemoryJobStore()
+		},
+		"sqlite": func(t *testing.T) jobStore {
+			store, err := newJobStore("sqlite", filepath.Join(t.TempDir(), "jobs.sqlite"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			defer store.close()
+
+			jobs := []*job{}
+			for i := 0; i < 3; i++ {
+				j := &job{MSPID: "Org1MSP", TransactionName: "DeleteAsset", TransactionArgs: []string{fmt.Sprint(i)}, Status: jobWaiting}
+				if err := store.add(j); err != nil {
+					t.Fatal(err)
+				}
+				jobs = append(jobs, j)
+			}
+			if jobs[0].ID == jobs[1].ID || jobs[1].ID == jobs[2].ID {
+				t.Fatalf("expected unique job IDs, got %s, %s and %s", jobs[0].ID, jobs[1].ID, jobs[2].ID)
+			}
+
+			jobs[1].Status = jobCompleted
+			jobs[1].TransactionIDs = []string{"tx1"}
+			jobs[1].Result = []byte("result")
+			if err := store.update(jobs[1]); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := store.get(jobs[1].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, jobs[1]) {
+				t.Errorf("expected %+v, got %+v", jobs[1], actual)
+			}
+
+			unfinished, err := store.unfinished()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(unfinished) != 2 || unfinished[0].ID != jobs[0].ID || unfinished[1].ID != jobs[2].ID {
+				t.Errorf("expected unfinished jobs %s and %s, got %+v", jobs[0].ID, jobs[2].ID, unfinished)
+			}
+
+			if _, err := store.get("999"); !errors.Is(err, errJobNotFound) {
+				t.Errorf("expected errJobNotFound, got %v", err)
+			}
+			if err := store.update(&job{ID: "999"}); !errors.Is(err, errJobNotFound) {
+				t.Errorf("expected errJobNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewJobStoreRejectsUnknownType(t *testing.T) {
+	if _, err := newJobStore("redis", ""); err == nil {
+		t.Fatal("expected error for unknown job store type")
+	}
+}
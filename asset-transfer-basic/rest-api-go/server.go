@@ -0,0 +1,366 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	assetsPath = "/api/assets"
+	jobsPath   = "/api/jobs/"
+	// readyTimeout bounds the time to check the connection to each gateway peer.
+	readyTimeout = 5 * time.Second
+)
+
+// evaluator evaluates transactions and checks the gateway connection of an organization. It is implemented by
+// *orgGateway.
+type evaluator interface {
+	evaluate(ctx context.Context, transactionName string, args ...string) ([]byte, error)
+	ping(ctx context.Context) error
+}
+
+// jobs queues submit jobs and reports their status. It is implemented by *jobQueue.
+type jobs interface {
+	add(mspID string, transactionName string, args ...string) (string, error)
+	get(id string) (*job, error)
+}
+
+// server is the REST API of the basic asset transfer sample. Submit transactions are queued as jobs and the server
+// immediately returns 202 Accepted with the job ID, since submitting a transaction can take a long time, especially
+// if it is retried. Evaluate transactions are processed during the request.
+type server struct {
+	// orgs are the gateway connections by MSP ID.
+	orgs map[string]evaluator
+	// apiKeys are the MSP IDs by API key.
+	apiKeys map[string]string
+	jobs    jobs
+}
+
+// handler returns the HTTP handler of the server. The /api endpoints require an X-Api-Key header, which selects the
+// organization identity used for the request. The /debug/vars endpoint serves the metrics published with expvar.
+func (s *server) handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc(assetsPath, s.handleAssets)
+	api.HandleFunc(assetsPath+"/", s.handleAsset)
+	api.HandleFunc(jobsPath, s.handleJob)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", s.authenticate(api))
+	mux.HandleFunc("/live", s.handleLive)
+	mux.HandleFunc("/ready", s.handleReady)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+type mspIDKey struct{}
+
+// authenticate maps the API key of a request to the MSP ID of an organization.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-Api-Key")
+		for key, mspID := range s.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), mspIDKey{}, mspID)))
+				return
+			}
+		}
+		writeStatus(w, http.StatusUnauthorized, &statusResponse{Reason: "NO_VALID_APIKEY"})
+	})
+}
+
+func (s *server) org(r *http.Request) (string, evaluator) {
+	mspID := r.Context().Value(mspIDKey{}).(string)
+	return mspID, s.orgs[mspID]
+}
+
+// handleAssets handles GET to list all assets and POST to create an asset.
+func (s *server) handleAssets(w http.ResponseWriter, r *http.Request) {
+	mspID, org := s.org(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		result, err := org.evaluate(r.Context(), "GetAllAssets")
+		if err != nil {
+			log.Printf("Error processing get all assets request: %v", err)
+			writeStatus(w, http.StatusInternalServerError, nil)
+			return
+		}
+		if len(result) == 0 {
+			result = []byte("[]")
+		}
+		writeJSON(w, http.StatusOK, json.RawMessage(result))
+
+	case http.MethodPost:
+		asset, errs := decodeAsset(r)
+		if errs != nil {
+			writeValidationErrors(w, errs)
+			return
+		}
+		s.addJob(w, mspID, "CreateAsset", asset.args()...)
+
+	default:
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleAsset handles the requests for an asset: OPTIONS to check that it exists, GET to read it, PUT to update it,
+// PATCH to transfer it and DELETE to delete it.
+func (s *server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	mspID, org := s.org(r)
+	assetID := strings.TrimPrefix(r.URL.Path, assetsPath+"/")
+	if assetID == "" || strings.Contains(assetID, "/") {
+		writeStatus(w, http.StatusNotFound, nil)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		result, err := org.evaluate(r.Context(), "AssetExists", assetID)
+		if err != nil {
+			log.Printf("Error processing asset options request for asset ID %s: %v", assetID, err)
+			writeStatus(w, http.StatusInternalServerError, nil)
+			return
+		}
+		if string(result) != "true" {
+			writeStatus(w, http.StatusNotFound, nil)
+			return
+		}
+		w.Header().Set("Allow", "DELETE,GET,OPTIONS,PATCH,PUT")
+		writeStatus(w, http.StatusOK, nil)
+
+	case http.MethodGet:
+		result, err := org.evaluate(r.Context(), "ReadAsset", assetID)
+		if isAssetNotFoundError(err) {
+			writeStatus(w, http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			log.Printf("Error processing read asset request for asset ID %s: %v", assetID, err)
+			writeStatus(w, http.StatusInternalServerError, nil)
+			return
+		}
+		writeJSON(w, http.StatusOK, json.RawMessage(result))
+
+	case http.MethodPut:
+		asset, errs := decodeAsset(r)
+		if errs != nil {
+			writeValidationErrors(w, errs)
+			return
+		}
+		if asset.ID != assetID {
+			writeStatus(w, http.StatusBadRequest, &statusResponse{
+				Reason:  "ASSET_ID_MISMATCH",
+				Message: "Asset IDs must match",
+			})
+			return
+		}
+		s.addJob(w, mspID, "UpdateAsset", asset.args()...)
+
+	case http.MethodPatch:
+		newOwner, errs := decodeTransfer(r)
+		if errs != nil {
+			writeValidationErrors(w, errs)
+			return
+		}
+		s.addJob(w, mspID, "TransferAsset", assetID, newOwner)
+
+	case http.MethodDelete:
+		s.addJob(w, mspID, "DeleteAsset", assetID)
+
+	default:
+		writeMethodNotAllowed(w, http.MethodDelete, http.MethodGet, http.MethodOptions, http.MethodPatch, http.MethodPut)
+	}
+}
+
+// addJob queues a submit job and responds with 202 Accepted and the job ID.
+func (s *server) addJob(w http.ResponseWriter, mspID string, transactionName string, args ...string) {
+	jobID, err := s.jobs.add(mspID, transactionName, args...)
+	if err != nil {
+		log.Printf("Error adding %s job: %v", transactionName, err)
+		writeStatus(w, http.StatusInternalServerError, nil)
+		return
+	}
+	writeStatus(w, http.StatusAccepted, &statusResponse{JobID: jobID})
+}
+
+// handleJob returns the status of a job, with the IDs of the transactions submitted for the job and the result of
+// the last transaction once the job is finished. The jobs of other organizations are reported as not found.
+func (s *server) handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	mspID, _ := s.org(r)
+	jobID := strings.TrimPrefix(r.URL.Path, jobsPath)
+	j, err := s.jobs.get(jobID)
+	if errors.Is(err, errJobNotFound) || (err == nil && j.MSPID != mspID) {
+		writeStatus(w, http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error processing read request for job ID %s: %v", jobID, err)
+		writeStatus(w, http.StatusInternalServerError, nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, j.summary())
+}
+
+// handleLive reports that the server is running.
+func (s *server) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, nil)
+}
+
+// handleReady reports whether the server can process requests, which requires a working connection to the gateway
+// peer of every organization.
+func (s *server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	for mspID, org := range s.orgs {
+		if err := org.ping(ctx); err != nil {
+			log.Printf("Gateway connection for %s is not ready: %v", mspID, err)
+			writeStatus(w, http.StatusServiceUnavailable, nil)
+			return
+		}
+	}
+	writeStatus(w, http.StatusOK, nil)
+}
+
+// asset is the basic asset transfer asset in create and update requests.
+type asset struct {
+	ID             string
+	Color          string
+	Size           int
+	Owner          string
+	AppraisedValue int
+}
+
+func (a *asset) args() []string {
+	return []string{a.ID, a.Color, strconv.Itoa(a.Size), a.Owner, strconv.Itoa(a.AppraisedValue)}
+}
+
+// validationError describes an invalid field of a request body.
+type validationError struct {
+	Param string `json:"param"`
+	Msg   string `json:"msg"`
+}
+
+// decodeAsset reads an asset from the request body, checking that the string fields are not empty and that the
+// number fields are integers.
+func decodeAsset(r *http.Request) (*asset, []validationError) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil || fields == nil {
+		return nil, []validationError{{Msg: "body must contain an asset object"}}
+	}
+
+	result := &asset{}
+	errs := []validationError{}
+	decodeString := func(name string, value *string) {
+		if err := json.Unmarshal(fields[name], value); err != nil || *value == "" {
+			errs = append(errs, validationError{Param: name, Msg: "must be a string"})
+		}
+	}
+	decodeNumber := func(name string, value *int) {
+		if err := json.Unmarshal(fields[name], value); err != nil {
+			errs = append(errs, validationError{Param: name, Msg: "must be a number"})
+		}
+	}
+	decodeString("ID", &result.ID)
+	decodeString("Color", &result.Color)
+	decodeNumber("Size", &result.Size)
+	decodeString("Owner", &result.Owner)
+	decodeNumber("AppraisedValue", &result.AppraisedValue)
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// patchOperation is a JSON Patch operation. Only replacing the owner of an asset is supported.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// decodeTransfer reads the new owner of an asset from a JSON Patch request body.
+func decodeTransfer(r *http.Request) (string, []validationError) {
+	var operations []patchOperation
+	if err := json.NewDecoder(r.Body).Decode(&operations); err != nil || len(operations) != 1 {
+		return "", []validationError{{Msg: "body must contain an array with a single patch operation"}}
+	}
+
+	operation := operations[0]
+	errs := []validationError{}
+	if operation.Op != "replace" {
+		errs = append(errs, validationError{Param: "[0].op", Msg: "operation must be 'replace'"})
+	}
+	if operation.Path != "/Owner" {
+		errs = append(errs, validationError{Param: "[0].path", Msg: "path must be '/Owner'"})
+	}
+	var newOwner string
+	if err := json.Unmarshal(operation.Value, &newOwner); err != nil {
+		errs = append(errs, validationError{Param: "[0].value", Msg: "must be a string"})
+	}
+
+	if len(errs) > 0 {
+		return "", errs
+	}
+	return newOwner, nil
+}
+
+// statusResponse is the body of responses without other content, as in the TypeScript REST API sample.
+type statusResponse struct {
+	Status    string            `json:"status"`
+	Reason    string            `json:"reason,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	JobID     string            `json:"jobId,omitempty"`
+	Timestamp string            `json:"timestamp"`
+	Errors    []validationError `json:"errors,omitempty"`
+}
+
+func writeStatus(w http.ResponseWriter, statusCode int, response *statusResponse) {
+	if response == nil {
+		response = &statusResponse{}
+	}
+	response.Status = http.StatusText(statusCode)
+	response.Timestamp = time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	writeJSON(w, statusCode, response)
+}
+
+func writeValidationErrors(w http.ResponseWriter, errs []validationError) {
+	writeStatus(w, http.StatusBadRequest, &statusResponse{
+		Reason:  "VALIDATION_ERROR",
+		Message: "Invalid request body",
+		Errors:  errs,
+	})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ","))
+	writeStatus(w, http.StatusMethodNotAllowed, nil)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
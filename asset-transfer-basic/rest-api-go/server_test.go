@@ -0,0 +1,282 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeOrg returns the results of evaluated transactions by transaction name.
+type fakeOrg struct {
+	results map[string]string
+	errors  map[string]error
+	pingErr error
+}
+
+func (o *fakeOrg) evaluate(ctx context.Context, transactionName string, args ...string) ([]byte, error) {
+	if err := o.errors[transactionName]; err != nil {
+		return nil, err
+	}
+	return []byte(o.results[transactionName]), nil
+}
+
+func (o *fakeOrg) ping(ctx context.Context) error {
+	return o.pingErr
+}
+
+type addedJob struct {
+	mspID           string
+	transactionName string
+	args            []string
+}
+
+// fakeJobs records the jobs added by the server.
+type fakeJobs struct {
+	added []addedJob
+}
+
+func (j *fakeJobs) add(mspID string, transactionName string, args ...string) (string, error) {
+	j.added = append(j.added, addedJob{mspID: mspID, transactionName: transactionName, args: args})
+	return "1", nil
+}
+
+func (j *fakeJobs) get(id string) (*job, error) {
+	if id != "1" {
+		return nil, errJobNotFound
+	}
+	return &job{ID: "1", MSPID: "Org1MSP", Status: jobWaiting}, nil
+}
+
+func newTestServer() (*server, *fakeOrg, *fakeJobs) {
+	org := &fakeOrg{results: map[string]string{}, errors: map[string]error{}}
+	jobs := &fakeJobs{}
+	s := &server{
+		orgs:    map[string]evaluator{"Org1MSP": org, "Org2MSP": &fakeOrg{}},
+		apiKeys: map[string]string{"org1key": "Org1MSP", "org2key": "Org2MSP"},
+		jobs:    jobs,
+	}
+	return s, org, jobs
+}
+
+func request(s *server, method string, path string, apiKey string, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if apiKey != "" {
+		r.Header.Set("X-Api-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+
+	response := map[string]interface{}{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestServerRequiresAPIKey(t *testing.T) {
+	s, _, _ := newTestServer()
+
+	for _, apiKey := range []string{"", "wrongkey"} {
+		w, response := request(s, http.MethodGet, "/api/assets", apiKey, "")
+		if w.Code != http.StatusUnauthorized || response["reason"] != "NO_VALID_APIKEY" {
+			t.Errorf("expected 401 NO_VALID_APIKEY for API key %q, got %d %v", apiKey, w.Code, response)
+		}
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	s, org, _ := newTestServer()
+
+	for _, path := range []string{"/live", "/ready"} {
+		if w, _ := request(s, http.MethodGet, path, "", ""); w.Code != http.StatusOK {
+			t.Errorf("expected %s to return 200, got %d", path, w.Code)
+		}
+	}
+
+	org.pingErr = errors.New("connection refused")
+	if w, _ := request(s, http.MethodGet, "/ready", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /ready to return 503, got %d", w.Code)
+	}
+	if w, _ := request(s, http.MethodGet, "/live", "", ""); w.Code != http.StatusOK {
+		t.Errorf("expected /live to return 200, got %d", w.Code)
+	}
+}
+
+func TestServerPublishesSignLatency(t *testing.T) {
+	s, _, _ := newTestServer()
+
+	w, response := request(s, http.MethodGet, "/debug/vars", "", "")
+	if w.Code != http.StatusOK || response["hlf_sign_latency"] == nil {
+		t.Errorf("expected 200 with the sign latency metric, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServerEvaluatesAssets(t *testing.T) {
+	s, org, _ := newTestServer()
+	org.results["ReadAsset"] = `{"ID":"asset1","Color":"blue","Size":5,"Owner":"Tom","AppraisedValue":100}`
+	org.results["AssetExists"] = "true"
+
+	w, _ := request(s, http.MethodGet, "/api/assets", "org1key", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected 200 with empty list, got %d %s", w.Code, w.Body.String())
+	}
+
+	w, response := request(s, http.MethodGet, "/api/assets/asset1", "org1key", "")
+	if w.Code != http.StatusOK || response["Owner"] != "Tom" {
+		t.Errorf("expected 200 with asset, got %d %s", w.Code, w.Body.String())
+	}
+
+	w, _ = request(s, http.MethodOptions, "/api/assets/asset1", "org1key", "")
+	if w.Code != http.StatusOK || w.Header().Get("Allow") != "DELETE,GET,OPTIONS,PATCH,PUT" {
+		t.Errorf("expected 200 with Allow header, got %d %v", w.Code, w.Header())
+	}
+
+	org.results["AssetExists"] = "false"
+	org.errors["ReadAsset"] = newEndorseError(t, "failed to evaluate transaction", "chaincode response 500, the asset asset1 does not exist")
+	for _, method := range []string{http.MethodOptions, http.MethodGet} {
+		if w, _ := request(s, method, "/api/assets/asset1", "org1key", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected %s of missing asset to return 404, got %d", method, w.Code)
+		}
+	}
+}
+
+func TestServerQueuesSubmitJobs(t *testing.T) {
+	const asset1 = `{"ID":"asset1","Color":"blue","Size":5,"Owner":"Tom","AppraisedValue":100}`
+
+	for name, test := range map[string]struct {
+		method   string
+		path     string
+		apiKey   string
+		body     string
+		expected addedJob
+	}{
+		"create": {
+			method:   http.MethodPost,
+			path:     "/api/assets",
+			apiKey:   "org1key",
+			body:     asset1,
+			expected: addedJob{"Org1MSP", "CreateAsset", []string{"asset1", "blue", "5", "Tom", "100"}},
+		},
+		"update": {
+			method:   http.MethodPut,
+			path:     "/api/assets/asset1",
+			apiKey:   "org2key",
+			body:     asset1,
+			expected: addedJob{"Org2MSP", "UpdateAsset", []string{"asset1", "blue", "5", "Tom", "100"}},
+		},
+		"transfer": {
+			method:   http.MethodPatch,
+			path:     "/api/assets/asset1",
+			apiKey:   "org1key",
+			body:     `[{"op":"replace","path":"/Owner","value":"Jerry"}]`,
+			expected: addedJob{"Org1MSP", "TransferAsset", []string{"asset1", "Jerry"}},
+		},
+		"delete": {
+			method:   http.MethodDelete,
+			path:     "/api/assets/asset1",
+			apiKey:   "org1key",
+			expected: addedJob{"Org1MSP", "DeleteAsset", []string{"asset1"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, _, jobs := newTestServer()
+
+			w, response := request(s, test.method, test.path, test.apiKey, test.body)
+
+			if w.Code != http.StatusAccepted || response["jobId"] != "1" {
+				t.Fatalf("expected 202 with job ID, got %d %s", w.Code, w.Body.String())
+			}
+			if len(jobs.added) != 1 || !reflect.DeepEqual(jobs.added[0], test.expected) {
+				t.Errorf("expected job %+v, got %+v", test.expected, jobs.added)
+			}
+		})
+	}
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	for name, test := range map[string]struct {
+		method         string
+		path           string
+		body           string
+		expectedReason string
+		expectedParams []string
+	}{
+		"missing fields": {
+			method:         http.MethodPost,
+			path:           "/api/assets",
+			body:           `{"ID":"asset1","Size":"5"}`,
+			expectedReason: "VALIDATION_ERROR",
+			expectedParams: []string{"Color", "Size", "Owner", "AppraisedValue"},
+		},
+		"not an object": {
+			method:         http.MethodPost,
+			path:           "/api/assets",
+			body:           `[]`,
+			expectedReason: "VALIDATION_ERROR",
+			expectedParams: []string{""},
+		},
+		"asset ID mismatch": {
+			method:         http.MethodPut,
+			path:           "/api/assets/asset2",
+			body:           `{"ID":"asset1","Color":"blue","Size":5,"Owner":"Tom","AppraisedValue":100}`,
+			expectedReason: "ASSET_ID_MISMATCH",
+		},
+		"unsupported patch": {
+			method:         http.MethodPatch,
+			path:           "/api/assets/asset1",
+			body:           `[{"op":"add","path":"/Color","value":"red"}]`,
+			expectedReason: "VALIDATION_ERROR",
+			expectedParams: []string{"[0].op", "[0].path"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, _, jobs := newTestServer()
+
+			w, response := request(s, test.method, test.path, "org1key", test.body)
+
+			if w.Code != http.StatusBadRequest || response["reason"] != test.expectedReason {
+				t.Fatalf("expected 400 %s, got %d %s", test.expectedReason, w.Code, w.Body.String())
+			}
+			params := []string{}
+			errs, _ := response["errors"].([]interface{})
+			for _, err := range errs {
+				param, _ := err.(map[string]interface{})["param"].(string)
+				params = append(params, param)
+			}
+			if test.expectedParams == nil {
+				test.expectedParams = []string{}
+			}
+			if !reflect.DeepEqual(params, test.expectedParams) {
+				t.Errorf("expected errors for %v, got %v", test.expectedParams, params)
+			}
+			if len(jobs.added) != 0 {
+				t.Errorf("expected no jobs, got %+v", jobs.added)
+			}
+		})
+	}
+}
+
+func TestServerReturnsJobs(t *testing.T) {
+	s, _, _ := newTestServer()
+
+	w, response := request(s, http.MethodGet, "/api/jobs/1", "org1key", "")
+	if w.Code != http.StatusOK || response["jobId"] != "1" || response["status"] != jobWaiting {
+		t.Errorf("expected 200 with job, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := request(s, http.MethodGet, "/api/jobs/2", "org1key", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", w.Code)
+	}
+	if w, _ := request(s, http.MethodGet, "/api/jobs/1", "org2key", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for job of another organization, got %d", w.Code)
+	}
+}
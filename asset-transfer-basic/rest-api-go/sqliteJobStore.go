@@ -0,0 +1,122 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteJobSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	id       TEXT    NOT NULL UNIQUE,
+	finished INTEGER NOT NULL,
+	data     TEXT    NOT NULL
+);
+`
+
+// sqliteJobStore keeps jobs in a SQLite database, so that unfinished jobs are processed again after the server
+// restarts. Each job is saved as JSON, and seq keeps the order in which the jobs were added.
+type sqliteJobStore struct {
+	db *sql.DB
+}
+
+// newSQLiteJobStore opens the SQLite database in the file, creating the file and the table if they do not exist.
+func newSQLiteJobStore(fileName string) (*sqliteJobStore, error) {
+	db, err := sql.Open("sqlite3", fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteJobSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteJobStore{db: db}, nil
+}
+
+func (s *sqliteJobStore) add(j *job) error {
+	id, err := newJobID()
+	if err != nil {
+		return err
+	}
+
+	j.ID = id
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT INTO jobs (id, finished, data) VALUES (?, ?, ?)", j.ID, j.finished(), string(data))
+	return err
+}
+
+func (s *sqliteJobStore) get(id string) (*job, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM jobs WHERE id = ?", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJob(data)
+}
+
+func (s *sqliteJobStore) update(j *job) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec("UPDATE jobs SET finished = ?, data = ? WHERE id = ?", j.finished(), string(data), j.ID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return errJobNotFound
+	}
+	return nil
+}
+
+func (s *sqliteJobStore) unfinished() ([]*job, error) {
+	rows, err := s.db.Query("SELECT data FROM jobs WHERE finished = 0 ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*job{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		j, err := unmarshalJob(data)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, j)
+	}
+	return result, rows.Err()
+}
+
+func (s *sqliteJobStore) close() error {
+	return s.db.Close()
+}
+
+func unmarshalJob(data string) (*job, error) {
+	j := &job{}
+	if err := json.Unmarshal([]byte(data), j); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return j, nil
+}
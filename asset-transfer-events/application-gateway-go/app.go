@@ -12,25 +12,51 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
 	channelName   = "mychannel"
 	chaincodeName = "events"
+	// clientProfile names the client identity and gateway peer, which can be overridden by HLF_* environment variables.
+	clientProfile = "clientProfile.yaml"
 )
 
+const checkpointFile = "checkpoint.json"
+
 var now = time.Now()
 var assetID = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
 
 func main() {
-	clientConnection := newGrpcConnection()
+	p, err := profile.Load(clientProfile)
+	if err != nil {
+		panic(err)
+	}
+
+	clientConnection, err := p.NewConnection()
+	if err != nil {
+		panic(err)
+	}
 	defer clientConnection.Close()
 
-	id := newIdentity()
-	sign := newSign()
+	id, err := p.NewIdentity()
+	if err != nil {
+		panic(err)
+	}
+	sign, closeSign, err := p.NewSign(profile.WithAuditLog(log.Default()))
+	if err != nil {
+		panic(err)
+	}
+	defer closeSign()
 
 	gateway, err := client.Connect(
 		id,
@@ -49,36 +75,106 @@ func main() {
 	network := gateway.GetNetwork(channelName)
 	contract := network.GetContract(chaincodeName)
 
-	// Context used for event listening
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Context used for event listening, cancelled on interrupt using Control-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	// Listen for events emitted by subsequent transactions
-	startChaincodeEventListening(ctx, network)
+	// Listen for events emitted by subsequent transactions, after any events processed by a previous run
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	deleted, listening := startChaincodeEventListening(listenCtx, network)
 
 	firstBlockNumber := createAsset(contract)
 	updateAsset(contract)
 	transferAsset(contract)
 	deleteAsset(contract)
 
+	// Wait for the listener to process the event of the last submitted transaction, then stop listening
+	select {
+	case <-time.After(30 * time.Second):
+		panic(errors.New("timeout waiting for chaincode event listening"))
+	case <-deleted:
+	}
+	stopListening()
+	if err := <-listening; err != nil {
+		panic(err)
+	}
+
 	// Replay events from the block containing the first transaction
 	replayChaincodeEvents(ctx, network, firstBlockNumber)
 }
 
-func startChaincodeEventListening(ctx context.Context, network *client.Network) {
+// startChaincodeEventListening listens for events in the background using a checkpoint persisted to a file, so that
+// listening resumes after the last processed event when the application is restarted. The returned deleted channel
+// is closed once the event of deleting the asset is received, and the result of listening is sent to the returned
+// listening channel once the context is cancelled.
+func startChaincodeEventListening(ctx context.Context, network *client.Network) (<-chan struct{}, <-chan error) {
 	fmt.Println("\n*** Start chaincode event listening")
 
-	events, err := network.ChaincodeEvents(ctx, chaincodeName)
+	checkpointer, err := client.NewFileCheckpointer(checkpointFile)
 	if err != nil {
-		panic(fmt.Errorf("failed to start chaincode event listening: %w", err))
+		panic(fmt.Errorf("failed to create checkpointer: %w", err))
 	}
+	deleted := make(chan struct{})
+	var deletedOnce sync.Once
 
-	go func() {
-		for event := range events {
-			asset := formatJSON(event.Payload)
-			fmt.Printf("\n<-- Chaincode event received: %s - %s\n", event.EventName, asset)
+	listener := newEventListener(network, chaincodeName, checkpointer, func(event *client.ChaincodeEvent) error {
+		asset := formatJSON(event.Payload)
+		fmt.Printf("\n<-- Chaincode event received: %s - %s\n", event.EventName, asset)
+
+		if isDeleteEvent(event) {
+			deletedOnce.Do(func() { close(deleted) })
 		}
+		return nil
+	})
+
+	if checkpointer.BlockNumber() == 0 && checkpointer.TransactionID() == "" {
+		// Without a checkpoint, start from the current ledger height so that no events are missed if the event stream
+		// needs to be reconnected before the first event is checkpointed
+		height := ledgerHeight(network)
+		listener.startBlock = &height
+		fmt.Printf("\n*** No checkpoint, listening from block %d\n", height)
+	} else {
+		fmt.Printf("\n*** Resuming from block %d after transaction %q\n", checkpointer.BlockNumber(), checkpointer.TransactionID())
+	}
+
+	listening := make(chan error, 1)
+	go func() {
+		defer checkpointer.Close()
+		listening <- listener.listen(ctx)
 	}()
+
+	return deleted, listening
+}
+
+// ledgerHeight returns the number of blocks in the channel ledger, which is the number of the next block to be
+// committed.
+func ledgerHeight(network *client.Network) uint64 {
+	result, err := network.GetContract("qscc").EvaluateTransaction("GetChainInfo", channelName)
+	if err != nil {
+		panic(fmt.Errorf("failed to get chain info: %w", err))
+	}
+
+	info := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(result, info); err != nil {
+		panic(fmt.Errorf("failed to parse chain info: %w", err))
+	}
+	return info.GetHeight()
+}
+
+// isDeleteEvent reports whether an event is the event of deleting the asset of this run.
+func isDeleteEvent(event *client.ChaincodeEvent) bool {
+	if event.EventName != "DeleteAsset" {
+		return false
+	}
+
+	var asset struct {
+		ID string
+	}
+	if err := json.Unmarshal(event.Payload, &asset); err != nil {
+		return false
+	}
+	return asset.ID == assetID
 }
 
 func formatJSON(data []byte) string {
@@ -147,24 +243,42 @@ func deleteAsset(contract *client.Contract) {
 func replayChaincodeEvents(ctx context.Context, network *client.Network, startBlock uint64) {
 	fmt.Println("\n*** Start chaincode event replay")
 
-	events, err := network.ChaincodeEvents(ctx, chaincodeName, client.WithStartBlock(startBlock))
-	if err != nil {
-		panic(fmt.Errorf("failed to start chaincode event listening: %w", err))
-	}
-
-	for {
-		select {
-		case <-time.After(10 * time.Second):
-			panic(errors.New("timeout waiting for event replay"))
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-		case event := <-events:
-			asset := formatJSON(event.Payload)
-			fmt.Printf("\n<-- Chaincode event replayed: %s - %s\n", event.EventName, asset)
+	replayed := false
+	listener := newEventListener(network, chaincodeName, &memoryCheckpointer{new(client.InMemoryCheckpointer)}, func(event *client.ChaincodeEvent) error {
+		asset := formatJSON(event.Payload)
+		fmt.Printf("\n<-- Chaincode event replayed: %s - %s\n", event.EventName, asset)
 
-			if event.EventName == "DeleteAsset" {
-				// Reached the last submitted transaction so return to stop listening for events
-				return
-			}
+		if isDeleteEvent(event) {
+			// Reached the last submitted transaction so cancel to stop listening for events
+			replayed = true
+			cancel()
 		}
+		return nil
+	})
+	listener.startBlock = &startBlock
+
+	if err := listener.listen(ctx); err != nil {
+		panic(err)
+	}
+	if !replayed {
+		panic(errors.New("timeout waiting for event replay"))
 	}
 }
+
+// memoryCheckpointer adapts a client.InMemoryCheckpointer for replaying events, where the position does not need to
+// survive a restart.
+type memoryCheckpointer struct {
+	*client.InMemoryCheckpointer
+}
+
+func (c *memoryCheckpointer) CheckpointChaincodeEvent(event *client.ChaincodeEvent) error {
+	c.InMemoryCheckpointer.CheckpointChaincodeEvent(event)
+	return nil
+}
+
+func (c *memoryCheckpointer) Sync() error {
+	return nil
+}
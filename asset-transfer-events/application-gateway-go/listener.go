@@ -0,0 +1,165 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// eventSource opens chaincode event streams. It is implemented by *client.Network.
+type eventSource interface {
+	ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error)
+}
+
+// checkpointer records the position of the last processed event. It is implemented by *client.FileCheckpointer.
+type checkpointer interface {
+	client.Checkpoint
+	CheckpointChaincodeEvent(event *client.ChaincodeEvent) error
+	Sync() error
+}
+
+// eventHandler processes a chaincode event. An event is only checkpointed once all handlers have processed it
+// successfully, so a handler may receive the same event more than once and should process it idempotently.
+type eventHandler func(event *client.ChaincodeEvent) error
+
+// eventListener delivers the chaincode events of a chaincode to its handlers, with at-least-once delivery. The
+// position of the last processed event is checkpointed after each event, and listening resumes after this event when
+// the event stream is reconnected or the application is restarted.
+type eventListener struct {
+	source        eventSource
+	chaincodeName string
+	checkpointer  checkpointer
+	handlers      []eventHandler
+	// startBlock is the block from which to listen if there is no checkpoint. If nil, listening starts at the next
+	// block to be committed.
+	startBlock        *uint64
+	minReconnectDelay time.Duration
+	maxReconnectDelay time.Duration
+}
+
+func newEventListener(source eventSource, chaincodeName string, checkpointer checkpointer, handlers ...eventHandler) *eventListener {
+	return &eventListener{
+		source:            source,
+		chaincodeName:     chaincodeName,
+		checkpointer:      checkpointer,
+		handlers:          handlers,
+		minReconnectDelay: minReconnectDelay,
+		maxReconnectDelay: maxReconnectDelay,
+	}
+}
+
+// listen delivers events until the context is cancelled. When the event stream breaks, or a handler fails, it
+// reconnects from the last checkpoint, waiting between attempts for a delay that doubles after each attempt without
+// progress. It returns an error only if an event cannot be checkpointed.
+func (l *eventListener) listen(ctx context.Context) error {
+	delay := l.minReconnectDelay
+	for {
+		processed, err := l.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if processed > 0 {
+			delay = l.minReconnectDelay
+		}
+
+		reason := "event stream closed"
+		if err != nil {
+			var checkpointErr *checkpointError
+			if errors.As(err, &checkpointErr) {
+				return err
+			}
+			reason = err.Error()
+		}
+		fmt.Printf("\n*** %s, reconnecting in %v from block %d\n", reason, delay, l.checkpointer.BlockNumber())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > l.maxReconnectDelay {
+			delay = l.maxReconnectDelay
+		}
+	}
+}
+
+// checkpointError reports a failure to persist the checkpoint, which stops the listener since listening can no
+// longer be resumed at the correct position.
+type checkpointError struct {
+	err error
+}
+
+func (e *checkpointError) Error() string {
+	return fmt.Sprintf("failed to checkpoint event: %v", e.err)
+}
+
+func (e *checkpointError) Unwrap() error {
+	return e.err
+}
+
+// listenOnce reads events from a single event stream until it closes, the context is cancelled or a handler fails,
+// and returns the number of events processed.
+func (l *eventListener) listenOnce(ctx context.Context) (int, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	options := []client.ChaincodeEventsOption{client.WithCheckpoint(l.checkpointer)}
+	if l.startBlock != nil {
+		// Used only if there is no checkpoint
+		options = append([]client.ChaincodeEventsOption{client.WithStartBlock(*l.startBlock)}, options...)
+	}
+
+	events, err := l.source.ChaincodeEvents(streamCtx, l.chaincodeName, options...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start chaincode event listening: %w", err)
+	}
+	defer func() {
+		// Release the goroutine delivering events once the stream is cancelled
+		cancel()
+		for range events {
+		}
+	}()
+
+	processed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return processed, nil
+
+		case event, ok := <-events:
+			if !ok {
+				return processed, nil
+			}
+
+			for _, handler := range l.handlers {
+				if err := handler(event); err != nil {
+					return processed, fmt.Errorf("failed to process event from transaction %s: %w", event.TransactionID, err)
+				}
+			}
+
+			if err := l.checkpointer.CheckpointChaincodeEvent(event); err != nil {
+				return processed, &checkpointError{err: err}
+			}
+			if err := l.checkpointer.Sync(); err != nil {
+				return processed, &checkpointError{err: err}
+			}
+			processed++
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// fakeSource delivers the events of one stream per call, closing each stream after its events to simulate a broken
+// connection. After the last stream it keeps the stream open until the context is cancelled.
+type fakeSource struct {
+	streams [][]*client.ChaincodeEvent
+	// checkpoints records the checkpointed transaction ID at each connection
+	checkpoints  []string
+	checkpointer checkpointer
+}
+
+func (s *fakeSource) ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error) {
+	s.checkpoints = append(s.checkpoints, s.checkpointer.TransactionID())
+
+	events := make(chan *client.ChaincodeEvent)
+	var stream []*client.ChaincodeEvent
+	last := len(s.streams) == 0
+	if !last {
+		stream, s.streams = s.streams[0], s.streams[1:]
+	}
+
+	go func() {
+		defer close(events)
+		for _, event := range stream {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if last {
+			<-ctx.Done()
+		}
+	}()
+	return events, nil
+}
+
+func newEvent(blockNumber uint64, transactionID string) *client.ChaincodeEvent {
+	return &client.ChaincodeEvent{BlockNumber: blockNumber, TransactionID: transactionID, ChaincodeName: "events"}
+}
+
+func newTestListener(t *testing.T, source *fakeSource, handlers ...eventHandler) *eventListener {
+	checkpointer, err := client.NewFileCheckpointer(filepath.Join(t.TempDir(), "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { checkpointer.Close() })
+	source.checkpointer = checkpointer
+
+	listener := newEventListener(source, "events", checkpointer, handlers...)
+	listener.minReconnectDelay = time.Millisecond
+	listener.maxReconnectDelay = 4 * time.Millisecond
+	return listener
+}
+
+func TestListenerReconnectsFromCheckpoint(t *testing.T) {
+	source := &fakeSource{streams: [][]*client.ChaincodeEvent{
+		{newEvent(5, "tx1"), newEvent(6, "tx2")},
+		// The handler fails on tx2, so it is not checkpointed and delivered again
+		{newEvent(6, "tx2")},
+		{},
+		{newEvent(6, "tx3"), newEvent(7, "tx4")},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := []string{}
+	failed := false
+	listener := newTestListener(t, source, func(event *client.ChaincodeEvent) error {
+		received = append(received, event.TransactionID)
+		if event.TransactionID == "tx2" && !failed {
+			failed = true
+			return errors.New("handler failure")
+		}
+		if event.TransactionID == "tx4" {
+			cancel()
+		}
+		return nil
+	})
+
+	if err := listener.listen(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"tx1", "tx2", "tx2", "tx3", "tx4"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("received %v, want %v", received, want)
+	}
+	if want := []string{"", "tx1", "tx2", "tx2"}; !reflect.DeepEqual(source.checkpoints, want) {
+		t.Errorf("connected after checkpoints %v, want %v", source.checkpoints, want)
+	}
+	if listener.checkpointer.BlockNumber() != 7 || listener.checkpointer.TransactionID() != "tx4" {
+		t.Errorf("checkpoint is block %d transaction %s, want block 7 transaction tx4", listener.checkpointer.BlockNumber(), listener.checkpointer.TransactionID())
+	}
+}
+
+func TestListenerStopsOnCancel(t *testing.T) {
+	source := &fakeSource{}
+	listener := newTestListener(t, source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- listener.listen(ctx)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+}
+
+// failingCheckpointer fails to persist checkpoints.
+type failingCheckpointer struct {
+	client.InMemoryCheckpointer
+}
+
+func (c *failingCheckpointer) CheckpointChaincodeEvent(event *client.ChaincodeEvent) error {
+	return errors.New("disk full")
+}
+
+func (c *failingCheckpointer) Sync() error {
+	return nil
+}
+
+func TestListenerFailsIfCheckpointFails(t *testing.T) {
+	checkpointer := &failingCheckpointer{}
+	source := &fakeSource{streams: [][]*client.ChaincodeEvent{{newEvent(5, "tx1")}}, checkpointer: checkpointer}
+	listener := newEventListener(source, "events", checkpointer)
+
+	err := listener.listen(context.Background())
+	if err == nil || err.Error() != "failed to checkpoint event: disk full" {
+		t.Errorf("err = %v, want failed to checkpoint event: disk full", err)
+	}
+}
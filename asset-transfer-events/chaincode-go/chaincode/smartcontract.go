@@ -0,0 +1,232 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	implicitCollectionPrefix = "_implicit_org_"
+	// privatePropertiesKey is the transient data key of the private properties of an asset
+	privatePropertiesKey = "asset_properties"
+)
+
+// SmartContract provides functions for managing an Asset and emits a chaincode event for each change
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// Asset describes the public details of an asset. Size and AppraisedValue are
+// written as JSON strings, as in the event payloads of the Java and JavaScript
+// implementations of this chaincode.
+type Asset struct {
+	ID             string `json:"ID"`
+	Color          string `json:"Color"`
+	Size           int    `json:"Size,string"`
+	Owner          string `json:"Owner"`
+	AppraisedValue int    `json:"AppraisedValue,string"`
+}
+
+// assetWithProperties is an asset together with its private properties
+type assetWithProperties struct {
+	*Asset
+	Properties json.RawMessage `json:"asset_properties,omitempty"`
+}
+
+// CreateAsset issues a new asset to the world state with given details and
+// emits a CreateAsset event with the asset. Private properties of the asset
+// passed in the transient data are saved to the implicit collection of the
+// organization, and are not included in the event.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, color string, size int, owner string, appraisedValue int) error {
+	existing, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the asset %s already exists", id)
+	}
+
+	asset := &Asset{
+		ID:             id,
+		Color:          color,
+		Size:           size,
+		Owner:          owner,
+		AppraisedValue: appraisedValue,
+	}
+	return putAsset(ctx, asset, "CreateAsset")
+}
+
+// ReadAsset returns the asset stored in the world state with given id, with
+// its private properties if the client belongs to the organization of the peer
+func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	asset, err := readAsset(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	result := assetWithProperties{Asset: asset}
+	collection, ok, err := ownCollection(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		properties, err := ctx.GetStub().GetPrivateData(collection, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to read private properties of asset %s: %v", id, err)
+		}
+		if len(properties) > 0 {
+			result.Properties = properties
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(resultJSON), nil
+}
+
+// UpdateAsset updates an existing asset in the world state with provided
+// parameters and emits an UpdateAsset event with the asset
+func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, id string, color string, size int, owner string, appraisedValue int) error {
+	asset, err := readAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset.Color = color
+	asset.Size = size
+	asset.Owner = owner
+	asset.AppraisedValue = appraisedValue
+	return putAsset(ctx, asset, "UpdateAsset")
+}
+
+// TransferAsset updates the owner field of asset with given id in world state
+// and emits a TransferAsset event with the asset
+func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	asset, err := readAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset.Owner = newOwner
+	return putAsset(ctx, asset, "TransferAsset")
+}
+
+// DeleteAsset deletes a given asset and its private properties from the world
+// state and emits a DeleteAsset event with the deleted asset
+func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
+	asset, err := readAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	collection, ok, err := ownCollection(ctx)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := ctx.GetStub().DelPrivateData(collection, id); err != nil {
+			return fmt.Errorf("failed to delete private properties of asset %s: %v", id, err)
+		}
+	}
+
+	if err := ctx.GetStub().DelState(id); err != nil {
+		return fmt.Errorf("failed to delete asset %s: %v", id, err)
+	}
+	return setEvent(ctx, "DeleteAsset", asset)
+}
+
+// readAsset reads the public details of an asset from the world state
+func readAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	assetJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if assetJSON == nil {
+		return nil, fmt.Errorf("the asset %s does not exist", id)
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(assetJSON, &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// putAsset writes an asset to the world state, saves its private properties
+// if any were passed in the transient data and emits an event with the
+// public details of the asset
+func putAsset(ctx contractapi.TransactionContextInterface, asset *Asset, eventName string) error {
+	if err := savePrivateProperties(ctx, asset.ID); err != nil {
+		return err
+	}
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(asset.ID, assetJSON); err != nil {
+		return fmt.Errorf("failed to put to world state. %v", err)
+	}
+	return setEvent(ctx, eventName, asset)
+}
+
+// setEvent emits an event with the public details of an asset. The event is
+// delivered to listeners once the block containing the transaction is
+// committed, so it must not carry private properties.
+func setEvent(ctx contractapi.TransactionContextInterface, eventName string, asset *Asset) error {
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetEvent(eventName, assetJSON); err != nil {
+		return fmt.Errorf("failed to set event %s: %v", eventName, err)
+	}
+	return nil
+}
+
+// savePrivateProperties saves the private properties of an asset passed in the
+// transient data to the implicit collection of the organization
+func savePrivateProperties(ctx contractapi.TransactionContextInterface, id string) error {
+	collection, ok, err := ownCollection(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+	properties, ok := transientMap[privatePropertiesKey]
+	if !ok {
+		return nil
+	}
+	if !json.Valid(properties) {
+		return fmt.Errorf("%s must be a JSON value", privatePropertiesKey)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(collection, id, properties); err != nil {
+		return fmt.Errorf("failed to put private properties of asset %s: %v", id, err)
+	}
+	return nil
+}
+
+// ownCollection returns the implicit collection of the organization of the
+// peer, and whether the client belongs to this organization. Only clients of
+// the organization of the peer read and write its implicit collection.
+func ownCollection(ctx contractapi.TransactionContextInterface) (string, bool, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", false, fmt.Errorf("failed getting the client's MSPID: %v", err)
+	}
+	peerMSPID, err := shim.GetMSPID()
+	if err != nil {
+		return "", false, fmt.Errorf("failed getting the peer's MSPID: %v", err)
+	}
+
+	return implicitCollectionPrefix + peerMSPID, clientMSPID == peerMSPID, nil
+}
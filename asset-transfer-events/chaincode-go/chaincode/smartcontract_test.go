@@ -0,0 +1,180 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/asset-transfer-events/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-events/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate counterfeiter -o mocks/transaction.go -fake-name TransactionContext . transactionContext
+type transactionContext interface {
+	contractapi.TransactionContextInterface
+}
+
+//go:generate counterfeiter -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+const (
+	myOrg1Msp        = "Org1MSP"
+	myOrg2Msp        = "Org2MSP"
+	org1Collection   = "_implicit_org_Org1MSP"
+	storedAssetJSON  = `{"ID":"asset1","Color":"blue","Size":"10","Owner":"Sam","AppraisedValue":"100"}`
+	assetProperties  = `{"object_type":"asset_properties","asset_id":"asset1","Price":"90","salt":"a94a8fe5"}`
+	propertiesKey    = "asset_properties"
+	peerMSPIDEnvName = "CORE_PEER_LOCALMSPID"
+)
+
+// prepMocks returns the mocks of a transaction submitted by a client of
+// clientMSP to a peer of Org1
+func prepMocks(clientMSP string) (*mocks.TransactionContext, *mocks.ChaincodeStub) {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(clientMSP, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	//set the peer MSP ID using peer shim env variable
+	os.Setenv(peerMSPIDEnvName, myOrg1Msp)
+	return transactionContext, chaincodeStub
+}
+
+// requireEvent checks the single event emitted by a transaction
+func requireEvent(t *testing.T, chaincodeStub *mocks.ChaincodeStub, name string, payload string) {
+	require.Equal(t, 1, chaincodeStub.SetEventCallCount())
+	eventName, eventPayload := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, name, eventName)
+	require.JSONEq(t, payload, string(eventPayload))
+}
+
+func TestCreateAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.NoError(t, err)
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "asset1", key)
+	require.JSONEq(t, storedAssetJSON, string(value))
+	requireEvent(t, chaincodeStub, "CreateAsset", storedAssetJSON)
+	require.Equal(t, 0, chaincodeStub.PutPrivateDataCallCount())
+
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.EqualError(t, err, "the asset asset1 already exists")
+
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+}
+
+func TestCreateAssetWithPrivateProperties(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetTransientReturns(map[string][]byte{propertiesKey: []byte(assetProperties)}, nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.NoError(t, err)
+	collection, key, value := chaincodeStub.PutPrivateDataArgsForCall(0)
+	require.Equal(t, org1Collection, collection)
+	require.Equal(t, "asset1", key)
+	require.JSONEq(t, assetProperties, string(value))
+	// the event only carries the public details of the asset
+	requireEvent(t, chaincodeStub, "CreateAsset", storedAssetJSON)
+
+	chaincodeStub.GetTransientReturns(map[string][]byte{propertiesKey: []byte("Price=90")}, nil)
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.EqualError(t, err, "asset_properties must be a JSON value")
+
+	// clients of other organizations cannot write to the implicit collection of the peer organization
+	transactionContext, chaincodeStub = prepMocks(myOrg2Msp)
+	chaincodeStub.GetTransientReturns(map[string][]byte{propertiesKey: []byte(assetProperties)}, nil)
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", "blue", 10, "Sam", 100)
+	require.NoError(t, err)
+	require.Equal(t, 0, chaincodeStub.PutPrivateDataCallCount())
+	requireEvent(t, chaincodeStub, "CreateAsset", storedAssetJSON)
+}
+
+func TestReadAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	asset, err := assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.JSONEq(t, storedAssetJSON, asset)
+
+	chaincodeStub.GetPrivateDataReturns([]byte(assetProperties), nil)
+	asset, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ID":"asset1","Color":"blue","Size":"10","Owner":"Sam","AppraisedValue":"100","asset_properties":`+assetProperties+`}`, asset)
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	_, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestUpdateAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.UpdateAsset(transactionContext, "asset1", "blue", 10, "Sam", 200)
+	require.NoError(t, err)
+	requireEvent(t, chaincodeStub, "UpdateAsset", `{"ID":"asset1","Color":"blue","Size":"10","Owner":"Sam","AppraisedValue":"200"}`)
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	err = assetTransfer.UpdateAsset(transactionContext, "asset1", "blue", 10, "Sam", 200)
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestTransferAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.TransferAsset(transactionContext, "asset1", "Mary")
+	require.NoError(t, err)
+	requireEvent(t, chaincodeStub, "TransferAsset", `{"ID":"asset1","Color":"blue","Size":"10","Owner":"Mary","AppraisedValue":"100"}`)
+
+	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
+	err = assetTransfer.TransferAsset(transactionContext, "asset1", "Mary")
+	require.EqualError(t, err, "failed to put to world state. failed inserting key")
+}
+
+func TestDeleteAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.DeleteAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, "asset1", chaincodeStub.DelStateArgsForCall(0))
+	collection, key := chaincodeStub.DelPrivateDataArgsForCall(0)
+	require.Equal(t, org1Collection, collection)
+	require.Equal(t, "asset1", key)
+	requireEvent(t, chaincodeStub, "DeleteAsset", storedAssetJSON)
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	err = assetTransfer.DeleteAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestNewChaincode(t *testing.T) {
+	_, err := contractapi.NewChaincode(&chaincode.SmartContract{})
+	require.NoError(t, err)
+}
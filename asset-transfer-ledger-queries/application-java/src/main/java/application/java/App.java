@@ -116,10 +116,10 @@ public class App {
 			result = contract.evaluateTransaction("GetAssetHistory", "asset13");
 			System.out.println("result: " + new String(result));
 
-			// Rich Query (Only supported if CouchDB is used as state database):
+			// Rich Query sorted using the index on size, docType and owner (Only supported if CouchDB is used as state database):
 			System.out.println("\n");
-			System.out.println("Evaluate Transaction:QueryAssets assets of size 15");
-			result = contract.evaluateTransaction("QueryAssets", "{\"selector\":{\"size\":15}}");
+			System.out.println("Evaluate Transaction:QueryAssets Michel's assets of size 10 or larger, largest first");
+			result = contract.evaluateTransaction("QueryAssets", "{\"selector\":{\"docType\":\"asset\",\"owner\":\"Michel\",\"size\":{\"$gte\":10}},\"sort\":[{\"size\":\"desc\"}],\"use_index\":[\"_design/indexSizeSortDoc\", \"indexSizeSortDesc\"]}");
 			System.out.println("result: " + new String(result));
 
 			// Rich Query with index design doc and index name specified (Only supported if CouchDB is used as state database):
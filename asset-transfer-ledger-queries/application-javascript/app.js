@@ -214,9 +214,9 @@ async function main() {
 			result = await contract.evaluateTransaction('GetAssetHistory', 'asset7');
 			console.log(`*** Result: ${prettyJSONString(result.toString())}`);
 
-			// Rich Query (Only supported if CouchDB is used as state database):
-			console.log('\n--> Evaluate Transaction: QueryAssets, assets of size 15');
-			result = await contract.evaluateTransaction('QueryAssets', '{"selector":{"size":15}}');
+			// Rich Query sorted using the index on size, docType and owner (Only supported if CouchDB is used as state database):
+			console.log('\n--> Evaluate Transaction: QueryAssets, Michel\'s assets of size 10 or larger, largest first');
+			result = await contract.evaluateTransaction('QueryAssets', '{"selector":{"docType":"asset","owner":"Michel","size":{"$gte":10}},"sort":[{"size":"desc"}],"use_index":["_design/indexSizeSortDoc", "indexSizeSortDesc"]}');
 			console.log(`*** Result: ${prettyJSONString(result.toString())}`);
 
 			// Rich Query with index design doc and index name specified (Only supported if CouchDB is used as state database):
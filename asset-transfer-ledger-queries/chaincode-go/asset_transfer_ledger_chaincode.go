@@ -11,19 +11,25 @@ peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["CreateAsset","asse
 peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["CreateAsset","asset3","blue","6","tom","70"]}'
 peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["TransferAsset","asset2","jerry"]}'
 peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["TransferAssetByColor","blue","jerry"]}'
+peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["BulkTransferAssetsByColor","blue","jerry","[\"asset1\",\"asset3\"]","true"]}'
+peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["BulkTransferAssetsByColor","blue","jerry","[\"asset1\",\"asset3\"]","false"]}'
 peer chaincode invoke -C myc1 -n asset_transfer -c '{"Args":["DeleteAsset","asset1"]}'
 
 ==== Query assets ====
 peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["ReadAsset","asset1"]}'
 peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetAssetsByRange","asset1","asset3"]}'
 peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetAssetHistory","asset1"]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetAssetAsOf","asset1","2021-03-31T17:00:00Z"]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetOwnershipTimeline","asset1"]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetAssetsByOwnerAsOf","tom","2021-03-31","10",""]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["GetAssetIDsByColor","blue","2",""]}'
 
 Rich Query (Only supported if CouchDB is used as state database):
 peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssetsByOwner","tom"]}'
-peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"selector\":{\"owner\":\"tom\"}}"]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}"]}'
 
 Rich Query with Pagination (Only supported if CouchDB is used as state database):
-peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssetsWithPagination","{\"selector\":{\"owner\":\"tom\"}}","3",""]}'
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssetsWithPagination","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}","3",""]}'
 
 INDEXES TO SUPPORT COUCHDB RICH QUERIES
 
@@ -34,8 +40,8 @@ text file with extension *.json with the index definition formatted in JSON foll
 CouchDB index JSON syntax as documented at:
 http://docs.couchdb.org/en/2.3.1/api/database/find.html#db-index
 
-This asset transfer ledger example chaincode demonstrates a packaged
-index which you can find in META-INF/statedb/couchdb/indexes/indexOwner.json.
+This asset transfer ledger example chaincode demonstrates packaged
+indexes which you can find in META-INF/statedb/couchdb/indexes.
 
 If you have access to the your peer's CouchDB state database in a development environment,
 you may want to iteratively test various indexes in support of your chaincode queries.  You
@@ -60,11 +66,21 @@ Index for docType, owner, size (descending order).
 Example curl command line to define index in the CouchDB channel_chaincode database:
 curl -i -X POST -H "Content-Type: application/json" -d "{\"index\":{\"fields\":[{\"size\":\"desc\"},{\"docType\":\"desc\"},{\"owner\":\"desc\"}]},\"ddoc\":\"indexSizeSortDoc\", \"name\":\"indexSizeSortDesc\",\"type\":\"json\"}" http://hostname:port/myc1_assets/_index
 
-Rich Query with index design doc and index name specified (Only supported if CouchDB is used as state database):
-peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"selector\":{\"docType\":\"asset\",\"owner\":\"tom\"}, \"use_index\":[\"_design/indexOwnerDoc\", \"indexOwner\"]}"]}'
+QUERIES USING THE INDEXES
 
-Rich Query with index design doc specified only (Only supported if CouchDB is used as state database):
-peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"selector\":{\"docType\":{\"$eq\":\"asset\"},\"owner\":{\"$eq\":\"tom\"},\"size\":{\"$gt\":0}},\"fields\":[\"docType\",\"owner\",\"size\"],\"sort\":[{\"size\":\"desc\"}],\"use_index\":\"_design/indexSizeSortDoc\"}"]}'
+QueryAssets and QueryAssetsWithPagination do not pass the query of the client to CouchDB as is.
+They build the query from structured filters and sort fields, restricted to assets and to the
+fields of one of the packaged indexes, see assetQueries. The query builder also accepts the
+CouchDB syntax for selectors that only compare fields with values.
+
+Rich Query on the owner, using indexOwner (Only supported if CouchDB is used as state database):
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}"]}'
+
+Rich Query on the owner and size sorted by size, using indexSizeSortDesc (Only supported if CouchDB is used as state database):
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"},{\"field\":\"size\",\"op\":\"gt\",\"value\":0}],\"sort\":[{\"field\":\"size\",\"descending\":true}]}"]}'
+
+The same query in the CouchDB syntax (Only supported if CouchDB is used as state database):
+peer chaincode query -C myc1 -n asset_transfer -c '{"Args":["QueryAssets","{\"selector\":{\"docType\":\"asset\",\"owner\":\"tom\",\"size\":{\"$gt\":0}},\"sort\":[{\"size\":\"desc\"}]}"]}'
 */
 
 package main
@@ -73,15 +89,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/richquery"
 )
 
 const index = "color~name"
 
+// maxPageSize is the largest number of assets returned by a page of a rich query
+const maxPageSize = 100
+
+// maxBulkTransferSize is the largest number of assets a batch of a bulk transfer processes
+const maxBulkTransferSize = 100
+
+// assetQueries builds the rich queries for assets. The indexes mirror the
+// index definitions in META-INF/statedb/couchdb/indexes, queries can only
+// filter and sort on the fields of one of them.
+var assetQueries = richquery.NewBuilder("asset", maxPageSize,
+	&richquery.Index{DesignDoc: "indexOwnerDoc", Name: "indexOwner", Fields: []string{"docType", "owner"}},
+	&richquery.Index{DesignDoc: "indexSizeSortDoc", Name: "indexSizeSortDesc", Fields: []string{"size", "docType", "owner"}},
+)
+
 // SimpleChaincode implements the fabric-contract-api-go programming model
 type SimpleChaincode struct {
 	contractapi.Contract
@@ -104,6 +136,45 @@ type HistoryQueryResult struct {
 	IsDelete  bool      `json:"isDelete"`
 }
 
+// OwnershipInterval is a period during which an asset had the same owner.
+// The interval of the current owner has not ended, its To is the zero time.
+type OwnershipInterval struct {
+	Owner    string    `json:"owner"`
+	From     time.Time `json:"from"`
+	FromTxId string    `json:"fromTxId"`
+	To       time.Time `json:"to"`
+	ToTxId   string    `json:"toTxId"`
+	Current  bool      `json:"current"`
+}
+
+// Statuses of the assets processed by a bulk transfer
+const (
+	BulkTransferred = "transferred"
+	BulkSkipped     = "skipped"
+)
+
+// BulkTransferItem reports what a bulk transfer did, or would do in a dry run, with one asset
+type BulkTransferItem struct {
+	ID     string `json:"ID"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkTransferResult reports a batch of a bulk transfer. ChangedKeys are the keys the batch
+// wrote, or would write in a dry run.
+type BulkTransferResult struct {
+	Items       []BulkTransferItem `json:"items"`
+	ChangedKeys []string           `json:"changedKeys"`
+	DryRun      bool               `json:"dryRun"`
+}
+
+// AssetIDsPage is a page of the IDs of the assets of a color. Bookmark continues with the
+// next page, it is empty once all assets of the color have been listed.
+type AssetIDsPage struct {
+	IDs      []string `json:"IDs"`
+	Bookmark string   `json:"bookmark"`
+}
+
 // PaginatedQueryResult structure used for returning paginated query results and metadata
 type PaginatedQueryResult struct {
 	Records             []*Asset `json:"records"`
@@ -253,6 +324,8 @@ func (t *SimpleChaincode) GetAssetsByRange(ctx contractapi.TransactionContextInt
 // between endorsement time and commit time. The transaction is invalidated by the
 // committing peers if the result set has changed between endorsement time and commit time.
 // Therefore, range queries are a safe option for performing update transactions based on query results.
+// All assets of the color are transferred in one transaction, see BulkTransferAssetsByColor for
+// large color groups, which skips the assets it cannot transfer instead of failing.
 // Example: GetStateByPartialCompositeKey/RangeQuery
 func (t *SimpleChaincode) TransferAssetByColor(ctx contractapi.TransactionContextInterface, color, newOwner string) error {
 	// Execute a key range query on all keys starting with 'color'
@@ -294,23 +367,137 @@ func (t *SimpleChaincode) TransferAssetByColor(ctx contractapi.TransactionContex
 	return nil
 }
 
+// GetAssetIDsByColor returns a page of the IDs of the assets of a given color from the
+// color~name index, for BulkTransferAssetsByColor. The page starts at the bookmark and holds
+// at most pageSize IDs, capped at maxBulkTransferSize. A page size of 0 requests the largest
+// page. The bookmark of the result continues with the next page, it is empty once all assets
+// of the color have been listed.
+// Paginated queries are only valid for read only transactions.
+// Example: GetStateByPartialCompositeKeyWithPagination
+func (t *SimpleChaincode) GetAssetIDsByColor(ctx contractapi.TransactionContextInterface, color string, pageSize int, bookmark string) (*AssetIDsPage, error) {
+	if pageSize <= 0 || pageSize > maxBulkTransferSize {
+		pageSize = maxBulkTransferSize
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(index, []string{color}, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := &AssetIDsPage{IDs: []string{}, Bookmark: responseMetadata.Bookmark}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(compositeKeyParts) > 1 {
+			page.IDs = append(page.IDs, compositeKeyParts[1])
+		}
+	}
+
+	return page, nil
+}
+
+// BulkTransferAssetsByColor transfers a batch of assets of a given color to a new owner, so that
+// large color groups can be transferred in several transactions within the proposal size limits.
+// The client lists the assets of the color a page at a time with GetAssetIDsByColor, which starts
+// every page at the bookmark of the previous one, and submits one transaction per page. Each
+// batch is atomic, and reads and writes only the keys of its own assets, so it only conflicts
+// with concurrent changes to those assets. Assets that no longer exist, no longer have the color
+// or are already owned by the new owner are skipped, assets that got the color after their page
+// was listed are not transferred. The result reports for each asset of the batch whether it was
+// transferred or skipped.
+// In a dry run nothing is written, the result previews the keys the batch would change.
+// Example: Resumable bulk update using GetStateByPartialCompositeKeyWithPagination
+func (t *SimpleChaincode) BulkTransferAssetsByColor(ctx contractapi.TransactionContextInterface, color, newOwner string, assetIDs []string, dryRun bool) (*BulkTransferResult, error) {
+	if len(assetIDs) > maxBulkTransferSize {
+		return nil, fmt.Errorf("a batch can transfer at most %d assets", maxBulkTransferSize)
+	}
+
+	result := &BulkTransferResult{Items: []BulkTransferItem{}, ChangedKeys: []string{}, DryRun: dryRun}
+	for _, assetID := range assetIDs {
+		item, err := transferAssetOfColor(ctx, assetID, color, newOwner, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("transfer failed for asset %s: %v", assetID, err)
+		}
+		result.Items = append(result.Items, *item)
+		if item.Status == BulkTransferred {
+			result.ChangedKeys = append(result.ChangedKeys, assetID)
+		}
+	}
+
+	return result, nil
+}
+
+// transferAssetOfColor transfers an asset found in the color~name index, unless the asset no
+// longer exists, no longer has the color or is already owned by the new owner.
+func transferAssetOfColor(ctx contractapi.TransactionContextInterface, assetID, color, newOwner string, dryRun bool) (*BulkTransferItem, error) {
+	assetBytes, err := ctx.GetStub().GetState(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if assetBytes == nil {
+		return &BulkTransferItem{ID: assetID, Status: BulkSkipped, Reason: "asset does not exist"}, nil
+	}
+
+	var asset Asset
+	err = json.Unmarshal(assetBytes, &asset)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Color != color {
+		return &BulkTransferItem{ID: assetID, Status: BulkSkipped, Reason: fmt.Sprintf("color changed to %s", asset.Color)}, nil
+	}
+	if asset.Owner == newOwner {
+		return &BulkTransferItem{ID: assetID, Status: BulkSkipped, Reason: fmt.Sprintf("already owned by %s", newOwner)}, nil
+	}
+
+	if !dryRun {
+		asset.Owner = newOwner
+		assetBytes, err = json.Marshal(asset)
+		if err != nil {
+			return nil, err
+		}
+		err = ctx.GetStub().PutState(assetID, assetBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &BulkTransferItem{ID: assetID, Status: BulkTransferred}, nil
+}
+
 // QueryAssetsByOwner queries for assets based on the owners name.
 // This is an example of a parameterized query where the query logic is baked into the chaincode,
 // and accepting a single query parameter (owner).
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Example: Parameterized rich query
 func (t *SimpleChaincode) QueryAssetsByOwner(ctx contractapi.TransactionContextInterface, owner string) ([]*Asset, error) {
-	queryString := fmt.Sprintf(`{"selector":{"docType":"asset","owner":"%s"}}`, owner)
+	queryString, err := assetQueries.Build(&richquery.Query{
+		Filters: []richquery.Filter{{Field: "owner", Value: owner}},
+	})
+	if err != nil {
+		return nil, err
+	}
 	return getQueryResultForQueryString(ctx, queryString)
 }
 
-// QueryAssets uses a query string to perform a query for assets.
-// Query string matching state database syntax is passed in and executed as is.
-// Supports ad hoc queries that can be defined at runtime by the client.
-// If this is not desired, follow the QueryAssetsForOwner example for parameterized queries.
+// QueryAssets uses filters and sort fields defined at runtime by the client to perform
+// a query for assets. The query string for the state database is built by assetQueries,
+// which rejects queries that are not supported by one of the packaged indexes.
 // Only available on state databases that support rich query (e.g. CouchDB)
-// Example: Ad hoc rich query
-func (t *SimpleChaincode) QueryAssets(ctx contractapi.TransactionContextInterface, queryString string) ([]*Asset, error) {
+// Example: Structured rich query
+func (t *SimpleChaincode) QueryAssets(ctx contractapi.TransactionContextInterface, query string) ([]*Asset, error) {
+	queryString, err := assetQueries.BuildJSON(query)
+	if err != nil {
+		return nil, err
+	}
 	return getQueryResultForQueryString(ctx, queryString)
 }
 
@@ -351,17 +538,20 @@ func (t *SimpleChaincode) GetAssetsByRangeWithPagination(ctx contractapi.Transac
 	}, nil
 }
 
-// QueryAssetsWithPagination uses a query string, page size and a bookmark to perform a query
-// for assets. Query string matching state database syntax is passed in and executed as is.
-// The number of fetched records would be equal to or lesser than the specified page size.
-// Supports ad hoc queries that can be defined at runtime by the client.
-// If this is not desired, follow the QueryAssetsForOwner example for parameterized queries.
+// QueryAssetsWithPagination uses filters and sort fields, a page size and a bookmark to
+// perform a query for assets. The query is built like in QueryAssets.
+// The number of fetched records would be equal to or lesser than the specified page size,
+// which is capped at maxPageSize. A page size of 0 requests the largest page.
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Paginated queries are only valid for read only transactions.
-// Example: Pagination with Ad hoc Rich Query
-func (t *SimpleChaincode) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+// Example: Pagination with Structured Rich Query
+func (t *SimpleChaincode) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, query string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	queryString, err := assetQueries.BuildJSON(query)
+	if err != nil {
+		return nil, err
+	}
 
-	return getQueryResultForQueryStringWithPagination(ctx, queryString, int32(pageSize), bookmark)
+	return getQueryResultForQueryStringWithPagination(ctx, queryString, assetQueries.PageSize(int32(pageSize)), bookmark)
 }
 
 // getQueryResultForQueryStringWithPagination executes the passed in query string with
@@ -432,6 +622,154 @@ func (t *SimpleChaincode) GetAssetHistory(ctx contractapi.TransactionContextInte
 	return records, nil
 }
 
+// GetAssetAsOf reconstructs the state of an asset at a past time from its history.
+// The timestamp is either an RFC 3339 time, such as 2021-03-31T17:00:00Z, or a date,
+// such as 2021-03-31, which stands for the end of that day in UTC. The history is
+// ordered by the timestamps of the transactions that wrote it.
+func (t *SimpleChaincode) GetAssetAsOf(ctx contractapi.TransactionContextInterface, assetID string, timestamp string) (*Asset, error) {
+	asOf, err := parseAsOf(timestamp)
+	if err != nil {
+		return nil, err
+	}
+	records, err := t.getChronologicalHistory(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	asset := assetAsOf(records, asOf)
+	if asset == nil {
+		return nil, fmt.Errorf("asset %s did not exist at %s", assetID, asOf.Format(time.RFC3339))
+	}
+	return asset, nil
+}
+
+// GetOwnershipTimeline returns the intervals during which an asset had the same owner,
+// oldest first. Deleting the asset ends an interval, recreating it starts a new one.
+func (t *SimpleChaincode) GetOwnershipTimeline(ctx contractapi.TransactionContextInterface, assetID string) ([]OwnershipInterval, error) {
+	records, err := t.getChronologicalHistory(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("asset %s has no history", assetID)
+	}
+
+	var timeline []OwnershipInterval
+	for _, record := range records {
+		var current *OwnershipInterval
+		if len(timeline) > 0 && timeline[len(timeline)-1].Current {
+			current = &timeline[len(timeline)-1]
+		}
+		if current != nil && !record.IsDelete && current.Owner == record.Record.Owner {
+			continue
+		}
+		if current != nil {
+			current.To = record.Timestamp
+			current.ToTxId = record.TxId
+			current.Current = false
+		}
+		if !record.IsDelete {
+			timeline = append(timeline, OwnershipInterval{
+				Owner:    record.Record.Owner,
+				From:     record.Timestamp,
+				FromTxId: record.TxId,
+				Current:  true,
+			})
+		}
+	}
+
+	return timeline, nil
+}
+
+// GetAssetsByOwnerAsOf returns the state at a past time of the assets an owner had at
+// that time. The timestamp is interpreted like in GetAssetAsOf. Owners change over
+// time, so no index of the world state can find the assets an owner used to have.
+// Instead, a page of the assets in the world state is examined at a time, and the
+// history of each of them is used to reconstruct its state. The page size is capped
+// at maxPageSize, a page size of 0 requests the largest page. FetchedRecordsCount is
+// the number of assets examined, Records are those that belonged to the owner, and
+// Bookmark continues with the next page, it is empty once all assets have been
+// examined. Assets that have been deleted since the given time are not found, as
+// their keys are no longer in the world state.
+// Paginated range queries are only valid for read only transactions.
+func (t *SimpleChaincode) GetAssetsByOwnerAsOf(ctx contractapi.TransactionContextInterface, owner string, timestamp string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	asOf, err := parseAsOf(timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", assetQueries.PageSize(int32(pageSize)), bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	assets := []*Asset{}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		records, err := t.getChronologicalHistory(ctx, queryResult.Key)
+		if err != nil {
+			return nil, err
+		}
+		asset := assetAsOf(records, asOf)
+		if asset != nil && asset.Owner == owner {
+			assets = append(assets, asset)
+		}
+	}
+
+	return &PaginatedQueryResult{
+		Records:             assets,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            responseMetadata.Bookmark,
+	}, nil
+}
+
+// getChronologicalHistory returns the history of an asset ordered by the timestamps
+// of the transactions, oldest first.
+func (t *SimpleChaincode) getChronologicalHistory(ctx contractapi.TransactionContextInterface, assetID string) ([]HistoryQueryResult, error) {
+	records, err := t.GetAssetHistory(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// assetAsOf returns the state of an asset at a time from its chronological history,
+// or nil if the asset did not exist at that time.
+func assetAsOf(records []HistoryQueryResult, asOf time.Time) *Asset {
+	var asset *Asset
+	for _, record := range records {
+		if record.Timestamp.After(asOf) {
+			break
+		}
+		asset = record.Record
+		if record.IsDelete {
+			asset = nil
+		}
+	}
+	return asset
+}
+
+// parseAsOf parses an RFC 3339 time, or a date that stands for the end of that day in UTC.
+func parseAsOf(timestamp string) (time.Time, error) {
+	asOf, err := time.Parse(time.RFC3339, timestamp)
+	if err == nil {
+		return asOf, nil
+	}
+	date, err := time.Parse("2006-01-02", timestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %s: expecting an RFC 3339 time or a date in the format YYYY-MM-DD", timestamp)
+	}
+	return date.Add(24*time.Hour - time.Nanosecond), nil
+}
+
 // AssetExists returns true when asset with given ID exists in the ledger.
 func (t *SimpleChaincode) AssetExists(ctx contractapi.TransactionContextInterface, assetID string) (bool, error) {
 	assetBytes, err := ctx.GetStub().GetState(assetID)
@@ -0,0 +1,366 @@
+/*
+ SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/richquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetQueriesMatchPackagedIndexes(t *testing.T) {
+	indexes, err := richquery.LoadIndexes("META-INF/statedb/couchdb/indexes")
+	require.NoError(t, err)
+	require.Equal(t, indexes, assetQueries.Indexes())
+}
+
+// historyStub serves the history of assets from memory, newest first like the peer
+type historyStub struct {
+	shim.ChaincodeStubInterface
+	history map[string][]*queryresult.KeyModification
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (it *historyIterator) HasNext() bool {
+	return len(it.modifications) > 0
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := it.modifications[0]
+	it.modifications = it.modifications[1:]
+	return modification, nil
+}
+
+func (it *historyIterator) Close() error {
+	return nil
+}
+
+type stateIterator struct {
+	keys []string
+}
+
+func (it *stateIterator) HasNext() bool {
+	return len(it.keys) > 0
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	key := it.keys[0]
+	it.keys = it.keys[1:]
+	return &queryresult.KV{Key: key}, nil
+}
+
+func (it *stateIterator) Close() error {
+	return nil
+}
+
+func (s *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: s.history[key]}, nil
+}
+
+// GetStateByRangeWithPagination pages through the assets that are not deleted. Like the
+// peer, the bookmark is the key the next page starts at.
+func (s *historyStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	keys := []string{}
+	for key, modifications := range s.history {
+		if !modifications[0].IsDelete && key >= bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	next := ""
+	if len(keys) > int(pageSize) {
+		keys, next = keys[:pageSize], keys[pageSize]
+	}
+	return &stateIterator{keys: keys}, &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(keys)), Bookmark: next}, nil
+}
+
+// write records a modification of an asset at a time on a day in March 2021
+func (s *historyStub) write(t *testing.T, txID string, day int, hour int, asset *Asset) {
+	timestamp, err := ptypes.TimestampProto(time.Date(2021, time.March, day, hour, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	modification := &queryresult.KeyModification{TxId: txID, Timestamp: timestamp, IsDelete: asset.Owner == ""}
+	if !modification.IsDelete {
+		modification.Value, err = json.Marshal(asset)
+		require.NoError(t, err)
+	}
+	s.history[asset.ID] = append([]*queryresult.KeyModification{modification}, s.history[asset.ID]...)
+}
+
+func newHistoryContext(t *testing.T) *contractapi.TransactionContext {
+	stub := &historyStub{history: map[string][]*queryresult.KeyModification{}}
+	stub.write(t, "tx1", 1, 9, &Asset{DocType: "asset", ID: "asset1", Color: "blue", Size: 5, Owner: "tom"})
+	stub.write(t, "tx2", 1, 10, &Asset{DocType: "asset", ID: "asset2", Color: "red", Size: 4, Owner: "tom"})
+	stub.write(t, "tx3", 10, 12, &Asset{DocType: "asset", ID: "asset1", Color: "blue", Size: 5, Owner: "jerry"})
+	stub.write(t, "tx4", 15, 12, &Asset{DocType: "asset", ID: "asset1", Color: "green", Size: 5, Owner: "jerry"})
+	stub.write(t, "tx5", 20, 12, &Asset{ID: "asset2"})
+	stub.write(t, "tx6", 25, 12, &Asset{DocType: "asset", ID: "asset1", Color: "green", Size: 5, Owner: "tom"})
+
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	return ctx
+}
+
+func TestGetAssetAsOf(t *testing.T) {
+	ctx := newHistoryContext(t)
+	chaincode := &SimpleChaincode{}
+
+	asset, err := chaincode.GetAssetAsOf(ctx, "asset1", "2021-03-10T11:59:59Z")
+	require.NoError(t, err)
+	require.Equal(t, "tom", asset.Owner)
+	asset, err = chaincode.GetAssetAsOf(ctx, "asset1", "2021-03-10T12:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, "jerry", asset.Owner)
+	asset, err = chaincode.GetAssetAsOf(ctx, "asset1", "2021-03-15")
+	require.NoError(t, err)
+	require.Equal(t, "green", asset.Color)
+
+	_, err = chaincode.GetAssetAsOf(ctx, "asset1", "2021-02-28")
+	require.EqualError(t, err, "asset asset1 did not exist at 2021-02-28T23:59:59Z")
+	_, err = chaincode.GetAssetAsOf(ctx, "asset2", "2021-03-21")
+	require.EqualError(t, err, "asset asset2 did not exist at 2021-03-21T23:59:59Z")
+	_, err = chaincode.GetAssetAsOf(ctx, "asset1", "yesterday")
+	require.EqualError(t, err, "invalid timestamp yesterday: expecting an RFC 3339 time or a date in the format YYYY-MM-DD")
+}
+
+func TestGetOwnershipTimeline(t *testing.T) {
+	ctx := newHistoryContext(t)
+	chaincode := &SimpleChaincode{}
+
+	timeline, err := chaincode.GetOwnershipTimeline(ctx, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, []OwnershipInterval{
+		{Owner: "tom", From: time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC), FromTxId: "tx1", To: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC), ToTxId: "tx3"},
+		{Owner: "jerry", From: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC), FromTxId: "tx3", To: time.Date(2021, time.March, 25, 12, 0, 0, 0, time.UTC), ToTxId: "tx6"},
+		{Owner: "tom", From: time.Date(2021, time.March, 25, 12, 0, 0, 0, time.UTC), FromTxId: "tx6", Current: true},
+	}, timeline)
+
+	timeline, err = chaincode.GetOwnershipTimeline(ctx, "asset2")
+	require.NoError(t, err)
+	require.Equal(t, []OwnershipInterval{
+		{Owner: "tom", From: time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC), FromTxId: "tx2", To: time.Date(2021, time.March, 20, 12, 0, 0, 0, time.UTC), ToTxId: "tx5"},
+	}, timeline)
+
+	_, err = chaincode.GetOwnershipTimeline(ctx, "asset3")
+	require.EqualError(t, err, "asset asset3 has no history")
+}
+
+func TestGetAssetsByOwnerAsOf(t *testing.T) {
+	ctx := newHistoryContext(t)
+	chaincode := &SimpleChaincode{}
+
+	result, err := chaincode.GetAssetsByOwnerAsOf(ctx, "jerry", "2021-03-12", 0, "")
+	require.NoError(t, err)
+	require.Len(t, result.Records, 1)
+	require.Equal(t, "asset1", result.Records[0].ID)
+
+	result, err = chaincode.GetAssetsByOwnerAsOf(ctx, "tom", "2021-03-26", 0, "")
+	require.NoError(t, err)
+	require.Len(t, result.Records, 1)
+	require.Equal(t, "green", result.Records[0].Color)
+
+	// deleted assets are no longer in the world state
+	result, err = chaincode.GetAssetsByOwnerAsOf(ctx, "tom", "2021-03-05", 0, "")
+	require.NoError(t, err)
+	require.Len(t, result.Records, 1)
+	require.Equal(t, "asset1", result.Records[0].ID)
+	require.Empty(t, result.Bookmark)
+}
+
+func TestGetAssetsByOwnerAsOfWithPagination(t *testing.T) {
+	ctx := newHistoryContext(t)
+	ctx.GetStub().(*historyStub).write(t, "tx7", 26, 12, &Asset{DocType: "asset", ID: "asset3", Color: "red", Size: 3, Owner: "tom"})
+	chaincode := &SimpleChaincode{}
+
+	result, err := chaincode.GetAssetsByOwnerAsOf(ctx, "tom", "2021-03-27", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.FetchedRecordsCount)
+	require.Equal(t, "asset1", result.Records[0].ID)
+	require.Equal(t, "asset3", result.Bookmark)
+
+	result, err = chaincode.GetAssetsByOwnerAsOf(ctx, "tom", "2021-03-27", 1, result.Bookmark)
+	require.NoError(t, err)
+	require.Equal(t, "asset3", result.Records[0].ID)
+	require.Empty(t, result.Bookmark)
+
+	// a page can examine assets without finding any of the owner
+	result, err = chaincode.GetAssetsByOwnerAsOf(ctx, "jerry", "2021-03-27", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.FetchedRecordsCount)
+	require.Empty(t, result.Records)
+}
+
+// pagingStub adds the paginated partial composite key queries the mock stub lacks. Like the
+// peer, the bookmark is the key the next page starts at.
+type pagingStub struct {
+	*shimtest.MockStub
+}
+
+func (s *pagingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	resultsIterator, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := []string{}
+	next := ""
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.Key < bookmark {
+			continue
+		}
+		if len(page) == int(pageSize) {
+			next = result.Key
+			break
+		}
+		page = append(page, result.Key)
+	}
+	return &stateIterator{keys: page}, &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}, nil
+}
+
+func newBulkTransferContext(t *testing.T) (*contractapi.TransactionContext, *shimtest.MockStub) {
+	stub := shimtest.NewMockStub("ledger", nil)
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(&pagingStub{stub})
+
+	chaincode := &SimpleChaincode{}
+	stub.MockTransactionStart("init")
+	for _, asset := range []Asset{
+		{ID: "asset1", Color: "blue", Owner: "tom"},
+		{ID: "asset2", Color: "blue", Owner: "jerry"},
+		{ID: "asset3", Color: "blue", Owner: "tom"},
+		{ID: "asset4", Color: "red", Owner: "tom"},
+		{ID: "asset5", Color: "blue", Owner: "tom"},
+	} {
+		require.NoError(t, chaincode.CreateAsset(ctx, asset.ID, asset.Color, 5, asset.Owner, 100))
+	}
+	stub.MockTransactionEnd("init")
+	return ctx, stub
+}
+
+func TestGetAssetIDsByColor(t *testing.T) {
+	ctx, _ := newBulkTransferContext(t)
+	chaincode := &SimpleChaincode{}
+
+	page, err := chaincode.GetAssetIDsByColor(ctx, "blue", 3, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"asset1", "asset2", "asset3"}, page.IDs)
+	require.NotEmpty(t, page.Bookmark)
+
+	page, err = chaincode.GetAssetIDsByColor(ctx, "blue", 3, page.Bookmark)
+	require.NoError(t, err)
+	require.Equal(t, &AssetIDsPage{IDs: []string{"asset5"}}, page)
+
+	page, err = chaincode.GetAssetIDsByColor(ctx, "green", 0, "")
+	require.NoError(t, err)
+	require.Equal(t, &AssetIDsPage{IDs: []string{}}, page)
+}
+
+func TestTransferAssetByColor(t *testing.T) {
+	ctx, stub := newBulkTransferContext(t)
+	chaincode := &SimpleChaincode{}
+
+	stub.MockTransactionStart("tx1")
+	err := chaincode.TransferAssetByColor(ctx, "blue", "jerry")
+	stub.MockTransactionEnd("tx1")
+	require.NoError(t, err)
+	for _, assetID := range []string{"asset1", "asset2", "asset3", "asset5"} {
+		asset, err := chaincode.ReadAsset(ctx, assetID)
+		require.NoError(t, err)
+		require.Equal(t, "jerry", asset.Owner)
+	}
+
+	// unlike a bulk transfer, an indexed asset that does not exist fails the transfer
+	stub.MockTransactionStart("tx2")
+	require.NoError(t, stub.DelState("asset3"))
+	err = chaincode.TransferAssetByColor(ctx, "blue", "tom")
+	stub.MockTransactionEnd("tx2")
+	require.EqualError(t, err, "asset asset3 does not exist")
+}
+
+func TestBulkTransferAssetsByColor(t *testing.T) {
+	ctx, stub := newBulkTransferContext(t)
+	chaincode := &SimpleChaincode{}
+
+	page, err := chaincode.GetAssetIDsByColor(ctx, "blue", 2, "")
+	require.NoError(t, err)
+	stub.MockTransactionStart("tx1")
+	result, err := chaincode.BulkTransferAssetsByColor(ctx, "blue", "jerry", page.IDs, false)
+	stub.MockTransactionEnd("tx1")
+	require.NoError(t, err)
+	require.Equal(t, &BulkTransferResult{
+		Items: []BulkTransferItem{
+			{ID: "asset1", Status: BulkTransferred},
+			{ID: "asset2", Status: BulkSkipped, Reason: "already owned by jerry"},
+		},
+		ChangedKeys: []string{"asset1"},
+	}, result)
+
+	page, err = chaincode.GetAssetIDsByColor(ctx, "blue", 2, page.Bookmark)
+	require.NoError(t, err)
+	require.Empty(t, page.Bookmark)
+	stub.MockTransactionStart("tx2")
+	result, err = chaincode.BulkTransferAssetsByColor(ctx, "blue", "jerry", page.IDs, false)
+	stub.MockTransactionEnd("tx2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"asset3", "asset5"}, result.ChangedKeys)
+
+	for _, assetID := range []string{"asset1", "asset3", "asset5"} {
+		asset, err := chaincode.ReadAsset(ctx, assetID)
+		require.NoError(t, err)
+		require.Equal(t, "jerry", asset.Owner)
+	}
+	asset, err := chaincode.ReadAsset(ctx, "asset4")
+	require.NoError(t, err)
+	require.Equal(t, "tom", asset.Owner)
+
+	// assets that changed color or were deleted since their page was listed are skipped
+	stub.MockTransactionStart("tx3")
+	result, err = chaincode.BulkTransferAssetsByColor(ctx, "blue", "tom", []string{"asset4", "asset6"}, false)
+	stub.MockTransactionEnd("tx3")
+	require.NoError(t, err)
+	require.Equal(t, []BulkTransferItem{
+		{ID: "asset4", Status: BulkSkipped, Reason: "color changed to red"},
+		{ID: "asset6", Status: BulkSkipped, Reason: "asset does not exist"},
+	}, result.Items)
+
+	_, err = chaincode.BulkTransferAssetsByColor(ctx, "blue", "jerry", make([]string, maxBulkTransferSize+1), false)
+	require.EqualError(t, err, "a batch can transfer at most 100 assets")
+}
+
+func TestBulkTransferAssetsByColorDryRun(t *testing.T) {
+	ctx, stub := newBulkTransferContext(t)
+	chaincode := &SimpleChaincode{}
+
+	page, err := chaincode.GetAssetIDsByColor(ctx, "blue", 10, "")
+	require.NoError(t, err)
+	stub.MockTransactionStart("tx1")
+	result, err := chaincode.BulkTransferAssetsByColor(ctx, "blue", "tom", page.IDs, true)
+	stub.MockTransactionEnd("tx1")
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Equal(t, []string{"asset2"}, result.ChangedKeys)
+	require.Len(t, result.Items, 4)
+
+	asset, err := chaincode.ReadAsset(ctx, "asset2")
+	require.NoError(t, err)
+	require.Equal(t, "jerry", asset.Owner)
+}
@@ -0,0 +1,157 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
+)
+
+// SmartContract provides functions for managing an Asset, using state-based
+// endorsement so that the peers of the organization owning an asset must
+// endorse the updates of the asset
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// Asset describes an asset and the organization owning it
+type Asset struct {
+	ID       string `json:"ID"`
+	Value    int    `json:"Value"`
+	Owner    string `json:"Owner"`
+	OwnerOrg string `json:"OwnerOrg"`
+}
+
+// CreateAsset issues a new asset to the world state, owned by the organization
+// of the client. It sets the endorsement policy of the asset key, so that the
+// owner organization must endorse future updates of the asset.
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, id string, value int, owner string) error {
+	exists, err := s.AssetExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the asset %s already exists", id)
+	}
+
+	ownerOrg, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the client's MSPID: %v", err)
+	}
+
+	asset := &Asset{
+		ID:       id,
+		Value:    value,
+		Owner:    owner,
+		OwnerOrg: ownerOrg,
+	}
+	if err := putAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	// Alternatively, any one of several organizations could be allowed to
+	// endorse updates of the asset with:
+	//	sbe.Set(ctx.GetStub(), id, sbe.NOutOfOrgs(1, "Org1MSP", "Org2MSP"))
+	return sbe.SetOrgs(ctx.GetStub(), id, ownerOrg)
+}
+
+// ReadAsset returns the asset stored in the world state with given id
+func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	assetJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if assetJSON == nil {
+		return nil, fmt.Errorf("the asset %s does not exist", id)
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(assetJSON, &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// ReadAssetEndorsementPolicy returns the endorsement policy of the asset key,
+// for instance AND('Org1MSP.peer') when Org1 owns the asset
+func (s *SmartContract) ReadAssetEndorsementPolicy(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	if _, err := s.ReadAsset(ctx, id); err != nil {
+		return "", err
+	}
+
+	policy, err := sbe.Get(ctx.GetStub(), id)
+	if err != nil {
+		return "", err
+	}
+	if policy == nil {
+		return "", fmt.Errorf("the asset %s has no endorsement policy", id)
+	}
+	return policy.String(), nil
+}
+
+// UpdateAsset updates the value of an existing asset. The update must be
+// endorsed by the organization owning the asset.
+func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, id string, newValue int) error {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset.Value = newValue
+	return putAsset(ctx, asset)
+}
+
+// DeleteAsset deletes a given asset from the world state. The deletion must be
+// endorsed by the organization owning the asset.
+func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
+	exists, err := s.AssetExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("the asset %s does not exist", id)
+	}
+
+	return ctx.GetStub().DelState(id)
+}
+
+// TransferAsset updates the owner and owner organization of an asset. The
+// transfer must be endorsed by the current owner organization, and it sets the
+// endorsement policy of the asset key so that the new owner organization must
+// endorse future updates of the asset.
+func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string, newOwnerOrg string) error {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset.Owner = newOwner
+	asset.OwnerOrg = newOwnerOrg
+	if err := putAsset(ctx, asset); err != nil {
+		return err
+	}
+
+	return sbe.SetOrgs(ctx.GetStub(), id, newOwnerOrg)
+}
+
+// AssetExists returns true when asset with given ID exists in world state
+func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	assetJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return assetJSON != nil, nil
+}
+
+func putAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(asset.ID, assetJSON); err != nil {
+		return fmt.Errorf("failed to put to world state. %v", err)
+	}
+	return nil
+}
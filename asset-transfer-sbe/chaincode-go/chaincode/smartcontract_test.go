@@ -0,0 +1,158 @@
+package chaincode_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/asset-transfer-sbe/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-sbe/chaincode-go/chaincode/mocks"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate counterfeiter -o mocks/transaction.go -fake-name TransactionContext . transactionContext
+type transactionContext interface {
+	contractapi.TransactionContextInterface
+}
+
+//go:generate counterfeiter -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+const (
+	myOrg1Msp       = "Org1MSP"
+	myOrg2Msp       = "Org2MSP"
+	storedAssetJSON = `{"ID":"asset1","Value":100,"Owner":"Tom","OwnerOrg":"Org1MSP"}`
+)
+
+func prepMocks(clientMSP string) (*mocks.TransactionContext, *mocks.ChaincodeStub) {
+	chaincodeStub := &mocks.ChaincodeStub{}
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetMSPIDReturns(clientMSP, nil)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+	return transactionContext, chaincodeStub
+}
+
+// requireEndorsers checks the endorsement policy set on a key by a transaction
+func requireEndorsers(t *testing.T, chaincodeStub *mocks.ChaincodeStub, key string, mspIDs ...string) {
+	require.Equal(t, 1, chaincodeStub.SetStateValidationParameterCallCount())
+	policyKey, policyBytes := chaincodeStub.SetStateValidationParameterArgsForCall(0)
+	require.Equal(t, key, policyKey)
+	policy, err := sbe.Parse(policyBytes)
+	require.NoError(t, err)
+	require.Equal(t, sbe.AllOrgs(mspIDs...).String(), policy.String())
+}
+
+func TestCreateAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.CreateAsset(transactionContext, "asset1", 100, "Tom")
+	require.NoError(t, err)
+	key, value := chaincodeStub.PutStateArgsForCall(0)
+	require.Equal(t, "asset1", key)
+	require.JSONEq(t, storedAssetJSON, string(value))
+	requireEndorsers(t, chaincodeStub, "asset1", myOrg1Msp)
+
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", 100, "Tom")
+	require.EqualError(t, err, "the asset asset1 already exists")
+
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("unable to retrieve asset"))
+	err = assetTransfer.CreateAsset(transactionContext, "asset1", 100, "Tom")
+	require.EqualError(t, err, "failed to read from world state: unable to retrieve asset")
+}
+
+func TestReadAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg2Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	asset, err := assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.Asset{ID: "asset1", Value: 100, Owner: "Tom", OwnerOrg: myOrg1Msp}, asset)
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	_, err = assetTransfer.ReadAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestReadAssetEndorsementPolicy(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg2Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	_, err := assetTransfer.ReadAssetEndorsementPolicy(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 has no endorsement policy")
+
+	policyBytes, err := sbe.NOutOfOrgs(1, myOrg1Msp, myOrg2Msp).Bytes()
+	require.NoError(t, err)
+	chaincodeStub.GetStateValidationParameterReturns(policyBytes, nil)
+	policy, err := assetTransfer.ReadAssetEndorsementPolicy(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, "OR('Org1MSP.peer', 'Org2MSP.peer')", policy)
+}
+
+func TestUpdateAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.UpdateAsset(transactionContext, "asset1", 200)
+	require.NoError(t, err)
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	require.JSONEq(t, `{"ID":"asset1","Value":200,"Owner":"Tom","OwnerOrg":"Org1MSP"}`, string(value))
+	// the endorsement policy of the owner organization is kept
+	require.Equal(t, 0, chaincodeStub.SetStateValidationParameterCallCount())
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	err = assetTransfer.UpdateAsset(transactionContext, "asset1", 200)
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestTransferAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.TransferAsset(transactionContext, "asset1", "Henry", myOrg2Msp)
+	require.NoError(t, err)
+	_, value := chaincodeStub.PutStateArgsForCall(0)
+	require.JSONEq(t, `{"ID":"asset1","Value":100,"Owner":"Henry","OwnerOrg":"Org2MSP"}`, string(value))
+	requireEndorsers(t, chaincodeStub, "asset1", myOrg2Msp)
+
+	chaincodeStub.PutStateReturns(fmt.Errorf("failed inserting key"))
+	err = assetTransfer.TransferAsset(transactionContext, "asset1", "Henry", myOrg2Msp)
+	require.EqualError(t, err, "failed to put to world state. failed inserting key")
+}
+
+func TestDeleteAsset(t *testing.T) {
+	transactionContext, chaincodeStub := prepMocks(myOrg1Msp)
+	chaincodeStub.GetStateReturns([]byte(storedAssetJSON), nil)
+	assetTransfer := chaincode.SmartContract{}
+
+	err := assetTransfer.DeleteAsset(transactionContext, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, "asset1", chaincodeStub.DelStateArgsForCall(0))
+
+	chaincodeStub.GetStateReturns(nil, nil)
+	err = assetTransfer.DeleteAsset(transactionContext, "asset1")
+	require.EqualError(t, err, "the asset asset1 does not exist")
+}
+
+func TestNewChaincode(t *testing.T) {
+	_, err := contractapi.NewChaincode(&chaincode.SmartContract{})
+	require.NoError(t, err)
+}
@@ -14,9 +14,9 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
-	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
 )
 
 const (
@@ -93,7 +93,7 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 	// Set the endorsement policy such that an owner org peer is required to endorse future updates.
 	// In practice, consider additional endorsers such as a trusted third party to further secure transfers.
 	endorsingOrgs := []string{clientOrgID}
-	err = setAssetStateBasedEndorsement(ctx, asset.ID, endorsingOrgs)
+	err = sbe.SetOrgs(ctx.GetStub(), asset.ID, endorsingOrgs...)
 	if err != nil {
 		return "", fmt.Errorf("failed setting state based endorsement for buyer and seller: %v", err)
 	}
@@ -440,7 +440,7 @@ func transferAssetState(ctx contractapi.TransactionContextInterface, asset *Asse
 
 	// Changes the endorsement policy to the new owner org
 	endorsingOrgs := []string{buyerOrgID}
-	err = setAssetStateBasedEndorsement(ctx, asset.ID, endorsingOrgs)
+	err = sbe.SetOrgs(ctx.GetStub(), asset.ID, endorsingOrgs...)
 	if err != nil {
 		return fmt.Errorf("failed setting state based endorsement for new owner: %v", err)
 	}
@@ -563,28 +563,6 @@ func buildCollectionName(clientOrgID string) string {
 	return fmt.Sprintf("_implicit_org_%s", clientOrgID)
 }
 
-// setAssetStateBasedEndorsement adds an endorsement policy to an asset so that the passed orgs need to agree upon transfer
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetID string, orgsToEndorse []string) error {
-	endorsementPolicy, err := statebased.NewStateEP(nil)
-	if err != nil {
-		return err
-	}
-	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgsToEndorse...)
-	if err != nil {
-		return fmt.Errorf("failed to add org to endorsement policy: %v", err)
-	}
-	policy, err := endorsementPolicy.Policy()
-	if err != nil {
-		return fmt.Errorf("failed to create endorsement policy bytes from org: %v", err)
-	}
-	err = ctx.GetStub().SetStateValidationParameter(assetID, policy)
-	if err != nil {
-		return fmt.Errorf("failed to set validation parameter on asset: %v", err)
-	}
-
-	return nil
-}
-
 // GetAssetHashId allows a potential buyer to validate the properties of an asset against the asset Id hash on chain and returns the hash
 func (s *SmartContract) GetAssetHashId(ctx contractapi.TransactionContextInterface) (string, error) {
 	transientMap, err := ctx.GetStub().GetTransient()
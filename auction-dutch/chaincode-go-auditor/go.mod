@@ -3,8 +3,15 @@ module github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go-
 go 1.14
 
 require (
-	github.com/golang/protobuf v1.5.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
 	github.com/hyperledger/fabric-contract-api-go v1.2.0
 	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
+	github.com/hyperledger/fabric-samples/chaincode/escrow v0.0.0
+	github.com/hyperledger/fabric-samples/chaincode/sbe v0.0.0
+	github.com/stretchr/testify v1.8.0
+	google.golang.org/protobuf v1.28.0
 )
+
+replace github.com/hyperledger/fabric-samples/chaincode/escrow => ../../chaincode/escrow
+
+replace github.com/hyperledger/fabric-samples/chaincode/sbe => ../../chaincode/sbe
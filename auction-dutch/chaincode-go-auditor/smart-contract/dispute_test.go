@@ -0,0 +1,235 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/escrow"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	auction "github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go-auditor/smart-contract"
+	"github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go-auditor/smart-contract/mocks"
+)
+
+//go:generate counterfeiter -o mocks/transaction.go -fake-name TransactionContext . transactionContext
+type transactionContext interface {
+	contractapi.TransactionContextInterface
+}
+
+//go:generate counterfeiter -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+const auctionID = "auction1"
+const auditorID = "x509::CN=auditor"
+const sellerID = "x509::CN=seller"
+const buyerID = "x509::CN=buyer1"
+const auditorMSP = "Org3MSP"
+const org1MSP = "Org1MSP"
+const bid1Key = "bid~auction1~tx1"
+const bid2Key = "bid~auction1~tx2"
+
+// prepMocks returns a transaction context backed by an in-memory world state that
+// holds a closed auction with an auditor and two bids, one of which is revealed
+func prepMocks(t *testing.T, clientID string, clientMSP string) (*mocks.TransactionContext, *mocks.ChaincodeStub, map[string][]byte) {
+	os.Setenv("CORE_PEER_LOCALMSPID", clientMSP)
+
+	auctionJSON, err := json.Marshal(auction.Auction{
+		Type:     "auction",
+		ItemSold: "tickets",
+		Seller:   sellerID,
+		Quantity: 10,
+		Orgs:     []string{org1MSP},
+		PrivateBids: map[string]auction.BidHash{
+			bid1Key: {Org: org1MSP, Hash: "0a0b"},
+			bid2Key: {Org: org1MSP, Hash: "0c0d"},
+		},
+		RevealedBids: map[string]auction.FullBid{
+			bid1Key: {Type: "bid", Quantity: 10, Price: 40, Org: org1MSP, Buyer: buyerID},
+		},
+		Winners: []auction.Winners{},
+		Status:  "closed",
+		Auditor: true,
+	})
+	require.NoError(t, err)
+	worldState := map[string][]byte{auctionID: auctionJSON}
+
+	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return worldState[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		worldState[key] = value
+		return nil
+	}
+	chaincodeStub.GetPrivateDataHashStub = func(collection string, key string) ([]byte, error) {
+		switch key {
+		case bid1Key:
+			return []byte{0x0a, 0x0b}, nil
+		case bid2Key:
+			return []byte{0xff}, nil
+		}
+		return nil, nil
+	}
+	chaincodeStub.GetTxIDReturns("audittx")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Unix(1000, 0)), nil)
+	chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetIDReturns(base64.StdEncoding.EncodeToString([]byte(clientID)), nil)
+	clientIdentity.GetMSPIDReturns(clientMSP, nil)
+
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	return transactionContext, chaincodeStub, worldState
+}
+
+func readAuction(t *testing.T, worldState map[string][]byte) *auction.Auction {
+	var result *auction.Auction
+	err := json.Unmarshal(worldState[auctionID], &result)
+	require.NoError(t, err)
+	return result
+}
+
+func TestRaiseDispute(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(t, auditorID, auditorMSP)
+	contract := &auction.SmartContract{}
+
+	err := contract.RaiseDispute(transactionContext, auctionID, "")
+	require.EqualError(t, err, "reason must be a non-empty string")
+
+	err = contract.RaiseDispute(transactionContext, auctionID, "bid hash mismatch reported by Org1")
+	require.NoError(t, err)
+
+	disputed := readAuction(t, worldState)
+	require.Equal(t, &auction.Dispute{Status: "open", Reason: "bid hash mismatch reported by Org1", RaisedBy: auditorID}, disputed.Dispute)
+	require.Equal(t, []auction.AuditRecord{
+		{Action: "DisputeRaised", Auditor: auditorID, TxID: "audittx", Time: 1000, Details: "bid hash mismatch reported by Org1"},
+	}, disputed.History)
+
+	eventName, eventJSON := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "DisputeRaised", eventName)
+	require.Contains(t, string(eventJSON), `"auctionID":"auction1"`)
+
+	err = contract.RaiseDispute(transactionContext, auctionID, "another reason")
+	require.EqualError(t, err, "auction already has an open dispute")
+}
+
+func TestRaiseDisputeNotAuditor(t *testing.T) {
+	transactionContext, _, worldState := prepMocks(t, sellerID, org1MSP)
+	contract := &auction.SmartContract{}
+
+	err := contract.RaiseDispute(transactionContext, auctionID, "reason")
+	require.EqualError(t, err, "only the auditor can perform this action")
+	require.Nil(t, readAuction(t, worldState).Dispute)
+}
+
+func TestEndAuctionFrozenByDispute(t *testing.T) {
+	transactionContext, _, _ := prepMocks(t, auditorID, auditorMSP)
+	contract := &auction.SmartContract{}
+	require.NoError(t, contract.RaiseDispute(transactionContext, auctionID, "reason"))
+
+	transactionContext.GetClientIdentity().(*mocks.ClientIdentity).GetIDReturns(base64.StdEncoding.EncodeToString([]byte(sellerID)), nil)
+	transactionContext.GetClientIdentity().(*mocks.ClientIdentity).GetMSPIDReturns(org1MSP, nil)
+	err := contract.EndAuction(transactionContext, auctionID)
+	require.EqualError(t, err, "Cannot end auction while a dispute is open")
+}
+
+func TestInspectBids(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(t, auditorID, auditorMSP)
+	contract := &auction.SmartContract{}
+
+	inspections, err := contract.InspectBids(transactionContext, auctionID)
+	require.NoError(t, err)
+	require.Equal(t, []auction.BidInspection{
+		{BidKey: bid1Key, Org: org1MSP, RecordedHash: "0a0b", LedgerHash: "0a0b", HashMatches: true, Revealed: true, OrgMatches: true},
+		{BidKey: bid2Key, Org: org1MSP, RecordedHash: "0c0d", LedgerHash: "ff", HashMatches: false, Revealed: false, OrgMatches: true},
+	}, inspections)
+
+	history := readAuction(t, worldState).History
+	require.Len(t, history, 1)
+	require.Equal(t, "BidsInspected", history[0].Action)
+	require.Equal(t, "2 bids, 1 revealed, 1 mismatches", history[0].Details)
+
+	eventName, _ := chaincodeStub.SetEventArgsForCall(0)
+	require.Equal(t, "BidsInspected", eventName)
+}
+
+func TestResolveDispute(t *testing.T) {
+	transactionContext, _, worldState := prepMocks(t, auditorID, auditorMSP)
+	contract := &auction.SmartContract{}
+
+	err := contract.ResolveDispute(transactionContext, auctionID, "confirmed", "")
+	require.EqualError(t, err, "auction does not have an open dispute")
+
+	require.NoError(t, contract.RaiseDispute(transactionContext, auctionID, "reason"))
+
+	err = contract.ResolveDispute(transactionContext, auctionID, "ignored", "")
+	require.EqualError(t, err, "resolution must be either confirmed or annulled")
+
+	err = contract.ResolveDispute(transactionContext, auctionID, "confirmed", "hashes verified with Org1")
+	require.NoError(t, err)
+
+	resolved := readAuction(t, worldState)
+	require.Equal(t, "closed", resolved.Status)
+	require.Equal(t, "confirmed", resolved.Dispute.Status)
+	require.Equal(t, "hashes verified with Org1", resolved.Dispute.Resolution)
+	require.Equal(t, []string{"DisputeRaised", "DisputeConfirmed"}, []string{resolved.History[0].Action, resolved.History[1].Action})
+}
+
+func TestResolveDisputeAnnulRefundsDeposits(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(t, auditorID, auditorMSP)
+	contract := &auction.SmartContract{}
+
+	escrowed := readAuction(t, worldState)
+	escrowed.Escrow = &escrow.Escrow{
+		TokenChaincode: "token_erc20",
+		SellerAccount:  "seller",
+		Deposits: map[string]escrow.Deposit{
+			bid1Key: {HoldID: "auction1_tx1", Bidder: buyerID, Amount: 400, Status: "locked"},
+		},
+	}
+	escrowedJSON, err := json.Marshal(escrowed)
+	require.NoError(t, err)
+	worldState[auctionID] = escrowedJSON
+
+	require.NoError(t, contract.RaiseDispute(transactionContext, auctionID, "reason"))
+
+	chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.ERROR, Message: "hold auction1_tx1 does not exist"})
+	err = contract.ResolveDispute(transactionContext, auctionID, "annulled", "bids were tampered with")
+	require.EqualError(t, err, "failed to annul auction: failed to refund deposit auction1_tx1: RefundHold on chaincode token_erc20 returned status 500: hold auction1_tx1 does not exist")
+
+	chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+	err = contract.ResolveDispute(transactionContext, auctionID, "annulled", "bids were tampered with")
+	require.NoError(t, err)
+
+	annulled := readAuction(t, worldState)
+	require.Equal(t, "annulled", annulled.Status)
+	require.Equal(t, "annulled", annulled.Dispute.Status)
+	require.Equal(t, "refunded", annulled.Escrow.Deposits[bid1Key].Status)
+
+	transactionContext.GetClientIdentity().(*mocks.ClientIdentity).GetIDReturns(base64.StdEncoding.EncodeToString([]byte(sellerID)), nil)
+	transactionContext.GetClientIdentity().(*mocks.ClientIdentity).GetMSPIDReturns(org1MSP, nil)
+	err = contract.EndAuction(transactionContext, auctionID)
+	require.EqualError(t, err, "Can only end a closed auction")
+}
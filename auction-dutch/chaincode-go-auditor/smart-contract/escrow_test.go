@@ -0,0 +1,227 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/escrow"
+	"github.com/stretchr/testify/require"
+
+	auction "github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go-auditor/smart-contract"
+	"github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go-auditor/smart-contract/mocks"
+)
+
+const tokenChaincode = "token_erc20"
+const buyer2ID = "x509::CN=buyer2"
+const buyer3ID = "x509::CN=buyer3"
+
+type escrowLedger struct {
+	transactionContext *mocks.TransactionContext
+	chaincodeStub      *mocks.ChaincodeStub
+	clientIdentity     *mocks.ClientIdentity
+	worldState         map[string][]byte
+	privateData        map[string][]byte
+}
+
+// prepEscrowMocks returns a transaction context backed by an in-memory world state and
+// implicit collection that holds an open auction with an auditor, settled through the
+// token chaincode if escrowed is true. Transactions are submitted by clientID of Org1
+func prepEscrowMocks(t *testing.T, clientID string, escrowed bool) *escrowLedger {
+	os.Setenv("CORE_PEER_LOCALMSPID", org1MSP)
+
+	openAuction := auction.Auction{
+		Type:         "auction",
+		ItemSold:     "tickets",
+		Seller:       sellerID,
+		Quantity:     10,
+		Orgs:         []string{org1MSP},
+		PrivateBids:  map[string]auction.BidHash{},
+		RevealedBids: map[string]auction.FullBid{},
+		Winners:      []auction.Winners{},
+		Status:       "open",
+		Auditor:      true,
+	}
+	if escrowed {
+		openAuction.Escrow = &escrow.Escrow{
+			TokenChaincode: tokenChaincode,
+			SellerAccount:  base64.StdEncoding.EncodeToString([]byte(sellerID)),
+			Deposits:       map[string]escrow.Deposit{},
+		}
+	}
+	auctionJSON, err := json.Marshal(openAuction)
+	require.NoError(t, err)
+
+	ledger := &escrowLedger{
+		transactionContext: &mocks.TransactionContext{},
+		chaincodeStub:      &mocks.ChaincodeStub{},
+		clientIdentity:     &mocks.ClientIdentity{},
+		worldState:         map[string][]byte{auctionID: auctionJSON},
+		privateData:        map[string][]byte{},
+	}
+
+	ledger.chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return ledger.worldState[key], nil
+	}
+	ledger.chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		ledger.worldState[key] = value
+		return nil
+	}
+	ledger.chaincodeStub.GetPrivateDataHashStub = func(collection string, key string) ([]byte, error) {
+		value, ok := ledger.privateData[key]
+		if !ok {
+			return nil, nil
+		}
+		hash := sha256.Sum256(value)
+		return hash[:], nil
+	}
+	ledger.chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+
+	ledger.setClient(clientID)
+	ledger.clientIdentity.GetMSPIDReturns(org1MSP, nil)
+
+	ledger.transactionContext.GetStubReturns(ledger.chaincodeStub)
+	ledger.transactionContext.GetClientIdentityReturns(ledger.clientIdentity)
+
+	return ledger
+}
+
+func (l *escrowLedger) setClient(clientID string) {
+	l.clientIdentity.GetIDReturns(base64.StdEncoding.EncodeToString([]byte(clientID)), nil)
+}
+
+func (l *escrowLedger) auction(t *testing.T) *auction.Auction {
+	return readAuction(t, l.worldState)
+}
+
+// placeBid stores a bid in the implicit collection and submits it to the auction
+func (l *escrowLedger) placeBid(t *testing.T, contract *auction.SmartContract, txID string, buyer string, quantity int, price int, deposit int) []byte {
+	bidJSON, err := json.Marshal(map[string]interface{}{"objectType": "bid", "quantity": quantity, "price": price, "org": org1MSP, "buyer": buyer})
+	require.NoError(t, err)
+	l.privateData["bid~"+auctionID+"~"+txID] = bidJSON
+
+	l.setClient(buyer)
+	err = contract.SubmitEscrowedBid(l.transactionContext, auctionID, txID, deposit)
+	require.NoError(t, err)
+
+	return bidJSON
+}
+
+func (l *escrowLedger) revealBid(contract *auction.SmartContract, txID string, buyer string, bidJSON []byte) error {
+	l.setClient(buyer)
+	l.chaincodeStub.GetTransientReturns(map[string][]byte{"bid": bidJSON}, nil)
+	return contract.RevealBid(l.transactionContext, auctionID, txID)
+}
+
+func invokedArgs(stub *mocks.ChaincodeStub, call int) []string {
+	_, args, _ := stub.InvokeChaincodeArgsForCall(call)
+	result := []string{}
+	for _, arg := range args {
+		result = append(result, string(arg))
+	}
+	return result
+}
+
+func TestSubmitEscrowedBid(t *testing.T) {
+	ledger := prepEscrowMocks(t, sellerID, true)
+	contract := &auction.SmartContract{}
+	ledger.privateData["bid~"+auctionID+"~tx1"] = []byte(`{"price":40}`)
+	ledger.setClient(buyerID)
+
+	err := contract.SubmitBid(ledger.transactionContext, auctionID, "tx1")
+	require.EqualError(t, err, "auction auction1 settles through token chaincode token_erc20, use SubmitEscrowedBid to lock a deposit")
+
+	err = contract.SubmitEscrowedBid(ledger.transactionContext, auctionID, "tx1", 0)
+	require.EqualError(t, err, "deposit must be a positive integer")
+
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.ERROR, Message: "client account has insufficient funds"})
+	err = contract.SubmitEscrowedBid(ledger.transactionContext, auctionID, "tx1", 50)
+	require.EqualError(t, err, "failed to lock deposit: HoldFunds on chaincode token_erc20 returned status 500: client account has insufficient funds")
+	require.Empty(t, ledger.auction(t).PrivateBids)
+
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+	err = contract.SubmitEscrowedBid(ledger.transactionContext, auctionID, "tx1", 50)
+	require.NoError(t, err)
+	require.Equal(t, []string{"HoldFunds", "auction1_tx1", "50"}, invokedArgs(ledger.chaincodeStub, 1))
+
+	deposit := ledger.auction(t).Escrow.Deposits["bid~"+auctionID+"~tx1"]
+	require.Equal(t, escrow.Deposit{HoldID: "auction1_tx1", Bidder: buyerID, Amount: 50, Status: "locked"}, deposit)
+
+	err = contract.SubmitEscrowedBid(ledger.transactionContext, auctionID, "tx1", 50)
+	require.EqualError(t, err, "a deposit is already locked for bid~auction1~tx1")
+}
+
+func TestSubmitEscrowedBidWithoutEscrow(t *testing.T) {
+	ledger := prepEscrowMocks(t, buyerID, false)
+	contract := &auction.SmartContract{}
+	ledger.privateData["bid~"+auctionID+"~tx1"] = []byte(`{"price":40}`)
+
+	err := contract.SubmitEscrowedBid(ledger.transactionContext, auctionID, "tx1", 50)
+	require.EqualError(t, err, "auction auction1 does not settle through a token chaincode")
+	require.Equal(t, 0, ledger.chaincodeStub.InvokeChaincodeCallCount())
+}
+
+func TestRevealBidExceedingDeposit(t *testing.T) {
+	ledger := prepEscrowMocks(t, sellerID, true)
+	contract := &auction.SmartContract{}
+	bidJSON := ledger.placeBid(t, contract, "tx1", buyerID, 2, 30, 50)
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+
+	err := ledger.revealBid(contract, "tx1", buyerID, bidJSON)
+	require.EqualError(t, err, "bid value 60 exceeds the deposit of 50")
+	require.Empty(t, ledger.auction(t).RevealedBids)
+}
+
+func TestEndAuctionSettlesEscrow(t *testing.T) {
+	ledger := prepEscrowMocks(t, sellerID, true)
+	contract := &auction.SmartContract{}
+	bid1JSON := ledger.placeBid(t, contract, "tx1", buyerID, 6, 40, 300)
+	bid2JSON := ledger.placeBid(t, contract, "tx2", buyer2ID, 6, 60, 400)
+	bid3JSON := ledger.placeBid(t, contract, "tx3", buyer3ID, 5, 30, 150)
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+	require.NoError(t, ledger.revealBid(contract, "tx1", buyerID, bid1JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx2", buyer2ID, bid2JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx3", buyer3ID, bid3JSON))
+
+	// a failing release aborts the end of the auction
+	ledger.setClient(sellerID)
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.ERROR, Message: "release amount 160 exceeds hold auction1_tx1 of 100"})
+	err := contract.EndAuction(ledger.transactionContext, auctionID)
+	require.EqualError(t, err, "Cannot end auction: failed to release deposit auction1_tx1: ReleaseHold on chaincode token_erc20 returned status 500: release amount 160 exceeds hold auction1_tx1 of 100")
+	require.Equal(t, "closed", ledger.auction(t).Status)
+
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+	calls := ledger.chaincodeStub.InvokeChaincodeCallCount()
+	err = contract.EndAuction(ledger.transactionContext, auctionID)
+	require.NoError(t, err)
+
+	// both winners pay the final price of 40 for their allocated quantity
+	sellerAccount := base64.StdEncoding.EncodeToString([]byte(sellerID))
+	require.Equal(t, []string{"ReleaseHold", "auction1_tx1", sellerAccount, "160"}, invokedArgs(ledger.chaincodeStub, calls))
+	require.Equal(t, []string{"ReleaseHold", "auction1_tx2", sellerAccount, "240"}, invokedArgs(ledger.chaincodeStub, calls+1))
+	require.Equal(t, []string{"RefundHold", "auction1_tx3"}, invokedArgs(ledger.chaincodeStub, calls+2))
+
+	ended := ledger.auction(t)
+	require.Equal(t, "ended", ended.Status)
+	require.Equal(t, 40, ended.Price)
+	require.Equal(t, []auction.Winners{{Buyer: buyer2ID, Quantity: 6}, {Buyer: buyerID, Quantity: 4}}, ended.Winners)
+	require.Equal(t, "released", ended.Escrow.Deposits["bid~"+auctionID+"~tx1"].Status)
+	require.Equal(t, "released", ended.Escrow.Deposits["bid~"+auctionID+"~tx2"].Status)
+	require.Equal(t, "refunded", ended.Escrow.Deposits["bid~"+auctionID+"~tx3"].Status)
+}
@@ -8,11 +8,9 @@ import (
 	"encoding/base64"
 	"fmt"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
-	"github.com/hyperledger/fabric-protos-go/common"
-	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
 )
 
 func (s *SmartContract) GetSubmittingClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
@@ -71,133 +69,16 @@ func contains(sli []string, str string) bool {
 	return false
 }
 
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, assetId string, mspids []string, auditor bool) error {
-
-	principals := make([]*msp.MSPPrincipal, len(mspids))
-	participantSigsPolicy := make([]*common.SignaturePolicy, len(mspids))
-
-	for i, id := range mspids {
-		principal, err := proto.Marshal(
-			&msp.MSPRole{
-				Role:          msp.MSPRole_PEER,
-				MspIdentifier: id,
-			},
-		)
-		if err != nil {
-			return err
-		}
-		principals[i] = &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               principal,
-		}
-		participantSigsPolicy[i] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(i),
-			},
-		}
-	}
-
-	if auditor == false {
-		// create the defalt policy for an auction without an auditor
-
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     int32(len(mspids)),
-						Rules: participantSigsPolicy,
-					},
-				},
-			},
-			Identities: principals,
-		}
-
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-		}
-	} else {
-
-		// create the defalt policy for an auction with an auditor
-
-		// create the auditor identity and signature policy
-		auditorMSP, err := proto.Marshal(
-			&msp.MSPRole{
-				Role:          msp.MSPRole_PEER,
-				MspIdentifier: "Org3MSP",
-			},
-		)
-		if err != nil {
-			return err
-		}
-		principals = append(principals, &msp.MSPPrincipal{
-			PrincipalClassification: msp.MSPPrincipal_ROLE,
-			Principal:               auditorMSP,
-		},
-		)
-		// Create the policies in case the auditor is needed. In this case, an
-		// auditor and 1 participant can update the auction.
-		auditorPolicies := make([]*common.SignaturePolicy, 2)
-		auditorPolicies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_SignedBy{
-				SignedBy: int32(len(principals) - 1),
-			},
-		}
-		auditorPolicies[1] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_NOutOf_{
-				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     1,
-					Rules: participantSigsPolicy,
-				},
-			},
-		}
-
-		// The auditor policy below is equivilent to AND(auditor, OR(participants))
-		policies := make([]*common.SignaturePolicy, 2)
-		policies[0] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_NOutOf_{
-				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     2,
-					Rules: auditorPolicies,
-				},
-			},
-		}
-		// Participants can also update the auction without an auditor
-		policies[1] = &common.SignaturePolicy{
-			Type: &common.SignaturePolicy_NOutOf_{
-				NOutOf: &common.SignaturePolicy_NOutOf{
-					N:     int32(len(mspids)),
-					Rules: participantSigsPolicy,
-				},
-			},
-		}
-		// Either the auditor policy or the participant policy can update
-		// the auction
-		policy := &common.SignaturePolicyEnvelope{
-			Version: 0,
-			Rule: &common.SignaturePolicy{
-				Type: &common.SignaturePolicy_NOutOf_{
-					NOutOf: &common.SignaturePolicy_NOutOf{
-						N:     1,
-						Rules: policies,
-					},
-				},
-			},
-			Identities: principals,
-		}
-		spBytes, err := proto.Marshal(policy)
-		if err != nil {
-			return err
-		}
-		err = ctx.GetStub().SetStateValidationParameter(assetId, spBytes)
-		if err != nil {
-			return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-		}
+// auctionEndorsementPolicy returns the endorsement policy of an auction, which
+// requires the endorsement of all participating organizations. The auditor of
+// an auction with an auditor can also update the auction together with any
+// participating organization, so the policy of such an auction is
+// OR(AND(participants), AND(auditor, OR(participants)))
+func auctionEndorsementPolicy(orgs []string, auditor bool) *sbe.Policy {
+	participants := sbe.Orgs(orgs...)
+	policy := sbe.AllOf(participants...)
+	if !auditor {
+		return policy
 	}
-	return nil
+	return sbe.AnyOf(policy, sbe.AllOf(sbe.Org(auditorMSP), sbe.AnyOf(participants...)))
 }
@@ -12,13 +12,16 @@ import (
 	"sort"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/escrow"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
 )
 
 type SmartContract struct {
 	contractapi.Contract
 }
 
-// Auction data
+// Auction data. Price is the final price of a sealed bid auction. It stays 0 for a
+// clock auction, where each winner pays the Payment of their Allocation
 type Auction struct {
 	Type         string             `json:"objectType"`
 	ItemSold     string             `json:"item"`
@@ -31,6 +34,11 @@ type Auction struct {
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
 	Auditor      bool               `json:"auditor"`
+	Escrow       *escrow.Escrow     `json:"escrow,omitempty"`
+	Clock        *PriceClock        `json:"clock,omitempty"`
+	Allocations  []Allocation       `json:"allocations,omitempty"`
+	Dispute      *Dispute           `json:"dispute,omitempty"`
+	History      []AuditRecord      `json:"history,omitempty"`
 }
 
 // FullBid is the structure of a revealed bid
@@ -59,6 +67,12 @@ const bidKeyType = "bid"
 // CreateAuction creates on auction on the public channel. The identity that
 // submits the transacion becomes the seller of the auction
 func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string) error {
+	return s.createAuction(ctx, auctionID, itemsold, quantity, withAuditor, nil, nil)
+}
+
+// createAuction puts a new auction into the public state. Auctions that settle through a
+// token chaincode carry the escrow configuration, and clock auctions carry their price clock
+func (s *SmartContract) createAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string, auctionEscrow *escrow.Escrow, clock *PriceClock) error {
 
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
@@ -94,6 +108,8 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		Winners:      []Winners{},
 		Status:       "open",
 		Auditor:      auditor,
+		Escrow:       auctionEscrow,
+		Clock:        clock,
 	}
 
 	auctionJSON, err := json.Marshal(auction)
@@ -108,7 +124,7 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 	}
 
 	// set the seller of the auction as an endorser
-	err = setAssetStateBasedEndorsement(ctx, auctionID, []string{clientOrgID}, auditor)
+	err = sbe.Set(ctx.GetStub(), auctionID, auctionEndorsementPolicy([]string{clientOrgID}, auditor))
 	if err != nil {
 		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 	}
@@ -167,6 +183,12 @@ func (s *SmartContract) Bid(ctx contractapi.TransactionContextInterface, auction
 // auction. Note that this function alters the auction in private state, and needs
 // to meet the auction endorsement policy. Transaction ID is used identify the bid
 func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+	return s.submitBid(ctx, auctionID, txID, 0)
+}
+
+// submitBid adds the bid hash to the auction. If the auction settles through a token
+// chaincode, the deposit is locked in escrow before the bid is accepted
+func (s *SmartContract) submitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string, deposit int) error {
 
 	// get the MSP ID of the bidder's org
 	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
@@ -218,13 +240,25 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 	bidders[bidKey] = newHash
 	auction.PrivateBids = bidders
 
+	// lock the deposit of the bidder in the token chaincode
+	err = s.lockBidDeposit(ctx, auction, auctionID, txID, bidKey, deposit)
+	if err != nil {
+		return err
+	}
+
+	// record the clock price that the bid was submitted at
+	err = recordClockBid(ctx, auction, bidKey)
+	if err != nil {
+		return err
+	}
+
 	// Add the bidding organization to the list of participating organization's if it is not already
 	orgs := auction.Orgs
 	if !(contains(orgs, clientOrgID)) {
 		newOrgs := append(orgs, clientOrgID)
 		auction.Orgs = newOrgs
 
-		err = setAssetStateBasedEndorsement(ctx, auctionID, newOrgs, auction.Auditor)
+		err = sbe.Set(ctx.GetStub(), auctionID, auctionEndorsementPolicy(newOrgs, auction.Auditor))
 		if err != nil {
 			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 		}
@@ -358,6 +392,12 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
 	}
 
+	// check 5: make sure that an escrowed bid is covered by the deposit of the bidder
+	err = checkBidDeposit(auction, bidKey, clientID, bidInput.Price*bidInput.Quantity)
+	if err != nil {
+		return err
+	}
+
 	revealedBids := make(map[string]FullBid)
 	revealedBids = auction.RevealedBids
 	revealedBids[bidKey] = newBid
@@ -442,6 +482,11 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("Can only end a closed auction")
 	}
 
+	// an open dispute freezes the auction until the auditor resolves it
+	if auction.Dispute != nil && auction.Dispute.Status == disputeOpen {
+		return fmt.Errorf("Cannot end auction while a dispute is open")
+	}
+
 	// get the list of revealed bids
 
 	revealedBidMap := auction.RevealedBids
@@ -449,24 +494,34 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("No bids have been revealed, cannot end auction: %v", err)
 	}
 
-	// sort the map of revealed bids to make it easier to calculate winners
-	// if bids are tied, fill smaller bids first
-	var bidders []FullBid
-
-	for _, bid := range revealedBidMap {
-		bidders = append(bidders, bid)
+	// clock auctions fill the bids in the order in which they were submitted
+	if auction.Clock != nil {
+		return s.endClockAuction(ctx, auctionID, auction)
 	}
 
-	sort.Slice(bidders, func(p, q int) bool {
-		if bidders[p].Price > bidders[q].Price {
+	// sort the map of revealed bids to make it easier to calculate winners
+	// if bids are tied, fill smaller bids first. Bids that are tied on price
+	// and quantity keep their key order, so every endorser picks the same winners
+	bidKeys := sortedBidKeys(revealedBidMap)
+
+	sort.SliceStable(bidKeys, func(p, q int) bool {
+		bidP := revealedBidMap[bidKeys[p]]
+		bidQ := revealedBidMap[bidKeys[q]]
+		if bidP.Price > bidQ.Price {
 			return true
 		}
-		if bidders[p].Price < bidders[q].Price {
+		if bidP.Price < bidQ.Price {
 			return false
 		}
-		return bidders[p].Quantity < bidders[q].Quantity
+		return bidP.Quantity < bidQ.Quantity
 	})
 
+	var bidders []FullBid
+
+	for _, bidKey := range bidKeys {
+		bidders = append(bidders, revealedBidMap[bidKey])
+	}
+
 	i := 0
 	remainingQuantity := auction.Quantity
 
@@ -507,6 +562,18 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 
 	auction.Status = string("ended")
 
+	// every winner pays the final price for the quantity they were allocated.
+	// The seller is paid from the winning deposits and all other bidders are refunded
+	payments := make(map[string]int)
+	for i, winner := range auction.Winners {
+		payments[bidKeys[i]] = auction.Price * winner.Quantity
+	}
+
+	err = settleEscrow(ctx, auction, payments)
+	if err != nil {
+		return fmt.Errorf("Cannot end auction: %v", err)
+	}
+
 	endedAuctionJSON, _ := json.Marshal(auction)
 
 	err = ctx.GetStub().PutState(auctionID, endedAuctionJSON)
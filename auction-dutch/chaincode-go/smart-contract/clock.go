@@ -0,0 +1,347 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// PriceClock is the descending price of a clock auction. The price starts at
+// StartPrice when the auction is created and drops by Decrement every TimeStep
+// seconds until it reaches ReservePrice
+type PriceClock struct {
+	StartTime    int64               `json:"startTime"`
+	StartPrice   int                 `json:"startPrice"`
+	Decrement    int                 `json:"decrement"`
+	TimeStep     int64               `json:"timeStep"`
+	ReservePrice int                 `json:"reservePrice"`
+	Bids         map[string]ClockBid `json:"bids"`
+}
+
+// ClockBid records when a bid was submitted and the clock price at that time
+type ClockBid struct {
+	SubmitTime int64 `json:"submitTime"`
+	Price      int   `json:"price"`
+}
+
+// Allocation shows how the quantity of a clock auction was allocated to a revealed bid
+type Allocation struct {
+	Buyer      string `json:"buyer"`
+	SubmitTime int64  `json:"submitTime"`
+	ClockPrice int    `json:"clockPrice"`
+	BidPrice   int    `json:"bidPrice"`
+	Requested  int    `json:"requested"`
+	Available  int    `json:"available"`
+	Allocated  int    `json:"allocated"`
+	Payment    int    `json:"payment"`
+	Result     string `json:"result"`
+}
+
+const (
+	allocationFilled          = "filled"
+	allocationPartiallyFilled = "partially filled"
+	allocationBelowClock      = "below clock price"
+	allocationExhausted       = "quantity exhausted"
+)
+
+// CreateClockAuction creates a Dutch auction with a descending price clock. The price
+// starts at startPrice and drops by decrement every timeStep seconds, but never below
+// reservePrice. Bids that are at or above the clock price when they are submitted are
+// filled in the order in which they were submitted, at that clock price
+func (s *SmartContract) CreateClockAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string, startPrice int, decrement int, timeStep int, reservePrice int) error {
+
+	clock, err := newPriceClock(ctx, startPrice, decrement, timeStep, reservePrice)
+	if err != nil {
+		return err
+	}
+
+	return s.createAuction(ctx, auctionID, itemsold, quantity, withAuditor, nil, clock)
+}
+
+// CreateClockAuctionWithEscrow creates a clock auction that settles through the token
+// chaincode with the name tokenChaincode. Buyers lock a deposit with SubmitEscrowedBid,
+// and each winner pays the clock price of their bid from that deposit
+func (s *SmartContract) CreateClockAuctionWithEscrow(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string, startPrice int, decrement int, timeStep int, reservePrice int, tokenChaincode string) error {
+
+	clock, err := newPriceClock(ctx, startPrice, decrement, timeStep, reservePrice)
+	if err != nil {
+		return err
+	}
+
+	auctionEscrow, err := newEscrow(ctx, tokenChaincode)
+	if err != nil {
+		return err
+	}
+
+	return s.createAuction(ctx, auctionID, itemsold, quantity, withAuditor, auctionEscrow, clock)
+}
+
+// newPriceClock creates a price clock that starts with the current transaction
+func newPriceClock(ctx contractapi.TransactionContextInterface, startPrice int, decrement int, timeStep int, reservePrice int) (*PriceClock, error) {
+
+	if startPrice <= 0 {
+		return nil, fmt.Errorf("start price must be a positive integer")
+	}
+	if decrement <= 0 {
+		return nil, fmt.Errorf("decrement must be a positive integer")
+	}
+	if timeStep <= 0 {
+		return nil, fmt.Errorf("time step must be a positive number of seconds")
+	}
+	if reservePrice < 0 || reservePrice > startPrice {
+		return nil, fmt.Errorf("reserve price must be between 0 and the start price")
+	}
+
+	// the clock starts with the transaction that creates the auction
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return &PriceClock{
+		StartTime:    timestamp.GetSeconds(),
+		StartPrice:   startPrice,
+		Decrement:    decrement,
+		TimeStep:     int64(timeStep),
+		ReservePrice: reservePrice,
+		Bids:         make(map[string]ClockBid),
+	}, nil
+}
+
+// QueryCurrentPrice returns the price of a clock auction at the time of the transaction
+func (s *SmartContract) QueryCurrentPrice(ctx contractapi.TransactionContextInterface, auctionID string) (int, error) {
+
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auction from public state %v", err)
+	}
+	if auction.Clock == nil {
+		return 0, fmt.Errorf("auction %s does not have a price clock", auctionID)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return auction.Clock.priceAt(timestamp.GetSeconds()), nil
+}
+
+// QueryAllocations returns the allocation report of an ended clock auction. The report
+// lists every revealed bid in the order in which it was submitted, along with the clock
+// price, the quantity that was still available and the quantity it received
+func (s *SmartContract) QueryAllocations(ctx contractapi.TransactionContextInterface, auctionID string) ([]Allocation, error) {
+
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction from public state %v", err)
+	}
+	if auction.Clock == nil {
+		return nil, fmt.Errorf("auction %s does not have a price clock", auctionID)
+	}
+	if auction.Status != "ended" {
+		return nil, fmt.Errorf("allocations are only available for an ended auction")
+	}
+
+	return auction.Allocations, nil
+}
+
+// priceAt returns the clock price at the given time in seconds
+func (clock *PriceClock) priceAt(seconds int64) int {
+
+	if seconds <= clock.StartTime {
+		return clock.StartPrice
+	}
+
+	steps := (seconds - clock.StartTime) / clock.TimeStep
+	price := int64(clock.StartPrice) - steps*int64(clock.Decrement)
+	if price < int64(clock.ReservePrice) {
+		return clock.ReservePrice
+	}
+
+	return int(price)
+}
+
+// recordClockBid stores the submission time and clock price of a bid on a clock auction
+func recordClockBid(ctx contractapi.TransactionContextInterface, auction *Auction, bidKey string) error {
+
+	if auction.Clock == nil {
+		return nil
+	}
+
+	// a bid cannot be resubmitted later to move it to a lower clock price
+	if _, exists := auction.Clock.Bids[bidKey]; exists {
+		return fmt.Errorf("bid %s has already been submitted", bidKey)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	auction.Clock.Bids[bidKey] = ClockBid{
+		SubmitTime: timestamp.GetSeconds(),
+		Price:      auction.Clock.priceAt(timestamp.GetSeconds()),
+	}
+
+	return nil
+}
+
+// endClockAuction allocates the quantity of a clock auction, settles any escrow and
+// ends the auction
+func (s *SmartContract) endClockAuction(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction) error {
+
+	bidKeys := sortedClockBidKeys(auction.Clock.Bids)
+	payments, cutoff := allocateClockAuction(auction, bidKeys)
+
+	// check if there is a bid that would have received quantity and has yet to be revealed
+	err := checkForUnrevealedClockBid(ctx, auction, bidKeys[:cutoff])
+	if err != nil {
+		return fmt.Errorf("Cannot end auction: %v", err)
+	}
+
+	auction.Status = string("ended")
+
+	err = settleEscrow(ctx, auction, payments)
+	if err != nil {
+		return fmt.Errorf("Cannot end auction: %v", err)
+	}
+
+	endedAuctionJSON, _ := json.Marshal(auction)
+
+	err = ctx.GetStub().PutState(auctionID, endedAuctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to end auction: %v", err)
+	}
+	return nil
+}
+
+// allocateClockAuction fills the revealed bids in submission order until the quantity is
+// exhausted. Every winner pays the clock price at which their bid was submitted, so
+// the auction has no single price and the payments are only recorded in the
+// allocations. The function returns the payments keyed by bid, and the number of bids that were visited
+// before the quantity ran out
+func allocateClockAuction(auction *Auction, bidKeys []string) (map[string]int, int) {
+
+	payments := make(map[string]int)
+	remainingQuantity := auction.Quantity
+	cutoff := len(bidKeys)
+
+	auction.Winners = []Winners{}
+	auction.Allocations = []Allocation{}
+
+	for i, bidKey := range bidKeys {
+
+		if remainingQuantity == 0 && cutoff == len(bidKeys) {
+			cutoff = i
+		}
+
+		bid, revealed := auction.RevealedBids[bidKey]
+		if !revealed {
+			continue
+		}
+
+		clockBid := auction.Clock.Bids[bidKey]
+		allocation := Allocation{
+			Buyer:      bid.Buyer,
+			SubmitTime: clockBid.SubmitTime,
+			ClockPrice: clockBid.Price,
+			BidPrice:   bid.Price,
+			Requested:  bid.Quantity,
+			Available:  remainingQuantity,
+		}
+
+		switch {
+		case bid.Price < clockBid.Price:
+			allocation.Result = allocationBelowClock
+		case remainingQuantity == 0:
+			allocation.Result = allocationExhausted
+		default:
+			allocation.Allocated = bid.Quantity
+			allocation.Result = allocationFilled
+			if bid.Quantity > remainingQuantity {
+				allocation.Allocated = remainingQuantity
+				allocation.Result = allocationPartiallyFilled
+			}
+			allocation.Payment = allocation.Allocated * clockBid.Price
+			remainingQuantity = remainingQuantity - allocation.Allocated
+
+			auction.Winners = append(auction.Winners, Winners{
+				Buyer:    bid.Buyer,
+				Quantity: allocation.Allocated,
+			})
+			payments[bidKey] = allocation.Payment
+		}
+
+		auction.Allocations = append(auction.Allocations, allocation)
+	}
+
+	return payments, cutoff
+}
+
+// checkForUnrevealedClockBid is an internal function that is used to determine if a bid of
+// the peer's organization that accepted the clock price has yet to be revealed
+func checkForUnrevealedClockBid(ctx contractapi.TransactionContextInterface, auction *Auction, bidKeys []string) error {
+
+	// Get MSP ID of peer org
+	peerMSPID, err := shim.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the peer's MSPID: %v", err)
+	}
+
+	for _, bidKey := range bidKeys {
+
+		if _, revealed := auction.RevealedBids[bidKey]; revealed {
+			continue
+		}
+
+		privateBid := auction.PrivateBids[bidKey]
+		if privateBid.Org != peerMSPID {
+			continue
+		}
+
+		collection := "_implicit_org_" + privateBid.Org
+		bidJSON, err := ctx.GetStub().GetPrivateData(collection, bidKey)
+		if err != nil {
+			return fmt.Errorf("failed to get bid %v: %v", bidKey, err)
+		}
+		if bidJSON == nil {
+			return fmt.Errorf("bid %v does not exist", bidKey)
+		}
+
+		var bid *FullBid
+		err = json.Unmarshal(bidJSON, &bid)
+		if err != nil {
+			return err
+		}
+
+		if bid.Price >= auction.Clock.Bids[bidKey].Price {
+			return fmt.Errorf("bid %v accepted the clock price and has not been revealed", bidKey)
+		}
+	}
+
+	return nil
+}
+
+// sortedClockBidKeys returns the keys of the bids in the order in which they were
+// submitted. Bids submitted in the same second are ordered by key
+func sortedClockBidKeys(bids map[string]ClockBid) []string {
+	keys := make([]string, 0, len(bids))
+	for key := range bids {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(p, q int) bool {
+		if bids[keys[p]].SubmitTime != bids[keys[q]].SubmitTime {
+			return bids[keys[p]].SubmitTime < bids[keys[q]].SubmitTime
+		}
+		return keys[p] < keys[q]
+	})
+	return keys
+}
@@ -0,0 +1,185 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	auction "github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go/smart-contract"
+)
+
+// createClockAuction creates a clock auction at time 1000 that starts at 100 and drops
+// by 10 every minute, down to 50
+func createClockAuction(t *testing.T, ledger *testLedger, contract *auction.SmartContract) {
+	ledger.setClient(sellerID)
+	ledger.setTime(1000)
+	err := contract.CreateClockAuction(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 10, 60, 50)
+	require.NoError(t, err)
+}
+
+func (l *testLedger) setTime(seconds int64) {
+	l.chaincodeStub.GetTxTimestampReturns(timestamppb.New(time.Unix(seconds, 0)), nil)
+}
+
+// submitClockBid stores a bid in the implicit collection and submits it at the given time
+func (l *testLedger) submitClockBid(t *testing.T, contract *auction.SmartContract, txID string, buyer string, quantity int, price int, seconds int64) []byte {
+	bidJSON, err := json.Marshal(map[string]interface{}{"objectType": "bid", "quantity": quantity, "price": price, "org": org1MSP, "buyer": buyer})
+	require.NoError(t, err)
+	l.privateData["bid~"+auctionID+"~"+txID] = bidJSON
+
+	l.setClient(buyer)
+	l.setTime(seconds)
+	err = contract.SubmitBid(l.transactionContext, auctionID, txID)
+	require.NoError(t, err)
+
+	return bidJSON
+}
+
+func TestCreateClockAuctionBadInput(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+
+	err := contract.CreateClockAuction(ledger.transactionContext, auctionID, "tickets", 10, "", 0, 10, 60, 0)
+	require.EqualError(t, err, "start price must be a positive integer")
+
+	err = contract.CreateClockAuction(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 0, 60, 0)
+	require.EqualError(t, err, "decrement must be a positive integer")
+
+	err = contract.CreateClockAuction(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 10, 0, 0)
+	require.EqualError(t, err, "time step must be a positive number of seconds")
+
+	err = contract.CreateClockAuction(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 10, 60, 120)
+	require.EqualError(t, err, "reserve price must be between 0 and the start price")
+}
+
+func TestQueryCurrentPrice(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	createClockAuction(t, ledger, contract)
+
+	for seconds, expected := range map[int64]int{1000: 100, 1059: 100, 1060: 90, 1130: 80, 1180: 70, 5000: 50} {
+		ledger.setTime(seconds)
+		price, err := contract.QueryCurrentPrice(ledger.transactionContext, auctionID)
+		require.NoError(t, err)
+		require.Equal(t, expected, price, "price at %d", seconds)
+	}
+
+	_, err := contract.QueryAllocations(ledger.transactionContext, auctionID)
+	require.EqualError(t, err, "allocations are only available for an ended auction")
+}
+
+func TestEndClockAuction(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	createClockAuction(t, ledger, contract)
+
+	bid1JSON := ledger.submitClockBid(t, contract, "tx1", buyer1ID, 4, 100, 1010)
+	bid2JSON := ledger.submitClockBid(t, contract, "tx2", buyer2ID, 3, 80, 1070)
+	bid3JSON := ledger.submitClockBid(t, contract, "tx3", buyer3ID, 8, 85, 1130)
+	bid4JSON := ledger.submitClockBid(t, contract, "tx4", buyer1ID, 2, 70, 1200)
+
+	err := contract.SubmitBid(ledger.transactionContext, auctionID, "tx4")
+	require.EqualError(t, err, "bid bid~auction1~tx4 has already been submitted")
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+	require.NoError(t, ledger.revealBid(contract, "tx4", buyer1ID, bid4JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx3", buyer3ID, bid3JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx2", buyer2ID, bid2JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx1", buyer1ID, bid1JSON))
+
+	ledger.setClient(sellerID)
+	err = contract.EndAuction(ledger.transactionContext, auctionID)
+	require.NoError(t, err)
+
+	ended := ledger.auction(t)
+	require.Equal(t, "ended", ended.Status)
+	require.Zero(t, ended.Price)
+	require.Equal(t, []auction.Winners{{Buyer: buyer1ID, Quantity: 4}, {Buyer: buyer3ID, Quantity: 6}}, ended.Winners)
+
+	// the report shows how every revealed bid was treated, in submission order
+	allocations, err := contract.QueryAllocations(ledger.transactionContext, auctionID)
+	require.NoError(t, err)
+	require.Equal(t, []auction.Allocation{
+		{Buyer: buyer1ID, SubmitTime: 1010, ClockPrice: 100, BidPrice: 100, Requested: 4, Available: 10, Allocated: 4, Payment: 400, Result: "filled"},
+		{Buyer: buyer2ID, SubmitTime: 1070, ClockPrice: 90, BidPrice: 80, Requested: 3, Available: 6, Result: "below clock price"},
+		{Buyer: buyer3ID, SubmitTime: 1130, ClockPrice: 80, BidPrice: 85, Requested: 8, Available: 6, Allocated: 6, Payment: 480, Result: "partially filled"},
+		{Buyer: buyer1ID, SubmitTime: 1200, ClockPrice: 70, BidPrice: 70, Requested: 2, Available: 0, Result: "quantity exhausted"},
+	}, allocations)
+}
+
+func TestEndClockAuctionWithUnrevealedBid(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	createClockAuction(t, ledger, contract)
+	ledger.chaincodeStub.GetPrivateDataStub = func(collection string, key string) ([]byte, error) {
+		return ledger.privateData[key], nil
+	}
+
+	bid1JSON := ledger.submitClockBid(t, contract, "tx1", buyer1ID, 4, 95, 1070)
+	bid2JSON := ledger.submitClockBid(t, contract, "tx2", buyer2ID, 20, 90, 1130)
+	ledger.submitClockBid(t, contract, "tx3", buyer3ID, 4, 90, 1200)
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+	require.NoError(t, ledger.revealBid(contract, "tx2", buyer2ID, bid2JSON))
+
+	// the first bid accepted the clock price and would have been filled before the second
+	ledger.setClient(sellerID)
+	err := contract.EndAuction(ledger.transactionContext, auctionID)
+	require.EqualError(t, err, "Cannot end auction: bid bid~auction1~tx1 accepted the clock price and has not been revealed")
+
+	// the third bid was submitted after the quantity ran out, so it does not need to be revealed
+	require.NoError(t, ledger.revealBid(contract, "tx1", buyer1ID, bid1JSON))
+	ledger.setClient(sellerID)
+	err = contract.EndAuction(ledger.transactionContext, auctionID)
+	require.NoError(t, err)
+	require.Equal(t, []auction.Winners{{Buyer: buyer1ID, Quantity: 4}, {Buyer: buyer2ID, Quantity: 6}}, ledger.auction(t).Winners)
+}
+
+func TestEndClockAuctionSettlesEscrow(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	ledger.setTime(1000)
+
+	err := contract.CreateClockAuctionWithEscrow(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 10, 60, 50, "")
+	require.EqualError(t, err, "token chaincode name must be a non-empty string")
+
+	err = contract.CreateClockAuctionWithEscrow(ledger.transactionContext, auctionID, "tickets", 10, "", 100, 10, 60, 50, tokenChaincode)
+	require.NoError(t, err)
+	require.NotNil(t, ledger.auction(t).Clock)
+
+	// buyer1 accepts the clock price of 100 and buyer2 the clock price of 80
+	ledger.setTime(1010)
+	bid1JSON := ledger.placeBid(t, contract, "tx1", buyer1ID, 4, 100, 400)
+	ledger.setTime(1130)
+	bid2JSON := ledger.placeBid(t, contract, "tx2", buyer2ID, 8, 85, 700)
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+	require.NoError(t, ledger.revealBid(contract, "tx1", buyer1ID, bid1JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx2", buyer2ID, bid2JSON))
+
+	ledger.setClient(sellerID)
+	calls := ledger.chaincodeStub.InvokeChaincodeCallCount()
+	err = contract.EndAuction(ledger.transactionContext, auctionID)
+	require.NoError(t, err)
+
+	// each winner pays the clock price of their bid for the quantity they were allocated
+	sellerAccount := base64.StdEncoding.EncodeToString([]byte(sellerID))
+	require.Equal(t, []string{"ReleaseHold", "auction1_tx1", sellerAccount, "400"}, invokedArgs(ledger.chaincodeStub, calls))
+	require.Equal(t, []string{"ReleaseHold", "auction1_tx2", sellerAccount, "480"}, invokedArgs(ledger.chaincodeStub, calls+1))
+
+	ended := ledger.auction(t)
+	require.Zero(t, ended.Price)
+	require.Equal(t, 400, ended.Allocations[0].Payment)
+	require.Equal(t, 480, ended.Allocations[1].Payment)
+}
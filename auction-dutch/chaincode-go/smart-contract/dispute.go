@@ -0,0 +1,278 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// auditorMSP is the organization that audits auctions created with an auditor
+const auditorMSP = "Org3MSP"
+
+// Dispute is raised by the auditor on a closed auction. While the dispute is
+// open the auction cannot be ended
+type Dispute struct {
+	Status     string `json:"status"`
+	Reason     string `json:"reason"`
+	RaisedBy   string `json:"raisedBy"`
+	Resolution string `json:"resolution"`
+}
+
+// AuditRecord is an entry in the history of the audit steps taken on an auction
+type AuditRecord struct {
+	Action  string `json:"action"`
+	Auditor string `json:"auditor"`
+	TxID    string `json:"txID"`
+	Time    int64  `json:"time"`
+	Details string `json:"details"`
+}
+
+// BidInspection compares the hash of a bid recorded in the auction with the hash
+// of the bid stored in the implicit collection of the bidder's organization
+type BidInspection struct {
+	BidKey       string `json:"bidKey"`
+	Org          string `json:"org"`
+	RecordedHash string `json:"recordedHash"`
+	LedgerHash   string `json:"ledgerHash"`
+	HashMatches  bool   `json:"hashMatches"`
+	Revealed     bool   `json:"revealed"`
+	OrgMatches   bool   `json:"orgMatches"`
+}
+
+// auditEvent is the payload of the events emitted by the audit functions
+type auditEvent struct {
+	AuctionID string      `json:"auctionID"`
+	Record    AuditRecord `json:"record"`
+}
+
+const (
+	disputeOpen      = "open"
+	disputeConfirmed = "confirmed"
+	disputeAnnulled  = "annulled"
+)
+
+// RaiseDispute is used by the auditor to dispute the outcome of a closed auction.
+// The auction is frozen and cannot be ended until the dispute is resolved
+func (s *SmartContract) RaiseDispute(ctx contractapi.TransactionContextInterface, auctionID string, reason string) error {
+
+	auction, err := s.getAuditedAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	if auction.Status != "closed" {
+		return fmt.Errorf("can only raise a dispute on a closed auction")
+	}
+	if auction.Dispute != nil && auction.Dispute.Status == disputeOpen {
+		return fmt.Errorf("auction already has an open dispute")
+	}
+	if reason == "" {
+		return fmt.Errorf("reason must be a non-empty string")
+	}
+
+	record, err := s.newAuditRecord(ctx, "DisputeRaised", reason)
+	if err != nil {
+		return err
+	}
+
+	auction.Dispute = &Dispute{
+		Status:   disputeOpen,
+		Reason:   reason,
+		RaisedBy: record.Auditor,
+	}
+
+	return putAuditedAuction(ctx, auctionID, auction, record)
+}
+
+// InspectBids is used by the auditor to compare the bid hashes recorded in the auction
+// with the hashes stored in the implicit collections of the bidders, and to list which
+// bids have been revealed. The result of the inspection is added to the auction history
+func (s *SmartContract) InspectBids(ctx contractapi.TransactionContextInterface, auctionID string) ([]BidInspection, error) {
+
+	auction, err := s.getAuditedAuction(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if auction.Status == "open" {
+		return nil, fmt.Errorf("cannot inspect the bids of an open auction")
+	}
+
+	var inspections []BidInspection
+	mismatches := 0
+	revealed := 0
+
+	for _, bidKey := range sortedBidHashKeys(auction.PrivateBids) {
+		privateBid := auction.PrivateBids[bidKey]
+
+		collection := "_implicit_org_" + privateBid.Org
+		ledgerHash, err := ctx.GetStub().GetPrivateDataHash(collection, bidKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bid hash from collection: %v", err)
+		}
+
+		inspection := BidInspection{
+			BidKey:       bidKey,
+			Org:          privateBid.Org,
+			RecordedHash: privateBid.Hash,
+			LedgerHash:   fmt.Sprintf("%x", ledgerHash),
+			OrgMatches:   true,
+		}
+		inspection.HashMatches = ledgerHash != nil && inspection.LedgerHash == privateBid.Hash
+
+		revealedBid, ok := auction.RevealedBids[bidKey]
+		if ok {
+			inspection.Revealed = true
+			inspection.OrgMatches = revealedBid.Org == privateBid.Org
+			revealed++
+		}
+
+		if !inspection.HashMatches || !inspection.OrgMatches {
+			mismatches++
+		}
+
+		inspections = append(inspections, inspection)
+	}
+
+	details := fmt.Sprintf("%d bids, %d revealed, %d mismatches", len(inspections), revealed, mismatches)
+	record, err := s.newAuditRecord(ctx, "BidsInspected", details)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putAuditedAuction(ctx, auctionID, auction, record)
+	if err != nil {
+		return nil, err
+	}
+
+	return inspections, nil
+}
+
+// ResolveDispute is used by the auditor to close an open dispute. A confirmed auction
+// can be ended by the seller. An annulled auction cannot be ended, and any deposits
+// locked for its bids are refunded
+func (s *SmartContract) ResolveDispute(ctx contractapi.TransactionContextInterface, auctionID string, resolution string, notes string) error {
+
+	auction, err := s.getAuditedAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	if auction.Dispute == nil || auction.Dispute.Status != disputeOpen {
+		return fmt.Errorf("auction does not have an open dispute")
+	}
+
+	var action string
+	switch resolution {
+	case disputeConfirmed:
+		action = "DisputeConfirmed"
+	case disputeAnnulled:
+		action = "DisputeAnnulled"
+		auction.Status = "annulled"
+
+		// no bid wins an annulled auction
+		err = settleEscrow(ctx, auction, nil)
+		if err != nil {
+			return fmt.Errorf("failed to annul auction: %v", err)
+		}
+	default:
+		return fmt.Errorf("resolution must be either %s or %s", disputeConfirmed, disputeAnnulled)
+	}
+
+	record, err := s.newAuditRecord(ctx, action, notes)
+	if err != nil {
+		return err
+	}
+
+	auction.Dispute.Status = resolution
+	auction.Dispute.Resolution = notes
+
+	return putAuditedAuction(ctx, auctionID, auction, record)
+}
+
+// getAuditedAuction reads an auction that was created with an auditor, after checking
+// that the transaction was submitted by the auditor organization
+func (s *SmartContract) getAuditedAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if clientOrgID != auditorMSP {
+		return nil, fmt.Errorf("only the auditor can perform this action")
+	}
+
+	auction, err := s.QueryAuction(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction from public state %v", err)
+	}
+	if !auction.Auditor {
+		return nil, fmt.Errorf("auction %s was not created with an auditor", auctionID)
+	}
+
+	return auction, nil
+}
+
+// newAuditRecord creates a history entry for the audit step taken by the submitting client
+func (s *SmartContract) newAuditRecord(ctx contractapi.TransactionContextInterface, action string, details string) (AuditRecord, error) {
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	return AuditRecord{
+		Action:  action,
+		Auditor: clientID,
+		TxID:    ctx.GetStub().GetTxID(),
+		Time:    timestamp.GetSeconds(),
+		Details: details,
+	}, nil
+}
+
+// putAuditedAuction appends the record to the auction history, puts the auction into
+// state and emits an event named after the action of the record
+func putAuditedAuction(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction, record AuditRecord) error {
+
+	auction.History = append(auction.History, record)
+
+	auctionJSON, _ := json.Marshal(auction)
+
+	err := ctx.GetStub().PutState(auctionID, auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+
+	eventJSON, err := json.Marshal(auditEvent{AuctionID: auctionID, Record: record})
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(record.Action, eventJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+// sortedBidHashKeys returns the keys of the private bids in a deterministic order
+func sortedBidHashKeys(bids map[string]BidHash) []string {
+	keys := make([]string, 0, len(bids))
+	for key := range bids {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+
+	auction "github.com/hyperledger/fabric-samples/auction/dutch-auction/chaincode-go/smart-contract"
+)
+
+const auditorID = "x509::CN=auditor"
+const auditorMSP = "Org3MSP"
+
+// disputeEscrowedAuction creates an audited auction that settles through the token
+// chaincode, with two revealed bids, and has the auditor raise a dispute once the
+// auction is closed
+func disputeEscrowedAuction(t *testing.T, ledger *testLedger, contract *auction.SmartContract) {
+	ledger.setTime(1000)
+	require.NoError(t, contract.CreateAuctionWithEscrow(ledger.transactionContext, auctionID, "tickets", 10, "withAuditor", tokenChaincode))
+	bid1JSON := ledger.placeBid(t, contract, "tx1", buyer1ID, 6, 40, 300)
+	bid2JSON := ledger.placeBid(t, contract, "tx2", buyer2ID, 6, 60, 400)
+
+	ledger.setClient(sellerID)
+	require.NoError(t, contract.CloseAuction(ledger.transactionContext, auctionID))
+	require.NoError(t, ledger.revealBid(contract, "tx1", buyer1ID, bid1JSON))
+	require.NoError(t, ledger.revealBid(contract, "tx2", buyer2ID, bid2JSON))
+
+	ledger.setAuditor()
+	require.NoError(t, contract.RaiseDispute(ledger.transactionContext, auctionID, "bid hash mismatch reported by Org1"))
+}
+
+func (l *testLedger) setAuditor() {
+	l.setClient(auditorID)
+	l.clientIdentity.GetMSPIDReturns(auditorMSP, nil)
+}
+
+func (l *testLedger) setSeller() {
+	l.setClient(sellerID)
+	l.clientIdentity.GetMSPIDReturns(org1MSP, nil)
+}
+
+func TestEndAuctionRefusedDuringDispute(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	disputeEscrowedAuction(t, ledger, contract)
+	calls := ledger.chaincodeStub.InvokeChaincodeCallCount()
+
+	ledger.setSeller()
+	err := contract.EndAuction(ledger.transactionContext, auctionID)
+	require.EqualError(t, err, "Cannot end auction while a dispute is open")
+	require.Equal(t, calls, ledger.chaincodeStub.InvokeChaincodeCallCount())
+
+	disputed := ledger.auction(t)
+	require.Equal(t, "closed", disputed.Status)
+	require.Equal(t, "locked", disputed.Escrow.Deposits["bid~"+auctionID+"~tx1"].Status)
+	require.Equal(t, "locked", disputed.Escrow.Deposits["bid~"+auctionID+"~tx2"].Status)
+
+	// once the dispute is confirmed, the seller can end the auction
+	ledger.setAuditor()
+	require.NoError(t, contract.ResolveDispute(ledger.transactionContext, auctionID, "confirmed", "hashes verified with Org1"))
+
+	ledger.setSeller()
+	require.NoError(t, contract.EndAuction(ledger.transactionContext, auctionID))
+	require.Equal(t, "ended", ledger.auction(t).Status)
+}
+
+func TestResolveDisputeAnnulRefundsDeposits(t *testing.T) {
+	ledger := prepMocks(sellerID)
+	contract := &auction.SmartContract{}
+	disputeEscrowedAuction(t, ledger, contract)
+
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.ERROR, Message: "hold auction1_tx1 does not exist"})
+	err := contract.ResolveDispute(ledger.transactionContext, auctionID, "annulled", "bids were tampered with")
+	require.EqualError(t, err, "failed to annul auction: failed to refund deposit auction1_tx1: RefundHold on chaincode token_erc20 returned status 500: hold auction1_tx1 does not exist")
+	require.Equal(t, "open", ledger.auction(t).Dispute.Status)
+
+	ledger.chaincodeStub.InvokeChaincodeReturns(peer.Response{Status: shim.OK})
+	calls := ledger.chaincodeStub.InvokeChaincodeCallCount()
+	err = contract.ResolveDispute(ledger.transactionContext, auctionID, "annulled", "bids were tampered with")
+	require.NoError(t, err)
+
+	// no bid wins an annulled auction, so every deposit is refunded
+	require.Equal(t, calls+2, ledger.chaincodeStub.InvokeChaincodeCallCount())
+	require.Equal(t, []string{"RefundHold", "auction1_tx1"}, invokedArgs(ledger.chaincodeStub, calls))
+	require.Equal(t, []string{"RefundHold", "auction1_tx2"}, invokedArgs(ledger.chaincodeStub, calls+1))
+
+	annulled := ledger.auction(t)
+	require.Equal(t, "annulled", annulled.Status)
+	require.Equal(t, "annulled", annulled.Dispute.Status)
+	require.Equal(t, "bids were tampered with", annulled.Dispute.Resolution)
+	require.Equal(t, "refunded", annulled.Escrow.Deposits["bid~"+auctionID+"~tx1"].Status)
+	require.Equal(t, "refunded", annulled.Escrow.Deposits["bid~"+auctionID+"~tx2"].Status)
+	require.Empty(t, annulled.Winners)
+
+	ledger.setSeller()
+	err = contract.EndAuction(ledger.transactionContext, auctionID)
+	require.EqualError(t, err, "Can only end a closed auction")
+}
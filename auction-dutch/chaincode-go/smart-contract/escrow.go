@@ -0,0 +1,106 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/escrow"
+)
+
+// CreateAuctionWithEscrow creates an auction that settles through the token chaincode
+// with the name tokenChaincode. The token account of the identity that submits the
+// transaction receives the payments of the winners
+func (s *SmartContract) CreateAuctionWithEscrow(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, quantity int, withAuditor string, tokenChaincode string) error {
+
+	auctionEscrow, err := newEscrow(ctx, tokenChaincode)
+	if err != nil {
+		return err
+	}
+
+	return s.createAuction(ctx, auctionID, itemsold, quantity, withAuditor, auctionEscrow, nil)
+}
+
+// newEscrow creates the escrow of an auction that settles through the token chaincode
+// with the name tokenChaincode, paying the identity that submits the transaction
+func newEscrow(ctx contractapi.TransactionContextInterface, tokenChaincode string) (*escrow.Escrow, error) {
+
+	// token accounts are identified by the encoded client ID
+	sellerAccount, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	return escrow.New(tokenChaincode, sellerAccount)
+}
+
+// SubmitEscrowedBid adds the hash of a bid to an auction that settles through a token
+// chaincode, and locks deposit tokens from the account of the buyer until the auction
+// ends. The deposit needs to cover the price times the quantity of the bid when it is revealed
+func (s *SmartContract) SubmitEscrowedBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string, deposit int) error {
+
+	if deposit <= 0 {
+		return fmt.Errorf("deposit must be a positive integer")
+	}
+
+	return s.submitBid(ctx, auctionID, txID, deposit)
+}
+
+// lockBidDeposit moves the deposit of the submitting client into a hold of the token
+// chaincode and records it on the auction
+func (s *SmartContract) lockBidDeposit(ctx contractapi.TransactionContextInterface, auction *Auction, auctionID string, txID string, bidKey string, deposit int) error {
+
+	if auction.Escrow == nil {
+		if deposit != 0 {
+			return fmt.Errorf("auction %s does not settle through a token chaincode", auctionID)
+		}
+		return nil
+	}
+
+	if deposit <= 0 {
+		return fmt.Errorf("auction %s settles through token chaincode %s, use SubmitEscrowedBid to lock a deposit", auctionID, auction.Escrow.TokenChaincode)
+	}
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	return auction.Escrow.Lock(ctx.GetStub(), bidKey, auctionID+"_"+txID, clientID, deposit)
+}
+
+// checkBidDeposit verifies that a revealed bid is backed by a deposit of the buyer
+// that covers the total value of the bid
+func checkBidDeposit(auction *Auction, bidKey string, buyer string, value int) error {
+
+	if auction.Escrow == nil {
+		return nil
+	}
+
+	return auction.Escrow.Check(bidKey, buyer, value)
+}
+
+// settleEscrow pays the seller from the deposits listed in payments, keyed by bid, and
+// refunds every other locked deposit.
+func settleEscrow(ctx contractapi.TransactionContextInterface, auction *Auction, payments map[string]int) error {
+
+	if auction.Escrow == nil {
+		return nil
+	}
+
+	return auction.Escrow.Settle(ctx.GetStub(), payments)
+}
+
+// sortedBidKeys returns the keys of the revealed bids in a deterministic order
+func sortedBidKeys(bids map[string]FullBid) []string {
+	keys := make([]string, 0, len(bids))
+	for key := range bids {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
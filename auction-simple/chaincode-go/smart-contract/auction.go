@@ -11,6 +11,8 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/chaincode/escrow"
+	"github.com/hyperledger/fabric-samples/chaincode/sbe"
 )
 
 type SmartContract struct {
@@ -28,6 +30,7 @@ type Auction struct {
 	Winner       string             `json:"winner"`
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
+	Escrow       *escrow.Escrow     `json:"escrow,omitempty"`
 }
 
 // FullBid is the structure of a revealed bid
@@ -49,6 +52,12 @@ const bidKeyType = "bid"
 // CreateAuction creates on auction on the public channel. The identity that
 // submits the transacion becomes the seller of the auction
 func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string) error {
+	return s.createAuction(ctx, auctionID, itemsold, nil)
+}
+
+// createAuction puts a new auction into the public state. Auctions that settle through a
+// token chaincode carry the escrow configuration
+func (s *SmartContract) createAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, auctionEscrow *escrow.Escrow) error {
 
 	// get ID of submitting client
 	clientID, err := s.GetSubmittingClientIdentity(ctx)
@@ -76,6 +85,7 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		RevealedBids: revealedBids,
 		Winner:       "",
 		Status:       "open",
+		Escrow:       auctionEscrow,
 	}
 
 	auctionJSON, err := json.Marshal(auction)
@@ -90,7 +100,7 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 	}
 
 	// set the seller of the auction as an endorser
-	err = setAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+	err = sbe.SetOrgs(ctx.GetStub(), auctionID, clientOrgID)
 	if err != nil {
 		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 	}
@@ -149,6 +159,12 @@ func (s *SmartContract) Bid(ctx contractapi.TransactionContextInterface, auction
 // auction. Note that this function alters the auction in private state, and needs
 // to meet the auction endorsement policy. Transaction ID is used identify the bid
 func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+	return s.submitBid(ctx, auctionID, txID, 0)
+}
+
+// submitBid adds the bid hash to the auction. If the auction settles through a token
+// chaincode, the deposit is locked in escrow before the bid is accepted
+func (s *SmartContract) submitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string, deposit int) error {
 
 	// get the MSP ID of the bidder's org
 	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
@@ -200,13 +216,19 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 	bidders[bidKey] = NewHash
 	auction.PrivateBids = bidders
 
+	// lock the deposit of the bidder in the token chaincode
+	err = s.lockBidDeposit(ctx, auction, auctionID, txID, bidKey, deposit)
+	if err != nil {
+		return err
+	}
+
 	// Add the bidding organization to the list of participating organizations if it is not already
 	Orgs := auction.Orgs
 	if !(contains(Orgs, clientOrgID)) {
 		newOrgs := append(Orgs, clientOrgID)
 		auction.Orgs = newOrgs
 
-		err = addAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+		err = sbe.AddOrgs(ctx.GetStub(), auctionID, clientOrgID)
 		if err != nil {
 			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 		}
@@ -338,6 +360,12 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
 	}
 
+	// check 5: make sure that an escrowed bid is covered by the deposit of the bidder
+	err = checkBidDeposit(auction, bidKey, clientID, bidInput.Price)
+	if err != nil {
+		return err
+	}
+
 	revealedBids := make(map[string]FullBid)
 	revealedBids = auction.RevealedBids
 	revealedBids[bidKey] = NewBid
@@ -428,11 +456,15 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("No bids have been revealed, cannot end auction: %v", err)
 	}
 
-	// determine the highest bid
-	for _, bid := range revealedBidMap {
+	// determine the highest bid. Bids are visited in key order so that every
+	// endorser picks the same winner when prices are tied
+	winningBidKey := ""
+	for _, bidKey := range sortedBidKeys(revealedBidMap) {
+		bid := revealedBidMap[bidKey]
 		if bid.Price > auction.Price {
 			auction.Winner = bid.Bidder
 			auction.Price = bid.Price
+			winningBidKey = bidKey
 		}
 	}
 
@@ -444,6 +476,12 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 
 	auction.Status = string("ended")
 
+	// pay the seller from the winning deposit and refund all other bidders
+	err = settleEscrow(ctx, auction, map[string]int{winningBidKey: auction.Price})
+	if err != nil {
+		return fmt.Errorf("Cannot end auction: %v", err)
+	}
+
 	endedAuctionJSON, _ := json.Marshal(auction)
 
 	err = ctx.GetStub().PutState(auctionID, endedAuctionJSON)
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"encoding/base64"
 
-	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -26,58 +25,6 @@ func (s *SmartContract) GetSubmittingClientIdentity(ctx contractapi.TransactionC
 	return string(decodeID), nil
 }
 
-// setAssetStateBasedEndorsement sets the endorsement policy of a new auction
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgToEndorse string) error {
-
-	endorsementPolicy, err := statebased.NewStateEP(nil)
-	if err != nil {
-		return err
-	}
-	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgToEndorse)
-	if err != nil {
-		return fmt.Errorf("failed to add org to endorsement policy: %v", err)
-	}
-	policy, err := endorsementPolicy.Policy()
-	if err != nil {
-		return fmt.Errorf("failed to create endorsement policy bytes from org: %v", err)
-	}
-	err = ctx.GetStub().SetStateValidationParameter(auctionID, policy)
-	if err != nil {
-		return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-	}
-
-	return nil
-}
-
-// addAssetStateBasedEndorsement adds a new organization as an endorser of the auction
-func addAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgToEndorse string) error {
-
-	endorsementPolicy, err := ctx.GetStub().GetStateValidationParameter(auctionID)
-	if err != nil {
-		return err
-	}
-
-	newEndorsementPolicy, err := statebased.NewStateEP(endorsementPolicy)
-	if err != nil {
-		return err
-	}
-
-	err = newEndorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgToEndorse)
-	if err != nil {
-		return fmt.Errorf("failed to add org to endorsement policy: %v", err)
-	}
-	policy, err := newEndorsementPolicy.Policy()
-	if err != nil {
-		return fmt.Errorf("failed to create endorsement policy bytes from org: %v", err)
-	}
-	err = ctx.GetStub().SetStateValidationParameter(auctionID, policy)
-	if err != nil {
-		return fmt.Errorf("failed to set validation parameter on auction: %v", err)
-	}
-
-	return nil
-}
-
 // getCollectionName is an internal helper function to get collection of submitting client identity.
 func getCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
 
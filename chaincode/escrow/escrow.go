@@ -0,0 +1,162 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package escrow settles the bids of an auction through a fungible token
+// chaincode on the same channel, such as the token_erc20 sample. Each bid locks
+// a deposit in a hold of the token chaincode, and when the auction ends the
+// seller is paid from the winning deposits and every other deposit is refunded:
+//
+//	e, err := escrow.New("token_erc20", sellerAccount)
+//	err = e.Lock(stub, bidKey, holdID, bidderAccount, deposit)
+//	err = e.Check(bidKey, bidderAccount, price)
+//	err = e.Settle(stub, map[string]int{winningBidKey: price})
+//
+// The token chaincode must provide the HoldFunds, ReleaseHold and RefundHold
+// functions. An Escrow is stored as part of the auction it settles.
+package escrow
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Deposit statuses
+const (
+	DepositLocked   = "locked"
+	DepositReleased = "released"
+	DepositRefunded = "refunded"
+)
+
+// Escrow ties an auction to a fungible token chaincode on the same channel. The
+// deposits are keyed by bid
+type Escrow struct {
+	TokenChaincode string             `json:"tokenChaincode"`
+	SellerAccount  string             `json:"sellerAccount"`
+	Deposits       map[string]Deposit `json:"deposits"`
+}
+
+// Deposit is the amount a bidder locked in the token chaincode for a single bid.
+// The deposit can be larger than the bid so that the value of the bid stays
+// private until the bid is revealed
+type Deposit struct {
+	HoldID string `json:"holdID"`
+	Bidder string `json:"bidder"`
+	Amount int    `json:"amount"`
+	Status string `json:"status"`
+}
+
+// New creates the escrow of an auction that settles through the token chaincode
+// with the name tokenChaincode, paying the token account sellerAccount
+func New(tokenChaincode string, sellerAccount string) (*Escrow, error) {
+	if tokenChaincode == "" {
+		return nil, fmt.Errorf("token chaincode name must be a non-empty string")
+	}
+
+	return &Escrow{
+		TokenChaincode: tokenChaincode,
+		SellerAccount:  sellerAccount,
+		Deposits:       make(map[string]Deposit),
+	}, nil
+}
+
+// Lock moves amount tokens of the client submitting the transaction into the
+// hold holdID of the token chaincode, and records the deposit of the bid with
+// the key bidKey, submitted by the token account bidder
+func (e *Escrow) Lock(stub shim.ChaincodeStubInterface, bidKey string, holdID string, bidder string, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("deposit must be a positive integer")
+	}
+	if _, exists := e.Deposits[bidKey]; exists {
+		return fmt.Errorf("a deposit is already locked for %s", bidKey)
+	}
+
+	err := invokeTokenChaincode(stub, e.TokenChaincode, "HoldFunds", holdID, strconv.Itoa(amount))
+	if err != nil {
+		return fmt.Errorf("failed to lock deposit: %v", err)
+	}
+
+	e.Deposits[bidKey] = Deposit{
+		HoldID: holdID,
+		Bidder: bidder,
+		Amount: amount,
+		Status: DepositLocked,
+	}
+	return nil
+}
+
+// Check verifies that the bid with the key bidKey is backed by a deposit of the
+// bidder that covers the value of the bid
+func (e *Escrow) Check(bidKey string, bidder string, value int) error {
+	deposit, ok := e.Deposits[bidKey]
+	if !ok {
+		return fmt.Errorf("no deposit is locked for %s", bidKey)
+	}
+	if deposit.Bidder != bidder {
+		return fmt.Errorf("Permission denied, client id %v did not lock the deposit of the bid", bidder)
+	}
+	if value > deposit.Amount {
+		return fmt.Errorf("bid value %d exceeds the deposit of %d", value, deposit.Amount)
+	}
+	return nil
+}
+
+// Settle pays the seller from the deposits listed in payments, keyed by bid, and
+// refunds every other locked deposit. Deposits are settled in key order so that
+// all endorsers invoke the token chaincode in the same way
+func (e *Escrow) Settle(stub shim.ChaincodeStubInterface, payments map[string]int) error {
+	for _, bidKey := range e.sortedDepositKeys() {
+		deposit := e.Deposits[bidKey]
+		if deposit.Status != DepositLocked {
+			continue
+		}
+
+		payment, won := payments[bidKey]
+		if won {
+			err := invokeTokenChaincode(stub, e.TokenChaincode, "ReleaseHold", deposit.HoldID, e.SellerAccount, strconv.Itoa(payment))
+			if err != nil {
+				return fmt.Errorf("failed to release deposit %s: %v", deposit.HoldID, err)
+			}
+			deposit.Status = DepositReleased
+		} else {
+			err := invokeTokenChaincode(stub, e.TokenChaincode, "RefundHold", deposit.HoldID)
+			if err != nil {
+				return fmt.Errorf("failed to refund deposit %s: %v", deposit.HoldID, err)
+			}
+			deposit.Status = DepositRefunded
+		}
+
+		e.Deposits[bidKey] = deposit
+	}
+
+	return nil
+}
+
+// sortedDepositKeys returns the keys of the deposits in a deterministic order
+func (e *Escrow) sortedDepositKeys() []string {
+	keys := make([]string, 0, len(e.Deposits))
+	for key := range e.Deposits {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// invokeTokenChaincode calls a function of the token chaincode on the same channel
+func invokeTokenChaincode(stub shim.ChaincodeStubInterface, tokenChaincode string, function string, args ...string) error {
+	chaincodeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		chaincodeArgs = append(chaincodeArgs, []byte(arg))
+	}
+
+	response := stub.InvokeChaincode(tokenChaincode, chaincodeArgs, "")
+	if response.Status != shim.OK {
+		return fmt.Errorf("%s on chaincode %s returned status %d: %s", function, tokenChaincode, response.Status, response.Message)
+	}
+	return nil
+}
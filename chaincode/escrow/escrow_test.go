@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package escrow
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenChaincode records the holds of the token chaincode, and the calls made to it
+type tokenChaincode struct {
+	holds map[string]int
+	calls []string
+}
+
+func (t *tokenChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (t *tokenChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+	t.calls = append(t.calls, fmt.Sprint(function, args))
+
+	switch function {
+	case "HoldFunds":
+		amount, _ := strconv.Atoi(args[1])
+		if amount > 100 {
+			return shim.Error("client account has insufficient funds")
+		}
+		t.holds[args[0]] = amount
+	case "ReleaseHold":
+		amount, _ := strconv.Atoi(args[2])
+		if amount > t.holds[args[0]] {
+			return shim.Error(fmt.Sprintf("release amount %d exceeds hold %s of %d", amount, args[0], t.holds[args[0]]))
+		}
+		delete(t.holds, args[0])
+	case "RefundHold":
+		if _, ok := t.holds[args[0]]; !ok {
+			return shim.Error(fmt.Sprintf("hold %s does not exist", args[0]))
+		}
+		delete(t.holds, args[0])
+	}
+	return shim.Success(nil)
+}
+
+func newStub() (*shimtest.MockStub, *tokenChaincode) {
+	token := &tokenChaincode{holds: map[string]int{}}
+	stub := shimtest.NewMockStub("auction", nil)
+	stub.MockPeerChaincode("token_erc20", shimtest.NewMockStub("token_erc20", token), "")
+	stub.MockTransactionStart("tx1")
+	return stub, token
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("", "seller")
+	require.EqualError(t, err, "token chaincode name must be a non-empty string")
+
+	e, err := New("token_erc20", "seller")
+	require.NoError(t, err)
+	require.Equal(t, &Escrow{TokenChaincode: "token_erc20", SellerAccount: "seller", Deposits: map[string]Deposit{}}, e)
+}
+
+func TestLockAndCheck(t *testing.T) {
+	stub, token := newStub()
+	e, err := New("token_erc20", "seller")
+	require.NoError(t, err)
+
+	require.NoError(t, e.Lock(stub, "bid1", "auction1_tx1", "bidder1", 50))
+	require.Equal(t, Deposit{HoldID: "auction1_tx1", Bidder: "bidder1", Amount: 50, Status: DepositLocked}, e.Deposits["bid1"])
+	require.Equal(t, map[string]int{"auction1_tx1": 50}, token.holds)
+
+	require.EqualError(t, e.Lock(stub, "bid1", "auction1_tx1", "bidder1", 50), "a deposit is already locked for bid1")
+	require.EqualError(t, e.Lock(stub, "bid2", "auction1_tx2", "bidder2", 0), "deposit must be a positive integer")
+	require.EqualError(t, e.Lock(stub, "bid2", "auction1_tx2", "bidder2", 500), "failed to lock deposit: HoldFunds on chaincode token_erc20 returned status 500: client account has insufficient funds")
+	require.NotContains(t, e.Deposits, "bid2")
+
+	require.NoError(t, e.Check("bid1", "bidder1", 50))
+	require.EqualError(t, e.Check("bid1", "bidder1", 60), "bid value 60 exceeds the deposit of 50")
+	require.EqualError(t, e.Check("bid1", "bidder2", 40), "Permission denied, client id bidder2 did not lock the deposit of the bid")
+	require.EqualError(t, e.Check("bid2", "bidder2", 40), "no deposit is locked for bid2")
+}
+
+func TestSettle(t *testing.T) {
+	stub, token := newStub()
+	e, err := New("token_erc20", "seller")
+	require.NoError(t, err)
+	require.NoError(t, e.Lock(stub, "bid2", "auction1_tx2", "bidder2", 80))
+	require.NoError(t, e.Lock(stub, "bid1", "auction1_tx1", "bidder1", 50))
+	token.calls = nil
+
+	require.NoError(t, e.Settle(stub, map[string]int{"bid2": 70}))
+
+	// deposits are settled in key order, and the seller is paid the winning price
+	require.Equal(t, []string{"RefundHold[auction1_tx1]", "ReleaseHold[auction1_tx2 seller 70]"}, token.calls)
+	require.Equal(t, DepositRefunded, e.Deposits["bid1"].Status)
+	require.Equal(t, DepositReleased, e.Deposits["bid2"].Status)
+	require.Empty(t, token.holds)
+
+	// settled deposits are not settled again
+	token.calls = nil
+	require.NoError(t, e.Settle(stub, nil))
+	require.Empty(t, token.calls)
+}
+
+func TestSettleReturnsTokenChaincodeErrors(t *testing.T) {
+	stub, token := newStub()
+	e, err := New("token_erc20", "seller")
+	require.NoError(t, err)
+	require.NoError(t, e.Lock(stub, "bid1", "auction1_tx1", "bidder1", 50))
+
+	err = e.Settle(stub, map[string]int{"bid1": 60})
+	require.EqualError(t, err, "failed to release deposit auction1_tx1: ReleaseHold on chaincode token_erc20 returned status 500: release amount 60 exceeds hold auction1_tx1 of 50")
+	require.Equal(t, DepositLocked, e.Deposits["bid1"].Status)
+
+	delete(token.holds, "auction1_tx1")
+	err = e.Settle(stub, nil)
+	require.EqualError(t, err, "failed to refund deposit auction1_tx1: RefundHold on chaincode token_erc20 returned status 500: hold auction1_tx1 does not exist")
+}
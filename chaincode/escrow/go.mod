@@ -0,0 +1,9 @@
+module github.com/hyperledger/fabric-samples/chaincode/escrow
+
+go 1.14
+
+require (
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
+	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
+	github.com/stretchr/testify v1.8.0
+)
@@ -22,10 +22,12 @@ import (
     "encoding/json"
     "fmt"
     "strconv"
+    "sync"
 
     "github.com/hyperledger/fabric-chaincode-go/pkg/cid"
     "github.com/hyperledger/fabric-chaincode-go/shim"
     sc "github.com/hyperledger/fabric-protos-go/peer"
+    "github.com/hyperledger/fabric-samples/chaincode/router"
 )
 
 // --- 常量定义 ---
@@ -37,7 +39,10 @@ const (
     ValidatorMSP = "Org1MSP" // 指定 Org1MSP 为验证者组织的 MSP ID
 )
 
-type SmartContract struct{}
+type SmartContract struct {
+    routerOnce sync.Once
+    routes     *router.Router
+}
 
 // --- 数据结构定义 ---
 type Student struct {
@@ -127,33 +132,52 @@ func (s *SmartContract) Init(APIstub shim.ChaincodeStubInterface) sc.Response {
 }
 
 func (s *SmartContract) Invoke(APIstub shim.ChaincodeStubInterface) sc.Response {
-    function, args := APIstub.GetFunctionAndParameters()
-    switch function {
+    // 路由只在第一次调用时构建一次
+    s.routerOnce.Do(func() { s.routes = s.newRouter() })
+    return s.routes.Invoke(APIstub)
+}
+
+// newRouter 声明链码的函数及其参数，路由在调用函数前检查参数的数量和格式
+func (s *SmartContract) newRouter() *router.Router {
+    status := func(name string) *router.Arg {
+        return router.String(name).OneOf(StatusApproved, StatusRejected)
+    }
+
+    r := router.New()
     // "all" 角色调用的函数
-    case "addStudent":
-        return s.addStudent(APIstub, args)
+    r.Handle("addStudent", s.addStudent,
+        router.String("school").NotEmpty(), router.String("major"), router.Int("id"), router.String("name")).
+        Describe("申请创建学生身份")
     // "student" 角色调用的函数
-    case "addGrade":
-        return s.addGrade(APIstub, args)
-    case "addPrice":
-        return s.addPrice(APIstub, args)
+    r.Handle("addGrade", s.addGrade,
+        router.String("course"), router.String("courseId").NotEmpty(), router.String("teacher"),
+        router.String("school").NotEmpty(), router.Int("studentId"), router.Int("year"),
+        router.Float("score"), router.Int("semester")).
+        Describe("为自己添加成绩记录")
+    r.Handle("addPrice", s.addPrice,
+        router.String("school").NotEmpty(), router.Int("studentId"), router.String("prizeName"),
+        router.String("prizeId").NotEmpty(), router.Int("year"), router.String("level"), router.String("institution")).
+        Describe("为自己添加奖项记录")
     // "validator" 角色调用的函数
-    case "validateStudent":
-        return s.validateStudent(APIstub, args)
-    case "validateGrade":
-        return s.validateGrade(APIstub, args)
-    case "validatePrice":
-        return s.validatePrice(APIstub, args)
+    r.Handle("validateStudent", s.validateStudent,
+        router.String("school"), router.String("studentId"), status("newStatus")).
+        Describe("审批学生身份申请")
+    r.Handle("validateGrade", s.validateGrade,
+        router.String("school"), router.String("studentId"), router.String("courseId"),
+        router.String("year"), router.String("semester"), status("newStatus")).
+        Describe("审批成绩")
+    r.Handle("validatePrice", s.validatePrice, router.String("priceId"), status("newStatus")).
+        Describe("审批奖项")
     // 公共查询函数
-    case "queryStudent":
-        return s.queryStudent(APIstub, args)
-    case "queryGrade":
-        return s.queryGrade(APIstub, args)
-    case "queryPrice":
-        return s.queryPrice(APIstub, args)
-    default:
-        return shim.Error("无效的链码函数名")
-    }
+    r.Handle("queryStudent", s.queryStudent, router.String("school"), router.String("studentId")).
+        Describe("查询已批准的学生信息")
+    r.Handle("queryGrade", s.queryGrade,
+        router.String("school"), router.String("studentId"), router.String("courseId"),
+        router.String("year"), router.String("semester")).
+        Describe("查询已批准的成绩")
+    r.Handle("queryPrice", s.queryPrice, router.String("priceId")).
+        Describe("查询已批准的奖项")
+    return r
 }
 
 // --- 业务逻辑函数 ---
@@ -161,8 +185,6 @@ func (s *SmartContract) Invoke(APIstub shim.ChaincodeStubInterface) sc.Response
 // addStudent 任何人都可以调用，申请创建一个学生身份
 // 参数顺序：school, major, id, name
 func (s *SmartContract) addStudent(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    if len(args) != 4 { return shim.Error("参数数量错误，需要4个 (school, major, id, name)") }
-
     callerID, err := getCallerID(APIstub)
     if err != nil { return shim.Error(err.Error()) }
 
@@ -183,8 +205,6 @@ func (s *SmartContract) addStudent(APIstub shim.ChaincodeStubInterface, args []s
 // addGrade 只有被批准的学生才能为自己添加成绩
 // 参数顺序：course_name, course_id, teacher, school, studentId, year, score, semester
 func (s *SmartContract) addGrade(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    if len(args) != 8 { return shim.Error("参数数量错误，需要8个") }
-    
     // 权限检查：必须是已验证的学生，且只能为自己操作
     school, studentId := args[3], args[4]
     if err := requireStudent(APIstub, school, studentId); err != nil {
@@ -211,11 +231,7 @@ func (s *SmartContract) addGrade(APIstub shim.ChaincodeStubInterface, args []str
 // addPrice 只有被批准的学生才能为自己添加奖项记录
 // 参数顺序：school, studentId, prizeName, prizeId, year, level, institution
 func (s *SmartContract) addPrice(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    // 1. 检查参数数量，现在需要7个参数
-    if len(args) != 7 {
-        return shim.Error("参数数量错误，需要7个 (school, studentId, prizeName, prizeId, year, level, institution)")
-    }
-
+    // 1. 参数数量和格式已由路由检查
     // 2. 提取参数，使其更具可读性
     school := args[0]
     studentId := args[1]
@@ -259,12 +275,8 @@ func (s *SmartContract) addPrice(APIstub shim.ChaincodeStubInterface, args []str
 // 参数顺序：school, studentId, newStatus
 func (s *SmartContract) validateStudent(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
     if err := requireValidator(APIstub); err != nil { return shim.Error(err.Error()) }
-    if len(args) != 3 { return shim.Error("参数数量错误，需要3个 (school, studentId, newStatus)") }
 
-    newStatus := args[2]
-    if newStatus != StatusApproved && newStatus != StatusRejected {
-        return shim.Error("无效的状态，只能是 'Approved' 或 'Rejected'")
-    }
+    newStatus := args[2] // 路由已确保状态只能是 'Approved' 或 'Rejected'
 
     key := args[0] + args[1]
     studentAsBytes, err := APIstub.GetState(key)
@@ -285,12 +297,8 @@ func (s *SmartContract) validateStudent(APIstub shim.ChaincodeStubInterface, arg
 // 参数顺序：school, studentId, courseId, year, semester, newStatus
 func (s *SmartContract) validateGrade(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
     if err := requireValidator(APIstub); err != nil { return shim.Error(err.Error()) }
-    if len(args) != 6 { return shim.Error("参数数量错误，需要6个 (school, studentId, courseId, year, semester, newStatus)") }
     
-    newStatus := args[5]
-    if newStatus != StatusApproved && newStatus != StatusRejected {
-        return shim.Error("无效的状态，只能是 'Approved' 或 'Rejected'")
-    }
+    newStatus := args[5] // 路由已确保状态只能是 'Approved' 或 'Rejected'
 
     key := args[0] + args[1] + args[2] + args[3] + args[4]
     gradeAsBytes, err := APIstub.GetState(key)
@@ -311,12 +319,8 @@ func (s *SmartContract) validateGrade(APIstub shim.ChaincodeStubInterface, args
 // 参数顺序：priceId, newStatus
 func (s *SmartContract) validatePrice(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
     if err := requireValidator(APIstub); err != nil { return shim.Error(err.Error()) }
-    if len(args) != 2 { return shim.Error("参数数量错误，需要2个 (priceId, newStatus)") }
 
-    newStatus := args[1]
-    if newStatus != StatusApproved && newStatus != StatusRejected {
-        return shim.Error("无效的状态，只能是 'Approved' 或 'Rejected'")
-    }
+    newStatus := args[1] // 路由已确保状态只能是 'Approved' 或 'Rejected'
 
     key := args[0]
     priceAsBytes, err := APIstub.GetState(key)
@@ -336,7 +340,6 @@ func (s *SmartContract) validatePrice(APIstub shim.ChaincodeStubInterface, args
 // queryStudent 任何人可调用，但只返回已批准的学生信息
 // 参数顺序：school, studentId
 func (s *SmartContract) queryStudent(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    if len(args) != 2 { return shim.Error("参数数量错误，需要2个 (school, studentId)") }
 
     key := args[0] + args[1]
     studentAsBytes, err := APIstub.GetState(key)
@@ -356,7 +359,6 @@ func (s *SmartContract) queryStudent(APIstub shim.ChaincodeStubInterface, args [
 // queryGrade 逻辑同 queryStudent
 // 参数顺序：school, studentId, courseId, year, semester
 func (s *SmartContract) queryGrade(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    if len(args) != 5 { return shim.Error("参数数量错误，需要5个") }
 
     key := args[0] + args[1] + args[2] + args[3] + args[4]
     gradeAsBytes, err := APIstub.GetState(key)
@@ -375,7 +377,6 @@ func (s *SmartContract) queryGrade(APIstub shim.ChaincodeStubInterface, args []s
 // queryPrice 逻辑同 queryStudent
 // 参数顺序：priceId
 func (s *SmartContract) queryPrice(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-    if len(args) != 1 { return shim.Error("参数数量错误，需要1个 (priceId)") }
 
     key := args[0]
     priceAsBytes, err := APIstub.GetState(key)
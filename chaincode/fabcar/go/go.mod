@@ -5,6 +5,9 @@ go 1.13
 require (
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
 	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
+	github.com/hyperledger/fabric-samples/chaincode/router v0.0.0
 	github.com/rogpeppe/go-internal v1.8.1 // indirect
 	google.golang.org/genproto v0.0.0-20220719170305-83ca9fad585f // indirect
 )
+
+replace github.com/hyperledger/fabric-samples/chaincode/router => ../../router
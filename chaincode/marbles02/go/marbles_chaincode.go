@@ -10,19 +10,22 @@
 // peer chaincode invoke -C myc1 -n marbles -c '{"Args":["initMarble","marble3","blue","70","tom"]}'
 // peer chaincode invoke -C myc1 -n marbles -c '{"Args":["transferMarble","marble2","jerry"]}'
 // peer chaincode invoke -C myc1 -n marbles -c '{"Args":["transferMarblesBasedOnColor","blue","jerry"]}'
+// peer chaincode invoke -C myc1 -n marbles -c '{"Args":["bulkTransferMarblesBasedOnColor","blue","jerry","[\"marble1\",\"marble3\"]","true"]}'
+// peer chaincode invoke -C myc1 -n marbles -c '{"Args":["bulkTransferMarblesBasedOnColor","blue","jerry","[\"marble1\",\"marble3\"]"]}'
 // peer chaincode invoke -C myc1 -n marbles -c '{"Args":["delete","marble1"]}'
 
 // ==== Query marbles ====
 // peer chaincode query -C myc1 -n marbles -c '{"Args":["readMarble","marble1"]}'
 // peer chaincode query -C myc1 -n marbles -c '{"Args":["getMarblesByRange","marble1","marble3"]}'
 // peer chaincode query -C myc1 -n marbles -c '{"Args":["getHistoryForMarble","marble1"]}'
+// peer chaincode query -C myc1 -n marbles -c '{"Args":["getMarbleNamesByColor","blue","2"]}'
 
 // Rich Query (Only supported if CouchDB is used as state database):
 // peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarblesByOwner","tom"]}'
-// peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"selector\":{\"owner\":\"tom\"}}"]}'
+// peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}"]}'
 
 // Rich Query with Pagination (Only supported if CouchDB is used as state database):
-// peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarblesWithPagination","{\"selector\":{\"owner\":\"tom\"}}","3",""]}'
+// peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarblesWithPagination","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}","3",""]}'
 
 // INDEXES TO SUPPORT COUCHDB RICH QUERIES
 //
@@ -33,8 +36,8 @@
 // CouchDB index JSON syntax as documented at:
 // http://docs.couchdb.org/en/2.1.1/api/database/find.html#db-index
 //
-// This marbles02 example chaincode demonstrates a packaged
-// index which you can find in META-INF/statedb/couchdb/indexes/indexOwner.json.
+// This marbles02 example chaincode demonstrates packaged
+// indexes which you can find in META-INF/statedb/couchdb/indexes.
 // For deployment of chaincode to production environments, it is recommended
 // to define any indexes alongside chaincode so that the chaincode and supporting indexes
 // are deployed automatically as a unit, once the chaincode has been installed on a peer and
@@ -71,11 +74,21 @@
 // Example curl command line to define index in the CouchDB channel_chaincode database. Default user_name/password in couchdb is admin/adminpw
 // curl -i -X POST -H "Content-Type: application/json" -d "{\"index\":{\"fields\":[{\"size\":\"desc\"},{\"docType\":\"desc\"},{\"owner\":\"desc\"}]},\"ddoc\":\"indexSizeSortDoc\", \"name\":\"indexSizeSortDesc\",\"type\":\"json\"}" user_name:password@hostname:port/myc1_marbles/_index
 
-// Rich Query with index design doc and index name specified (Only supported if CouchDB is used as state database):
-//   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"selector\":{\"docType\":\"marble\",\"owner\":\"tom\"}, \"use_index\":[\"_design/indexOwnerDoc\", \"indexOwner\"]}"]}'
+// QUERIES USING THE INDEXES
+//
+// queryMarbles and queryMarblesWithPagination do not pass the query of the client to CouchDB as is.
+// They build the query from structured filters and sort fields, restricted to marbles and to the
+// fields of one of the packaged indexes, see marbleQueries. The query builder also accepts the
+// CouchDB syntax for selectors that only compare fields with values.
+
+// Rich Query on the owner, using indexOwner (Only supported if CouchDB is used as state database):
+//   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"}]}"]}'
 
-// Rich Query with index design doc specified only (Only supported if CouchDB is used as state database):
-//   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"selector\":{\"docType\":{\"$eq\":\"marble\"},\"owner\":{\"$eq\":\"tom\"},\"size\":{\"$gt\":0}},\"fields\":[\"docType\",\"owner\",\"size\"],\"sort\":[{\"size\":\"desc\"}],\"use_index\":\"_design/indexSizeSortDoc\"}"]}'
+// Rich Query on the owner and size sorted by size, using indexSizeSortDesc (Only supported if CouchDB is used as state database):
+//   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"filters\":[{\"field\":\"owner\",\"value\":\"tom\"},{\"field\":\"size\",\"op\":\"gt\",\"value\":0}],\"sort\":[{\"field\":\"size\",\"descending\":true}]}"]}'
+
+// The same query in the CouchDB syntax (Only supported if CouchDB is used as state database):
+//   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"selector\":{\"docType\":\"marble\",\"owner\":\"tom\",\"size\":{\"$gt\":0}},\"sort\":[{\"size\":\"desc\"}]}"]}'
 
 package main
 
@@ -85,14 +98,61 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/richquery"
+	"github.com/hyperledger/fabric-samples/chaincode/router"
+)
+
+// maxPageSize is the largest number of marbles returned by a page of a rich query
+const maxPageSize = 100
+
+// marbleQueries builds the rich queries for marbles. The indexes mirror the
+// index definitions in META-INF/statedb/couchdb/indexes, queries can only
+// filter and sort on the fields of one of them.
+var marbleQueries = richquery.NewBuilder("marble", maxPageSize,
+	&richquery.Index{DesignDoc: "indexOwnerDoc", Name: "indexOwner", Fields: []string{"docType", "owner"}},
+	&richquery.Index{DesignDoc: "indexSizeSortDoc", Name: "indexSizeSortDesc", Fields: []string{"size", "docType", "owner"}},
 )
 
 // SimpleChaincode example simple Chaincode implementation
 type SimpleChaincode struct {
+	routerOnce sync.Once
+	routes     *router.Router
+}
+
+// maxBulkTransferSize is the largest number of marbles a batch of a bulk transfer processes
+const maxBulkTransferSize = 100
+
+// Statuses of the marbles processed by a bulk transfer
+const (
+	bulkTransferred = "transferred"
+	bulkSkipped     = "skipped"
+)
+
+// bulkTransferItem reports what a bulk transfer did, or would do in a dry run, with one marble
+type bulkTransferItem struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// bulkTransferResult reports a batch of a bulk transfer. ChangedKeys are the keys the batch
+// wrote, or would write in a dry run.
+type bulkTransferResult struct {
+	Items       []bulkTransferItem `json:"items"`
+	ChangedKeys []string           `json:"changedKeys"`
+	DryRun      bool               `json:"dryRun"`
+}
+
+// marbleNamesPage is a page of the names of the marbles of a color. Bookmark continues with
+// the next page, it is empty once all marbles of the color have been listed.
+type marbleNamesPage struct {
+	Names    []string `json:"names"`
+	Bookmark string   `json:"bookmark"`
 }
 
 type marble struct {
@@ -122,36 +182,52 @@ func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // Invoke - Our entry point for Invocations
 // ========================================
 func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
-	function, args := stub.GetFunctionAndParameters()
+	function, _ := stub.GetFunctionAndParameters()
 	fmt.Println("invoke is running " + function)
 
-	// Handle different functions
-	if function == "initMarble" { //create a new marble
-		return t.initMarble(stub, args)
-	} else if function == "transferMarble" { //change owner of a specific marble
-		return t.transferMarble(stub, args)
-	} else if function == "transferMarblesBasedOnColor" { //transfer all marbles of a certain color
-		return t.transferMarblesBasedOnColor(stub, args)
-	} else if function == "delete" { //delete a marble
-		return t.delete(stub, args)
-	} else if function == "readMarble" { //read a marble
-		return t.readMarble(stub, args)
-	} else if function == "queryMarblesByOwner" { //find marbles for owner X using rich query
-		return t.queryMarblesByOwner(stub, args)
-	} else if function == "queryMarbles" { //find marbles based on an ad hoc rich query
-		return t.queryMarbles(stub, args)
-	} else if function == "getHistoryForMarble" { //get history of values for a marble
-		return t.getHistoryForMarble(stub, args)
-	} else if function == "getMarblesByRange" { //get marbles based on range query
-		return t.getMarblesByRange(stub, args)
-	} else if function == "getMarblesByRangeWithPagination" {
-		return t.getMarblesByRangeWithPagination(stub, args)
-	} else if function == "queryMarblesWithPagination" {
-		return t.queryMarblesWithPagination(stub, args)
-	}
-
-	fmt.Println("invoke did not find func: " + function) //error
-	return shim.Error("Received unknown function invocation")
+	t.routerOnce.Do(func() { t.routes = t.newRouter() })
+	return t.routes.Invoke(stub)
+}
+
+// newRouter - Declares the functions of the chaincode and their arguments
+// ========================================================================
+func (t *SimpleChaincode) newRouter() *router.Router {
+	r := router.New()
+	r.Handle("initMarble", t.initMarble,
+		router.String("name").NotEmpty(), router.String("color").NotEmpty(),
+		router.Int("size").NotEmpty(), router.String("owner").NotEmpty()).
+		Describe("Create a new marble")
+	r.Handle("transferMarble", t.transferMarble, router.String("name"), router.String("newOwner")).
+		Describe("Change the owner of a specific marble")
+	r.Handle("transferMarblesBasedOnColor", t.transferMarblesBasedOnColor, router.String("color"), router.String("newOwner")).
+		Describe("Transfer all marbles of a certain color")
+	r.Handle("getMarbleNamesByColor", t.getMarbleNamesByColor,
+		router.String("color"), router.Int("pageSize"), router.String("bookmark")).
+		Optional(1).
+		Describe("Get a page of the names of the marbles of a certain color")
+	r.Handle("bulkTransferMarblesBasedOnColor", t.bulkTransferMarblesBasedOnColor,
+		router.String("color"), router.String("newOwner"), router.JSON("names", []string{}), router.Bool("dryRun")).
+		Optional(1).
+		Describe("Transfer a batch of the marbles of a certain color, or preview it")
+	r.Handle("delete", t.delete, router.String("name")).
+		Describe("Delete a marble")
+	r.Handle("readMarble", t.readMarble, router.String("name")).
+		Describe("Read a marble")
+	r.Handle("queryMarblesByOwner", t.queryMarblesByOwner, router.String("owner")).
+		Describe("Find the marbles of an owner using a rich query")
+	r.Handle("queryMarbles", t.queryMarbles, router.JSON("query", nil)).
+		Describe("Find marbles based on filters and sort fields supported by an index")
+	r.Handle("getHistoryForMarble", t.getHistoryForMarble, router.String("name")).
+		Describe("Get the history of values of a marble")
+	r.Handle("getMarblesByRange", t.getMarblesByRange, router.String("startKey"), router.String("endKey")).
+		Describe("Get the marbles in a range of names")
+	r.Handle("getMarblesByRangeWithPagination", t.getMarblesByRangeWithPagination,
+		router.String("startKey"), router.String("endKey"), router.Int("pageSize"), router.String("bookmark")).
+		Describe("Get a page of the marbles in a range of names")
+	r.Handle("queryMarblesWithPagination", t.queryMarblesWithPagination,
+		router.JSON("query", nil), router.Int("pageSize"), router.String("bookmark")).
+		Describe("Get a page of the marbles matching filters supported by an index")
+	return r
 }
 
 // ============================================================
@@ -162,24 +238,9 @@ func (t *SimpleChaincode) initMarble(stub shim.ChaincodeStubInterface, args []st
 
 	//   0       1       2     3
 	// "asdf", "blue", "35", "bob"
-	if len(args) != 4 {
-		return shim.Error("Incorrect number of arguments. Expecting 4")
-	}
 
-	// ==== Input sanitation ====
+	// ==== Input sanitation is done by the router, see newRouter ====
 	fmt.Println("- start init marble")
-	if len(args[0]) <= 0 {
-		return shim.Error("1st argument must be a non-empty string")
-	}
-	if len(args[1]) <= 0 {
-		return shim.Error("2nd argument must be a non-empty string")
-	}
-	if len(args[2]) <= 0 {
-		return shim.Error("3rd argument must be a non-empty string")
-	}
-	if len(args[3]) <= 0 {
-		return shim.Error("4th argument must be a non-empty string")
-	}
 	marbleName := args[0]
 	color := strings.ToLower(args[1])
 	owner := strings.ToLower(args[3])
@@ -241,10 +302,6 @@ func (t *SimpleChaincode) readMarble(stub shim.ChaincodeStubInterface, args []st
 	var name, jsonResp string
 	var err error
 
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting name of the marble to query")
-	}
-
 	name = args[0]
 	valAsbytes, err := stub.GetState(name) //get the marble from chaincode state
 	if err != nil {
@@ -264,9 +321,6 @@ func (t *SimpleChaincode) readMarble(stub shim.ChaincodeStubInterface, args []st
 func (t *SimpleChaincode) delete(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	var jsonResp string
 	var marbleJSON marble
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
 	marbleName := args[0]
 
 	// to maintain the color~name index, we need to read the marble first and get its color
@@ -312,10 +366,6 @@ func (t *SimpleChaincode) transferMarble(stub shim.ChaincodeStubInterface, args
 
 	//   0       1
 	// "name", "bob"
-	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2")
-	}
-
 	marbleName := args[0]
 	newOwner := strings.ToLower(args[1])
 	fmt.Println("- start transferMarble ", marbleName, newOwner)
@@ -410,10 +460,6 @@ func addPaginationMetadataToQueryResults(buffer *bytes.Buffer, responseMetadata
 // ===========================================================================================
 func (t *SimpleChaincode) getMarblesByRange(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2")
-	}
-
 	startKey := args[0]
 	endKey := args[1]
 
@@ -440,15 +486,13 @@ func (t *SimpleChaincode) getMarblesByRange(stub shim.ChaincodeStubInterface, ar
 // between endorsement time and commit time. The transaction is invalidated by the
 // committing peers if the result set has changed between endorsement time and commit time.
 // Therefore, range queries are a safe option for performing update transactions based on query results.
+// All marbles of the color are transferred in one transaction, see bulkTransferMarblesBasedOnColor
+// for large color groups, which skips the marbles it cannot transfer instead of failing.
 // ===========================================================================================
 func (t *SimpleChaincode) transferMarblesBasedOnColor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	//   0       1
 	// "color", "bob"
-	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2")
-	}
-
 	color := args[0]
 	newOwner := strings.ToLower(args[1])
 	fmt.Println("- start transferMarblesBasedOnColor ", color, newOwner)
@@ -493,6 +537,147 @@ func (t *SimpleChaincode) transferMarblesBasedOnColor(stub shim.ChaincodeStubInt
 	return shim.Success([]byte(responsePayload))
 }
 
+// ==== Example: GetStateByPartialCompositeKeyWithPagination =================================
+// getMarbleNamesByColor returns a page of the names of the marbles of a given color from the
+// color~name index, for bulkTransferMarblesBasedOnColor. The page starts at the bookmark and
+// holds at most pageSize names, capped at maxBulkTransferSize. The bookmark of the response
+// continues with the next page, it is empty once all marbles of the color have been listed.
+// Paginated queries are only valid for read only transactions.
+// ===========================================================================================
+func (t *SimpleChaincode) getMarbleNamesByColor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	//   0       1       2
+	// "color", "100", "bookmark"
+	color := args[0]
+	pageSize, err := strconv.Atoi(args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if pageSize <= 0 || pageSize > maxBulkTransferSize {
+		pageSize = maxBulkTransferSize
+	}
+	bookmark := ""
+	if len(args) > 2 {
+		bookmark = args[2]
+	}
+
+	resultsIterator, responseMetadata, err := stub.GetStateByPartialCompositeKeyWithPagination("color~name", []string{color}, int32(pageSize), bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	page := &marbleNamesPage{Names: []string{}, Bookmark: responseMetadata.Bookmark}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		page.Names = append(page.Names, compositeKeyParts[1])
+	}
+
+	pageJSON, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(pageJSON)
+}
+
+// ==== Example: Resumable bulk update ======================================================
+// bulkTransferMarblesBasedOnColor transfers a batch of marbles of a given color to a new owner,
+// so that large color groups can be transferred in several transactions within the proposal
+// size limits. The client lists the marbles of the color a page at a time with
+// getMarbleNamesByColor, which starts every page at the bookmark of the previous one, and
+// submits one transaction per page. Each batch is atomic, and reads and writes only the keys
+// of its own marbles, so it only conflicts with concurrent changes to those marbles. Marbles
+// that no longer exist, no longer have the color or are already owned by the new owner are
+// skipped, marbles that got the color after their page was listed are not transferred. The
+// response reports for each marble of the batch whether it was transferred or skipped.
+// In a dry run nothing is written, the response previews the keys the batch would change.
+// ===========================================================================================
+func (t *SimpleChaincode) bulkTransferMarblesBasedOnColor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	//   0       1                 2                    3
+	// "color", "bob", "[\"marble1\",\"marble3\"]", "false"
+	color := args[0]
+	newOwner := strings.ToLower(args[1])
+	var names []string
+	err := json.Unmarshal([]byte(args[2]), &names)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(names) > maxBulkTransferSize {
+		return shim.Error(fmt.Sprintf("A batch can transfer at most %d marbles", maxBulkTransferSize))
+	}
+	dryRun := false
+	if len(args) > 3 {
+		dryRun, err = strconv.ParseBool(args[3])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	fmt.Println("- start bulkTransferMarblesBasedOnColor ", color, newOwner, names, dryRun)
+
+	result := &bulkTransferResult{Items: []bulkTransferItem{}, ChangedKeys: []string{}, DryRun: dryRun}
+	for _, name := range names {
+		item, err := transferMarbleOfColor(stub, name, color, newOwner, dryRun)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Transfer failed: marble %s: %s", name, err))
+		}
+		result.Items = append(result.Items, *item)
+		if item.Status == bulkTransferred {
+			result.ChangedKeys = append(result.ChangedKeys, name)
+		}
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	fmt.Printf("- end bulkTransferMarblesBasedOnColor: %s\n", resultJSON)
+	return shim.Success(resultJSON)
+}
+
+// ===========================================================================================
+// transferMarbleOfColor transfers a marble found in the color~name index, unless the marble
+// no longer exists, no longer has the color or is already owned by the new owner.
+// ===========================================================================================
+func transferMarbleOfColor(stub shim.ChaincodeStubInterface, marbleName, color, newOwner string, dryRun bool) (*bulkTransferItem, error) {
+	marbleAsBytes, err := stub.GetState(marbleName)
+	if err != nil {
+		return nil, err
+	} else if marbleAsBytes == nil {
+		return &bulkTransferItem{Name: marbleName, Status: bulkSkipped, Reason: "marble does not exist"}, nil
+	}
+
+	marbleToTransfer := marble{}
+	err = json.Unmarshal(marbleAsBytes, &marbleToTransfer) //unmarshal it aka JSON.parse()
+	if err != nil {
+		return nil, err
+	}
+	if marbleToTransfer.Color != color {
+		return &bulkTransferItem{Name: marbleName, Status: bulkSkipped, Reason: "color changed to " + marbleToTransfer.Color}, nil
+	}
+	if marbleToTransfer.Owner == newOwner {
+		return &bulkTransferItem{Name: marbleName, Status: bulkSkipped, Reason: "already owned by " + newOwner}, nil
+	}
+
+	if !dryRun {
+		marbleToTransfer.Owner = newOwner //change the owner
+		marbleJSONasBytes, _ := json.Marshal(marbleToTransfer)
+		err = stub.PutState(marbleName, marbleJSONasBytes) //rewrite the marble
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &bulkTransferItem{Name: marbleName, Status: bulkTransferred}, nil
+}
+
 // =======Rich queries =========================================================================
 // Two examples of rich queries are provided below (parameterized query and ad hoc query).
 // Rich queries pass a query string to the state database.
@@ -516,13 +701,14 @@ func (t *SimpleChaincode) queryMarblesByOwner(stub shim.ChaincodeStubInterface,
 
 	//   0
 	// "bob"
-	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
-
 	owner := strings.ToLower(args[0])
 
-	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"marble\",\"owner\":\"%s\"}}", owner)
+	queryString, err := marbleQueries.Build(&richquery.Query{
+		Filters: []richquery.Filter{{Field: "owner", Value: owner}},
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
 	queryResults, err := getQueryResultForQueryString(stub, queryString)
 	if err != nil {
@@ -531,23 +717,21 @@ func (t *SimpleChaincode) queryMarblesByOwner(stub shim.ChaincodeStubInterface,
 	return shim.Success(queryResults)
 }
 
-// ===== Example: Ad hoc rich query ========================================================
-// queryMarbles uses a query string to perform a query for marbles.
-// Query string matching state database syntax is passed in and executed as is.
-// Supports ad hoc queries that can be defined at runtime by the client.
-// If this is not desired, follow the queryMarblesForOwner example for parameterized queries.
+// ===== Example: Structured rich query ====================================================
+// queryMarbles uses filters and sort fields defined at runtime by the client to perform
+// a query for marbles. The query string for the state database is built by marbleQueries,
+// which rejects queries that are not supported by one of the packaged indexes.
 // Only available on state databases that support rich query (e.g. CouchDB)
 // =========================================================================================
 func (t *SimpleChaincode) queryMarbles(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	//   0
-	// "queryString"
-	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
+	// "query"
+	queryString, err := marbleQueries.BuildJSON(args[0])
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	queryString := args[0]
-
 	queryResults, err := getQueryResultForQueryString(stub, queryString)
 	if err != nil {
 		return shim.Error(err.Error())
@@ -600,10 +784,6 @@ func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString
 // ===========================================================================================
 func (t *SimpleChaincode) getMarblesByRangeWithPagination(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	if len(args) < 4 {
-		return shim.Error("Incorrect number of arguments. Expecting 4")
-	}
-
 	startKey := args[0]
 	endKey := args[1]
 	//return type of ParseInt is int64
@@ -631,24 +811,22 @@ func (t *SimpleChaincode) getMarblesByRangeWithPagination(stub shim.ChaincodeStu
 	return shim.Success(buffer.Bytes())
 }
 
-// ===== Example: Pagination with Ad hoc Rich Query ========================================================
-// queryMarblesWithPagination uses a query string, page size and a bookmark to perform a query
-// for marbles. Query string matching state database syntax is passed in and executed as is.
-// The number of fetched records would be equal to or lesser than the specified page size.
-// Supports ad hoc queries that can be defined at runtime by the client.
-// If this is not desired, follow the queryMarblesForOwner example for parameterized queries.
+// ===== Example: Pagination with Structured Rich Query ====================================
+// queryMarblesWithPagination uses filters and sort fields, a page size and a bookmark to
+// perform a query for marbles. The query is built like in queryMarbles.
+// The number of fetched records would be equal to or lesser than the specified page size,
+// which is capped at maxPageSize. A page size of 0 requests the largest page.
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Paginated queries are only valid for read only transactions.
 // =========================================================================================
 func (t *SimpleChaincode) queryMarblesWithPagination(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	//   0
-	// "queryString"
-	if len(args) < 3 {
-		return shim.Error("Incorrect number of arguments. Expecting 3")
+	// "query"
+	queryString, err := marbleQueries.BuildJSON(args[0])
+	if err != nil {
+		return shim.Error(err.Error())
 	}
-
-	queryString := args[0]
 	//return type of ParseInt is int64
 	pageSize, err := strconv.ParseInt(args[1], 10, 32)
 	if err != nil {
@@ -656,7 +834,7 @@ func (t *SimpleChaincode) queryMarblesWithPagination(stub shim.ChaincodeStubInte
 	}
 	bookmark := args[2]
 
-	queryResults, err := getQueryResultForQueryStringWithPagination(stub, queryString, int32(pageSize), bookmark)
+	queryResults, err := getQueryResultForQueryStringWithPagination(stub, queryString, marbleQueries.PageSize(int32(pageSize)), bookmark)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -691,10 +869,6 @@ func getQueryResultForQueryStringWithPagination(stub shim.ChaincodeStubInterface
 
 func (t *SimpleChaincode) getHistoryForMarble(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
-
 	marbleName := args[0]
 
 	fmt.Printf("- start getHistoryForMarble: %s\n", marbleName)
@@ -0,0 +1,167 @@
+/*
+ SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/richquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarbleQueriesMatchPackagedIndexes(t *testing.T) {
+	indexes, err := richquery.LoadIndexes("META-INF/statedb/couchdb/indexes")
+	require.NoError(t, err)
+	require.Equal(t, indexes, marbleQueries.Indexes())
+}
+
+func invoke(stub *shimtest.MockStub, args ...string) pb.Response {
+	byteArgs := [][]byte{}
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	return stub.MockInvoke("tx", byteArgs)
+}
+
+func newMarblesStub(t *testing.T) *shimtest.MockStub {
+	stub := shimtest.NewMockStub("marbles", new(SimpleChaincode))
+	for _, m := range [][]string{
+		{"marble1", "blue", "35", "tom"},
+		{"marble2", "blue", "50", "jerry"},
+		{"marble3", "blue", "70", "tom"},
+		{"marble4", "red", "20", "tom"},
+		{"marble5", "blue", "10", "tom"},
+	} {
+		response := invoke(stub, append([]string{"initMarble"}, m...)...)
+		require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	}
+	return stub
+}
+
+// pagingStub adds the paginated partial composite key queries the mock stub lacks. Like the
+// peer, the bookmark is the key the next page starts at.
+type pagingStub struct {
+	*shimtest.MockStub
+}
+
+type keyIterator struct {
+	keys []string
+}
+
+func (it *keyIterator) HasNext() bool {
+	return len(it.keys) > 0
+}
+
+func (it *keyIterator) Next() (*queryresult.KV, error) {
+	key := it.keys[0]
+	it.keys = it.keys[1:]
+	return &queryresult.KV{Key: key}, nil
+}
+
+func (it *keyIterator) Close() error {
+	return nil
+}
+
+func (s *pagingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	resultsIterator, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := []string{}
+	next := ""
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.Key < bookmark {
+			continue
+		}
+		if len(page) == int(pageSize) {
+			next = result.Key
+			break
+		}
+		page = append(page, result.Key)
+	}
+	return &keyIterator{keys: page}, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}, nil
+}
+
+func TestGetMarbleNamesByColor(t *testing.T) {
+	stub := &pagingStub{newMarblesStub(t)}
+	chaincode := new(SimpleChaincode)
+
+	response := chaincode.getMarbleNamesByColor(stub, []string{"blue", "3"})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	var page marbleNamesPage
+	require.NoError(t, json.Unmarshal(response.Payload, &page))
+	require.Equal(t, []string{"marble1", "marble2", "marble3"}, page.Names)
+	require.NotEmpty(t, page.Bookmark)
+
+	response = chaincode.getMarbleNamesByColor(stub, []string{"blue", "3", page.Bookmark})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.JSONEq(t, `{"names":["marble5"],"bookmark":""}`, string(response.Payload))
+
+	response = chaincode.getMarbleNamesByColor(stub, []string{"blue", "three"})
+	require.Equal(t, `strconv.Atoi: parsing "three": invalid syntax`, response.Message)
+}
+
+func TestBulkTransferMarblesBasedOnColor(t *testing.T) {
+	stub := newMarblesStub(t)
+
+	response := invoke(stub, "bulkTransferMarblesBasedOnColor", "blue", "Jerry", `["marble1","marble2"]`)
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.JSONEq(t, `{
+		"items":[{"name":"marble1","status":"transferred"},{"name":"marble2","status":"skipped","reason":"already owned by jerry"}],
+		"changedKeys":["marble1"],"dryRun":false}`, string(response.Payload))
+
+	// a dry run previews the next batch without writing it
+	response = invoke(stub, "bulkTransferMarblesBasedOnColor", "blue", "jerry", `["marble3","marble5"]`, "true")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.JSONEq(t, `{
+		"items":[{"name":"marble3","status":"transferred"},{"name":"marble5","status":"transferred"}],
+		"changedKeys":["marble3","marble5"],"dryRun":true}`, string(response.Payload))
+	response = invoke(stub, "readMarble", "marble3")
+	require.Contains(t, string(response.Payload), `"owner":"tom"`)
+
+	// marbles that changed color or were deleted since their page was listed are skipped
+	response = invoke(stub, "bulkTransferMarblesBasedOnColor", "blue", "jerry", `["marble3","marble4","marble6"]`)
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.JSONEq(t, `{
+		"items":[{"name":"marble3","status":"transferred"},
+			{"name":"marble4","status":"skipped","reason":"color changed to red"},
+			{"name":"marble6","status":"skipped","reason":"marble does not exist"}],
+		"changedKeys":["marble3"],"dryRun":false}`, string(response.Payload))
+	response = invoke(stub, "readMarble", "marble3")
+	require.Contains(t, string(response.Payload), `"owner":"jerry"`)
+	response = invoke(stub, "readMarble", "marble4")
+	require.Contains(t, string(response.Payload), `"owner":"tom"`)
+
+	names, err := json.Marshal(make([]string, maxBulkTransferSize+1))
+	require.NoError(t, err)
+	response = invoke(stub, "bulkTransferMarblesBasedOnColor", "blue", "jerry", string(names))
+	require.Equal(t, "A batch can transfer at most 100 marbles", response.Message)
+}
+
+func TestTransferMarblesBasedOnColor(t *testing.T) {
+	stub := newMarblesStub(t)
+
+	response := invoke(stub, "transferMarblesBasedOnColor", "blue", "jerry")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.Equal(t, "Transferred 4 blue marbles to jerry", string(response.Payload))
+
+	// unlike a bulk transfer, an indexed marble that does not exist fails the transfer
+	stub.MockTransactionStart("delete")
+	require.NoError(t, stub.DelState("marble3"))
+	stub.MockTransactionEnd("delete")
+	response = invoke(stub, "transferMarblesBasedOnColor", "blue", "tom")
+	require.Equal(t, "Transfer failed: Marble does not exist", response.Message)
+}
@@ -0,0 +1,5 @@
+module github.com/hyperledger/fabric-samples/chaincode/richquery
+
+go 1.12
+
+require github.com/stretchr/testify v1.4.0
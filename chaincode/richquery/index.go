@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package richquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Index is a CouchDB index of a chaincode, as defined in a JSON file in the
+// META-INF/statedb/couchdb/indexes directory of the chaincode package
+type Index struct {
+	DesignDoc string
+	Name      string
+	Fields    []string
+}
+
+// indexDefinition is the CouchDB syntax of an index definition
+type indexDefinition struct {
+	Index struct {
+		Fields []interface{} `json:"fields"`
+	} `json:"index"`
+	DesignDoc string `json:"ddoc"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+}
+
+// ParseIndex reads a CouchDB index definition. The sort direction of the fields
+// is dropped, CouchDB can use an index for sorting in either direction.
+func ParseIndex(definition []byte) (*Index, error) {
+	var d indexDefinition
+	if err := json.Unmarshal(definition, &d); err != nil {
+		return nil, fmt.Errorf("invalid index definition: %v", err)
+	}
+	if d.Type != "" && d.Type != "json" {
+		return nil, fmt.Errorf("index %s: unsupported index type %s", d.Name, d.Type)
+	}
+	if d.DesignDoc == "" || d.Name == "" {
+		return nil, fmt.Errorf("index %s: the design document and the name of the index are required", d.Name)
+	}
+	if len(d.Index.Fields) == 0 {
+		return nil, fmt.Errorf("index %s: no fields", d.Name)
+	}
+
+	index := &Index{DesignDoc: strings.TrimPrefix(d.DesignDoc, "_design/"), Name: d.Name}
+	for _, field := range d.Index.Fields {
+		switch f := field.(type) {
+		case string:
+			index.Fields = append(index.Fields, f)
+		case map[string]interface{}:
+			if len(f) != 1 {
+				return nil, fmt.Errorf("index %s: invalid field %v", d.Name, f)
+			}
+			for name, direction := range f {
+				if direction != "asc" && direction != "desc" {
+					return nil, fmt.Errorf("index %s: invalid sort direction %v of field %s", d.Name, direction, name)
+				}
+				index.Fields = append(index.Fields, name)
+			}
+		default:
+			return nil, fmt.Errorf("index %s: invalid field %v", d.Name, f)
+		}
+	}
+	return index, nil
+}
+
+// LoadIndexes reads the index definitions of the *.json files in a directory,
+// ordered by file name. Chaincodes cannot read their META-INF directory at
+// runtime, so they declare their indexes in code and use LoadIndexes in their
+// tests to check the declarations against the packaged definitions.
+func LoadIndexes(dir string) ([]*Index, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []*Index{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		definition, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		index, err := ParseIndex(definition)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Name(), err)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
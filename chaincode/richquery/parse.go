@@ -0,0 +1,143 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package richquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parse reads a query in its structured form, such as
+//
+//	{"filters":[{"field":"owner","value":"tom"},{"field":"size","op":"gt","value":10}],"sort":[{"field":"size","descending":true}]}
+//
+// For clients written against the CouchDB query syntax, Parse also accepts a
+// query with a selector that compares fields with values, using the $eq, $gt,
+// $gte, $lt and $lte operators, and a sort. The use_index of such a query is
+// ignored, the builder picks the index.
+//
+//	{"selector":{"docType":"marble","owner":"tom","size":{"$gt":10}},"sort":[{"size":"desc"}]}
+func (b *Builder) Parse(queryJSON string) (*Query, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(queryJSON), &document); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	if _, ok := document["selector"]; ok {
+		return b.parseSelector(document)
+	}
+
+	query := &Query{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(queryJSON)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(query); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	return query, nil
+}
+
+// parseSelector converts a query in the CouchDB syntax to its structured form
+func (b *Builder) parseSelector(document map[string]json.RawMessage) (*Query, error) {
+	for option := range document {
+		if option != "selector" && option != "sort" && option != "use_index" {
+			return nil, fmt.Errorf("invalid query: unsupported query option %s", option)
+		}
+	}
+
+	var selector map[string]interface{}
+	if err := json.Unmarshal(document["selector"], &selector); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	query := &Query{Filters: []Filter{}}
+	for _, field := range sortedKeys(selector) {
+		if strings.HasPrefix(field, "$") {
+			return nil, fmt.Errorf("invalid query: unsupported operator %s", field)
+		}
+		filters, err := parseCondition(field, selector[field])
+		if err != nil {
+			return nil, err
+		}
+		if field == DocTypeField {
+			if len(filters) != 1 || filters[0].Op != Eq || filters[0].Value != b.docType {
+				return nil, fmt.Errorf("invalid query: queries are restricted to documents of type %s", b.docType)
+			}
+			continue
+		}
+		query.Filters = append(query.Filters, filters...)
+	}
+
+	if sortJSON, ok := document["sort"]; ok {
+		var sortFields []interface{}
+		if err := json.Unmarshal(sortJSON, &sortFields); err != nil {
+			return nil, fmt.Errorf("invalid query: %v", err)
+		}
+		for _, field := range sortFields {
+			s, err := parseSort(field)
+			if err != nil {
+				return nil, err
+			}
+			query.Sort = append(query.Sort, s)
+		}
+	}
+	return query, nil
+}
+
+// parseCondition converts the condition of a field in a selector to filters
+func parseCondition(field string, condition interface{}) ([]Filter, error) {
+	conditions, ok := condition.(map[string]interface{})
+	if !ok {
+		return []Filter{{Field: field, Op: Eq, Value: condition}}, nil
+	}
+
+	filters := []Filter{}
+	for _, couchOp := range sortedKeys(conditions) {
+		op := ""
+		for name, operator := range operators {
+			if operator == couchOp {
+				op = name
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("invalid query: unsupported operator %s of field %s", couchOp, field)
+		}
+		filters = append(filters, Filter{Field: field, Op: op, Value: conditions[couchOp]})
+	}
+	return filters, nil
+}
+
+// parseSort converts a field of a CouchDB sort, either a field name or an
+// object of a field name and a direction
+func parseSort(field interface{}) (Sort, error) {
+	switch f := field.(type) {
+	case string:
+		return Sort{Field: f}, nil
+	case map[string]interface{}:
+		if len(f) == 1 {
+			for name, direction := range f {
+				switch direction {
+				case "asc":
+					return Sort{Field: name}, nil
+				case "desc":
+					return Sort{Field: name, Descending: true}, nil
+				}
+			}
+		}
+	}
+	return Sort{}, fmt.Errorf("invalid query: invalid sort field %v", field)
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
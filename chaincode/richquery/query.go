@@ -0,0 +1,265 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package richquery builds CouchDB rich queries for chaincodes from structured
+// filters and sort fields, instead of passing client-supplied selectors to the
+// state database as is. A query is only built if one of the indexes of the
+// chaincode covers all of its filter and sort fields, it is always restricted
+// to documents of a single docType and the page size of paginated queries is
+// capped, so clients cannot run unindexed scans or read other object types.
+//
+//	queries := richquery.NewBuilder("marble", 100,
+//		&richquery.Index{DesignDoc: "indexOwnerDoc", Name: "indexOwner", Fields: []string{"docType", "owner"}})
+//	queryString, err := queries.Build(&richquery.Query{
+//		Filters: []richquery.Filter{{Field: "owner", Value: "tom"}},
+//	})
+package richquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DocTypeField is the field that distinguishes the object types in the state
+// database. The builder adds the filter on it to every query.
+const DocTypeField = "docType"
+
+// Operators of filters
+const (
+	Eq  = "eq"
+	Gt  = "gt"
+	Gte = "gte"
+	Lt  = "lt"
+	Lte = "lte"
+)
+
+// operators maps the operators of filters to CouchDB selector operators. Only
+// operators CouchDB can answer from an index are supported.
+var operators = map[string]string{
+	Eq:  "$eq",
+	Gt:  "$gt",
+	Gte: "$gte",
+	Lt:  "$lt",
+	Lte: "$lte",
+}
+
+// Filter compares a field with a string, number or boolean value. The operator
+// defaults to Eq.
+type Filter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// Sort orders the results by a field
+type Sort struct {
+	Field      string `json:"field"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// Query is the structured form of a rich query
+type Query struct {
+	Filters []Filter `json:"filters"`
+	Sort    []Sort   `json:"sort,omitempty"`
+}
+
+// couchQuery is the CouchDB syntax of a query
+type couchQuery struct {
+	Selector map[string]map[string]interface{} `json:"selector"`
+	Sort     []map[string]string               `json:"sort,omitempty"`
+	UseIndex []string                          `json:"use_index"`
+}
+
+// Builder builds the rich queries for the documents of one docType
+type Builder struct {
+	docType     string
+	maxPageSize int32
+	indexes     []*Index
+}
+
+// NewBuilder returns a builder for queries on documents of docType that can
+// use the given indexes, with pages of at most maxPageSize documents
+func NewBuilder(docType string, maxPageSize int32, indexes ...*Index) *Builder {
+	return &Builder{docType: docType, maxPageSize: maxPageSize, indexes: indexes}
+}
+
+// Indexes returns the indexes the builder can use
+func (b *Builder) Indexes() []*Index {
+	return b.indexes
+}
+
+// PageSize caps a requested page size. A page size that is not positive
+// requests the largest page.
+func (b *Builder) PageSize(requested int32) int32 {
+	if requested <= 0 || requested > b.maxPageSize {
+		return b.maxPageSize
+	}
+	return requested
+}
+
+// BuildJSON parses a query and builds the query string for the state database
+func (b *Builder) BuildJSON(queryJSON string) (string, error) {
+	query, err := b.Parse(queryJSON)
+	if err != nil {
+		return "", err
+	}
+	return b.Build(query)
+}
+
+// Build returns the CouchDB query string of a query. It fails if the query
+// uses a field of another docType, or if no index covers exactly the fields
+// the query filters and sorts on together with the docType.
+func (b *Builder) Build(query *Query) (string, error) {
+	selector := map[string]map[string]interface{}{
+		DocTypeField: {operators[Eq]: b.docType},
+	}
+	for _, filter := range query.Filters {
+		if filter.Field == "" {
+			return "", fmt.Errorf("invalid query: filter without a field")
+		}
+		if filter.Field == DocTypeField {
+			return "", fmt.Errorf("invalid query: queries are restricted to documents of type %s", b.docType)
+		}
+		op := filter.Op
+		if op == "" {
+			op = Eq
+		}
+		couchOp, ok := operators[op]
+		if !ok {
+			return "", fmt.Errorf("invalid query: unsupported operator %s of field %s", op, filter.Field)
+		}
+		if !isScalar(filter.Value) {
+			return "", fmt.Errorf("invalid query: the value of field %s must be a string, number or boolean", filter.Field)
+		}
+		if _, ok := selector[filter.Field]; !ok {
+			selector[filter.Field] = map[string]interface{}{}
+		}
+		if _, ok := selector[filter.Field][couchOp]; ok {
+			return "", fmt.Errorf("invalid query: duplicate operator %s of field %s", op, filter.Field)
+		}
+		selector[filter.Field][couchOp] = filter.Value
+	}
+
+	sortFields := map[string]bool{}
+	for _, s := range query.Sort {
+		if s.Field == "" {
+			return "", fmt.Errorf("invalid query: sort without a field")
+		}
+		if sortFields[s.Field] {
+			return "", fmt.Errorf("invalid query: duplicate sort field %s", s.Field)
+		}
+		if s.Descending != query.Sort[0].Descending {
+			return "", fmt.Errorf("invalid query: all sort fields need the same direction")
+		}
+		sortFields[s.Field] = true
+		// CouchDB only sorts on fields of the selector
+		if _, ok := selector[s.Field]; !ok {
+			selector[s.Field] = map[string]interface{}{operators[Gt]: nil}
+		}
+	}
+
+	for _, index := range b.indexes {
+		sortSpec, ok := indexSort(index, selector, query.Sort)
+		if !ok {
+			continue
+		}
+		queryJSON, err := json.Marshal(couchQuery{
+			Selector: selector,
+			Sort:     sortSpec,
+			UseIndex: []string{"_design/" + index.DesignDoc, index.Name},
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(queryJSON), nil
+	}
+	return "", b.noIndexError(selector, query.Sort)
+}
+
+// indexSort checks whether an index covers the fields of a selector and can
+// sort on the sort fields, and returns the sort of the query using the index.
+// The sort lists the fields of the index up to the last sort field, which only
+// changes the order of the results if the additional fields are compared for
+// equality.
+func indexSort(index *Index, selector map[string]map[string]interface{}, sortFields []Sort) ([]map[string]string, bool) {
+	if len(index.Fields) != len(selector) {
+		return nil, false
+	}
+	positions := map[string]int{}
+	for i, field := range index.Fields {
+		if _, ok := selector[field]; !ok {
+			return nil, false
+		}
+		positions[field] = i
+	}
+	if len(sortFields) == 0 {
+		return nil, true
+	}
+
+	last := -1
+	for _, s := range sortFields {
+		if positions[s.Field] <= last {
+			return nil, false
+		}
+		last = positions[s.Field]
+	}
+	direction := "asc"
+	if sortFields[0].Descending {
+		direction = "desc"
+	}
+	sortSpec := []map[string]string{}
+	next := 0
+	for _, field := range index.Fields[:last+1] {
+		if next < len(sortFields) && sortFields[next].Field == field {
+			next++
+		} else if _, ok := selector[field][operators[Eq]]; !ok || len(selector[field]) != 1 {
+			return nil, false
+		}
+		sortSpec = append(sortSpec, map[string]string{field: direction})
+	}
+	return sortSpec, true
+}
+
+// noIndexError reports the fields of a query that is not covered by an index
+func (b *Builder) noIndexError(selector map[string]map[string]interface{}, sortFields []Sort) error {
+	fields := []string{}
+	for field := range selector {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	message := fmt.Sprintf("no index supports a query on %s", strings.Join(fields, ", "))
+	if len(sortFields) > 0 {
+		sorts := []string{}
+		for _, s := range sortFields {
+			sorts = append(sorts, s.Field)
+		}
+		message += fmt.Sprintf(" sorted by %s", strings.Join(sorts, ", "))
+	}
+
+	indexes := []string{}
+	for _, index := range b.indexes {
+		indexes = append(indexes, fmt.Sprintf("%s (%s)", index.Name, strings.Join(index.Fields, ", ")))
+	}
+	return fmt.Errorf("%s. Supported indexes: %s", message, strings.Join(indexes, ", "))
+}
+
+// isScalar reports whether a value is a string, number or boolean
+func isScalar(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
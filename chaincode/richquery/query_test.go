@@ -0,0 +1,108 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package richquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBuilder() *Builder {
+	return NewBuilder("marble", 50,
+		&Index{DesignDoc: "indexOwnerDoc", Name: "indexOwner", Fields: []string{"docType", "owner"}},
+		&Index{DesignDoc: "indexSizeSortDoc", Name: "indexSizeSortDesc", Fields: []string{"size", "docType", "owner"}},
+	)
+}
+
+func TestLoadIndexes(t *testing.T) {
+	indexes, err := LoadIndexes("testdata/indexes")
+	require.NoError(t, err)
+	require.Equal(t, newBuilder().Indexes(), indexes)
+
+	_, err = ParseIndex([]byte(`{"index":{"fields":["owner"]},"name":"indexOwner","type":"json"}`))
+	require.EqualError(t, err, "index indexOwner: the design document and the name of the index are required")
+	_, err = ParseIndex([]byte(`{"index":{"fields":[{"size":"up"}]},"ddoc":"indexSizeDoc","name":"indexSize"}`))
+	require.EqualError(t, err, "index indexSize: invalid sort direction up of field size")
+	_, err = ParseIndex([]byte(`{"index":{"fields":["owner"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"text"}`))
+	require.EqualError(t, err, "index indexOwner: unsupported index type text")
+}
+
+func TestBuild(t *testing.T) {
+	b := newBuilder()
+
+	queryString, err := b.Build(&Query{Filters: []Filter{{Field: "owner", Value: "tom"}}})
+	require.NoError(t, err)
+	require.Equal(t, `{"selector":{"docType":{"$eq":"marble"},"owner":{"$eq":"tom"}},"use_index":["_design/indexOwnerDoc","indexOwner"]}`, queryString)
+
+	queryString, err = b.Build(&Query{
+		Filters: []Filter{{Field: "owner", Value: "tom"}, {Field: "size", Op: Gt, Value: 10}, {Field: "size", Op: Lte, Value: 50}},
+		Sort:    []Sort{{Field: "size", Descending: true}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"selector":{"docType":{"$eq":"marble"},"owner":{"$eq":"tom"},"size":{"$gt":10,"$lte":50}},"sort":[{"size":"desc"}],"use_index":["_design/indexSizeSortDoc","indexSizeSortDesc"]}`, queryString)
+
+	// the sort includes the leading fields of the index that are compared for equality
+	queryString, err = b.Build(&Query{Sort: []Sort{{Field: "owner"}}})
+	require.NoError(t, err)
+	require.Equal(t, `{"selector":{"docType":{"$eq":"marble"},"owner":{"$gt":null}},"sort":[{"docType":"asc"},{"owner":"asc"}],"use_index":["_design/indexOwnerDoc","indexOwner"]}`, queryString)
+}
+
+func TestBuildRejectsQueries(t *testing.T) {
+	b := newBuilder()
+
+	_, err := b.Build(&Query{Filters: []Filter{{Field: "color", Value: "blue"}}})
+	require.EqualError(t, err, "no index supports a query on color, docType. Supported indexes: indexOwner (docType, owner), indexSizeSortDesc (size, docType, owner)")
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "size", Op: Gt, Value: 10}}})
+	require.EqualError(t, err, "no index supports a query on docType, size. Supported indexes: indexOwner (docType, owner), indexSizeSortDesc (size, docType, owner)")
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "size", Op: Gt, Value: 10}}, Sort: []Sort{{Field: "owner"}}})
+	require.EqualError(t, err, "no index supports a query on docType, owner, size sorted by owner. Supported indexes: indexOwner (docType, owner), indexSizeSortDesc (size, docType, owner)")
+
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "docType", Value: "asset"}}})
+	require.EqualError(t, err, "invalid query: queries are restricted to documents of type marble")
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "owner", Op: "regex", Value: "t.*"}}})
+	require.EqualError(t, err, "invalid query: unsupported operator regex of field owner")
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "owner", Value: map[string]interface{}{"$ne": "tom"}}}})
+	require.EqualError(t, err, "invalid query: the value of field owner must be a string, number or boolean")
+	_, err = b.Build(&Query{Filters: []Filter{{Field: "owner", Value: "tom"}, {Field: "owner", Value: "bob"}}})
+	require.EqualError(t, err, "invalid query: duplicate operator eq of field owner")
+	_, err = b.Build(&Query{Sort: []Sort{{Field: "size", Descending: true}, {Field: "owner"}}})
+	require.EqualError(t, err, "invalid query: all sort fields need the same direction")
+}
+
+func TestParse(t *testing.T) {
+	b := newBuilder()
+
+	queryString, err := b.BuildJSON(`{"filters":[{"field":"owner","value":"tom"}]}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"selector":{"docType":{"$eq":"marble"},"owner":{"$eq":"tom"}},"use_index":["_design/indexOwnerDoc","indexOwner"]}`, queryString)
+
+	queryString, err = b.BuildJSON(`{"selector":{"docType":{"$eq":"marble"},"owner":{"$eq":"tom"},"size":{"$gt":0}},"sort":[{"size":"desc"}],"use_index":"_design/indexSizeSortDoc"}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"selector":{"docType":{"$eq":"marble"},"owner":{"$eq":"tom"},"size":{"$gt":0}},"sort":[{"size":"desc"}],"use_index":["_design/indexSizeSortDoc","indexSizeSortDesc"]}`, queryString)
+
+	_, err = b.BuildJSON(`{"filters":[{"field":"owner","value":"tom"}],"limit":10}`)
+	require.EqualError(t, err, `invalid query: json: unknown field "limit"`)
+	_, err = b.BuildJSON(`{"selector":{"owner":"tom"},"fields":["owner"]}`)
+	require.EqualError(t, err, "invalid query: unsupported query option fields")
+	_, err = b.BuildJSON(`{"selector":{"$or":[{"owner":"tom"},{"owner":"bob"}]}}`)
+	require.EqualError(t, err, "invalid query: unsupported operator $or")
+	_, err = b.BuildJSON(`{"selector":{"owner":{"$regex":"t.*"}}}`)
+	require.EqualError(t, err, "invalid query: unsupported operator $regex of field owner")
+	_, err = b.BuildJSON(`{"selector":{"docType":"asset","owner":"tom"}}`)
+	require.EqualError(t, err, "invalid query: queries are restricted to documents of type marble")
+	_, err = b.BuildJSON(`{"selector":{"size":15}}`)
+	require.EqualError(t, err, "no index supports a query on docType, size. Supported indexes: indexOwner (docType, owner), indexSizeSortDesc (size, docType, owner)")
+}
+
+func TestPageSize(t *testing.T) {
+	b := newBuilder()
+
+	require.Equal(t, int32(10), b.PageSize(10))
+	require.Equal(t, int32(50), b.PageSize(500))
+	require.Equal(t, int32(50), b.PageSize(0))
+}
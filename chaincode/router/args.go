@@ -0,0 +1,166 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ArgType is the format an argument needs to have
+type ArgType string
+
+// Supported argument types
+const (
+	StringType ArgType = "string"
+	IntType    ArgType = "int"
+	UintType   ArgType = "uint"
+	FloatType  ArgType = "float"
+	BoolType   ArgType = "bool"
+	JSONType   ArgType = "json"
+)
+
+// Arg declares an argument of a function
+type Arg struct {
+	name     string
+	argType  ArgType
+	notEmpty bool
+	oneOf    []string
+	shape    reflect.Type
+}
+
+// ArgInfo describes an argument in the output of ListFunctions
+type ArgInfo struct {
+	Name     string   `json:"name"`
+	Type     ArgType  `json:"type"`
+	NotEmpty bool     `json:"notEmpty,omitempty"`
+	OneOf    []string `json:"oneOf,omitempty"`
+	Shape    string   `json:"shape,omitempty"`
+}
+
+// String declares an argument that can be any string
+func String(name string) *Arg {
+	return &Arg{name: name, argType: StringType}
+}
+
+// Int declares an argument that needs to be a signed 64-bit integer
+func Int(name string) *Arg {
+	return &Arg{name: name, argType: IntType}
+}
+
+// Uint declares an argument that needs to be an unsigned 64-bit integer
+func Uint(name string) *Arg {
+	return &Arg{name: name, argType: UintType}
+}
+
+// Float declares an argument that needs to be a 64-bit floating point number
+func Float(name string) *Arg {
+	return &Arg{name: name, argType: FloatType}
+}
+
+// Bool declares an argument that needs to be true or false
+func Bool(name string) *Arg {
+	return &Arg{name: name, argType: BoolType}
+}
+
+// JSON declares an argument that needs to be a JSON document that decodes into
+// a value of the type of shape without any unknown fields. shape may be a value
+// or a pointer, such as Asset{} or &Asset{}.
+func JSON(name string, shape interface{}) *Arg {
+	shapeType := reflect.TypeOf(shape)
+	for shapeType != nil && shapeType.Kind() == reflect.Ptr {
+		shapeType = shapeType.Elem()
+	}
+	return &Arg{name: name, argType: JSONType, shape: shapeType}
+}
+
+// NotEmpty rejects the empty string for the argument
+func (a *Arg) NotEmpty() *Arg {
+	a.notEmpty = true
+	return a
+}
+
+// OneOf restricts the argument to the given values
+func (a *Arg) OneOf(values ...string) *Arg {
+	a.oneOf = values
+	return a
+}
+
+// validate checks a value of the argument against its declaration
+func (a *Arg) validate(value string) error {
+	if value == "" {
+		if a.notEmpty {
+			return fmt.Errorf("Invalid argument %s: must be a non-empty string", a.name)
+		}
+		if a.argType == StringType {
+			return nil
+		}
+	}
+	if len(a.oneOf) > 0 && !contains(a.oneOf, value) {
+		return fmt.Errorf("Invalid argument %s: %q is not one of %s", a.name, value, strings.Join(a.oneOf, ", "))
+	}
+
+	var err error
+	switch a.argType {
+	case IntType:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case UintType:
+		_, err = strconv.ParseUint(value, 10, 64)
+	case FloatType:
+		_, err = strconv.ParseFloat(value, 64)
+	case BoolType:
+		_, err = strconv.ParseBool(value)
+	case JSONType:
+		return a.validateJSON(value)
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid argument %s: %q is not a valid %s", a.name, value, a.argType)
+	}
+	return nil
+}
+
+// validateJSON checks that the value is a JSON document of the argument's shape
+func (a *Arg) validateJSON(value string) error {
+	if a.shape == nil {
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("Invalid argument %s: not a valid JSON document", a.name)
+		}
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(a.shape).Interface()); err != nil {
+		return fmt.Errorf("Invalid argument %s: %s", a.name, err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("Invalid argument %s: unexpected data after the JSON document", a.name)
+	}
+	return nil
+}
+
+// info describes the argument for ListFunctions
+func (a *Arg) info() ArgInfo {
+	info := ArgInfo{Name: a.name, Type: a.argType, NotEmpty: a.notEmpty, OneOf: a.oneOf}
+	if a.shape != nil {
+		info.Shape = a.shape.Name()
+	}
+	return info
+}
+
+// contains reports whether value is one of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
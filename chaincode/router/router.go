@@ -0,0 +1,218 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package router dispatches the invocations of chaincodes written against the
+// low-level chaincode shim API. Functions are registered with a handler and a
+// schema of their arguments. The router checks the arguments of an invocation
+// against the schema before it calls the handler, so handlers can rely on the
+// number and format of their arguments, and all chaincodes using the router
+// report invalid invocations the same way.
+//
+//	r := router.New()
+//	r.Handle("set", set, router.String("key").NotEmpty(), router.String("value"))
+//	r.Handle("get", get, router.String("key").NotEmpty())
+//
+//	func (t *SimpleAsset) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+//		return r.Invoke(stub)
+//	}
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// ListFunctions is the name of the function every router provides to list the
+// functions it dispatches, unless a function of this name is registered
+const ListFunctions = "listFunctions"
+
+// Handler handles the invocation of a function with validated arguments
+type Handler func(stub shim.ChaincodeStubInterface, args []string) peer.Response
+
+// Function is a function registered with a router
+type Function struct {
+	name        string
+	description string
+	usage       string
+	args        []*Arg
+	optional    int
+	variadic    bool
+	handler     Handler
+}
+
+// FunctionInfo describes a function in the output of ListFunctions
+type FunctionInfo struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Usage       string    `json:"usage"`
+	Args        []ArgInfo `json:"args"`
+	Optional    int       `json:"optional,omitempty"`
+	Variadic    bool      `json:"variadic,omitempty"`
+}
+
+// Router dispatches the invocations of a chaincode to the registered functions
+type Router struct {
+	functions map[string]*Function
+	fallback  string
+}
+
+// New returns a router without any functions
+func New() *Router {
+	return &Router{functions: map[string]*Function{}}
+}
+
+// Handle registers a function with the arguments it expects. Registering a
+// function again replaces it.
+func (r *Router) Handle(name string, handler Handler, args ...*Arg) *Function {
+	function := &Function{name: name, args: args, handler: handler}
+	r.functions[name] = function
+	return function
+}
+
+// Fallback sets the registered function that handles invocations of unknown
+// functions. Without a fallback, unknown functions are rejected.
+func (r *Router) Fallback(name string) {
+	r.fallback = name
+}
+
+// Describe sets a short description of the function for ListFunctions
+func (f *Function) Describe(description string) *Function {
+	f.description = description
+	return f
+}
+
+// WithUsage overrides the description of the expected arguments in the error
+// that reports a wrong number of arguments
+func (f *Function) WithUsage(usage string) *Function {
+	f.usage = usage
+	return f
+}
+
+// Optional allows the last n arguments of the function to be omitted
+func (f *Function) Optional(n int) *Function {
+	f.optional = n
+	return f
+}
+
+// Variadic allows the last argument of the function to be repeated. It panics
+// if the function has no arguments.
+func (f *Function) Variadic() *Function {
+	if len(f.args) == 0 {
+		panic(fmt.Sprintf("router: function %s has no argument that can be repeated", f.name))
+	}
+	f.variadic = true
+	return f
+}
+
+// Invoke dispatches the invocation of the transaction to the function it names
+func (r *Router) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	name, args := stub.GetFunctionAndParameters()
+	return r.Dispatch(stub, name, args)
+}
+
+// Dispatch validates the arguments of the named function and calls its handler
+func (r *Router) Dispatch(stub shim.ChaincodeStubInterface, name string, args []string) peer.Response {
+	function, ok := r.functions[name]
+	if !ok && name == ListFunctions {
+		return r.listFunctions()
+	}
+	if !ok && r.fallback != "" {
+		function, ok = r.functions[r.fallback]
+	}
+	if !ok {
+		return shim.Error(fmt.Sprintf("Unknown function %s. Expecting one of: %s", name, strings.Join(r.names(), ", ")))
+	}
+
+	if err := function.Validate(args); err != nil {
+		return shim.Error(err.Error())
+	}
+	return function.handler(stub, args)
+}
+
+// Validate checks the arguments of an invocation against the function's schema
+func (f *Function) Validate(args []string) error {
+	min, max := len(f.args)-f.optional, len(f.args)
+	if len(args) < min || (len(args) > max && !f.variadic) {
+		return fmt.Errorf("Incorrect arguments. Expecting %s", f.Usage())
+	}
+	for i, value := range args {
+		arg := f.args[len(f.args)-1]
+		if i < len(f.args) {
+			arg = f.args[i]
+		}
+		if err := arg.validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Usage returns the description of the expected arguments of the function
+func (f *Function) Usage() string {
+	if f.usage != "" {
+		return f.usage
+	}
+	if len(f.args) == 0 {
+		return "no arguments"
+	}
+	usage := []string{}
+	for i, arg := range f.args {
+		placeholder := "<" + arg.name + ">"
+		if i == len(f.args)-1 && f.variadic {
+			placeholder += "..."
+		}
+		if i >= len(f.args)-f.optional {
+			placeholder = "[" + placeholder + "]"
+		}
+		usage = append(usage, placeholder)
+	}
+	return strings.Join(usage, " ")
+}
+
+// Functions describes the registered functions, ordered by name
+func (r *Router) Functions() []FunctionInfo {
+	functions := []FunctionInfo{}
+	for _, name := range r.names() {
+		function := r.functions[name]
+		info := FunctionInfo{
+			Name:        name,
+			Description: function.description,
+			Usage:       function.Usage(),
+			Args:        []ArgInfo{},
+			Optional:    function.optional,
+			Variadic:    function.variadic,
+		}
+		for _, arg := range function.args {
+			info.Args = append(info.Args, arg.info())
+		}
+		functions = append(functions, info)
+	}
+	return functions
+}
+
+// listFunctions returns the description of the registered functions as JSON
+func (r *Router) listFunctions() peer.Response {
+	functionsJSON, err := json.Marshal(r.Functions())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(functionsJSON)
+}
+
+// names returns the names of the registered functions in sorted order
+func (r *Router) names() []string {
+	names := []string{}
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,157 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+type asset struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+type chaincode struct {
+	router *Router
+}
+
+func (c *chaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (c *chaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	return c.router.Invoke(stub)
+}
+
+func echo(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	payload, _ := json.Marshal(args)
+	return shim.Success(payload)
+}
+
+func newStub() *shimtest.MockStub {
+	r := New()
+	r.Handle("set", echo, String("key").NotEmpty(), String("value")).Describe("Set a value")
+	r.Handle("update", echo, String("name"), Float("delta"), String("op").OneOf("+", "-"))
+	r.Handle("create", echo, JSON("asset", &asset{}))
+	r.Handle("range", echo, String("start"), String("end"), Int("pageSize"), String("bookmark")).Optional(2)
+	r.Handle("init", echo, String("auditor"), Uint("threshold"), String("providers")).Variadic()
+	r.Handle("flag", echo, Bool("enabled")).WithUsage("true or false")
+	return shimtest.NewMockStub("router", &chaincode{router: r})
+}
+
+func invoke(stub *shimtest.MockStub, args ...string) peer.Response {
+	byteArgs := [][]byte{}
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	return stub.MockInvoke("tx", byteArgs)
+}
+
+func requireError(t *testing.T, response peer.Response, message string) {
+	require.Equal(t, int32(shim.ERROR), response.Status)
+	require.Equal(t, message, response.Message)
+}
+
+func TestDispatch(t *testing.T) {
+	stub := newStub()
+
+	response := invoke(stub, "set", "a", "")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.Equal(t, `["a",""]`, string(response.Payload))
+
+	response = invoke(stub, "range", "a", "z")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	response = invoke(stub, "range", "a", "z", "10", "")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+
+	response = invoke(stub, "init", "auditor", "1000", "p1", "p2", "p3")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.Equal(t, `["auditor","1000","p1","p2","p3"]`, string(response.Payload))
+
+	requireError(t, invoke(stub, "get", "a"), "Unknown function get. Expecting one of: create, flag, init, range, set, update")
+}
+
+func TestArgumentCount(t *testing.T) {
+	stub := newStub()
+
+	requireError(t, invoke(stub, "set", "a"), "Incorrect arguments. Expecting <key> <value>")
+	requireError(t, invoke(stub, "set", "a", "b", "c"), "Incorrect arguments. Expecting <key> <value>")
+	requireError(t, invoke(stub, "range", "a"), "Incorrect arguments. Expecting <start> <end> [<pageSize>] [<bookmark>]")
+	requireError(t, invoke(stub, "init", "auditor", "1000"), "Incorrect arguments. Expecting <auditor> <threshold> <providers>...")
+	requireError(t, invoke(stub, "flag"), "Incorrect arguments. Expecting true or false")
+}
+
+func TestVariadicWithoutArguments(t *testing.T) {
+	r := New()
+	require.PanicsWithValue(t, "router: function list has no argument that can be repeated", func() {
+		r.Handle("list", echo).Variadic()
+	})
+}
+
+func TestArgumentTypes(t *testing.T) {
+	stub := newStub()
+
+	requireError(t, invoke(stub, "set", "", "b"), "Invalid argument key: must be a non-empty string")
+	requireError(t, invoke(stub, "update", "x", "ten", "+"), `Invalid argument delta: "ten" is not a valid float`)
+	requireError(t, invoke(stub, "update", "x", "10", "*"), `Invalid argument op: "*" is not one of +, -`)
+	requireError(t, invoke(stub, "range", "a", "z", "-"), `Invalid argument pageSize: "-" is not a valid int`)
+	requireError(t, invoke(stub, "init", "auditor", "-1", "p1"), `Invalid argument threshold: "-1" is not a valid uint`)
+	requireError(t, invoke(stub, "flag", "yes"), `Invalid argument enabled: "yes" is not a valid bool`)
+}
+
+func TestJSONArgument(t *testing.T) {
+	stub := newStub()
+
+	response := invoke(stub, "create", `{"id":"a1","value":5}`)
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+
+	requireError(t, invoke(stub, "create", `{"id":"a1","owner":"bob"}`), `Invalid argument asset: json: unknown field "owner"`)
+	requireError(t, invoke(stub, "create", `{"id":"a1","value":"5"}`), "Invalid argument asset: json: cannot unmarshal string into Go struct field asset.value of type int")
+	requireError(t, invoke(stub, "create", `{"id":"a1"} {}`), "Invalid argument asset: unexpected data after the JSON document")
+	requireError(t, invoke(stub, "create", ""), "Invalid argument asset: EOF")
+}
+
+func TestFallback(t *testing.T) {
+	r := New()
+	r.Handle("get", echo, String("key"))
+	r.Fallback("get")
+	stub := shimtest.NewMockStub("router", &chaincode{router: r})
+
+	response := invoke(stub, "query", "a")
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	require.Equal(t, `["a"]`, string(response.Payload))
+}
+
+func TestListFunctions(t *testing.T) {
+	stub := newStub()
+
+	response := invoke(stub, ListFunctions)
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	var functions []FunctionInfo
+	require.NoError(t, json.Unmarshal(response.Payload, &functions))
+	require.Len(t, functions, 6)
+	require.Equal(t, FunctionInfo{
+		Name:  "create",
+		Usage: "<asset>",
+		Args:  []ArgInfo{{Name: "asset", Type: JSONType, Shape: "asset"}},
+	}, functions[0])
+	require.Equal(t, FunctionInfo{
+		Name:        "set",
+		Description: "Set a value",
+		Usage:       "<key> <value>",
+		Args:        []ArgInfo{{Name: "key", Type: StringType, NotEmpty: true}, {Name: "value", Type: StringType}},
+	}, functions[4])
+	require.Equal(t, []string{"+", "-"}, functions[5].Args[2].OneOf)
+	require.Equal(t, 2, functions[3].Optional)
+	require.True(t, functions[2].Variadic)
+}
@@ -5,4 +5,7 @@ go 1.12
 require (
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20190823162523-04390e015b85
 	github.com/hyperledger/fabric-protos-go v0.0.0-20190821214336-621b908d5022
+	github.com/hyperledger/fabric-samples/chaincode/router v0.0.0
 )
+
+replace github.com/hyperledger/fabric-samples/chaincode/router => ../router
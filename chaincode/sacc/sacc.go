@@ -8,13 +8,31 @@ package main
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/router"
 )
 
 // SimpleAsset implements a simple chaincode to manage an asset
 type SimpleAsset struct {
+	routerOnce sync.Once
+	routes     *router.Router
+}
+
+// newRouter declares the transactions of the chaincode. Each transaction is
+// either a 'set' or a 'get', and unknown functions are treated as a 'get'.
+func (t *SimpleAsset) newRouter() *router.Router {
+	r := router.New()
+	r.Handle("set", respond(set), router.String("key"), router.String("value")).
+		WithUsage("a key and a value").
+		Describe("Set the value of an asset, creating it if it does not exist")
+	r.Handle("get", respond(get), router.String("key")).
+		WithUsage("a key").
+		Describe("Get the value of an asset")
+	r.Fallback("get")
+	return r
 }
 
 // Init is called during chaincode instantiation to initialize any
@@ -41,31 +59,25 @@ func (t *SimpleAsset) Init(stub shim.ChaincodeStubInterface) peer.Response {
 // either a 'get' or a 'set' on the asset created by Init function. The Set
 // method may create a new asset by specifying a new key-value pair.
 func (t *SimpleAsset) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
-	// Extract the function and args from the transaction proposal
-	fn, args := stub.GetFunctionAndParameters()
+	t.routerOnce.Do(func() { t.routes = t.newRouter() })
+	return t.routes.Invoke(stub)
+}
 
-	var result string
-	var err error
-	if fn == "set" {
-		result, err = set(stub, args)
-	} else { // assume 'get' even if fn is nil
-		result, err = get(stub, args)
+// respond turns the result of a transaction into a response, returning the
+// result as success payload
+func respond(fn func(shim.ChaincodeStubInterface, []string) (string, error)) router.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+		result, err := fn(stub, args)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(result))
 	}
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-
-	// Return the result as success payload
-	return shim.Success([]byte(result))
 }
 
 // Set stores the asset (both key and value) on the ledger. If the key exists,
 // it will override the value with the new one
 func set(stub shim.ChaincodeStubInterface, args []string) (string, error) {
-	if len(args) != 2 {
-		return "", fmt.Errorf("Incorrect arguments. Expecting a key and a value")
-	}
-
 	err := stub.PutState(args[0], []byte(args[1]))
 	if err != nil {
 		return "", fmt.Errorf("Failed to set asset: %s", args[0])
@@ -75,10 +87,6 @@ func set(stub shim.ChaincodeStubInterface, args []string) (string, error) {
 
 // Get returns the value of the specified asset key
 func get(stub shim.ChaincodeStubInterface, args []string) (string, error) {
-	if len(args) != 1 {
-		return "", fmt.Errorf("Incorrect arguments. Expecting a key")
-	}
-
 	value, err := stub.GetState(args[0])
 	if err != nil {
 		return "", fmt.Errorf("Failed to get asset: %s with error: %s", args[0], err)
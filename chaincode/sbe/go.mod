@@ -0,0 +1,10 @@
+module github.com/hyperledger/fabric-samples/chaincode/sbe
+
+go 1.14
+
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
+	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
+	github.com/stretchr/testify v1.8.0
+)
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sbe
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Set sets the endorsement policy of a key in the world state, so that future
+// updates of the key must satisfy the policy
+func Set(stub shim.ChaincodeStubInterface, key string, policy *Policy) error {
+	policyBytes, err := policy.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := stub.SetStateValidationParameter(key, policyBytes); err != nil {
+		return fmt.Errorf("failed to set validation parameter on key %s: %v", key, err)
+	}
+	return nil
+}
+
+// SetOrgs sets the endorsement policy of a key in the world state, so that
+// future updates of the key must be endorsed by all the given organizations
+func SetOrgs(stub shim.ChaincodeStubInterface, key string, mspIDs ...string) error {
+	return Set(stub, key, AllOrgs(mspIDs...))
+}
+
+// Get returns the endorsement policy of a key in the world state, or nil if the
+// key has no endorsement policy of its own and updates are governed by the
+// endorsement policy of the chaincode
+func Get(stub shim.ChaincodeStubInterface, key string) (*Policy, error) {
+	policyBytes, err := stub.GetStateValidationParameter(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validation parameter of key %s: %v", key, err)
+	}
+	if len(policyBytes) == 0 {
+		return nil, nil
+	}
+	return Parse(policyBytes)
+}
+
+// ListOrgs returns the MSP IDs of the organizations named in the endorsement
+// policy of a key in the world state
+func ListOrgs(stub shim.ChaincodeStubInterface, key string) ([]string, error) {
+	policy, err := Get(stub, key)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return policy.MSPIDs(), nil
+}
+
+// AddOrgs adds organizations to the endorsement policy of a key in the world
+// state, which must require the endorsement of all of its organizations. If the
+// key has no endorsement policy, the policy is set to the given organizations.
+func AddOrgs(stub shim.ChaincodeStubInterface, key string, mspIDs ...string) error {
+	current, err := orgsOf(stub, key)
+	if err != nil {
+		return err
+	}
+
+	for _, mspID := range mspIDs {
+		if !contains(current, mspID) {
+			current = append(current, mspID)
+		}
+	}
+	return SetOrgs(stub, key, current...)
+}
+
+// DelOrgs removes organizations from the endorsement policy of a key in the
+// world state, which must require the endorsement of all of its organizations.
+// At least one organization must remain in the policy.
+func DelOrgs(stub shim.ChaincodeStubInterface, key string, mspIDs ...string) error {
+	current, err := orgsOf(stub, key)
+	if err != nil {
+		return err
+	}
+
+	remaining := []string{}
+	for _, mspID := range current {
+		if !contains(mspIDs, mspID) {
+			remaining = append(remaining, mspID)
+		}
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("cannot remove all organizations from the endorsement policy of key %s", key)
+	}
+	return SetOrgs(stub, key, remaining...)
+}
+
+// orgsOf returns the organizations of the endorsement policy of a key, which
+// must require all of its organizations, or none if the key has no policy
+func orgsOf(stub shim.ChaincodeStubInterface, key string) ([]string, error) {
+	policy, err := Get(stub, key)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	mspIDs, ok := policy.orgs()
+	if !ok {
+		return nil, fmt.Errorf("endorsement policy %s of key %s does not require all of its organizations, set a new policy instead", policy, key)
+	}
+	return mspIDs, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package sbe manages the state-based endorsement policies of chaincode keys,
+// also known as key-level endorsement policies. A Policy is built from the
+// organizations that must endorse updates of a key, either all of them or a
+// given number of them, and policies can be nested:
+//
+//	// Org1 and Org2, or the auditor with either of them
+//	participants := sbe.Orgs("Org1MSP", "Org2MSP")
+//	policy := sbe.AnyOf(
+//		sbe.AllOf(participants...),
+//		sbe.AllOf(sbe.Org("Org3MSP"), sbe.AnyOf(participants...)),
+//	)
+//	err := sbe.Set(stub, key, policy)
+//
+// The policy of a key is read back with Get, and the organizations of a policy
+// requiring all of its organizations are changed with AddOrgs and DelOrgs.
+// Organizations are represented by the peer role of their MSP, as peers endorse
+// transactions.
+package sbe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Policy is an endorsement policy, either the signature of an organization or
+// a number of signatures out of nested policies
+type Policy struct {
+	mspID string
+	role  msp.MSPRole_MSPRoleType
+	n     int
+	rules []*Policy
+}
+
+// Org returns a policy satisfied by the endorsement of a peer of the
+// organization with given MSP ID
+func Org(mspID string) *Policy {
+	return &Policy{mspID: mspID, role: msp.MSPRole_PEER}
+}
+
+// Orgs returns the policies of the organizations with given MSP IDs
+func Orgs(mspIDs ...string) []*Policy {
+	policies := make([]*Policy, len(mspIDs))
+	for i, mspID := range mspIDs {
+		policies[i] = Org(mspID)
+	}
+	return policies
+}
+
+// NOutOf returns a policy satisfied when n of the given policies are satisfied
+func NOutOf(n int, rules ...*Policy) *Policy {
+	return &Policy{n: n, rules: append([]*Policy{}, rules...)}
+}
+
+// AllOf returns a policy satisfied when all the given policies are satisfied
+func AllOf(rules ...*Policy) *Policy {
+	return NOutOf(len(rules), rules...)
+}
+
+// AnyOf returns a policy satisfied when any of the given policies is satisfied
+func AnyOf(rules ...*Policy) *Policy {
+	return NOutOf(1, rules...)
+}
+
+// AllOrgs returns a policy requiring the endorsement of all the organizations
+// with given MSP IDs
+func AllOrgs(mspIDs ...string) *Policy {
+	return AllOf(Orgs(mspIDs...)...)
+}
+
+// NOutOfOrgs returns a policy requiring the endorsement of n of the
+// organizations with given MSP IDs
+func NOutOfOrgs(n int, mspIDs ...string) *Policy {
+	return NOutOf(n, Orgs(mspIDs...)...)
+}
+
+// IsOrg reports whether the policy is the signature of a single organization
+func (p *Policy) IsOrg() bool {
+	return p.rules == nil
+}
+
+// MSPIDs returns the MSP IDs of the organizations named in the policy, in the
+// order in which they first appear
+func (p *Policy) MSPIDs() []string {
+	mspIDs := []string{}
+	seen := map[string]bool{}
+	p.walk(func(org *Policy) {
+		if !seen[org.mspID] {
+			seen[org.mspID] = true
+			mspIDs = append(mspIDs, org.mspID)
+		}
+	})
+	return mspIDs
+}
+
+// orgs returns the MSP IDs of a policy requiring all of its organizations, and
+// false for any other policy
+func (p *Policy) orgs() ([]string, bool) {
+	if p.IsOrg() {
+		return []string{p.mspID}, p.role == msp.MSPRole_PEER
+	}
+	if p.n != len(p.rules) {
+		return nil, false
+	}
+	mspIDs := make([]string, len(p.rules))
+	for i, rule := range p.rules {
+		if !rule.IsOrg() || rule.role != msp.MSPRole_PEER {
+			return nil, false
+		}
+		mspIDs[i] = rule.mspID
+	}
+	return mspIDs, true
+}
+
+func (p *Policy) walk(visit func(org *Policy)) {
+	if p.IsOrg() {
+		visit(p)
+		return
+	}
+	for _, rule := range p.rules {
+		rule.walk(visit)
+	}
+}
+
+// validate checks that the policy can be satisfied by some endorsements
+func (p *Policy) validate() error {
+	if p.IsOrg() {
+		if p.mspID == "" {
+			return fmt.Errorf("policy has no MSP ID")
+		}
+		return nil
+	}
+	if p.n < 1 || p.n > len(p.rules) {
+		return fmt.Errorf("policy requires %d out of %d policies", p.n, len(p.rules))
+	}
+	for _, rule := range p.rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bytes returns the serialized signature policy envelope of the policy, which
+// is the format of the endorsement policy of a key
+func (p *Policy) Bytes() ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid endorsement policy %s: %v", p, err)
+	}
+
+	envelope := &common.SignaturePolicyEnvelope{Version: 0}
+	indexes := map[string]int32{}
+	rule, err := p.signaturePolicy(envelope, indexes)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Rule = rule
+
+	return proto.Marshal(envelope)
+}
+
+// signaturePolicy converts the policy to a signature policy, adding each
+// principal once to the identities of the envelope
+func (p *Policy) signaturePolicy(envelope *common.SignaturePolicyEnvelope, indexes map[string]int32) (*common.SignaturePolicy, error) {
+	if p.IsOrg() {
+		principalKey := p.mspID + "." + p.role.String()
+		index, ok := indexes[principalKey]
+		if !ok {
+			principal, err := proto.Marshal(&msp.MSPRole{Role: p.role, MspIdentifier: p.mspID})
+			if err != nil {
+				return nil, err
+			}
+			index = int32(len(envelope.Identities))
+			indexes[principalKey] = index
+			envelope.Identities = append(envelope.Identities, &msp.MSPPrincipal{
+				PrincipalClassification: msp.MSPPrincipal_ROLE,
+				Principal:               principal,
+			})
+		}
+		return &common.SignaturePolicy{
+			Type: &common.SignaturePolicy_SignedBy{SignedBy: index},
+		}, nil
+	}
+
+	rules := make([]*common.SignaturePolicy, len(p.rules))
+	for i, rule := range p.rules {
+		signaturePolicy, err := rule.signaturePolicy(envelope, indexes)
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = signaturePolicy
+	}
+	return &common.SignaturePolicy{
+		Type: &common.SignaturePolicy_NOutOf_{
+			NOutOf: &common.SignaturePolicy_NOutOf{N: int32(p.n), Rules: rules},
+		},
+	}, nil
+}
+
+// Parse returns the policy of a serialized signature policy envelope. Only
+// policies whose principals are MSP roles are supported.
+func Parse(policyBytes []byte) (*Policy, error) {
+	envelope := &common.SignaturePolicyEnvelope{}
+	if err := proto.Unmarshal(policyBytes, envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal endorsement policy: %v", err)
+	}
+
+	orgs := make([]*Policy, len(envelope.Identities))
+	for i, identity := range envelope.Identities {
+		if identity.PrincipalClassification != msp.MSPPrincipal_ROLE {
+			return nil, fmt.Errorf("unsupported principal classification %s in endorsement policy", identity.PrincipalClassification)
+		}
+		role := &msp.MSPRole{}
+		if err := proto.Unmarshal(identity.Principal, role); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal principal of endorsement policy: %v", err)
+		}
+		orgs[i] = &Policy{mspID: role.MspIdentifier, role: role.Role}
+	}
+
+	return parseRule(envelope.Rule, orgs)
+}
+
+func parseRule(rule *common.SignaturePolicy, orgs []*Policy) (*Policy, error) {
+	switch t := rule.GetType().(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(orgs) {
+			return nil, fmt.Errorf("endorsement policy refers to identity %d out of %d", t.SignedBy, len(orgs))
+		}
+		return orgs[t.SignedBy], nil
+	case *common.SignaturePolicy_NOutOf_:
+		rules := make([]*Policy, len(t.NOutOf.Rules))
+		for i, nested := range t.NOutOf.Rules {
+			policy, err := parseRule(nested, orgs)
+			if err != nil {
+				return nil, err
+			}
+			rules[i] = policy
+		}
+		return NOutOf(int(t.NOutOf.N), rules...), nil
+	default:
+		return nil, fmt.Errorf("endorsement policy has no rule")
+	}
+}
+
+// String returns the policy in the syntax of the Fabric peer CLI, for instance
+// OR('Org1MSP.peer', AND('Org2MSP.peer', 'Org3MSP.peer'))
+func (p *Policy) String() string {
+	if p.IsOrg() {
+		return fmt.Sprintf("'%s.%s'", p.mspID, strings.ToLower(p.role.String()))
+	}
+
+	rules := make([]string, len(p.rules))
+	for i, rule := range p.rules {
+		rules[i] = rule.String()
+	}
+	list := strings.Join(rules, ", ")
+
+	switch {
+	case p.n == len(p.rules) && p.n > 0:
+		return fmt.Sprintf("AND(%s)", list)
+	case p.n == 1 && len(p.rules) > 1:
+		return fmt.Sprintf("OR(%s)", list)
+	default:
+		return fmt.Sprintf("OutOf(%d, %s)", p.n, list)
+	}
+}
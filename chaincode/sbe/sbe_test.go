@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sbe
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/require"
+)
+
+func newStub() *shimtest.MockStub {
+	stub := shimtest.NewMockStub("sbe", nil)
+	stub.MockTransactionStart("tx1")
+	return stub
+}
+
+func TestPolicyString(t *testing.T) {
+	participants := Orgs("Org1MSP", "Org2MSP")
+	policy := AnyOf(AllOf(participants...), AllOf(Org("Org3MSP"), AnyOf(participants...)))
+	require.Equal(t, "OR(AND('Org1MSP.peer', 'Org2MSP.peer'), AND('Org3MSP.peer', OR('Org1MSP.peer', 'Org2MSP.peer')))", policy.String())
+	require.Equal(t, "OutOf(2, 'Org1MSP.peer', 'Org2MSP.peer', 'Org3MSP.peer')", NOutOfOrgs(2, "Org1MSP", "Org2MSP", "Org3MSP").String())
+	require.Equal(t, "AND('Org1MSP.peer')", AllOrgs("Org1MSP").String())
+	require.Equal(t, []string{"Org1MSP", "Org2MSP", "Org3MSP"}, policy.MSPIDs())
+}
+
+func TestPolicyBytes(t *testing.T) {
+	participants := Orgs("Org1MSP", "Org2MSP")
+	policy := AnyOf(AllOf(participants...), AllOf(Org("Org3MSP"), AnyOf(participants...)))
+
+	policyBytes, err := policy.Bytes()
+	require.NoError(t, err)
+	envelope := &common.SignaturePolicyEnvelope{}
+	require.NoError(t, proto.Unmarshal(policyBytes, envelope))
+
+	// each organization is an identity of the envelope once
+	require.Len(t, envelope.Identities, 3)
+	for i, mspID := range []string{"Org1MSP", "Org2MSP", "Org3MSP"} {
+		role := &msp.MSPRole{}
+		require.Equal(t, msp.MSPPrincipal_ROLE, envelope.Identities[i].PrincipalClassification)
+		require.NoError(t, proto.Unmarshal(envelope.Identities[i].Principal, role))
+		require.Equal(t, mspID, role.MspIdentifier)
+		require.Equal(t, msp.MSPRole_PEER, role.Role)
+	}
+
+	parsed, err := Parse(policyBytes)
+	require.NoError(t, err)
+	require.Equal(t, policy.String(), parsed.String())
+}
+
+func TestPolicyBytesMatchesStatebased(t *testing.T) {
+	ep, err := statebased.NewStateEP(nil)
+	require.NoError(t, err)
+	require.NoError(t, ep.AddOrgs(statebased.RoleTypePeer, "Org1MSP", "Org2MSP"))
+	statebasedBytes, err := ep.Policy()
+	require.NoError(t, err)
+
+	policyBytes, err := AllOrgs("Org1MSP", "Org2MSP").Bytes()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(mustUnmarshal(t, statebasedBytes), mustUnmarshal(t, policyBytes)))
+
+	// policies written with statebased are read back
+	parsed, err := Parse(statebasedBytes)
+	require.NoError(t, err)
+	require.Equal(t, "AND('Org1MSP.peer', 'Org2MSP.peer')", parsed.String())
+}
+
+func mustUnmarshal(t *testing.T, policyBytes []byte) *common.SignaturePolicyEnvelope {
+	envelope := &common.SignaturePolicyEnvelope{}
+	require.NoError(t, proto.Unmarshal(policyBytes, envelope))
+	return envelope
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	_, err := AllOf().Bytes()
+	require.EqualError(t, err, "invalid endorsement policy OutOf(0, ): policy requires 0 out of 0 policies")
+
+	_, err = NOutOfOrgs(3, "Org1MSP", "Org2MSP").Bytes()
+	require.EqualError(t, err, "invalid endorsement policy OutOf(3, 'Org1MSP.peer', 'Org2MSP.peer'): policy requires 3 out of 2 policies")
+
+	_, err = AnyOf(Org("Org1MSP"), Org("")).Bytes()
+	require.EqualError(t, err, "invalid endorsement policy OR('Org1MSP.peer', '.peer'): policy has no MSP ID")
+
+	_, err = Parse([]byte("not a policy"))
+	require.Error(t, err)
+}
+
+func TestSetAndGet(t *testing.T) {
+	stub := newStub()
+
+	policy, err := Get(stub, "asset1")
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	require.NoError(t, Set(stub, "asset1", NOutOfOrgs(2, "Org1MSP", "Org2MSP", "Org3MSP")))
+	policy, err = Get(stub, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, "OutOf(2, 'Org1MSP.peer', 'Org2MSP.peer', 'Org3MSP.peer')", policy.String())
+
+	require.NoError(t, SetOrgs(stub, "asset1", "Org2MSP"))
+	orgs, err := ListOrgs(stub, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Org2MSP"}, orgs)
+
+	require.Error(t, Set(stub, "asset1", AllOf()))
+}
+
+func TestAddAndDelOrgs(t *testing.T) {
+	stub := newStub()
+
+	require.NoError(t, AddOrgs(stub, "asset1", "Org1MSP"))
+	require.NoError(t, AddOrgs(stub, "asset1", "Org2MSP", "Org1MSP", "Org3MSP"))
+	policy, err := Get(stub, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, "AND('Org1MSP.peer', 'Org2MSP.peer', 'Org3MSP.peer')", policy.String())
+
+	require.NoError(t, DelOrgs(stub, "asset1", "Org2MSP", "Org4MSP"))
+	orgs, err := ListOrgs(stub, "asset1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Org1MSP", "Org3MSP"}, orgs)
+
+	err = DelOrgs(stub, "asset1", "Org1MSP", "Org3MSP")
+	require.EqualError(t, err, "cannot remove all organizations from the endorsement policy of key asset1")
+
+	require.NoError(t, Set(stub, "asset2", NOutOfOrgs(1, "Org1MSP", "Org2MSP")))
+	err = AddOrgs(stub, "asset2", "Org3MSP")
+	require.EqualError(t, err, "endorsement policy OR('Org1MSP.peer', 'Org2MSP.peer') of key asset2 does not require all of its organizations, set a new policy instead")
+}
@@ -165,3 +165,16 @@ SIMULATED_FAILURE_COUNT=1 ./gradlew run --quiet --args='getAllAssets transact ge
 SIMULATED_FAILURE_COUNT=1 ./gradlew run --quiet --args=listen
 popd
 stopNetwork
+
+# Run off-chain data Go application
+createNetwork
+print "Initializing Go off-chain data application"
+pushd ../off_chain_data/application-go
+rm -f checkpoint.json store.json store.sqlite
+print "Running the output app"
+SIMULATED_FAILURE_COUNT=1 go run . getAllAssets transact getAllAssets listen
+SIMULATED_FAILURE_COUNT=1 go run . listen
+rm -f checkpoint.json
+SIMULATED_FAILURE_COUNT=1 STORE_TYPE=sqlite go run . listen
+popd
+stopNetwork
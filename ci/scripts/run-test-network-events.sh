@@ -52,8 +52,11 @@ stopNetwork
 createNetwork
 print "Initializing Go gateway application"
 pushd ../asset-transfer-events/application-gateway-go
+rm -f checkpoint.json
 print "Executing application"
 go run .
+print "Executing application again to resume from the checkpoint"
+go run .
 popd
 stopNetwork
 
@@ -0,0 +1,408 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command hsmtool manages the keys and certificates of Fabric client identities in a PKCS#11 token, such as the
+// SoftHSM token of the HSM sample. It requires building with the pkcs11 build tag.
+//
+// A new identity is created by generating a key in the token along with a certificate signing request (CSR), having
+// the CSR signed by the CA of the organization, and importing the signed certificate:
+//
+//	hsmtool generate -label HSMUser -cn HSMUser -ou client -csr HSMUser.csr
+//	hsmtool import -cert HSMUser.pem
+//
+// A key is rotated by generating a new key with a CSR for the subject of the current certificate, and importing the
+// new certificate while retiring the current one, which deletes the old key:
+//
+//	hsmtool rotate -cert HSMUser.pem -csr HSMUser-next.csr
+//	hsmtool import -cert HSMUser-next.pem -retire HSMUser.pem
+//
+// The list command shows the keys and certificates of the token with their subject key identifiers (SKIs), by which
+// the client profile finds the private key of its certificate. The token is selected by the -library, -token and -pin
+// flags, which default to the HLF_PKCS11_LIBRARY, HLF_PKCS11_LABEL and HLF_PKCS11_PIN environment variables used by
+// the client profile.
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/hsm"
+)
+
+const usage = `Usage: hsmtool <command> [flags]
+
+Commands:
+  generate  generate a key and a certificate signing request for it
+  csr       create a certificate signing request for an existing key
+  import    import the signed certificate of a key
+  list      list the keys and certificates of the token
+  rotate    generate a new key with a certificate signing request for the subject of a certificate
+  delete    delete a key and its certificates
+
+Run hsmtool <command> -h for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	commands := map[string]func(args []string, out io.Writer) error{
+		"generate": runGenerate,
+		"csr":      runCSR,
+		"import":   runImport,
+		"list":     runList,
+		"rotate":   runRotate,
+		"delete":   runDelete,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+	return command(args[1:], out)
+}
+
+// tokenFlags are the flags that select the token.
+type tokenFlags struct {
+	library string
+	label   string
+	pin     string
+}
+
+func newTokenFlags(name string, flags *tokenFlags) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.StringVar(&flags.library, "library", os.Getenv("HLF_PKCS11_LIBRARY"), "PKCS#11 library, by default PKCS11_LIB or the installed SoftHSM library")
+	flagSet.StringVar(&flags.label, "token", envOrDefault("HLF_PKCS11_LABEL", "ForFabric"), "token label")
+	flagSet.StringVar(&flags.pin, "pin", os.Getenv("HLF_PKCS11_PIN"), "user PIN of the token")
+	return flagSet
+}
+
+func (flags *tokenFlags) open() (*hsm.Token, error) {
+	library := flags.library
+	if library == "" {
+		var err error
+		if library, err = hsm.FindSoftHSMLibrary(); err != nil {
+			return nil, err
+		}
+	}
+	if flags.pin == "" {
+		return nil, errors.New("the token PIN must be set with -pin or HLF_PKCS11_PIN")
+	}
+	return hsm.Open(library, flags.label, flags.pin)
+}
+
+// subjectFlags are the flags that name the subject of a certificate signing request.
+type subjectFlags struct {
+	commonName   string
+	organization string
+	unit         string
+	hosts        string
+}
+
+func (flags *subjectFlags) add(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&flags.commonName, "cn", "", "common name of the subject, such as the enrollment ID")
+	flagSet.StringVar(&flags.organization, "o", "", "organization of the subject")
+	flagSet.StringVar(&flags.unit, "ou", "", "organizational unit of the subject, such as client")
+	flagSet.StringVar(&flags.hosts, "hosts", "", "comma-separated DNS names of the subject")
+}
+
+func (flags *subjectFlags) subject() (pkix.Name, []string, error) {
+	if flags.commonName == "" {
+		return pkix.Name{}, nil, errors.New("the -cn flag is required")
+	}
+
+	subject := pkix.Name{CommonName: flags.commonName}
+	if flags.organization != "" {
+		subject.Organization = []string{flags.organization}
+	}
+	if flags.unit != "" {
+		subject.OrganizationalUnit = []string{flags.unit}
+	}
+	var hosts []string
+	if flags.hosts != "" {
+		hosts = strings.Split(flags.hosts, ",")
+	}
+	return subject, hosts, nil
+}
+
+func runGenerate(args []string, out io.Writer) error {
+	var flags tokenFlags
+	var subjectFlags subjectFlags
+	flagSet := newTokenFlags("generate", &flags)
+	subjectFlags.add(flagSet)
+	keyLabel := flagSet.String("label", "", "label of the key, by default the common name")
+	csrFile := flagSet.String("csr", "", "certificate signing request file to write, by default the common name with a .csr extension")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	subject, hosts, err := subjectFlags.subject()
+	if err != nil {
+		return err
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	key, err := token.GenerateKey(defaultString(*keyLabel, subject.CommonName))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Generated key %s with SKI %x\n", key.Label, key.SKI)
+
+	return writeCSR(defaultString(*csrFile, subject.CommonName+".csr"), key, subject, hosts, out)
+}
+
+func runCSR(args []string, out io.Writer) error {
+	var flags tokenFlags
+	var subjectFlags subjectFlags
+	flagSet := newTokenFlags("csr", &flags)
+	subjectFlags.add(flagSet)
+	skiHex := flagSet.String("ski", "", "SKI of the key")
+	csrFile := flagSet.String("csr", "", "certificate signing request file to write, by default the common name with a .csr extension")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	subject, hosts, err := subjectFlags.subject()
+	if err != nil {
+		return err
+	}
+	ski, err := hex.DecodeString(*skiHex)
+	if err != nil || len(ski) == 0 {
+		return errors.New("the -ski flag must be the hex SKI of a key")
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	key, err := token.FindKey(ski)
+	if err != nil {
+		return err
+	}
+
+	return writeCSR(defaultString(*csrFile, subject.CommonName+".csr"), key, subject, hosts, out)
+}
+
+func runImport(args []string, out io.Writer) error {
+	var flags tokenFlags
+	flagSet := newTokenFlags("import", &flags)
+	certFile := flagSet.String("cert", "", "PEM certificate to import")
+	retireFile := flagSet.String("retire", "", "PEM certificate whose key is deleted once the certificate is imported")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *certFile == "" {
+		return errors.New("the -cert flag is required")
+	}
+
+	certificate, err := readCertificate(*certFile)
+	if err != nil {
+		return err
+	}
+	var retired *x509.Certificate
+	if *retireFile != "" {
+		if retired, err = readCertificate(*retireFile); err != nil {
+			return err
+		}
+		if retired.Subject.String() != certificate.Subject.String() {
+			return fmt.Errorf("cannot retire certificate of %s in favor of certificate of %s", retired.Subject, certificate.Subject)
+		}
+		if bytes.Equal(certificateSKI(retired), certificateSKI(certificate)) {
+			return fmt.Errorf("cannot retire key %x, which is the key of the imported certificate", certificateSKI(retired))
+		}
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	if err := token.ImportCertificate(certificate); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Imported certificate of %s for key %x\n", certificate.Subject, certificateSKI(certificate))
+
+	if retired != nil {
+		if err := token.DeleteKey(certificateSKI(retired)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted retired key %x, use %s as the client certificate\n", certificateSKI(retired), *certFile)
+	}
+	return nil
+}
+
+func runList(args []string, out io.Writer) error {
+	var flags tokenFlags
+	flagSet := newTokenFlags("list", &flags)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	objects, err := token.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLASS\tLABEL\tSKI\tSUBJECT\tEXPIRES")
+	for _, object := range objects {
+		var expires string
+		if !object.NotAfter.IsZero() {
+			expires = object.NotAfter.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%x\t%s\t%s\n", object.Class, object.Label, object.SKI, object.Subject, expires)
+	}
+	return w.Flush()
+}
+
+func runRotate(args []string, out io.Writer) error {
+	var flags tokenFlags
+	flagSet := newTokenFlags("rotate", &flags)
+	certFile := flagSet.String("cert", "", "current PEM certificate")
+	keyLabel := flagSet.String("label", "", "label of the new key, by default the label of the current key")
+	csrFile := flagSet.String("csr", "", "certificate signing request file to write, by default the common name with a .csr extension")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *certFile == "" {
+		return errors.New("the -cert flag is required")
+	}
+
+	current, err := readCertificate(*certFile)
+	if err != nil {
+		return err
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	currentKey, err := token.FindKey(certificateSKI(current))
+	if err != nil {
+		return fmt.Errorf("no key for the current certificate: %w", err)
+	}
+	key, err := token.GenerateKey(defaultString(*keyLabel, currentKey.Label))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Generated key %s with SKI %x to replace key %x\n", key.Label, key.SKI, currentKey.SKI)
+
+	csrFileName := defaultString(*csrFile, current.Subject.CommonName+".csr")
+	if err := writeCSR(csrFileName, key, current.Subject, current.DNSNames, out); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Once the CA has signed the request, run: hsmtool import -cert <new certificate> -retire %s\n", *certFile)
+	return nil
+}
+
+func runDelete(args []string, out io.Writer) error {
+	var flags tokenFlags
+	flagSet := newTokenFlags("delete", &flags)
+	skiHex := flagSet.String("ski", "", "SKI of the key")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	ski, err := hex.DecodeString(*skiHex)
+	if err != nil || len(ski) == 0 {
+		return errors.New("the -ski flag must be the hex SKI of a key")
+	}
+
+	token, err := flags.open()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	if err := token.DeleteKey(ski); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Deleted key %x\n", ski)
+	return nil
+}
+
+func writeCSR(fileName string, key *hsm.Key, subject pkix.Name, hosts []string, out io.Writer) error {
+	csr, err := key.CreateCertificateRequest(subject, hosts)
+	if err != nil {
+		return err
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})
+	if err := os.WriteFile(fileName, csrPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write certificate signing request: %w", err)
+	}
+	fmt.Fprintf(out, "Wrote certificate signing request for %s to %s\n", subject, fileName)
+	return nil
+}
+
+func readCertificate(fileName string) (*x509.Certificate, error) {
+	certificatePEM, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := certificate.PublicKey.(*ecdsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unsupported public key type %T in certificate %s", certificate.PublicKey, fileName)
+	}
+	return certificate, nil
+}
+
+// certificateSKI returns the SKI of the ECDSA public key of a certificate read by readCertificate.
+func certificateSKI(certificate *x509.Certificate) []byte {
+	return hsm.SKI(certificate.PublicKey.(*ecdsa.PublicKey))
+}
+
+func envOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func defaultString(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,287 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-samples/client-go/hsm"
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	testLabel = "ForFabricTest"
+	testPin   = "98765432"
+)
+
+// newTestToken initializes an empty SoftHSM token in a temporary directory, skipping the test if SoftHSM is not
+// installed.
+func newTestToken(t *testing.T) (library string) {
+	library, err := hsm.FindSoftHSMLibrary()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(config, []byte("directories.tokendir = "+dir+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", config)
+
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		t.Skipf("failed to load %s", library)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Finalize()
+
+	slots, err := ctx.GetSlotList(false)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("no free slot: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], "1234", testLabel); err != nil {
+		t.Fatal(err)
+	}
+
+	// SoftHSM moves an initialized token to a new slot
+	slots, err = ctx.GetSlotList(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, slot := range slots {
+		if tokenInfo, err := ctx.GetTokenInfo(slot); err != nil || tokenInfo.Label != testLabel {
+			continue
+		}
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ctx.CloseSession(session)
+		if err := ctx.Login(session, pkcs11.CKU_SO, "1234"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ctx.InitPIN(session, testPin); err != nil {
+			t.Fatal(err)
+		}
+		return library
+	}
+
+	t.Fatal("initialized token not found")
+	return ""
+}
+
+// testCA issues certificates, as the CA of an organization would.
+type testCA struct {
+	key         *ecdsa.PrivateKey
+	certificate *x509.Certificate
+	serial      int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca.org1.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	return &testCA{key: key, certificate: template, serial: 1}
+}
+
+// issue writes a PEM certificate for a public key to a file.
+func (ca *testCA) issue(t *testing.T, fileName string, subject pkix.Name, publicKey interface{}) {
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, publicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER})
+	if err := os.WriteFile(fileName, certificatePEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sign writes a PEM certificate for the public key of a certificate signing request file written by hsmtool.
+func (ca *testCA) sign(t *testing.T, csrFileName string, fileName string) {
+	csrPEM, err := os.ReadFile(csrFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		t.Fatalf("expected PEM certificate request in %s", csrFileName)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("invalid certificate request signature: %v", err)
+	}
+	ca.issue(t, fileName, csr.Subject, csr.PublicKey)
+}
+
+func readTestCertificate(t *testing.T, fileName string) *x509.Certificate {
+	certificate, err := readCertificate(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func TestRunRejectsInvalidFlags(t *testing.T) {
+	for name, test := range map[string]struct {
+		args     []string
+		expected string
+	}{
+		"no command":          {args: nil, expected: "Usage: hsmtool"},
+		"unknown command":     {args: []string{"export"}, expected: `unknown command "export"`},
+		"undefined flag":      {args: []string{"list", "-label", "HSMUser"}, expected: "flag provided but not defined: -label"},
+		"generate without cn": {args: []string{"generate", "-label", "HSMUser"}, expected: "the -cn flag is required"},
+		"csr with bad SKI":    {args: []string{"csr", "-cn", "HSMUser", "-ski", "xyz"}, expected: "the -ski flag must be the hex SKI of a key"},
+		"delete without SKI":  {args: []string{"delete"}, expected: "the -ski flag must be the hex SKI of a key"},
+		"import without cert": {args: []string{"import", "-retire", "HSMUser.pem"}, expected: "the -cert flag is required"},
+		"rotate without cert": {args: []string{"rotate", "-csr", "HSMUser.csr"}, expected: "the -cert flag is required"},
+		"list without PIN":    {args: []string{"list", "-library", "libsofthsm2.so", "-pin", ""}, expected: "the token PIN must be set"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run(test.args, &out)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestImportRejectsInvalidRetiredCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "HSMUser.pem")
+	ca.issue(t, certFile, pkix.Name{CommonName: "HSMUser"}, key.Public())
+	renewedFile := filepath.Join(dir, "HSMUser-renewed.pem")
+	ca.issue(t, renewedFile, pkix.Name{CommonName: "HSMUser"}, key.Public())
+	otherFile := filepath.Join(dir, "OtherUser.pem")
+	ca.issue(t, otherFile, pkix.Name{CommonName: "OtherUser"}, otherKey.Public())
+
+	for name, test := range map[string]struct {
+		retireFile string
+		expected   string
+	}{
+		"same key":          {retireFile: renewedFile, expected: fmt.Sprintf("cannot retire key %x", certificateSKI(readTestCertificate(t, certFile)))},
+		"different subject": {retireFile: otherFile, expected: "cannot retire certificate of CN=OtherUser in favor of certificate of CN=HSMUser"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run([]string{"import", "-library", "libsofthsm2.so", "-pin", testPin, "-cert", certFile, "-retire", test.retireFile}, &out)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestImportAndRetireKeys(t *testing.T) {
+	library := newTestToken(t)
+	tokenArgs := []string{"-library", library, "-token", testLabel, "-pin", testPin}
+	runCommand := func(command string, args ...string) string {
+		var out bytes.Buffer
+		if err := run(append(append([]string{command}, tokenArgs...), args...), &out); err != nil {
+			t.Fatalf("hsmtool %s failed: %v", command, err)
+		}
+		return out.String()
+	}
+
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile := filepath.Join(dir, "HSMUser.pem")
+	csrFile := filepath.Join(dir, "HSMUser.csr")
+	runCommand("generate", "-cn", "HSMUser", "-ou", "client", "-csr", csrFile)
+	ca.sign(t, csrFile, certFile)
+	runCommand("import", "-cert", certFile)
+	current := readTestCertificate(t, certFile)
+
+	nextCertFile := filepath.Join(dir, "HSMUser-next.pem")
+	nextCSRFile := filepath.Join(dir, "HSMUser-next.csr")
+	runCommand("rotate", "-cert", certFile, "-csr", nextCSRFile)
+	ca.sign(t, nextCSRFile, nextCertFile)
+	next := readTestCertificate(t, nextCertFile)
+	if next.Subject.String() != current.Subject.String() {
+		t.Fatalf("expected rotated certificate for %s, got %s", current.Subject, next.Subject)
+	}
+
+	out := runCommand("import", "-cert", nextCertFile, "-retire", certFile)
+	if !strings.Contains(out, fmt.Sprintf("Deleted retired key %x", certificateSKI(current))) {
+		t.Errorf("expected retired key to be deleted, got %q", out)
+	}
+
+	list := runCommand("list")
+	if strings.Contains(list, fmt.Sprintf("%x", certificateSKI(current))) {
+		t.Errorf("expected retired key to be removed from the token, got\n%s", list)
+	}
+	if !strings.Contains(list, fmt.Sprintf("%x", certificateSKI(next))) {
+		t.Errorf("expected new key in the token, got\n%s", list)
+	}
+
+	token, err := hsm.Open(library, testLabel, testPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer token.Close()
+	if _, err := token.FindKey(certificateSKI(current)); err == nil {
+		t.Error("expected retired key to be deleted")
+	}
+	certificates, err := token.Certificates(certificateSKI(next))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certificates) != 1 || !certificates[0].Equal(next) {
+		t.Errorf("expected the imported certificate for the new key, got %d certificates", len(certificates))
+	}
+}
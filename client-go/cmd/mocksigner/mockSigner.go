@@ -0,0 +1,117 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command mocksigner runs a local signing service with the protocol of the remotesign package, so that the remote
+// signer of a client profile can be tried without an external key management service. It signs with a PEM private
+// key file, such as the key of a test network user:
+//
+//	mocksigner -key keystore/priv_sk -id User1 -token secret
+//
+// A client uses the service with a client profile signer of type remote:
+//
+//	signer:
+//	  type: remote
+//	  remote:
+//	    url: http://localhost:8090
+//	    keyId: User1
+//
+// and the bearer token in the HLF_REMOTE_SIGNER_TOKEN environment variable. The service is not meant to protect real
+// keys.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/remotesign"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	address := flag.String("addr", "localhost:8090", "address to listen on")
+	keyFile := flag.String("key", "", "PEM private key to sign with")
+	keyID := flag.String("id", "User1", "ID of the key in signing requests")
+	token := flag.String("token", os.Getenv("HLF_REMOTE_SIGNER_TOKEN"), "bearer token required by the service, by default HLF_REMOTE_SIGNER_TOKEN")
+	tlsCertFile := flag.String("tls-cert", "", "TLS certificate, to serve https")
+	tlsKeyFile := flag.String("tls-key", "", "TLS private key, to serve https")
+	flag.Parse()
+
+	if *keyFile == "" {
+		return errors.New("the -key flag is required")
+	}
+	privateKeyPEM, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              *address,
+		Handler:           logRequests(remotesign.NewHandler(map[string]identity.Sign{*keyID: sign}, *token)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx) //nolint:errcheck // the service is stopping anyway
+	}()
+
+	log.Printf("Signing service for key %s listening on %s", *keyID, *address)
+	if *tlsCertFile != "" {
+		err = server.ListenAndServeTLS(*tlsCertFile, *tlsKeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func logRequests(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+		log.Printf("%s %s from %s: %d in %s", r.Method, r.URL.Path, r.RemoteAddr, recorder.statusCode, time.Since(start))
+	})
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command offlinesign submits a transaction whose messages are signed offline, by a signer that holds the private key
+// of the client identity and need not be connected to the network.
+//
+// Each step of the transaction writes the next message to sign to a JSON file, which describes the message for review
+// along with its serialized bytes and the digest to sign. The sign command checks that the description matches the
+// bytes, shows it to the signer, and writes a detached signature file. The next step resumes the transaction with the
+// signature:
+//
+//	offlinesign propose -channel mychannel -chaincode basic -out proposal.json CreateAsset asset1 blue 5 Tom 100
+//	offlinesign sign -key keystore/priv_sk -in proposal.json -out proposal.sig
+//	offlinesign endorse -in proposal.json -signature proposal.sig -out transaction.json
+//	offlinesign sign -key keystore/priv_sk -in transaction.json -out transaction.sig
+//	offlinesign submit -in transaction.json -signature transaction.sig -out commit.json
+//	offlinesign sign -key keystore/priv_sk -in commit.json -out commit.sig
+//	offlinesign status -in commit.json -signature commit.sig
+//
+// The propose, endorse, submit and status commands connect to the gateway peers of a client profile, whose signer
+// type should be offline.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+)
+
+const usage = `Usage: offlinesign <command> [flags] [arguments]
+
+Commands:
+  propose   create a transaction proposal to sign
+  sign      review and sign a proposal, transaction or commit file
+  endorse   endorse a signed proposal, creating the transaction to sign
+  submit    submit a signed transaction, creating the commit status request to sign
+  status    wait for the commit status of a submitted transaction
+
+Run offlinesign <command> -h for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	commands := map[string]func(args []string, in io.Reader, out io.Writer) error{
+		"propose": runPropose,
+		"sign":    runSign,
+		"endorse": runEndorse,
+		"submit":  runSubmit,
+		"status":  runStatus,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+	return command(args[1:], in, out)
+}
+
+// gatewayFlags are the flags of the commands that connect to the gateway.
+type gatewayFlags struct {
+	profile string
+	timeout time.Duration
+}
+
+func newGatewayFlags(name string, flags *gatewayFlags) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.StringVar(&flags.profile, "profile", "clientProfile.yaml", "client profile with the identity and gateway peers")
+	flagSet.DurationVar(&flags.timeout, "timeout", time.Minute, "timeout of the request to the gateway")
+	return flagSet
+}
+
+// connect creates a Gateway connection without a signing function, which only accepts messages signed offline.
+func (flags *gatewayFlags) connect() (*client.Gateway, func(), error) {
+	p, err := profile.Load(flags.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := p.NewIdentity()
+	if err != nil {
+		return nil, nil, err
+	}
+	connection, err := p.NewConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gateway, err := client.Connect(id, client.WithClientConnection(connection))
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+
+	closeGateway := func() {
+		gateway.Close()
+		connection.Close()
+	}
+	return gateway, closeGateway, nil
+}
+
+func runPropose(args []string, _ io.Reader, out io.Writer) error {
+	var flags gatewayFlags
+	flagSet := newGatewayFlags("propose", &flags)
+	channelName := flagSet.String("channel", "mychannel", "channel name")
+	chaincodeName := flagSet.String("chaincode", "basic", "chaincode name")
+	outFile := flagSet.String("out", "proposal.json", "proposal file to write")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() == 0 {
+		return errors.New("missing transaction name")
+	}
+
+	gateway, closeGateway, err := flags.connect()
+	if err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	contract := gateway.GetNetwork(*channelName).GetContract(*chaincodeName)
+	proposal, err := contract.NewProposal(flagSet.Arg(0), client.WithArguments(flagSet.Args()[1:]...))
+	if err != nil {
+		return err
+	}
+	proposalBytes, err := proposal.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return writeRequest(*outFile, proposalRequest, proposalBytes, proposal.Digest(), out)
+}
+
+func runSign(args []string, in io.Reader, out io.Writer) error {
+	flagSet := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyFile := flagSet.String("key", "", "PEM private key of the creator")
+	inFile := flagSet.String("in", "", "proposal, transaction or commit file to sign")
+	outFile := flagSet.String("out", "", "signature file to write, by default the input file with a .sig extension")
+	yes := flagSet.Bool("yes", false, "sign without asking for confirmation")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *keyFile == "" || *inFile == "" {
+		return errors.New("the -key and -in flags are required")
+	}
+	if *outFile == "" {
+		*outFile = signatureFileName(*inFile)
+	}
+
+	r := &request{}
+	if err := readJSON(*inFile, r); err != nil {
+		return err
+	}
+	r, err := r.verify()
+	if err != nil {
+		return fmt.Errorf("invalid request %s: %w", *inFile, err)
+	}
+
+	if err := r.review(out); err != nil {
+		return err
+	}
+	if !*yes && !confirm(in, out, fmt.Sprintf("Sign this %s?", r.Type)) {
+		return errors.New("not signed")
+	}
+
+	privateKeyPEM, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+	signDigest, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return err
+	}
+	signed, err := signDigest(r.digest)
+	if err != nil {
+		return err
+	}
+	if err := r.verifySignature(signed); err != nil {
+		return err
+	}
+
+	s := &signature{Type: r.Type, TransactionID: r.TransactionID, Digest: r.Digest, Signature: signed}
+	if err := writeJSON(*outFile, s); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote signature to %s\n", *outFile)
+	return nil
+}
+
+func runEndorse(args []string, _ io.Reader, out io.Writer) error {
+	var flags gatewayFlags
+	flagSet := newGatewayFlags("endorse", &flags)
+	inFile := flagSet.String("in", "proposal.json", "proposal file")
+	signatureFile := flagSet.String("signature", "", "signature of the proposal, by default the proposal file with a .sig extension")
+	outFile := flagSet.String("out", "transaction.json", "transaction file to write")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	r, signature, err := readSignedRequest(*inFile, *signatureFile, proposalRequest)
+	if err != nil {
+		return err
+	}
+
+	gateway, closeGateway, err := flags.connect()
+	if err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	proposal, err := gateway.NewSignedProposal(r.Bytes, signature)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flags.timeout)
+	defer cancel()
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to endorse transaction %s: %w", r.TransactionID, err)
+	}
+	transactionBytes, err := transaction.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return writeRequest(*outFile, transactionRequest, transactionBytes, transaction.Digest(), out)
+}
+
+func runSubmit(args []string, _ io.Reader, out io.Writer) error {
+	var flags gatewayFlags
+	flagSet := newGatewayFlags("submit", &flags)
+	inFile := flagSet.String("in", "transaction.json", "transaction file")
+	signatureFile := flagSet.String("signature", "", "signature of the transaction, by default the transaction file with a .sig extension")
+	outFile := flagSet.String("out", "commit.json", "commit file to write")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	r, signature, err := readSignedRequest(*inFile, *signatureFile, transactionRequest)
+	if err != nil {
+		return err
+	}
+
+	gateway, closeGateway, err := flags.connect()
+	if err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	transaction, err := gateway.NewSignedTransaction(r.Bytes, signature)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flags.timeout)
+	defer cancel()
+	commit, err := transaction.SubmitWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction %s: %w", r.TransactionID, err)
+	}
+	commitBytes, err := commit.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return writeRequest(*outFile, commitRequest, commitBytes, commit.Digest(), out)
+}
+
+func runStatus(args []string, _ io.Reader, out io.Writer) error {
+	var flags gatewayFlags
+	flagSet := newGatewayFlags("status", &flags)
+	inFile := flagSet.String("in", "commit.json", "commit file")
+	signatureFile := flagSet.String("signature", "", "signature of the commit, by default the commit file with a .sig extension")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	r, signature, err := readSignedRequest(*inFile, *signatureFile, commitRequest)
+	if err != nil {
+		return err
+	}
+
+	gateway, closeGateway, err := flags.connect()
+	if err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	commit, err := gateway.NewSignedCommit(r.Bytes, signature)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flags.timeout)
+	defer cancel()
+	commitStatus, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get commit status of transaction %s: %w", r.TransactionID, err)
+	}
+	if !commitStatus.Successful {
+		return fmt.Errorf("transaction %s failed to commit with status code %d (%s)", commitStatus.TransactionID, int32(commitStatus.Code), commitStatus.Code)
+	}
+
+	fmt.Fprintf(out, "Transaction %s committed successfully in block %d\n", commitStatus.TransactionID, commitStatus.BlockNumber)
+	return nil
+}
+
+// writeRequest writes a message to sign to a file. The digest computed by the Fabric Gateway client API must match the
+// digest that the signer computes from the bytes.
+func writeRequest(fileName string, requestType string, bytes []byte, digest []byte, out io.Writer) error {
+	r, err := newRequest(requestType, bytes)
+	if err != nil {
+		return err
+	}
+	if r.Digest != hex.EncodeToString(digest) {
+		return fmt.Errorf("%s digest %s does not match the digest %x of the client API", requestType, r.Digest, digest)
+	}
+
+	if err := writeJSON(fileName, r); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s %s to %s for signing\n", requestType, r.TransactionID, fileName)
+	return nil
+}
+
+func readSignedRequest(requestFile string, signatureFile string, requestType string) (*request, []byte, error) {
+	if signatureFile == "" {
+		signatureFile = signatureFileName(requestFile)
+	}
+
+	r, err := readRequest(requestFile, requestType)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := readSignature(signatureFile, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, signature, nil
+}
+
+func signatureFileName(requestFile string) string {
+	return strings.TrimSuffix(requestFile, ".json") + ".sig"
+}
+
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
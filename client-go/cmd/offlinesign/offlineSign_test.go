@@ -0,0 +1,300 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeCertificate writes a self-signed certificate for a common name, and its private key, to a directory.
+func writeCertificate(t *testing.T, dir string, commonName string) (certPath string, keyPath string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, commonName+".pem")
+	keyPath = filepath.Join(dir, commonName+"_sk")
+	writeFile(t, certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}))
+	writeFile(t, keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyDER}))
+	return certPath, keyPath
+}
+
+func writeFile(t *testing.T, fileName string, content []byte) {
+	if err := os.WriteFile(fileName, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeGateway is a gateway peer that checks the signatures of the client and endorses every proposal.
+type fakeGateway struct {
+	gateway.UnimplementedGatewayServer
+	t           *testing.T
+	certificate *x509.Certificate
+
+	mutex     sync.Mutex
+	submitted []string
+}
+
+func (g *fakeGateway) verify(message []byte, signature []byte) error {
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(g.certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		return status.Error(codes.PermissionDenied, "invalid signature")
+	}
+	return nil
+}
+
+func (g *fakeGateway) Endorse(_ context.Context, request *gateway.EndorseRequest) (*gateway.EndorseResponse, error) {
+	signedProposal := request.GetProposedTransaction()
+	if err := g.verify(signedProposal.GetProposalBytes(), signedProposal.GetSignature()); err != nil {
+		return nil, err
+	}
+
+	proposal := &peer.Proposal{}
+	header := &common.Header{}
+	if err := proto.Unmarshal(signedProposal.GetProposalBytes(), proposal); err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(proposal.GetHeader(), header); err != nil {
+		return nil, err
+	}
+
+	chaincodeAction := &peer.ChaincodeAction{Response: &peer.Response{Status: 200, Payload: []byte("asset1 created")}}
+	responsePayload := &peer.ProposalResponsePayload{Extension: g.marshal(chaincodeAction)}
+	endorser := &msp.SerializedIdentity{Mspid: "Org2MSP"}
+	actionPayload := &peer.ChaincodeActionPayload{
+		ChaincodeProposalPayload: proposal.GetPayload(),
+		Action: &peer.ChaincodeEndorsedAction{
+			ProposalResponsePayload: g.marshal(responsePayload),
+			Endorsements:            []*peer.Endorsement{{Endorser: g.marshal(endorser)}},
+		},
+	}
+	transaction := &peer.Transaction{Actions: []*peer.TransactionAction{
+		{Header: header.GetSignatureHeader(), Payload: g.marshal(actionPayload)},
+	}}
+	payload := &common.Payload{Header: header, Data: g.marshal(transaction)}
+
+	return &gateway.EndorseResponse{PreparedTransaction: &common.Envelope{Payload: g.marshal(payload)}}, nil
+}
+
+func (g *fakeGateway) Submit(_ context.Context, request *gateway.SubmitRequest) (*gateway.SubmitResponse, error) {
+	envelope := request.GetPreparedTransaction()
+	if err := g.verify(envelope.GetPayload(), envelope.GetSignature()); err != nil {
+		return nil, err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.submitted = append(g.submitted, request.GetTransactionId())
+	return &gateway.SubmitResponse{}, nil
+}
+
+func (g *fakeGateway) CommitStatus(_ context.Context, request *gateway.SignedCommitStatusRequest) (*gateway.CommitStatusResponse, error) {
+	if err := g.verify(request.GetRequest(), request.GetSignature()); err != nil {
+		return nil, err
+	}
+	return &gateway.CommitStatusResponse{Result: peer.TxValidationCode_VALID, BlockNumber: 7}, nil
+}
+
+func (g *fakeGateway) marshal(message proto.Message) []byte {
+	result, err := proto.Marshal(message)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	return result
+}
+
+// setup starts a fake gateway peer and writes a client profile with an offline signer, returning the profile and the
+// private key of the client.
+func setup(t *testing.T) (dir string, profileFile string, keyFile string, fake *fakeGateway) {
+	dir = t.TempDir()
+	clientCertPath, clientKeyPath := writeCertificate(t, dir, "User1@org1.example.com")
+	tlsCertPath, tlsKeyPath := writeCertificate(t, dir, "peer0.org1.example.com")
+
+	certificatePEM, err := os.ReadFile(clientCertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsCertificate, err := tls.LoadX509KeyPair(tlsCertPath, tlsKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake = &fakeGateway{t: t, certificate: certificate}
+	server := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&tlsCertificate)))
+	gateway.RegisterGatewayServer(server, fake)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener) //nolint:errcheck // Serve returns when the server stops
+	t.Cleanup(server.Stop)
+
+	profileFile = filepath.Join(dir, "clientProfile.yaml")
+	writeFile(t, profileFile, []byte(fmt.Sprintf(`
+mspId: Org1MSP
+certPath: %s
+signer:
+  type: offline
+peers:
+  - endpoint: %s
+    hostAlias: peer0.org1.example.com
+    tlsCertPath: %s
+`, clientCertPath, listener.Addr(), tlsCertPath)))
+
+	return dir, profileFile, clientKeyPath, fake
+}
+
+func runCommand(t *testing.T, stdin string, args ...string) string {
+	var out bytes.Buffer
+	if err := run(args, strings.NewReader(stdin), &out); err != nil {
+		t.Fatalf("offlinesign %s: %v", strings.Join(args, " "), err)
+	}
+	return out.String()
+}
+
+func TestOfflineSigningSubmitsTransaction(t *testing.T) {
+	dir, profileFile, keyFile, fake := setup(t)
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	runCommand(t, "", "propose", "-profile", profileFile, "-out", file("proposal.json"), "CreateAsset", "asset1", "blue", "5")
+
+	review := runCommand(t, "y\n", "sign", "-key", keyFile, "-in", file("proposal.json"))
+	for _, expected := range []string{"Creator:", "Org1MSP", "CreateAsset", "asset1", "blue"} {
+		if !strings.Contains(review, expected) {
+			t.Errorf("expected proposal review to contain %q, got:\n%s", expected, review)
+		}
+	}
+
+	runCommand(t, "", "endorse", "-profile", profileFile, "-in", file("proposal.json"), "-out", file("transaction.json"))
+	transaction, err := readRequest(file("transaction.json"), transactionRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.Result != "asset1 created" || !reflect.DeepEqual(transaction.Endorsers, []string{"Org2MSP"}) {
+		t.Errorf("unexpected transaction description: %+v", transaction)
+	}
+
+	runCommand(t, "", "sign", "-yes", "-key", keyFile, "-in", file("transaction.json"))
+	runCommand(t, "", "submit", "-profile", profileFile, "-in", file("transaction.json"), "-out", file("commit.json"))
+	runCommand(t, "", "sign", "-yes", "-key", keyFile, "-in", file("commit.json"))
+	out := runCommand(t, "", "status", "-profile", profileFile, "-in", file("commit.json"))
+
+	if !reflect.DeepEqual(fake.submitted, []string{transaction.TransactionID}) {
+		t.Errorf("expected transaction %s to be submitted, got %v", transaction.TransactionID, fake.submitted)
+	}
+	if !strings.Contains(out, "committed successfully in block 7") {
+		t.Errorf("unexpected status output: %s", out)
+	}
+}
+
+func TestSignRejectsRequestWhoseDescriptionDoesNotMatchItsBytes(t *testing.T) {
+	dir, profileFile, keyFile, _ := setup(t)
+	proposalFile := filepath.Join(dir, "proposal.json")
+	runCommand(t, "", "propose", "-profile", profileFile, "-out", proposalFile, "TransferAsset", "asset1", "Tom")
+
+	r := &request{}
+	if err := readJSON(proposalFile, r); err != nil {
+		t.Fatal(err)
+	}
+	r.Arguments[1] = "Mallory"
+	if err := writeJSON(proposalFile, r); err != nil {
+		t.Fatal(err)
+	}
+
+	err := run([]string{"sign", "-yes", "-key", keyFile, "-in", proposalFile}, strings.NewReader(""), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "does not match its bytes") {
+		t.Fatalf("expected description mismatch error, got %v", err)
+	}
+}
+
+func TestSignRequiresConfirmation(t *testing.T) {
+	dir, profileFile, keyFile, _ := setup(t)
+	proposalFile := filepath.Join(dir, "proposal.json")
+	runCommand(t, "", "propose", "-profile", profileFile, "-out", proposalFile, "DeleteAsset", "asset1")
+
+	err := run([]string{"sign", "-key", keyFile, "-in", proposalFile}, strings.NewReader("n\n"), &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := os.Stat(signatureFileName(proposalFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no signature file, got %v", err)
+	}
+}
+
+func TestEndorseRejectsSignatureOfAnotherKey(t *testing.T) {
+	dir, profileFile, _, _ := setup(t)
+	_, otherKeyFile := writeCertificate(t, dir, "User2@org1.example.com")
+	proposalFile := filepath.Join(dir, "proposal.json")
+	runCommand(t, "", "propose", "-profile", profileFile, "-out", proposalFile, "DeleteAsset", "asset1")
+
+	err := run([]string{"sign", "-yes", "-key", otherKeyFile, "-in", proposalFile}, strings.NewReader(""), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "not made by the private key of the creator") {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+
+	err = run([]string{"endorse", "-profile", profileFile, "-in", proposalFile}, strings.NewReader(""), &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error for a missing signature")
+	}
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Request types, one for each message signed by the client during a transaction.
+const (
+	proposalRequest    = "proposal"
+	transactionRequest = "transaction"
+	commitRequest      = "commit"
+)
+
+// request is a message waiting to be signed offline. Bytes is the serialized message created by the Fabric Gateway
+// client API. The other fields describe the message for review before signing, and are all derived from Bytes, so a
+// signer can check that the description matches what is signed.
+type request struct {
+	Type            string   `json:"type"`
+	TransactionID   string   `json:"transactionId"`
+	Channel         string   `json:"channel"`
+	Creator         string   `json:"creator"`
+	Chaincode       string   `json:"chaincode,omitempty"`
+	TransactionName string   `json:"transactionName,omitempty"`
+	Arguments       []string `json:"arguments,omitempty"`
+	Result          string   `json:"result,omitempty"`
+	Endorsers       []string `json:"endorsers,omitempty"`
+	// Digest is the hex SHA-256 hash of the signed part of Bytes, which is the value that is signed.
+	Digest string `json:"digest"`
+	Bytes  []byte `json:"bytes"`
+
+	creatorIdentity []byte
+	digest          []byte
+}
+
+// signature is a detached signature of a request. The type, transaction ID and digest identify the signed request.
+type signature struct {
+	Type          string `json:"type"`
+	TransactionID string `json:"transactionId"`
+	Digest        string `json:"digest"`
+	Signature     []byte `json:"signature"`
+}
+
+// newRequest describes the serialized message of a request type.
+func newRequest(requestType string, bytes []byte) (*request, error) {
+	r := &request{Type: requestType, Bytes: bytes}
+
+	var err error
+	switch requestType {
+	case proposalRequest:
+		err = r.describeProposal()
+	case transactionRequest:
+		err = r.describeTransaction()
+	case commitRequest:
+		err = r.describeCommit()
+	default:
+		err = fmt.Errorf("unknown request type %q", requestType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.Digest = hex.EncodeToString(r.digest)
+	return r, nil
+}
+
+func (r *request) describeProposal() error {
+	proposedTransaction := &gateway.ProposedTransaction{}
+	if err := proto.Unmarshal(r.Bytes, proposedTransaction); err != nil {
+		return fmt.Errorf("failed to deserialize proposed transaction: %w", err)
+	}
+	proposalBytes := proposedTransaction.GetProposal().GetProposalBytes()
+	r.digest = hash(proposalBytes)
+
+	proposal := &peer.Proposal{}
+	if err := proto.Unmarshal(proposalBytes, proposal); err != nil {
+		return fmt.Errorf("failed to deserialize proposal: %w", err)
+	}
+	if err := r.describeHeader(proposal.GetHeader()); err != nil {
+		return err
+	}
+	return r.describeInvocation(proposal.GetPayload())
+}
+
+func (r *request) describeTransaction() error {
+	preparedTransaction := &gateway.PreparedTransaction{}
+	if err := proto.Unmarshal(r.Bytes, preparedTransaction); err != nil {
+		return fmt.Errorf("failed to deserialize prepared transaction: %w", err)
+	}
+	payloadBytes := preparedTransaction.GetEnvelope().GetPayload()
+	r.digest = hash(payloadBytes)
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(payloadBytes, payload); err != nil {
+		return fmt.Errorf("failed to deserialize payload: %w", err)
+	}
+	header, err := proto.Marshal(payload.GetHeader())
+	if err != nil {
+		return err
+	}
+	if err := r.describeHeader(header); err != nil {
+		return err
+	}
+
+	transaction := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.GetData(), transaction); err != nil {
+		return fmt.Errorf("failed to deserialize transaction: %w", err)
+	}
+	if len(transaction.GetActions()) != 1 {
+		return fmt.Errorf("expected 1 transaction action, found %d", len(transaction.GetActions()))
+	}
+	actionPayload := &peer.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(transaction.GetActions()[0].GetPayload(), actionPayload); err != nil {
+		return fmt.Errorf("failed to deserialize chaincode action payload: %w", err)
+	}
+	if err := r.describeInvocation(actionPayload.GetChaincodeProposalPayload()); err != nil {
+		return err
+	}
+
+	endorsedAction := actionPayload.GetAction()
+	responsePayload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(endorsedAction.GetProposalResponsePayload(), responsePayload); err != nil {
+		return fmt.Errorf("failed to deserialize proposal response payload: %w", err)
+	}
+	chaincodeAction := &peer.ChaincodeAction{}
+	if err := proto.Unmarshal(responsePayload.GetExtension(), chaincodeAction); err != nil {
+		return fmt.Errorf("failed to deserialize chaincode action: %w", err)
+	}
+	r.Result = printable(chaincodeAction.GetResponse().GetPayload())
+
+	for _, endorsement := range endorsedAction.GetEndorsements() {
+		endorser, err := mspID(endorsement.GetEndorser())
+		if err != nil {
+			return err
+		}
+		r.Endorsers = append(r.Endorsers, endorser)
+	}
+	return nil
+}
+
+func (r *request) describeCommit() error {
+	signedRequest := &gateway.SignedCommitStatusRequest{}
+	if err := proto.Unmarshal(r.Bytes, signedRequest); err != nil {
+		return fmt.Errorf("failed to deserialize signed commit status request: %w", err)
+	}
+	r.digest = hash(signedRequest.GetRequest())
+
+	statusRequest := &gateway.CommitStatusRequest{}
+	if err := proto.Unmarshal(signedRequest.GetRequest(), statusRequest); err != nil {
+		return fmt.Errorf("failed to deserialize commit status request: %w", err)
+	}
+	r.TransactionID = statusRequest.GetTransactionId()
+	r.Channel = statusRequest.GetChannelId()
+	return r.describeCreator(statusRequest.GetIdentity())
+}
+
+// describeHeader describes the transaction ID, channel and creator of a serialized common.Header.
+func (r *request) describeHeader(headerBytes []byte) error {
+	header := &common.Header{}
+	if err := proto.Unmarshal(headerBytes, header); err != nil {
+		return fmt.Errorf("failed to deserialize header: %w", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(header.GetChannelHeader(), channelHeader); err != nil {
+		return fmt.Errorf("failed to deserialize channel header: %w", err)
+	}
+	signatureHeader := &common.SignatureHeader{}
+	if err := proto.Unmarshal(header.GetSignatureHeader(), signatureHeader); err != nil {
+		return fmt.Errorf("failed to deserialize signature header: %w", err)
+	}
+
+	r.TransactionID = channelHeader.GetTxId()
+	r.Channel = channelHeader.GetChannelId()
+	return r.describeCreator(signatureHeader.GetCreator())
+}
+
+func (r *request) describeCreator(serializedIdentity []byte) error {
+	creator, err := mspID(serializedIdentity)
+	if err != nil {
+		return err
+	}
+	r.Creator = creator
+	r.creatorIdentity = serializedIdentity
+	return nil
+}
+
+// describeInvocation describes the chaincode, transaction name and arguments of a serialized
+// peer.ChaincodeProposalPayload. Transient data is not described, since it is not part of the signed message.
+func (r *request) describeInvocation(payloadBytes []byte) error {
+	payload := &peer.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(payloadBytes, payload); err != nil {
+		return fmt.Errorf("failed to deserialize chaincode proposal payload: %w", err)
+	}
+	invocation := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.GetInput(), invocation); err != nil {
+		return fmt.Errorf("failed to deserialize chaincode invocation: %w", err)
+	}
+
+	spec := invocation.GetChaincodeSpec()
+	r.Chaincode = spec.GetChaincodeId().GetName()
+	args := spec.GetInput().GetArgs()
+	if len(args) == 0 {
+		return errors.New("missing transaction name")
+	}
+	r.TransactionName = printable(args[0])
+	for _, arg := range args[1:] {
+		r.Arguments = append(r.Arguments, printable(arg))
+	}
+	return nil
+}
+
+// verify checks that the description of a request read from a file matches its bytes, so that what is reviewed is
+// what is signed.
+func (r *request) verify() (*request, error) {
+	expected, err := newRequest(r.Type, r.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(exported(r), exported(expected)) {
+		return nil, errors.New("the description of the request does not match its bytes")
+	}
+	return expected, nil
+}
+
+// verifySignature checks that a signature of the request digest was made by the private key of the creator.
+func (r *request) verifySignature(signature []byte) error {
+	serializedIdentity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(r.creatorIdentity, serializedIdentity); err != nil {
+		return fmt.Errorf("failed to deserialize creator identity: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(serializedIdentity.GetIdBytes())
+	if err != nil {
+		return fmt.Errorf("failed to read creator certificate: %w", err)
+	}
+
+	var valid bool
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(publicKey, r.digest, signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(publicKey, r.digest, signature)
+	default:
+		return fmt.Errorf("unsupported creator public key type %T", publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("the signature was not made by the private key of the creator certificate %s", certificate.Subject)
+	}
+	return nil
+}
+
+// review writes the description of a request for a signer to check before signing it.
+func (r *request) review(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	line := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", name, value)
+		}
+	}
+
+	line("Type", r.Type)
+	line("Transaction ID", r.TransactionID)
+	line("Channel", r.Channel)
+	line("Creator", r.Creator)
+	line("Chaincode", r.Chaincode)
+	line("Transaction", r.TransactionName)
+	for i, arg := range r.Arguments {
+		line(fmt.Sprintf("Argument %d", i+1), arg)
+	}
+	line("Result", r.Result)
+	line("Endorsers", strings.Join(r.Endorsers, ", "))
+	line("Digest", r.Digest)
+	return w.Flush()
+}
+
+// exported returns a copy of a request without its unexported fields, to compare what is written to a file.
+func exported(r *request) request {
+	return request{
+		Type:            r.Type,
+		TransactionID:   r.TransactionID,
+		Channel:         r.Channel,
+		Creator:         r.Creator,
+		Chaincode:       r.Chaincode,
+		TransactionName: r.TransactionName,
+		Arguments:       r.Arguments,
+		Result:          r.Result,
+		Endorsers:       r.Endorsers,
+		Digest:          r.Digest,
+		Bytes:           r.Bytes,
+	}
+}
+
+func mspID(serializedIdentity []byte) (string, error) {
+	id := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, id); err != nil {
+		return "", fmt.Errorf("failed to deserialize identity: %w", err)
+	}
+	return id.GetMspid(), nil
+}
+
+func hash(message []byte) []byte {
+	digest := sha256.Sum256(message)
+	return digest[:]
+}
+
+// printable returns a value as text, or as hex if it is binary.
+func printable(value []byte) string {
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	return "0x" + hex.EncodeToString(value)
+}
+
+func readRequest(fileName string, requestType string) (*request, error) {
+	r := &request{}
+	if err := readJSON(fileName, r); err != nil {
+		return nil, err
+	}
+	if r.Type != requestType {
+		return nil, fmt.Errorf("%s contains a %s, expected a %s", fileName, r.Type, requestType)
+	}
+
+	verified, err := r.verify()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", requestType, fileName, err)
+	}
+	return verified, nil
+}
+
+// readSignature reads the detached signature of a request, and checks that it was made for the request by its creator.
+func readSignature(fileName string, r *request) ([]byte, error) {
+	s := &signature{}
+	if err := readJSON(fileName, s); err != nil {
+		return nil, err
+	}
+	if s.Type != r.Type || s.TransactionID != r.TransactionID || s.Digest != r.Digest {
+		return nil, fmt.Errorf("%s is not a signature of %s %s with digest %s", fileName, r.Type, r.TransactionID, r.Digest)
+	}
+	if err := r.verifySignature(s.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature %s: %w", fileName, err)
+	}
+	return s.Signature, nil
+}
+
+func readJSON(fileName string, value interface{}) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", fileName, err)
+	}
+	return nil
+}
+
+func writeJSON(fileName string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fileName, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", fileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hsm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+)
+
+// Key is an ECDSA key pair stored in a token. It implements crypto.Signer, so that certificate requests can be signed
+// by the private key without extracting it from the token.
+type Key struct {
+	SKI   []byte
+	Label string
+
+	token     *Token
+	handle    pkcs11.ObjectHandle
+	publicKey *ecdsa.PublicKey
+}
+
+// Public returns the *ecdsa.PublicKey of the key pair.
+func (k *Key) Public() crypto.PublicKey {
+	return k.publicKey
+}
+
+// Sign signs a digest with the private key in the token, returning an ASN.1 encoded ECDSA signature.
+func (k *Key) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}
+	if err := k.token.ctx.SignInit(k.token.session, mechanism, k.handle); err != nil {
+		return nil, fmt.Errorf("failed to initialize signing: %w", err)
+	}
+	signature, err := k.token.ctx.Sign(k.token.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	// PKCS#11 ECDSA signatures are the concatenation of r and s
+	if len(signature)%2 != 0 {
+		return nil, errors.New("invalid PKCS#11 signature length")
+	}
+	half := len(signature) / 2
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(signature[:half]),
+		S: new(big.Int).SetBytes(signature[half:]),
+	})
+}
+
+// CreateCertificateRequest returns a DER certificate signing request for the key pair, signed by its private key.
+func (k *Key) CreateCertificateRequest(subject pkix.Name, dnsNames []string) ([]byte, error) {
+	template := &x509.CertificateRequest{
+		Subject:            subject,
+		DNSNames:           dnsNames,
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	return csr, nil
+}
@@ -0,0 +1,399 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package hsm manages the ECDSA keys and X.509 certificates of Fabric client identities in a PKCS#11 token, such as
+// an HSM or SoftHSM. It requires building with the pkcs11 build tag.
+//
+// Keys and certificates are identified, as Fabric identifies them, by the subject key identifier (SKI) of their public
+// key, which is stored as the CKA_ID attribute of the private key, public key and certificate objects. The PKCS#11
+// signer of the profile package finds a private key by the SKI of the client certificate.
+package hsm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/miekg/pkcs11"
+)
+
+// oidNamedCurveP256 is the object identifier of the P-256 curve, the only curve supported by the Fabric HSM signer.
+var oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+// Object classes listed by Token.List.
+const (
+	PrivateKeyClass  = "private key"
+	PublicKeyClass   = "public key"
+	CertificateClass = "certificate"
+)
+
+// Token is a user session of a PKCS#11 token. It is not safe for concurrent use.
+type Token struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+}
+
+// Object is a key or certificate stored in a token.
+type Object struct {
+	Class string
+	Label string
+	SKI   []byte
+	// Subject and NotAfter are only set for certificates.
+	Subject  string
+	NotAfter time.Time
+}
+
+// Open logs in to the token with a label, using a PKCS#11 library such as the one returned by FindSoftHSMLibrary.
+func Open(library string, label string, pin string) (*Token, error) {
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %s", library)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 library: %w", err)
+	}
+
+	t := &Token{ctx: ctx}
+	if err := t.login(label, pin); err != nil {
+		t.finalize()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Token) login(label string, pin string) error {
+	slots, err := t.ctx.GetSlotList(true)
+	if err != nil {
+		return fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+
+	for _, slot := range slots {
+		tokenInfo, err := t.ctx.GetTokenInfo(slot)
+		if err != nil || tokenInfo.Label != label {
+			continue
+		}
+
+		if t.session, err = t.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION); err != nil {
+			return fmt.Errorf("failed to open session: %w", err)
+		}
+		if err := t.ctx.Login(t.session, pkcs11.CKU_USER, pin); err != nil {
+			return fmt.Errorf("failed to log in to token %s: %w", label, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no token with label %s", label)
+}
+
+// Close logs out of the token and releases the PKCS#11 library.
+func (t *Token) Close() error {
+	err := t.ctx.Logout(t.session)
+	t.finalize()
+	return err
+}
+
+func (t *Token) finalize() {
+	t.ctx.CloseAllSessions(0) //#nosec G104 -- the library is finalized anyway
+	t.ctx.Finalize()          //#nosec G104 -- nothing useful can be done with the error
+	t.ctx.Destroy()
+}
+
+// GenerateKey generates an ECDSA P-256 key pair in the token. The private key is sensitive and cannot be extracted,
+// and both keys are identified by the SKI of the public key.
+func (t *Token) GenerateKey(label string) (*Key, error) {
+	ecParams, err := asn1.Marshal(oidNamedCurveP256)
+	if err != nil {
+		return nil, err
+	}
+
+	publicTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, ecParams),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)}
+
+	publicHandle, privateHandle, err := t.ctx.GenerateKeyPair(t.session, mechanism, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+
+	publicKey, err := t.publicKey(publicHandle)
+	if err != nil {
+		return nil, err
+	}
+	ski := SKI(publicKey)
+
+	// The SKI is only known once the key is generated
+	id := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, ski)}
+	for _, handle := range []pkcs11.ObjectHandle{publicHandle, privateHandle} {
+		if err := t.ctx.SetAttributeValue(t.session, handle, id); err != nil {
+			return nil, fmt.Errorf("failed to set key identifier: %w", err)
+		}
+	}
+
+	return &Key{token: t, handle: privateHandle, publicKey: publicKey, SKI: ski, Label: label}, nil
+}
+
+// FindKey returns the key pair identified by an SKI.
+func (t *Token) FindKey(ski []byte) (*Key, error) {
+	privateHandle, err := t.findObject(pkcs11.CKO_PRIVATE_KEY, ski)
+	if err != nil {
+		return nil, err
+	}
+	publicHandle, err := t.findObject(pkcs11.CKO_PUBLIC_KEY, ski)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := t.publicKey(publicHandle)
+	if err != nil {
+		return nil, err
+	}
+	label, err := t.attribute(privateHandle, pkcs11.CKA_LABEL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{token: t, handle: privateHandle, publicKey: publicKey, SKI: ski, Label: string(label)}, nil
+}
+
+// ImportCertificate stores the certificate of a key pair in the token, with the label of the key pair. It fails if
+// the token does not contain the private key of the certificate.
+func (t *Token) ImportCertificate(certificate *x509.Certificate) error {
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", certificate.PublicKey)
+	}
+	key, err := t.FindKey(SKI(publicKey))
+	if err != nil {
+		return fmt.Errorf("no private key for certificate %s: %w", certificate.Subject, err)
+	}
+	if !key.publicKey.Equal(publicKey) {
+		return fmt.Errorf("the public key of certificate %s does not match key %x", certificate.Subject, key.SKI)
+	}
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, key.SKI),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, key.Label),
+		pkcs11.NewAttribute(pkcs11.CKA_SUBJECT, certificate.RawSubject),
+		pkcs11.NewAttribute(pkcs11.CKA_ISSUER, certificate.RawIssuer),
+		pkcs11.NewAttribute(pkcs11.CKA_SERIAL_NUMBER, certificate.SerialNumber.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, certificate.Raw),
+	}
+	if _, err := t.ctx.CreateObject(t.session, template); err != nil {
+		return fmt.Errorf("failed to store certificate: %w", err)
+	}
+	return nil
+}
+
+// Certificates returns the certificates of the key pair identified by an SKI.
+func (t *Token) Certificates(ski []byte) ([]*x509.Certificate, error) {
+	handles, err := t.findObjects(pkcs11.CKO_CERTIFICATE, ski)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := make([]*x509.Certificate, 0, len(handles))
+	for _, handle := range handles {
+		certificate, err := t.certificate(handle)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// DeleteKey deletes the private key, public key and certificates identified by an SKI.
+func (t *Token) DeleteKey(ski []byte) error {
+	var handles []pkcs11.ObjectHandle
+	for _, class := range []uint{pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY, pkcs11.CKO_CERTIFICATE} {
+		classHandles, err := t.findObjects(class, ski)
+		if err != nil {
+			return err
+		}
+		handles = append(handles, classHandles...)
+	}
+	if len(handles) == 0 {
+		return fmt.Errorf("no key with SKI %x", ski)
+	}
+
+	for _, handle := range handles {
+		if err := t.ctx.DestroyObject(t.session, handle); err != nil {
+			return fmt.Errorf("failed to delete object of key %x: %w", ski, err)
+		}
+	}
+	return nil
+}
+
+// List returns the keys and certificates of the token.
+func (t *Token) List() ([]Object, error) {
+	classes := []struct {
+		class uint
+		name  string
+	}{
+		{pkcs11.CKO_PRIVATE_KEY, PrivateKeyClass},
+		{pkcs11.CKO_PUBLIC_KEY, PublicKeyClass},
+		{pkcs11.CKO_CERTIFICATE, CertificateClass},
+	}
+
+	var objects []Object
+	for _, class := range classes {
+		handles, err := t.findObjects(class.class, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, handle := range handles {
+			attributes, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{
+				pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil),
+				pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read object attributes: %w", err)
+			}
+			object := Object{Class: class.name, Label: string(attributes[0].Value), SKI: attributes[1].Value}
+
+			if class.class == pkcs11.CKO_CERTIFICATE {
+				certificate, err := t.certificate(handle)
+				if err != nil {
+					return nil, err
+				}
+				object.Subject = certificate.Subject.String()
+				object.NotAfter = certificate.NotAfter
+			}
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (t *Token) publicKey(handle pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	ecPoint, err := t.attribute(handle, pkcs11.CKA_EC_POINT)
+	if err != nil {
+		return nil, err
+	}
+
+	// CKA_EC_POINT is a DER octet string containing the uncompressed point
+	var point []byte
+	if rest, err := asn1.Unmarshal(ecPoint, &point); err != nil || len(rest) > 0 {
+		return nil, errors.New("failed to decode public key point")
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), point)
+	if x == nil {
+		return nil, errors.New("public key is not a P-256 point")
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+func (t *Token) certificate(handle pkcs11.ObjectHandle) (*x509.Certificate, error) {
+	value, err := t.attribute(handle, pkcs11.CKA_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(value)
+}
+
+func (t *Token) attribute(handle pkcs11.ObjectHandle, attributeType uint) ([]byte, error) {
+	attributes, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{pkcs11.NewAttribute(attributeType, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object attribute: %w", err)
+	}
+	return attributes[0].Value, nil
+}
+
+func (t *Token) findObject(class uint, ski []byte) (pkcs11.ObjectHandle, error) {
+	handles, err := t.findObjects(class, ski)
+	if err != nil {
+		return 0, err
+	}
+	if len(handles) == 0 {
+		return 0, fmt.Errorf("no key with SKI %x", ski)
+	}
+	return handles[0], nil
+}
+
+// findObjects returns the objects of a class with an SKI, or all the objects of the class if the SKI is nil.
+func (t *Token) findObjects(class uint, ski []byte) ([]pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if ski != nil {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, ski))
+	}
+
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return nil, fmt.Errorf("failed to find objects: %w", err)
+	}
+	defer t.ctx.FindObjectsFinal(t.session) //nolint:errcheck // the search is complete
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		found, _, err := t.ctx.FindObjects(t.session, 100)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find objects: %w", err)
+		}
+		if len(found) == 0 {
+			return handles, nil
+		}
+		handles = append(handles, found...)
+	}
+}
+
+// SKI returns the subject key identifier of a public key, as used by Fabric to identify keys in an HSM.
+func SKI(publicKey *ecdsa.PublicKey) []byte {
+	ski := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+	return ski[:]
+}
+
+// FindSoftHSMLibrary returns the library named by the PKCS11_LIB environment variable, as used by the scripts of the
+// HSM sample, or else the first of the usual SoftHSM library locations that exists.
+func FindSoftHSMLibrary() (string, error) {
+	if library := os.Getenv("PKCS11_LIB"); library != "" {
+		return library, nil
+	}
+
+	libraryLocations := []string{
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/libacsp-pkcs11.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, libraryLocation := range libraryLocations {
+		if _, err := os.Stat(libraryLocation); !errors.Is(err, os.ErrNotExist) {
+			return libraryLocation, nil
+		}
+	}
+
+	return "", errors.New("no SoftHSM library found, set PKCS11_LIB to the PKCS#11 library")
+}
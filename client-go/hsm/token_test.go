@@ -0,0 +1,267 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hsm
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	testLabel = "ForFabricTest"
+	testPin   = "98765432"
+)
+
+// newTestToken initializes an empty SoftHSM token in a temporary directory, skipping the test if SoftHSM is not
+// installed.
+func newTestToken(t *testing.T) (library string) {
+	library, err := FindSoftHSMLibrary()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(config, []byte("directories.tokendir = "+dir+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", config)
+
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		t.Skipf("failed to load %s", library)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Finalize()
+
+	slots, err := ctx.GetSlotList(false)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("no free slot: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], "1234", testLabel); err != nil {
+		t.Fatal(err)
+	}
+
+	// SoftHSM moves an initialized token to a new slot
+	slots, err = ctx.GetSlotList(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, slot := range slots {
+		if tokenInfo, err := ctx.GetTokenInfo(slot); err != nil || tokenInfo.Label != testLabel {
+			continue
+		}
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ctx.CloseSession(session)
+		if err := ctx.Login(session, pkcs11.CKU_SO, "1234"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ctx.InitPIN(session, testPin); err != nil {
+			t.Fatal(err)
+		}
+		return library
+	}
+
+	t.Fatal("initialized token not found")
+	return ""
+}
+
+func openTestToken(t *testing.T, library string) *Token {
+	token, err := Open(library, testLabel, testPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// signCertificate issues a certificate for the public key of a certificate request, as the CA of an organization would.
+func signCertificate(t *testing.T, csrDER []byte) *x509.Certificate {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("invalid certificate request signature: %v", err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca.org1.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, csr.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func TestGeneratedKeySignsForFabricHSMSigner(t *testing.T) {
+	library := newTestToken(t)
+	token := openTestToken(t, library)
+
+	key, err := token.GenerateKey("HSMUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.SKI, SKI(key.Public().(*ecdsa.PublicKey))) {
+		t.Fatalf("expected SKI of the public key, got %x", key.SKI)
+	}
+
+	subject := pkix.Name{CommonName: "HSMUser", OrganizationalUnit: []string{"client"}}
+	csr, err := key.CreateCertificateRequest(subject, []string{"example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := signCertificate(t, csr)
+	if err := token.ImportCertificate(certificate); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Fabric Gateway HSM signer finds the private key by the SKI of the certificate
+	factory, err := identity.NewHSMSignerFactory(library)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer factory.Dispose()
+	sign, closeSign, err := factory.NewHSMSigner(identity.HSMSignerOptions{
+		Label:      testLabel,
+		Pin:        testPin,
+		Identifier: string(SKI(certificate.PublicKey.(*ecdsa.PublicKey))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSign()
+
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		t.Fatal("signature does not verify with the certificate")
+	}
+}
+
+func TestListAndRotateKeys(t *testing.T) {
+	token := openTestToken(t, newTestToken(t))
+	defer token.Close()
+
+	subject := pkix.Name{CommonName: "HSMUser"}
+	issue := func() (*Key, *x509.Certificate) {
+		key, err := token.GenerateKey("HSMUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+		csr, err := key.CreateCertificateRequest(subject, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certificate := signCertificate(t, csr)
+		if err := token.ImportCertificate(certificate); err != nil {
+			t.Fatal(err)
+		}
+		return key, certificate
+	}
+
+	oldKey, _ := issue()
+	newKey, newCertificate := issue()
+	if err := token.DeleteKey(oldKey.SKI); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := token.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	classes := map[string]bool{}
+	for _, object := range objects {
+		if !bytes.Equal(object.SKI, newKey.SKI) {
+			t.Errorf("expected only objects of key %x, found %s of %x", newKey.SKI, object.Class, object.SKI)
+		}
+		if object.Class == CertificateClass && object.Subject != newCertificate.Subject.String() {
+			t.Errorf("unexpected certificate subject %s", object.Subject)
+		}
+		classes[object.Class] = true
+	}
+	if len(objects) != 3 || !classes[PrivateKeyClass] || !classes[PublicKeyClass] || !classes[CertificateClass] {
+		t.Fatalf("expected private key, public key and certificate, got %+v", objects)
+	}
+
+	if _, err := token.FindKey(oldKey.SKI); err == nil {
+		t.Fatal("expected rotated key to be deleted")
+	}
+	certificates, err := token.Certificates(newKey.SKI)
+	if err != nil || len(certificates) != 1 || !certificates[0].Equal(newCertificate) {
+		t.Fatalf("expected new certificate, got %v, %v", certificates, err)
+	}
+}
+
+func TestImportCertificateRequiresPrivateKey(t *testing.T) {
+	token := openTestToken(t, newTestToken(t))
+	defer token.Close()
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "Other"}}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := token.ImportCertificate(signCertificate(t, csr)); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// resolverScheme is the scheme of the target of the gRPC connections, whose addresses are the peers of the profile.
+const resolverScheme = "profile"
+
+// NewConnection creates a gRPC connection to the gateway peers of the profile. The connection should be shared by all
+// the Gateway connections to these peers. Requests are sent to the peers in turn, skipping the peers that are not
+// available.
+func (p *Profile) NewConnection(options ...grpc.DialOption) (*grpc.ClientConn, error) {
+	certPool := x509.NewCertPool()
+	addresses := make([]resolver.Address, 0, len(p.Peers))
+	for _, peer := range p.Peers {
+		certificate, err := loadCertificate(peer.TLSCertPath)
+		if err != nil {
+			return nil, err
+		}
+		certPool.AddCert(certificate)
+
+		// The server name of an address overrides the host name used to verify the TLS certificate of its peer
+		addresses = append(addresses, resolver.Address{Addr: peer.Endpoint, ServerName: peer.HostAlias})
+	}
+
+	peers := manual.NewBuilderWithScheme(resolverScheme)
+	peers.InitialState(resolver.State{Addresses: addresses})
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, "")),
+		grpc.WithResolvers(peers),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+	}
+	if p.Keepalive.Time > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                p.Keepalive.Time,
+			Timeout:             p.Keepalive.Timeout,
+			PermitWithoutStream: p.Keepalive.PermitWithoutStream,
+		}))
+	}
+
+	connection, err := grpc.Dial(resolverScheme+":///"+p.MSPID, append(dialOptions, options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+
+	return connection, nil
+}
+
+// NewIdentity creates the client identity of the profile using its X.509 certificate.
+func (p *Profile) NewIdentity() (*identity.X509Identity, error) {
+	certificate, err := loadCertificate(p.CertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity.NewX509Identity(p.MSPID, certificate)
+}
+
+func loadCertificate(fileName string) (*x509.Certificate, error) {
+	certificatePEM, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	return identity.CertificateFromPEM(certificatePEM)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// SignLatency is the default latency metric of signing requests. The package does not publish it, so that importing
+// the package has no side effects; an application can publish it from its main package, for example with
+// expvar.Publish("hlf_sign_latency", profile.SignLatency).
+var SignLatency = NewLatencyMetric()
+
+// LatencyObserver records the latency of requests, and whether they failed. It is implemented by *LatencyMetric.
+type LatencyObserver interface {
+	Observe(latency time.Duration, err error)
+}
+
+// latencyBuckets are the upper bounds of the latency histogram, from local keys to remote signing services.
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// LatencyMetric is a histogram of the latency of requests. It is safe for concurrent use, and implements the Var
+// interface of the expvar package.
+type LatencyMetric struct {
+	mutex    sync.Mutex
+	snapshot LatencySnapshot
+}
+
+// LatencySnapshot is the state of a LatencyMetric.
+type LatencySnapshot struct {
+	Count  uint64        `json:"count"`
+	Errors uint64        `json:"errors"`
+	Total  time.Duration `json:"totalNanoseconds"`
+	Max    time.Duration `json:"maxNanoseconds"`
+	// Buckets are the number of requests whose latency is at most the upper bound of each bucket.
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket is a cumulative histogram bucket.
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"upperBoundNanoseconds"`
+	Count      uint64        `json:"count"`
+}
+
+// NewLatencyMetric creates an empty latency metric.
+func NewLatencyMetric() *LatencyMetric {
+	buckets := make([]LatencyBucket, len(latencyBuckets))
+	for i, upperBound := range latencyBuckets {
+		buckets[i].UpperBound = upperBound
+	}
+	return &LatencyMetric{snapshot: LatencySnapshot{Buckets: buckets}}
+}
+
+// Observe records the latency of a request, and whether it failed.
+func (m *LatencyMetric) Observe(latency time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.snapshot.Count++
+	if err != nil {
+		m.snapshot.Errors++
+	}
+	m.snapshot.Total += latency
+	if latency > m.snapshot.Max {
+		m.snapshot.Max = latency
+	}
+	for i := range m.snapshot.Buckets {
+		if latency <= m.snapshot.Buckets[i].UpperBound {
+			m.snapshot.Buckets[i].Count++
+		}
+	}
+}
+
+// Snapshot returns a copy of the state of the metric.
+func (m *LatencyMetric) Snapshot() LatencySnapshot {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	snapshot := m.snapshot
+	snapshot.Buckets = append([]LatencyBucket(nil), m.snapshot.Buckets...)
+	return snapshot
+}
+
+// String returns the metric as JSON, for expvar.
+func (m *LatencyMetric) String() string {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
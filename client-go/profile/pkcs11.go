@@ -0,0 +1,59 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/hsm"
+)
+
+// newPKCS11Sign creates a signing function using the private key of a PKCS#11 token whose subject key identifier
+// matches the public key of the client certificate.
+func newPKCS11Sign(certPath string, options PKCS11) (identity.Sign, func() error, error) {
+	certificate, err := loadCertificate(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported public key type %T in certificate %s", certificate.PublicKey, certPath)
+	}
+
+	library := options.Library
+	if library == "" {
+		if library, err = hsm.FindSoftHSMLibrary(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	factory, err := identity.NewHSMSignerFactory(library)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sign, hsmClose, err := factory.NewHSMSigner(identity.HSMSignerOptions{
+		Label:      options.Label,
+		Pin:        options.Pin,
+		Identifier: string(hsm.SKI(publicKey)),
+	})
+	if err != nil {
+		factory.Dispose()
+		return nil, nil, err
+	}
+
+	closeSign := func() error {
+		defer factory.Dispose()
+		return hsmClose()
+	}
+	return sign, closeSign, nil
+}
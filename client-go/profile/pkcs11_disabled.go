@@ -0,0 +1,20 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+func newPKCS11Sign(certPath string, options PKCS11) (identity.Sign, func() error, error) {
+	return nil, nil, errors.New("the pkcs11 signer requires building with the pkcs11 build tag")
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package profile loads the connection and identity settings of a Fabric Gateway client from a YAML or JSON client
+// profile, and creates the gRPC connection, client identity and signing function for a Gateway connection.
+//
+// A profile names the MSP ID and certificate of the client identity, the signer of the identity, and one or more
+// gateway peers of the organization. Requests are load balanced across the peers. Relative paths in a profile are
+// relative to the directory of the profile file:
+//
+//	mspId: Org1MSP
+//	certPath: users/User1@org1.example.com/msp/signcerts/cert.pem
+//	signer:
+//	  type: file
+//	  keyPath: users/User1@org1.example.com/msp/keystore
+//	peers:
+//	  - endpoint: localhost:7051
+//	    hostAlias: peer0.org1.example.com
+//	    tlsCertPath: peers/peer0.org1.example.com/tls/ca.crt
+//	keepalive:
+//	  time: 2m
+//	  timeout: 20s
+//
+// The following environment variables override the settings of the profile, so that a client can be pointed at
+// another network without changing its profile:
+//
+//	HLF_CLIENT_PROFILE       profile file to load instead of the one given by the client
+//	HLF_MSP_ID               MSP ID of the client identity
+//	HLF_CERT_PATH            certificate of the client identity
+//	HLF_SIGNER_TYPE          signer of the client identity: file, pkcs11, env, remote, offline or a registered type
+//	HLF_KEY_PATH             private key file, or directory containing a single private key, for the file signer
+//	HLF_PRIVATE_KEY          PEM private key used by the env signer
+//	HLF_PKCS11_LIBRARY       PKCS#11 library used by the pkcs11 signer
+//	HLF_PKCS11_LABEL         label of the PKCS#11 token
+//	HLF_PKCS11_PIN           PIN of the PKCS#11 token
+//	HLF_REMOTE_SIGNER_URL    URL of the signing service used by the remote signer
+//	HLF_REMOTE_SIGNER_KEY_ID ID of the key of the remote signer
+//	HLF_REMOTE_SIGNER_TOKEN  bearer token of the remote signer, which is never read from the profile file
+//	HLF_PEER_ENDPOINT        gateway peer endpoint, replacing the peers of the profile with a single peer
+//	HLF_PEER_HOST_ALIAS      TLS host name of the gateway peer
+//	HLF_TLS_CERT_PATH        TLS CA certificate of the gateway peer
+//
+// Paths set by environment variables are relative to the working directory. A client that loads a profile for each
+// of several organizations can give each profile its own variables with WithEnvSuffix.
+//
+// Other signer types can be added with RegisterSigner. Whatever the signer, the latency of each signing request is
+// recorded in the SignLatency metric, or a metric given to NewSign, and requests are audit logged if the application
+// enables it with WithAuditLog.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Signer types.
+const (
+	// FileSigner signs with a PEM private key read from a file.
+	FileSigner = "file"
+	// PKCS11Signer signs with a private key stored in a PKCS#11 token, such as an HSM. It requires building with the
+	// pkcs11 build tag.
+	PKCS11Signer = "pkcs11"
+	// EnvSigner signs with a PEM private key read from the HLF_PRIVATE_KEY environment variable.
+	EnvSigner = "env"
+	// RemoteSigner signs with a key held by a remote signing service, using the protocol of the remotesign package.
+	RemoteSigner = "remote"
+	// OfflineSigner is a client without a private key, whose requests are signed offline by another process.
+	OfflineSigner = "offline"
+)
+
+// Profile is the connection and identity settings of a Fabric Gateway client.
+type Profile struct {
+	MSPID     string    `yaml:"mspId"`
+	CertPath  string    `yaml:"certPath"`
+	Signer    Signer    `yaml:"signer"`
+	Peers     []Peer    `yaml:"peers"`
+	Keepalive Keepalive `yaml:"keepalive"`
+
+	// envSuffix is appended to the names of the environment variables read for the profile.
+	envSuffix string
+}
+
+// Signer is the private key used to sign the requests of the client identity.
+type Signer struct {
+	// Type is one of FileSigner, PKCS11Signer, EnvSigner, RemoteSigner, OfflineSigner or a type added with
+	// RegisterSigner.
+	Type string `yaml:"type"`
+	// KeyPath is the private key file, or a directory containing a single private key file, of a FileSigner.
+	KeyPath string `yaml:"keyPath"`
+	PKCS11  PKCS11 `yaml:"pkcs11"`
+	Remote  Remote `yaml:"remote"`
+	// Options are the settings of signer types added with RegisterSigner.
+	Options map[string]string `yaml:"options"`
+}
+
+// PKCS11 locates the private key of a PKCS11Signer. The key is identified by the subject key identifier of the
+// client certificate.
+type PKCS11 struct {
+	// Library is the PKCS#11 library. If it is empty, the PKCS11_LIB environment variable or else the usual locations
+	// of the SoftHSM library are used.
+	Library string `yaml:"library"`
+	Label   string `yaml:"label"`
+	Pin     string `yaml:"pin"`
+}
+
+// Remote locates the key of a RemoteSigner in a signing service.
+type Remote struct {
+	// URL is the base URL of the signing service, such as https://signer.example.com.
+	URL   string `yaml:"url"`
+	KeyID string `yaml:"keyId"`
+	// TLSCertPath is the TLS CA certificate of an https signing service. If it is empty, the system certificates are
+	// used.
+	TLSCertPath string `yaml:"tlsCertPath"`
+	// Timeout of each signing request. The default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Peer is a gateway peer of the client's organization.
+type Peer struct {
+	Endpoint string `yaml:"endpoint"`
+	// HostAlias is the host name in the TLS certificate of the peer, if it differs from the host of the endpoint.
+	HostAlias   string `yaml:"hostAlias"`
+	TLSCertPath string `yaml:"tlsCertPath"`
+}
+
+// Keepalive configures gRPC keepalive pings, which detect broken connections to the gateway peers. Pings are
+// disabled if Time is zero.
+type Keepalive struct {
+	Time                time.Duration `yaml:"time"`
+	Timeout             time.Duration `yaml:"timeout"`
+	PermitWithoutStream bool          `yaml:"permitWithoutStream"`
+}
+
+// LoadOption configures how Load reads a profile.
+type LoadOption func(options *loadOptions)
+
+type loadOptions struct {
+	envSuffix string
+}
+
+// WithEnvSuffix appends a suffix to the names of all the environment variables read for the profile, including those
+// read by its signer. For example, with the suffix _ORG1 the gateway peer endpoint is overridden by
+// HLF_PEER_ENDPOINT_ORG1 instead of HLF_PEER_ENDPOINT.
+func WithEnvSuffix(suffix string) LoadOption {
+	return func(options *loadOptions) {
+		options.envSuffix = suffix
+	}
+}
+
+// Load reads a YAML or JSON client profile, applies the environment variable overrides and checks that the profile
+// is complete.
+func Load(fileName string, options ...LoadOption) (*Profile, error) {
+	loadOptions := &loadOptions{}
+	for _, option := range options {
+		option(loadOptions)
+	}
+
+	p := &Profile{envSuffix: loadOptions.envSuffix}
+	if value, ok := p.lookupEnv("HLF_CLIENT_PROFILE"); ok {
+		fileName = value
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client profile: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse client profile %s: %w", fileName, err)
+	}
+	p.resolvePaths(filepath.Dir(fileName))
+	p.applyEnv()
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid client profile %s: %w", fileName, err)
+	}
+	return p, nil
+}
+
+// resolvePaths makes the relative paths of the profile relative to its directory.
+func (p *Profile) resolvePaths(dir string) {
+	resolve := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+
+	resolve(&p.CertPath)
+	resolve(&p.Signer.KeyPath)
+	resolve(&p.Signer.Remote.TLSCertPath)
+	for i := range p.Peers {
+		resolve(&p.Peers[i].TLSCertPath)
+	}
+}
+
+// lookupEnv returns the value of an environment variable of the profile, whose name ends with the suffix given by
+// WithEnvSuffix.
+func (p *Profile) lookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key + p.envSuffix)
+}
+
+func (p *Profile) applyEnv() {
+	override := func(key string, value *string) {
+		if envValue, ok := p.lookupEnv(key); ok {
+			*value = envValue
+		}
+	}
+
+	override("HLF_MSP_ID", &p.MSPID)
+	override("HLF_CERT_PATH", &p.CertPath)
+	override("HLF_SIGNER_TYPE", &p.Signer.Type)
+	override("HLF_KEY_PATH", &p.Signer.KeyPath)
+	override("HLF_PKCS11_LIBRARY", &p.Signer.PKCS11.Library)
+	override("HLF_PKCS11_LABEL", &p.Signer.PKCS11.Label)
+	override("HLF_PKCS11_PIN", &p.Signer.PKCS11.Pin)
+	override("HLF_REMOTE_SIGNER_URL", &p.Signer.Remote.URL)
+	override("HLF_REMOTE_SIGNER_KEY_ID", &p.Signer.Remote.KeyID)
+
+	if endpoint, ok := p.lookupEnv("HLF_PEER_ENDPOINT"); ok {
+		peer := Peer{Endpoint: endpoint}
+		if len(p.Peers) > 0 {
+			peer.HostAlias = p.Peers[0].HostAlias
+			peer.TLSCertPath = p.Peers[0].TLSCertPath
+		}
+		p.Peers = []Peer{peer}
+	}
+	for i := range p.Peers {
+		override("HLF_PEER_HOST_ALIAS", &p.Peers[i].HostAlias)
+		override("HLF_TLS_CERT_PATH", &p.Peers[i].TLSCertPath)
+	}
+}
+
+func (p *Profile) validate() error {
+	if p.MSPID == "" {
+		return errors.New("missing mspId")
+	}
+	if p.CertPath == "" {
+		return errors.New("missing certPath")
+	}
+
+	switch p.Signer.Type {
+	case FileSigner:
+		if p.Signer.KeyPath == "" {
+			return errors.New("missing signer keyPath")
+		}
+	case PKCS11Signer:
+		if p.Signer.PKCS11.Label == "" {
+			return errors.New("missing signer pkcs11 label")
+		}
+	case RemoteSigner:
+		if p.Signer.Remote.URL == "" || p.Signer.Remote.KeyID == "" {
+			return errors.New("missing signer remote url or keyId")
+		}
+	default:
+		if _, ok := signerProvider(p.Signer.Type); !ok {
+			return fmt.Errorf("invalid signer type %q, must be one of %s", p.Signer.Type, strings.Join(signerTypes(), ", "))
+		}
+	}
+
+	if len(p.Peers) == 0 {
+		return errors.New("missing peers")
+	}
+	for i, peer := range p.Peers {
+		if peer.Endpoint == "" {
+			return fmt.Errorf("missing endpoint of peer %d", i)
+		}
+		if peer.TLSCertPath == "" {
+			return fmt.Errorf("missing tlsCertPath of peer %s", peer.Endpoint)
+		}
+	}
+
+	if p.Keepalive.Time < 0 || p.Keepalive.Timeout < 0 {
+		return errors.New("keepalive time and timeout must not be negative")
+	}
+	return nil
+}
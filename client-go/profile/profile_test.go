@@ -0,0 +1,443 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/remotesign"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// writeCertificate writes a self-signed certificate for a host name, and its private key, to a directory.
+func writeCertificate(t *testing.T, dir string, hostName string) (certPath string, keyPath string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: hostName},
+		DNSNames:              []string{hostName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, hostName+".pem")
+	keyPath = filepath.Join(dir, hostName+"_sk")
+	writeFile(t, certPath, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER})))
+	writeFile(t, keyPath, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyDER})))
+	return certPath, keyPath
+}
+
+func writeFile(t *testing.T, fileName string, content string) {
+	if err := os.WriteFile(fileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const yamlProfile = `
+mspId: Org1MSP
+certPath: users/User1/cert.pem
+signer:
+  type: file
+  keyPath: users/User1/keystore
+peers:
+  - endpoint: localhost:7051
+    hostAlias: peer0.org1.example.com
+    tlsCertPath: peers/peer0/ca.crt
+  - endpoint: localhost:7061
+    tlsCertPath: /tls/ca.crt
+keepalive:
+  time: 2m
+  timeout: 20s
+`
+
+const jsonProfile = `{
+  "mspId": "Org1MSP",
+  "certPath": "users/User1/cert.pem",
+  "signer": {"type": "file", "keyPath": "users/User1/keystore"},
+  "peers": [
+    {"endpoint": "localhost:7051", "hostAlias": "peer0.org1.example.com", "tlsCertPath": "peers/peer0/ca.crt"},
+    {"endpoint": "localhost:7061", "tlsCertPath": "/tls/ca.crt"}
+  ],
+  "keepalive": {"time": "2m", "timeout": "20s"}
+}`
+
+func TestLoad(t *testing.T) {
+	for name, content := range map[string]string{"yaml": yamlProfile, "json": jsonProfile} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			fileName := filepath.Join(dir, "clientProfile."+name)
+			writeFile(t, fileName, content)
+
+			p, err := Load(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := &Profile{
+				MSPID:    "Org1MSP",
+				CertPath: filepath.Join(dir, "users/User1/cert.pem"),
+				Signer:   Signer{Type: FileSigner, KeyPath: filepath.Join(dir, "users/User1/keystore")},
+				Peers: []Peer{
+					{Endpoint: "localhost:7051", HostAlias: "peer0.org1.example.com", TLSCertPath: filepath.Join(dir, "peers/peer0/ca.crt")},
+					{Endpoint: "localhost:7061", TLSCertPath: "/tls/ca.crt"},
+				},
+				Keepalive: Keepalive{Time: 2 * time.Minute, Timeout: 20 * time.Second},
+			}
+			if !reflect.DeepEqual(p, expected) {
+				t.Errorf("expected %+v, got %+v", expected, p)
+			}
+		})
+	}
+}
+
+func TestLoadAppliesEnvironmentOverrides(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "clientProfile.yaml")
+	writeFile(t, fileName, yamlProfile)
+
+	t.Setenv("HLF_MSP_ID", "Org2MSP")
+	t.Setenv("HLF_SIGNER_TYPE", PKCS11Signer)
+	t.Setenv("HLF_PKCS11_LABEL", "ForFabric")
+	t.Setenv("HLF_PEER_ENDPOINT", "localhost:9051")
+	t.Setenv("HLF_PEER_HOST_ALIAS", "peer0.org2.example.com")
+
+	p, err := Load(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.MSPID != "Org2MSP" || p.Signer.Type != PKCS11Signer || p.Signer.PKCS11.Label != "ForFabric" {
+		t.Errorf("expected overridden identity settings, got %+v", p)
+	}
+	expectedPeers := []Peer{{Endpoint: "localhost:9051", HostAlias: "peer0.org2.example.com", TLSCertPath: filepath.Join(dir, "peers/peer0/ca.crt")}}
+	if !reflect.DeepEqual(p.Peers, expectedPeers) {
+		t.Errorf("expected peers %+v, got %+v", expectedPeers, p.Peers)
+	}
+}
+
+func TestLoadWithEnvSuffixReadsOnlySuffixedOverrides(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "clientProfile.yaml")
+	writeFile(t, fileName, yamlProfile)
+
+	t.Setenv("HLF_MSP_ID", "Org3MSP")
+	t.Setenv("HLF_MSP_ID_ORG2", "Org2MSP")
+	t.Setenv("HLF_PEER_ENDPOINT_ORG2", "localhost:9051")
+
+	p, err := Load(fileName, WithEnvSuffix("_ORG2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.MSPID != "Org2MSP" {
+		t.Errorf("expected MSP ID Org2MSP, got %s", p.MSPID)
+	}
+	if len(p.Peers) != 1 || p.Peers[0].Endpoint != "localhost:9051" {
+		t.Errorf("expected peer localhost:9051, got %+v", p.Peers)
+	}
+}
+
+func TestLoadRejectsInvalidProfiles(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing MSP ID":      "certPath: cert.pem\nsigner: {type: env}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]",
+		"missing certificate": "mspId: Org1MSP\nsigner: {type: env}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]",
+		"unknown signer":      "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: kms}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]",
+		"missing key path":    "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: file}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]",
+		"missing remote key":  "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: remote, remote: {url: http://localhost:8090}}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]",
+		"missing peers":       "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: env}",
+		"missing TLS cert":    "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: env}\npeers: [{endpoint: localhost:7051}]",
+		"invalid duration":    "mspId: Org1MSP\ncertPath: cert.pem\nsigner: {type: env}\npeers: [{endpoint: localhost:7051, tlsCertPath: ca.crt}]\nkeepalive: {time: often}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "clientProfile.yaml")
+			writeFile(t, fileName, content)
+
+			if _, err := Load(fileName); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestNewIdentityAndSign(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificate(t, dir, "User1@org1.example.com")
+	keystore := filepath.Join(dir, "keystore")
+	if err := os.Mkdir(keystore, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(keyPath, filepath.Join(keystore, "priv_sk")); err != nil {
+		t.Fatal(err)
+	}
+	privateKeyPEM, err := os.ReadFile(filepath.Join(keystore, "priv_sk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingService := startSigningService(t, privateKeyPEM)
+
+	for name, signer := range map[string]Signer{
+		"file":      {Type: FileSigner, KeyPath: filepath.Join(keystore, "priv_sk")},
+		"directory": {Type: FileSigner, KeyPath: keystore},
+		"env":       {Type: EnvSigner},
+		"remote":    {Type: RemoteSigner, Remote: signingService},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HLF_PRIVATE_KEY", string(privateKeyPEM))
+			t.Setenv("HLF_REMOTE_SIGNER_TOKEN", "secret")
+			p := &Profile{MSPID: "Org1MSP", CertPath: certPath, Signer: signer}
+
+			id, err := p.NewIdentity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.MspID() != "Org1MSP" {
+				t.Errorf("expected MSP ID Org1MSP, got %s", id.MspID())
+			}
+
+			sign, closeSign, err := p.NewSign()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer closeSign()
+
+			digest := sha256.Sum256([]byte("message"))
+			signature, err := sign(digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			certificate, err := identity.CertificateFromPEM(id.Credentials())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ecdsa.VerifyASN1(certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+				t.Error("signature does not verify with the certificate of the identity")
+			}
+		})
+	}
+}
+
+// startSigningService starts a mock TLS signing service holding a private key with ID User1, and returns the settings
+// of a remote signer for the key.
+func startSigningService(t *testing.T, privateKeyPEM []byte) Remote {
+	sign, err := newPEMSign(privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewTLSServer(remotesign.NewHandler(map[string]identity.Sign{"User1": sign}, "secret"))
+	t.Cleanup(server.Close)
+
+	tlsCertPath := filepath.Join(t.TempDir(), "signer-ca.pem")
+	writeFile(t, tlsCertPath, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	return Remote{URL: server.URL, KeyID: "User1", TLSCertPath: tlsCertPath, Timeout: 5 * time.Second}
+}
+
+func TestNewSignAuditsAndMeasuresSigningRequests(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificate(t, dir, "User1@org1.example.com")
+	privateKeyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HLF_REMOTE_SIGNER_TOKEN", "secret")
+	remote := startSigningService(t, privateKeyPEM)
+	unknownKey := remote
+	unknownKey.KeyID = "User2"
+
+	var auditLog bytes.Buffer
+	latency := NewLatencyMetric()
+	digest := sha256.Sum256([]byte("message"))
+	for _, remote := range []Remote{remote, unknownKey} {
+		p := &Profile{MSPID: "Org1MSP", CertPath: certPath, Signer: Signer{Type: RemoteSigner, Remote: remote}}
+		sign, closeSign, err := p.NewSign(WithAuditLog(log.New(&auditLog, "", 0)), WithLatencyMetric(latency))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sign(digest[:]) //nolint:errcheck // the result is checked through the audit log and metric
+		closeSign()
+	}
+
+	records := strings.Split(strings.TrimSpace(auditLog.String()), "\n")
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audit records, got %q", auditLog.String())
+	}
+	expected := fmt.Sprintf("signer=remote mspId=Org1MSP digest=%x latency=", digest)
+	for _, record := range records {
+		if !strings.HasPrefix(record, expected) {
+			t.Errorf("expected audit record starting with %q, got %q", expected, record)
+		}
+	}
+	if !strings.HasSuffix(records[0], "result=ok") || !strings.Contains(records[1], "result=error: ") {
+		t.Errorf("unexpected audit results: %q", records)
+	}
+
+	snapshot := latency.Snapshot()
+	if snapshot.Count != 2 || snapshot.Errors != 1 || snapshot.Total <= 0 || snapshot.Max <= 0 {
+		t.Errorf("unexpected latency snapshot: %+v", snapshot)
+	}
+	if last := snapshot.Buckets[len(snapshot.Buckets)-1]; last.Count != 2 {
+		t.Errorf("expected both requests within %s, got %d", last.UpperBound, last.Count)
+	}
+}
+
+var registerTestSigner sync.Once
+
+func TestRegisterSignerAddsSignerType(t *testing.T) {
+	registerTestSigner.Do(func() {
+		RegisterSigner("test-kms", func(p *Profile) (identity.Sign, func() error, error) {
+			sign, err := newFileSign(p.Signer.Options["keyFile"])
+			return sign, noClose, err
+		})
+	})
+
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificate(t, dir, "User1@org1.example.com")
+	fileName := filepath.Join(dir, "clientProfile.yaml")
+	writeFile(t, fileName, fmt.Sprintf(`
+mspId: Org1MSP
+certPath: %s
+signer:
+  type: test-kms
+  options:
+    keyFile: %s
+peers:
+  - endpoint: localhost:7051
+    tlsCertPath: ca.crt
+`, certPath, keyPath))
+
+	p, err := Load(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, closeSign, err := p.NewSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSign()
+
+	digest := sha256.Sum256([]byte("message"))
+	if _, err := sign(digest[:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSignRejectsKeyDirectoryWithSeveralFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "key1_sk"), "")
+	writeFile(t, filepath.Join(dir, "key2_sk"), "")
+	p := &Profile{Signer: Signer{Type: FileSigner, KeyPath: dir}}
+
+	if _, _, err := p.NewSign(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// countingServer is a TLS gRPC server that counts the requests it receives.
+type countingServer struct {
+	mutex    sync.Mutex
+	requests int
+}
+
+func (s *countingServer) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+func startServer(t *testing.T, dir string, hostName string) (*countingServer, Peer) {
+	certPath, keyPath := writeCertificate(t, dir, hostName)
+	certificate, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter := &countingServer{}
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewServerTLSFromCert(&certificate)),
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			counter.mutex.Lock()
+			defer counter.mutex.Unlock()
+			counter.requests++
+			return status.Error(codes.Unimplemented, "counted")
+		}),
+	)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener) //nolint:errcheck // Serve returns when the server stops
+	t.Cleanup(server.Stop)
+
+	return counter, Peer{Endpoint: listener.Addr().String(), HostAlias: hostName, TLSCertPath: certPath}
+}
+
+func TestNewConnectionBalancesRequestsAcrossPeers(t *testing.T) {
+	dir := t.TempDir()
+	server0, peer0 := startServer(t, dir, "peer0.org1.example.com")
+	server1, peer1 := startServer(t, dir, "peer1.org1.example.com")
+	p := &Profile{MSPID: "Org1MSP", Peers: []Peer{peer0, peer1}, Keepalive: Keepalive{Time: time.Minute, Timeout: time.Second}}
+
+	connection, err := p.NewConnection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := 0; i < 100 && (server0.count() == 0 || server1.count() == 0); i++ {
+		err := connection.Invoke(ctx, "/test.Service/Method", &emptypb.Empty{}, &emptypb.Empty{}, grpc.WaitForReady(true))
+		if status.Code(err) != codes.Unimplemented {
+			t.Fatalf("expected request to reach a peer, got %v", err)
+		}
+	}
+
+	if server0.count() == 0 || server1.count() == 0 {
+		t.Errorf("expected requests to both peers, got %d and %d", server0.count(), server1.count())
+	}
+}
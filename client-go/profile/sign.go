@@ -0,0 +1,232 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-samples/client-go/remotesign"
+)
+
+// SignerProvider creates the signing function of the client identity of a profile, and a function that releases the
+// resources of the signer.
+type SignerProvider func(p *Profile) (identity.Sign, func() error, error)
+
+var (
+	signersMutex sync.RWMutex
+	signers      = map[string]SignerProvider{}
+)
+
+func init() {
+	RegisterSigner(FileSigner, func(p *Profile) (identity.Sign, func() error, error) {
+		sign, err := newFileSign(p.Signer.KeyPath)
+		return sign, noClose, err
+	})
+	RegisterSigner(EnvSigner, func(p *Profile) (identity.Sign, func() error, error) {
+		sign, err := newEnvSign(p)
+		return sign, noClose, err
+	})
+	RegisterSigner(PKCS11Signer, func(p *Profile) (identity.Sign, func() error, error) {
+		return newPKCS11Sign(p.CertPath, p.Signer.PKCS11)
+	})
+	RegisterSigner(RemoteSigner, func(p *Profile) (identity.Sign, func() error, error) {
+		sign, err := newRemoteSign(p)
+		return sign, noClose, err
+	})
+	RegisterSigner(OfflineSigner, func(p *Profile) (identity.Sign, func() error, error) {
+		return nil, nil, errors.New("the offline signer has no signing function, requests must be signed offline")
+	})
+}
+
+// RegisterSigner makes a signer type available to client profiles, for example to sign with a key management service
+// that has its own client library. The settings of the signer are read from the options of the profile signer.
+// RegisterSigner should be called from an init function, and panics if the type is already registered.
+func RegisterSigner(signerType string, provider SignerProvider) {
+	signersMutex.Lock()
+	defer signersMutex.Unlock()
+
+	if signerType == "" || provider == nil {
+		panic("profile: RegisterSigner requires a signer type and provider")
+	}
+	if _, exists := signers[signerType]; exists {
+		panic("profile: RegisterSigner called twice for signer type " + signerType)
+	}
+	signers[signerType] = provider
+}
+
+func signerProvider(signerType string) (SignerProvider, bool) {
+	signersMutex.RLock()
+	defer signersMutex.RUnlock()
+
+	provider, ok := signers[signerType]
+	return provider, ok
+}
+
+// signerTypes returns the registered signer types in order.
+func signerTypes() []string {
+	signersMutex.RLock()
+	defer signersMutex.RUnlock()
+
+	types := make([]string, 0, len(signers))
+	for signerType := range signers {
+		types = append(types, signerType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// SignOption configures the signing function created by NewSign.
+type SignOption func(options *signOptions)
+
+type signOptions struct {
+	auditLog *log.Logger
+	latency  LatencyObserver
+}
+
+// WithAuditLog enables audit logging, writing a record for each signing request to the logger. Signing requests are
+// not audit logged by default.
+func WithAuditLog(logger *log.Logger) SignOption {
+	return func(options *signOptions) {
+		options.auditLog = logger
+	}
+}
+
+// WithLatencyMetric sets the metric that records the latency of signing requests, which can be a *LatencyMetric or
+// an adapter to the metrics library of the application. The default is SignLatency, and nil disables the metric.
+func WithLatencyMetric(metric LatencyObserver) SignOption {
+	return func(options *signOptions) {
+		options.latency = metric
+	}
+}
+
+// NewSign creates the function that signs the message digests of the client identity with the signer of the profile.
+// The latency of each signing request is recorded, and requests are audit logged if enabled with WithAuditLog. The
+// returned close function releases the resources of the signer, such as PKCS#11 sessions, and must be called when the
+// signing function is no longer used.
+func (p *Profile) NewSign(options ...SignOption) (identity.Sign, func() error, error) {
+	provider, ok := signerProvider(p.Signer.Type)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid signer type %q", p.Signer.Type)
+	}
+
+	signOptions := &signOptions{latency: SignLatency}
+	for _, option := range options {
+		option(signOptions)
+	}
+
+	sign, closeSign, err := provider(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.audited(sign, signOptions), closeSign, nil
+}
+
+// audited wraps a signing function to measure and, if enabled, audit log each signing request. The audit record
+// identifies the signer and the digest, but never contains the signature.
+func (p *Profile) audited(sign identity.Sign, options *signOptions) identity.Sign {
+	return func(digest []byte) ([]byte, error) {
+		start := time.Now()
+		signature, err := sign(digest)
+		latency := time.Since(start)
+
+		if options.latency != nil {
+			options.latency.Observe(latency, err)
+		}
+		if options.auditLog != nil {
+			result := "ok"
+			if err != nil {
+				result = "error: " + err.Error()
+			}
+			options.auditLog.Printf("signer=%s mspId=%s digest=%x latency=%s result=%s", p.Signer.Type, p.MSPID, digest, latency, result)
+		}
+		return signature, err
+	}
+}
+
+func noClose() error {
+	return nil
+}
+
+// newFileSign creates a signing function using a PEM private key read from a file, or from the only file of a
+// directory such as the keystore directory of an MSP.
+func newFileSign(keyPath string) (identity.Sign, error) {
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	if info.IsDir() {
+		files, err := os.ReadDir(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key directory: %w", err)
+		}
+		if len(files) != 1 {
+			return nil, fmt.Errorf("expected a single private key file in directory %s, found %d files", keyPath, len(files))
+		}
+		keyPath = filepath.Join(keyPath, files[0].Name())
+	}
+
+	privateKeyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	return newPEMSign(privateKeyPEM)
+}
+
+// newEnvSign creates a signing function using a PEM private key read from the HLF_PRIVATE_KEY environment variable,
+// so that the key need not be stored in a file.
+func newEnvSign(p *Profile) (identity.Sign, error) {
+	privateKeyPEM, _ := p.lookupEnv("HLF_PRIVATE_KEY")
+	if privateKeyPEM == "" {
+		return nil, fmt.Errorf("HLF_PRIVATE_KEY%s must be set for the env signer", p.envSuffix)
+	}
+	return newPEMSign([]byte(privateKeyPEM))
+}
+
+func newPEMSign(privateKeyPEM []byte) (identity.Sign, error) {
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return identity.NewPrivateKeySign(privateKey)
+}
+
+// newRemoteSign creates a signing function using a key of a remote signing service. The bearer token is read from the
+// HLF_REMOTE_SIGNER_TOKEN environment variable, so that it need not be stored in the profile.
+func newRemoteSign(p *Profile) (identity.Sign, error) {
+	remote := p.Signer.Remote
+	certificate, err := loadCertificate(p.CertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	token, _ := p.lookupEnv("HLF_REMOTE_SIGNER_TOKEN")
+	options := []remotesign.ClientOption{remotesign.WithToken(token)}
+	if remote.Timeout > 0 {
+		options = append(options, remotesign.WithTimeout(remote.Timeout))
+	}
+	if remote.TLSCertPath != "" {
+		certificate, err := loadCertificate(remote.TLSCertPath)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, remotesign.WithRootCertificates(certificate))
+	}
+
+	client, err := remotesign.NewClient(remote.URL, remote.KeyID, certificate, options...)
+	if err != nil {
+		return nil, err
+	}
+	return client.Sign, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package remotesign signs the message digests of a Fabric client identity with a remote signing service, such as a
+// key management service, so that the private key never leaves the service.
+//
+// The protocol is a single HTTP request. The client posts the SHA-256 digest to sign and the ID of the key to the
+// /v1/sign path of the service, with an optional bearer token:
+//
+//	POST /v1/sign
+//	Authorization: Bearer <token>
+//	Content-Type: application/json
+//
+//	{"keyId": "User1@org1.example.com", "digest": "<base64 digest>"}
+//
+// The service responds with the ASN.1 DER encoded ECDSA signature, with a low S value as Fabric requires:
+//
+//	{"signature": "<base64 signature>"}
+//
+// Errors are returned with a 4xx or 5xx status code and a JSON body {"error": "<message>"}. The client verifies each
+// signature with the public key of the client certificate, so that a wrong key ID or a faulty service is detected
+// before a badly signed request is sent. NewHandler implements the service with keys held in memory, as a mock for
+// testing clients without an external service.
+package remotesign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignPath is the path of the signing request.
+const SignPath = "/v1/sign"
+
+type signRequest struct {
+	KeyID  string `json:"keyId"`
+	Digest []byte `json:"digest"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Client signs digests with a key of a remote signing service. It is safe for concurrent use.
+type Client struct {
+	url        string
+	keyID      string
+	publicKey  *ecdsa.PublicKey
+	token      string
+	httpClient *http.Client
+}
+
+// ClientOption configures a Client.
+type ClientOption func(c *Client) error
+
+// WithToken sets the bearer token sent with each signing request.
+func WithToken(token string) ClientOption {
+	return func(c *Client) error {
+		c.token = token
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each signing request. The default is 10 seconds.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.httpClient.Timeout = timeout
+		return nil
+	}
+}
+
+// WithRootCertificates sets the CA certificates that verify the TLS certificate of an https service, instead of the
+// system certificates.
+func WithRootCertificates(certificates ...*x509.Certificate) ClientOption {
+	return func(c *Client) error {
+		certPool := x509.NewCertPool()
+		for _, certificate := range certificates {
+			certPool.AddCert(certificate)
+		}
+		c.httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12}}
+		return nil
+	}
+}
+
+// NewClient creates a client for a key of the signing service at a base URL, such as https://signer.example.com. The
+// certificate is the client certificate of the key, whose ECDSA public key verifies the signatures of the service.
+func NewClient(url string, keyID string, certificate *x509.Certificate, options ...ClientOption) (*Client, error) {
+	if url == "" {
+		return nil, errors.New("missing signing service URL")
+	}
+	if keyID == "" {
+		return nil, errors.New("missing signing service key ID")
+	}
+	if certificate == nil {
+		return nil, errors.New("missing certificate of the signing service key")
+	}
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T in certificate of the signing service key", certificate.PublicKey)
+	}
+
+	c := &Client{
+		url:        strings.TrimSuffix(url, "/") + SignPath,
+		keyID:      keyID,
+		publicKey:  publicKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Sign signs a message digest with the remote key, and checks that the signature verifies with the public key of the
+// certificate. It has the signature of identity.Sign.
+func (c *Client) Sign(digest []byte) ([]byte, error) {
+	body, err := json.Marshal(&signRequest{KeyID: c.keyID, Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("signing request failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		message := &errorResponse{}
+		if err := json.Unmarshal(responseBody, message); err != nil || message.Error == "" {
+			message.Error = http.StatusText(response.StatusCode)
+		}
+		return nil, fmt.Errorf("signing service returned status %d: %s", response.StatusCode, message.Error)
+	}
+
+	result := &signResponse{}
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return nil, fmt.Errorf("failed to parse signing response: %w", err)
+	}
+	if len(result.Signature) == 0 {
+		return nil, errors.New("signing service returned an empty signature")
+	}
+	if !ecdsa.VerifyASN1(c.publicKey, digest, result.Signature) {
+		return nil, fmt.Errorf("signature of key %s does not verify with the public key of the certificate", c.keyID)
+	}
+	return result.Signature, nil
+}
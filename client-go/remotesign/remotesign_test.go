@@ -0,0 +1,102 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remotesign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// newKey generates a private key with a self-signed certificate, and returns the certificate and the signing function
+// of the key.
+func newKey(t *testing.T) (*x509.Certificate, identity.Sign) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "User1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate, sign
+}
+
+func TestClientSignsWithRemoteKey(t *testing.T) {
+	certificate, sign := newKey(t)
+	server := httptest.NewTLSServer(NewHandler(map[string]identity.Sign{"User1": sign}, "secret"))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "User1", certificate, WithToken("secret"), WithRootCertificates(server.Certificate()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := client.Sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		t.Fatal("signature does not verify")
+	}
+}
+
+func TestClientReturnsServiceErrors(t *testing.T) {
+	certificate, sign := newKey(t)
+	_, otherSign := newKey(t)
+	server := httptest.NewServer(NewHandler(map[string]identity.Sign{"User1": sign, "Admin": otherSign}, "secret"))
+	defer server.Close()
+	digest := sha256.Sum256([]byte("message"))
+
+	for name, test := range map[string]struct {
+		keyID    string
+		token    string
+		digest   []byte
+		expected string
+	}{
+		"invalid token":              {keyID: "User1", token: "wrong", digest: digest[:], expected: "status 401: invalid bearer token"},
+		"unknown key":                {keyID: "User2", token: "secret", digest: digest[:], expected: `status 404: unknown key "User2"`},
+		"invalid digest":             {keyID: "User1", token: "secret", digest: []byte("message"), expected: "status 400: digest must be 32 bytes"},
+		"key of another certificate": {keyID: "Admin", token: "secret", digest: digest[:], expected: "signature of key Admin does not verify with the public key of the certificate"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, err := NewClient(server.URL, test.keyID, certificate, WithToken(test.token))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.Sign(test.digest)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remotesign
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// NewHandler creates a mock signing service that signs with keys held in memory, indexed by key ID. If token is not
+// empty, requests must carry it as a bearer token. The handler is meant for testing, not for protecting real keys.
+func NewHandler(keys map[string]identity.Sign, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(SignPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		request := &signRequest{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(request); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			return
+		}
+		if len(request.Digest) != sha256.Size {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("digest must be %d bytes, got %d", sha256.Size, len(request.Digest)))
+			return
+		}
+		sign, ok := keys[request.KeyID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("unknown key %q", request.KeyID))
+			return
+		}
+
+		signature, err := sign(request.Digest)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, &signResponse{Signature: signature})
+	})
+	return mux
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, &errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(value) //nolint:errcheck // the client has gone if the response cannot be written
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package submit
+
+import (
+	"context"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Contract creates transaction proposals. NewContract adapts a *client.Contract, and tests use fakes.
+type Contract interface {
+	NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error)
+}
+
+// Proposal is a transaction proposal, which is endorsed by the gateway peer.
+type Proposal interface {
+	TransactionID() string
+	Endorse(ctx context.Context) (Transaction, error)
+}
+
+// Transaction is an endorsed transaction, which is submitted to the orderer.
+type Transaction interface {
+	Result() []byte
+	Submit(ctx context.Context) (Commit, error)
+}
+
+// Commit is a submitted transaction, whose commit status can be read any number of times.
+type Commit interface {
+	Status(ctx context.Context) (*client.Status, error)
+}
+
+// NewContract adapts a Fabric Gateway contract to the Contract interface.
+func NewContract(contract *client.Contract) Contract {
+	return &gatewayContract{contract: contract}
+}
+
+type gatewayContract struct {
+	contract *client.Contract
+}
+
+func (c *gatewayContract) NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error) {
+	proposal, err := c.contract.NewProposal(transactionName, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayProposal{proposal: proposal}, nil
+}
+
+type gatewayProposal struct {
+	proposal *client.Proposal
+}
+
+func (p *gatewayProposal) TransactionID() string {
+	return p.proposal.TransactionID()
+}
+
+func (p *gatewayProposal) Endorse(ctx context.Context) (Transaction, error) {
+	transaction, err := p.proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayTransaction{transaction: transaction}, nil
+}
+
+type gatewayTransaction struct {
+	transaction *client.Transaction
+}
+
+func (t *gatewayTransaction) Result() []byte {
+	return t.transaction.Result()
+}
+
+func (t *gatewayTransaction) Submit(ctx context.Context) (Commit, error) {
+	commit, err := t.transaction.SubmitWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayCommit{commit: commit}, nil
+}
+
+type gatewayCommit struct {
+	commit *client.Commit
+}
+
+func (c *gatewayCommit) Status(ctx context.Context) (*client.Status, error) {
+	return c.commit.StatusWithContext(ctx)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package submit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Kind classifies the failure of a transaction submission.
+type Kind int
+
+const (
+	// Unknown is any failure that is not otherwise classified. It is not retried.
+	Unknown Kind = iota
+	// ChaincodeError is an error returned by the smart contract. It is not retried, since the smart contract would
+	// return the same error again.
+	ChaincodeError
+	// EndorsementMismatch is a failure to assemble the transaction because the endorsing peers returned different
+	// results, for instance because their ledgers were at different heights. It is retried.
+	EndorsementMismatch
+	// MVCCConflict is a transaction that committed as invalid because the keys it read were changed by another
+	// transaction after it was endorsed. It is retried.
+	MVCCConflict
+	// Timeout is a deadline exceeded before the transaction completed. It is retried if the transaction was not yet
+	// submitted to the orderer.
+	Timeout
+	// Unavailable is a gateway peer that could not be reached. It is retried if the transaction was not yet submitted
+	// to the orderer.
+	Unavailable
+	// DuplicateTransaction is a transaction whose ID was already used, because the transaction was submitted before.
+	// It is not retried.
+	DuplicateTransaction
+)
+
+func (k Kind) String() string {
+	switch k {
+	case ChaincodeError:
+		return "chaincode error"
+	case EndorsementMismatch:
+		return "endorsement mismatch"
+	case MVCCConflict:
+		return "MVCC conflict"
+	case Timeout:
+		return "timeout"
+	case Unavailable:
+		return "unavailable"
+	case DuplicateTransaction:
+		return "duplicate transaction"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is the failure of a transaction submission.
+type Error struct {
+	Kind Kind
+	// TransactionID of the last attempt.
+	TransactionID string
+	// Attempts is the number of times the transaction was endorsed.
+	Attempts int
+	// MayBeCommitted reports that the transaction was sent to the orderer but its commit status is unknown. Such a
+	// transaction is never submitted again, and its commit status can be checked later using its transaction ID.
+	MayBeCommitted bool
+	err            error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s submitting transaction %s after %d attempts: %v", e.Kind, e.TransactionID, e.Attempts, e.err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func (e *Error) retryable() bool {
+	if e.MayBeCommitted {
+		return false
+	}
+	switch e.Kind {
+	case EndorsementMismatch, MVCCConflict, Timeout, Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// Classify returns the kind of an error returned by the Fabric Gateway client API, such as a *client.EndorseError or
+// a *client.CommitError.
+func Classify(err error) Kind {
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		return kindOfValidationCode(commitErr.Code)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+
+	for _, message := range errorMessages(err) {
+		if strings.Contains(message, chaincodeResponsePrefix) {
+			return ChaincodeError
+		}
+		if strings.Contains(message, "ProposalResponsePayloads do not match") {
+			return EndorsementMismatch
+		}
+		if strings.Contains(message, "duplicate transaction found") {
+			return DuplicateTransaction
+		}
+	}
+
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return Timeout
+	case codes.Unavailable:
+		return Unavailable
+	default:
+		return Unknown
+	}
+}
+
+func kindOfValidationCode(code peer.TxValidationCode) Kind {
+	switch code {
+	case peer.TxValidationCode_MVCC_READ_CONFLICT, peer.TxValidationCode_PHANTOM_READ_CONFLICT:
+		return MVCCConflict
+	case peer.TxValidationCode_DUPLICATE_TXID:
+		return DuplicateTransaction
+	default:
+		return Unknown
+	}
+}
+
+const chaincodeResponsePrefix = "chaincode response "
+
+// ChaincodeMessage returns the message of an error returned by the smart contract, such as
+// "the asset asset1 does not exist", and whether the error is a ChaincodeError.
+func ChaincodeMessage(err error) (string, bool) {
+	for _, message := range errorMessages(err) {
+		if index := strings.Index(message, chaincodeResponsePrefix); index >= 0 {
+			// Strip the response status, as in "chaincode response 500, the asset asset1 does not exist"
+			message = message[index+len(chaincodeResponsePrefix):]
+			if comma := strings.Index(message, ", "); comma >= 0 {
+				message = message[comma+2:]
+			}
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// errorMessages returns the message of a gRPC status error, and the messages of the peers and orderers that caused
+// it.
+func errorMessages(err error) []string {
+	statusErr := status.Convert(err)
+	messages := []string{statusErr.Message()}
+	for _, detail := range statusErr.Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			messages = append(messages, errorDetail.GetMessage())
+		}
+	}
+	return messages
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package submit submits transactions with the Fabric Gateway client API, retrying the failures that another attempt
+// can fix.
+//
+// A failed transaction is endorsed again, with a new transaction ID, only if it was never sent to the orderer or if it
+// committed as invalid. A transaction sent to the orderer whose commit status is unknown might already be committed, so
+// it is never submitted again: only its commit status is read again. Failures are classified as chaincode errors,
+// endorsement mismatches, MVCC conflicts, timeouts or unavailable peers, and returned as an *Error.
+package submit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Submitter submits transactions of a contract. It is safe for concurrent use.
+type Submitter struct {
+	contract             Contract
+	attempts             int
+	commitStatusAttempts int
+	baseDelay            time.Duration
+	maxDelay             time.Duration
+
+	mutex sync.Mutex
+	rand  *rand.Rand
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+// Option configures a Submitter.
+type Option func(s *Submitter)
+
+// WithAttempts sets the number of times a transaction is endorsed before the submission fails. The default is 3.
+func WithAttempts(attempts int) Option {
+	return func(s *Submitter) {
+		s.attempts = attempts
+	}
+}
+
+// WithCommitStatusAttempts sets the number of times the commit status of a submitted transaction is read before the
+// submission fails. The default is 3.
+func WithCommitStatusAttempts(attempts int) Option {
+	return func(s *Submitter) {
+		s.commitStatusAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delays between attempts. The delay before the nth retry is a random duration up to
+// baseDelay * 2^(n-1), capped at maxDelay, so that clients whose transactions conflict do not retry in step. The
+// defaults are 500 milliseconds and 10 seconds.
+func WithBackoff(baseDelay time.Duration, maxDelay time.Duration) Option {
+	return func(s *Submitter) {
+		s.baseDelay = baseDelay
+		s.maxDelay = maxDelay
+	}
+}
+
+// New creates a Submitter for a contract, for example NewContract(network.GetContract("basic")).
+func New(contract Contract, options ...Option) *Submitter {
+	s := &Submitter{
+		contract:             contract,
+		attempts:             3,
+		commitStatusAttempts: 3,
+		baseDelay:            500 * time.Millisecond,
+		maxDelay:             10 * time.Second,
+		rand:                 rand.New(rand.NewSource(time.Now().UnixNano())), //#nosec G404 -- jitter only
+		sleep:                sleep,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if s.attempts < 1 {
+		s.attempts = 1
+	}
+	if s.commitStatusAttempts < 1 {
+		s.commitStatusAttempts = 1
+	}
+	return s
+}
+
+// Submit submits a transaction and waits for it to commit, retrying retryable failures. It returns the result of the
+// transaction, or an *Error.
+func (s *Submitter) Submit(ctx context.Context, transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := s.submitOnce(ctx, transactionName, options...)
+		if err == nil {
+			return result, nil
+		}
+
+		err.Attempts = attempt
+		if !err.retryable() || attempt >= s.attempts {
+			return nil, err
+		}
+		if sleepErr := s.sleep(ctx, s.backoff(attempt)); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// submitOnce makes one attempt to endorse and submit a transaction. Once the transaction is submitted, the result of
+// the attempt is either its commit status or an error that marks it as possibly committed.
+func (s *Submitter) submitOnce(ctx context.Context, transactionName string, options ...client.ProposalOption) ([]byte, *Error) {
+	proposal, err := s.contract.NewProposal(transactionName, options...)
+	if err != nil {
+		return nil, &Error{Kind: Unknown, err: err}
+	}
+	transactionID := proposal.TransactionID()
+
+	transaction, err := proposal.Endorse(ctx)
+	if err != nil {
+		return nil, &Error{Kind: Classify(err), TransactionID: transactionID, err: err}
+	}
+
+	commit, err := transaction.Submit(ctx)
+	if err != nil {
+		// The orderer might have received the transaction before the failure
+		return nil, &Error{Kind: Classify(err), TransactionID: transactionID, MayBeCommitted: true, err: err}
+	}
+
+	status, err := s.commitStatus(ctx, commit)
+	if err != nil {
+		return nil, &Error{Kind: Classify(err), TransactionID: transactionID, MayBeCommitted: true, err: err}
+	}
+	if !status.Successful {
+		err := fmt.Errorf("transaction %s failed to commit with status code %d (%s)", transactionID, int32(status.Code), status.Code)
+		return nil, &Error{Kind: kindOfValidationCode(status.Code), TransactionID: transactionID, err: err}
+	}
+
+	return transaction.Result(), nil
+}
+
+// commitStatus reads the commit status of a submitted transaction, retrying failures to read it. Reading the status
+// never submits the transaction again.
+func (s *Submitter) commitStatus(ctx context.Context, commit Commit) (*client.Status, error) {
+	for attempt := 1; ; attempt++ {
+		status, err := commit.Status(ctx)
+		if err == nil {
+			return status, nil
+		}
+		if attempt >= s.commitStatusAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+		if sleepErr := s.sleep(ctx, s.backoff(attempt)); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns a random delay up to the exponential backoff of an attempt.
+func (s *Submitter) backoff(attempt int) time.Duration {
+	delay := s.maxDelay
+	if shift := attempt - 1; shift < 32 && s.baseDelay<<shift > 0 && s.baseDelay<<shift < s.maxDelay {
+		delay = s.baseDelay << shift
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return time.Duration(s.rand.Int63n(int64(delay) + 1))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
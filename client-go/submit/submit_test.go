@@ -0,0 +1,355 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package submit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// attempt scripts the outcome of one transaction proposal of a fake gateway.
+type attempt struct {
+	endorseErr   error
+	submitErr    error
+	statusErrs   []error
+	status       peer.TxValidationCode
+	statusChecks int
+}
+
+// fakeGateway plays the scripted attempts in order, and records the calls of the submitter.
+type fakeGateway struct {
+	attempts  []*attempt
+	proposals int
+	submits   int
+}
+
+func (g *fakeGateway) NewProposal(transactionName string, options ...client.ProposalOption) (Proposal, error) {
+	if g.proposals >= len(g.attempts) {
+		return nil, errors.New("unexpected proposal")
+	}
+	g.proposals++
+	return &fakeProposal{gateway: g, id: fmt.Sprintf("tx%d", g.proposals), attempt: g.attempts[g.proposals-1]}, nil
+}
+
+type fakeProposal struct {
+	gateway *fakeGateway
+	id      string
+	attempt *attempt
+}
+
+func (p *fakeProposal) TransactionID() string {
+	return p.id
+}
+
+func (p *fakeProposal) Endorse(ctx context.Context) (Transaction, error) {
+	if p.attempt.endorseErr != nil {
+		return nil, p.attempt.endorseErr
+	}
+	return p, nil
+}
+
+func (p *fakeProposal) Result() []byte {
+	return []byte("result of " + p.id)
+}
+
+func (p *fakeProposal) Submit(ctx context.Context) (Commit, error) {
+	p.gateway.submits++
+	if p.attempt.submitErr != nil {
+		return nil, p.attempt.submitErr
+	}
+	return p, nil
+}
+
+func (p *fakeProposal) Status(ctx context.Context) (*client.Status, error) {
+	p.attempt.statusChecks++
+	if len(p.attempt.statusErrs) > 0 {
+		err := p.attempt.statusErrs[0]
+		p.attempt.statusErrs = p.attempt.statusErrs[1:]
+		return nil, err
+	}
+	return &client.Status{
+		Code:          p.attempt.status,
+		Successful:    p.attempt.status == peer.TxValidationCode_VALID,
+		TransactionID: p.id,
+	}, nil
+}
+
+// newTestSubmitter returns a submitter that records its backoff delays instead of sleeping.
+func newTestSubmitter(contract Contract, delays *[]time.Duration) *Submitter {
+	s := New(contract, WithAttempts(3), WithCommitStatusAttempts(3), WithBackoff(100*time.Millisecond, time.Second))
+	s.sleep = func(ctx context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return ctx.Err()
+	}
+	return s
+}
+
+func newStatusError(t *testing.T, code codes.Code, message string, detailMessages ...string) error {
+	statusErr := status.New(code, message)
+	for _, detailMessage := range detailMessages {
+		var err error
+		statusErr, err = statusErr.WithDetails(&gateway.ErrorDetail{Address: "peer0.org1.example.com:7051", MspId: "Org1MSP", Message: detailMessage})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return statusErr.Err()
+}
+
+func TestSubmit(t *testing.T) {
+	mismatch := newStatusError(t, codes.Aborted, "failed to assemble transaction: ProposalResponsePayloads do not match")
+	chaincodeErr := newStatusError(t, codes.Aborted, "failed to endorse transaction", "chaincode response 500, the asset asset1 already exists")
+	timeout := newStatusError(t, codes.DeadlineExceeded, "context deadline exceeded")
+	unavailable := newStatusError(t, codes.Unavailable, "connection refused")
+
+	for name, test := range map[string]struct {
+		attempts               []*attempt
+		expectedResult         string
+		expectedKind           Kind
+		expectedMayBeCommitted bool
+		expectedProposals      int
+		expectedSubmits        int
+		expectedStatusChecks   []int
+	}{
+		"commits": {
+			attempts:             []*attempt{{}},
+			expectedResult:       "result of tx1",
+			expectedProposals:    1,
+			expectedSubmits:      1,
+			expectedStatusChecks: []int{1},
+		},
+		"retries endorsement mismatch": {
+			attempts:             []*attempt{{endorseErr: mismatch}, {}},
+			expectedResult:       "result of tx2",
+			expectedProposals:    2,
+			expectedSubmits:      1,
+			expectedStatusChecks: []int{0, 1},
+		},
+		"retries MVCC conflict with a new transaction": {
+			attempts:             []*attempt{{status: peer.TxValidationCode_MVCC_READ_CONFLICT}, {status: peer.TxValidationCode_PHANTOM_READ_CONFLICT}, {}},
+			expectedResult:       "result of tx3",
+			expectedProposals:    3,
+			expectedSubmits:      3,
+			expectedStatusChecks: []int{1, 1, 1},
+		},
+		"retries endorsement timeout": {
+			attempts:             []*attempt{{endorseErr: timeout}, {endorseErr: unavailable}, {}},
+			expectedResult:       "result of tx3",
+			expectedProposals:    3,
+			expectedSubmits:      1,
+			expectedStatusChecks: []int{0, 0, 1},
+		},
+		"does not retry chaincode error": {
+			attempts:             []*attempt{{endorseErr: chaincodeErr}},
+			expectedKind:         ChaincodeError,
+			expectedProposals:    1,
+			expectedStatusChecks: []int{0},
+		},
+		"does not retry invalid transaction": {
+			attempts:             []*attempt{{status: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE}},
+			expectedKind:         Unknown,
+			expectedProposals:    1,
+			expectedSubmits:      1,
+			expectedStatusChecks: []int{1},
+		},
+		"fails after all attempts": {
+			attempts:             []*attempt{{endorseErr: mismatch}, {endorseErr: mismatch}, {endorseErr: mismatch}},
+			expectedKind:         EndorsementMismatch,
+			expectedProposals:    3,
+			expectedStatusChecks: []int{0, 0, 0},
+		},
+		"never resubmits after submit timeout": {
+			attempts:               []*attempt{{submitErr: timeout}},
+			expectedKind:           Timeout,
+			expectedMayBeCommitted: true,
+			expectedProposals:      1,
+			expectedSubmits:        1,
+			expectedStatusChecks:   []int{0},
+		},
+		"retries commit status without resubmitting": {
+			attempts:             []*attempt{{statusErrs: []error{unavailable, timeout}}},
+			expectedResult:       "result of tx1",
+			expectedProposals:    1,
+			expectedSubmits:      1,
+			expectedStatusChecks: []int{3},
+		},
+		"never resubmits when commit status is unknown": {
+			attempts:               []*attempt{{statusErrs: []error{timeout, timeout, timeout}}},
+			expectedKind:           Timeout,
+			expectedMayBeCommitted: true,
+			expectedProposals:      1,
+			expectedSubmits:        1,
+			expectedStatusChecks:   []int{3},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := &fakeGateway{attempts: test.attempts}
+			var delays []time.Duration
+			s := newTestSubmitter(fake, &delays)
+
+			result, err := s.Submit(context.Background(), "CreateAsset", client.WithArguments("asset1"))
+
+			if test.expectedResult != "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(result) != test.expectedResult {
+					t.Errorf("expected result %q, got %q", test.expectedResult, result)
+				}
+			} else {
+				var submitErr *Error
+				if !errors.As(err, &submitErr) {
+					t.Fatalf("expected *Error, got %v", err)
+				}
+				if submitErr.Kind != test.expectedKind || submitErr.MayBeCommitted != test.expectedMayBeCommitted {
+					t.Errorf("expected %s error with MayBeCommitted %t, got %s with %t", test.expectedKind, test.expectedMayBeCommitted, submitErr.Kind, submitErr.MayBeCommitted)
+				}
+				if submitErr.Attempts != test.expectedProposals || submitErr.TransactionID != fmt.Sprintf("tx%d", test.expectedProposals) {
+					t.Errorf("expected %d attempts and last transaction ID tx%d, got %d and %s", test.expectedProposals, test.expectedProposals, submitErr.Attempts, submitErr.TransactionID)
+				}
+			}
+
+			if fake.proposals != test.expectedProposals || fake.submits != test.expectedSubmits {
+				t.Errorf("expected %d proposals and %d submits, got %d and %d", test.expectedProposals, test.expectedSubmits, fake.proposals, fake.submits)
+			}
+			statusChecks := []int{}
+			for _, a := range test.attempts {
+				statusChecks = append(statusChecks, a.statusChecks)
+			}
+			if !reflect.DeepEqual(statusChecks, test.expectedStatusChecks) {
+				t.Errorf("expected status checks %v, got %v", test.expectedStatusChecks, statusChecks)
+			}
+			for _, delay := range delays {
+				if delay < 0 || delay > time.Second {
+					t.Errorf("expected backoff delays up to 1s, got %v", delays)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitStopsRetryingWhenContextIsDone(t *testing.T) {
+	mismatch := newStatusError(t, codes.Aborted, "failed to assemble transaction: ProposalResponsePayloads do not match")
+	fake := &fakeGateway{attempts: []*attempt{{endorseErr: mismatch}, {}}}
+	var delays []time.Duration
+	s := newTestSubmitter(fake, &delays)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Submit(ctx, "CreateAsset")
+
+	var submitErr *Error
+	if !errors.As(err, &submitErr) || submitErr.Kind != EndorsementMismatch {
+		t.Fatalf("expected endorsement mismatch error, got %v", err)
+	}
+	if fake.proposals != 1 {
+		t.Errorf("expected 1 proposal, got %d", fake.proposals)
+	}
+}
+
+func TestBackoffIsJitteredAndCapped(t *testing.T) {
+	s := New(nil, WithBackoff(100*time.Millisecond, time.Second))
+
+	for attempt, limit := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		40: time.Second,
+	} {
+		distinct := map[time.Duration]bool{}
+		for i := 0; i < 20; i++ {
+			delay := s.backoff(attempt)
+			if delay < 0 || delay > limit {
+				t.Fatalf("expected backoff of attempt %d up to %v, got %v", attempt, limit, delay)
+			}
+			distinct[delay] = true
+		}
+		if len(distinct) < 2 {
+			t.Errorf("expected jittered backoff delays for attempt %d, got %v", attempt, distinct)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for name, test := range map[string]struct {
+		err      error
+		expected Kind
+	}{
+		"chaincode error in detail": {
+			err:      newStatusError(t, codes.Aborted, "failed to endorse transaction, see attached details for more info", "chaincode response 500, the asset asset1 does not exist"),
+			expected: ChaincodeError,
+		},
+		"chaincode error in message": {
+			err:      newStatusError(t, codes.Unknown, "evaluate call to endorser returned error: chaincode response 500, the asset asset1 does not exist"),
+			expected: ChaincodeError,
+		},
+		"endorsement mismatch": {
+			err:      newStatusError(t, codes.Aborted, "failed to assemble transaction: ProposalResponsePayloads do not match (base64): 'CiA...'"),
+			expected: EndorsementMismatch,
+		},
+		"MVCC conflict": {
+			err:      &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+			expected: MVCCConflict,
+		},
+		"duplicate transaction": {
+			err:      newStatusError(t, codes.Aborted, "failed to endorse transaction", "duplicate transaction found [tx1]. Creator [0a07]"),
+			expected: DuplicateTransaction,
+		},
+		"duplicate transaction ID committed": {
+			err:      &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_DUPLICATE_TXID},
+			expected: DuplicateTransaction,
+		},
+		"invalid transaction": {
+			err:      &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE},
+			expected: Unknown,
+		},
+		"gRPC deadline": {
+			err:      newStatusError(t, codes.DeadlineExceeded, "context deadline exceeded"),
+			expected: Timeout,
+		},
+		"context deadline": {
+			err:      fmt.Errorf("failed: %w", context.DeadlineExceeded),
+			expected: Timeout,
+		},
+		"unavailable": {
+			err:      newStatusError(t, codes.Unavailable, "connection refused"),
+			expected: Unavailable,
+		},
+		"other": {
+			err:      errors.New("failed"),
+			expected: Unknown,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := Classify(test.err); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestChaincodeMessage(t *testing.T) {
+	err := newStatusError(t, codes.Aborted, "failed to endorse transaction", "chaincode response 500, the asset asset1 does not exist")
+	if message, ok := ChaincodeMessage(err); !ok || message != "the asset asset1 does not exist" {
+		t.Errorf("expected message of chaincode error, got %q, %v", message, ok)
+	}
+
+	if message, ok := ChaincodeMessage(newStatusError(t, codes.Unavailable, "connection refused")); ok {
+		t.Errorf("expected no message for unavailable error, got %q", message)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"fmt"
+)
+
+// BuyRequest places a request to buy a commercial paper at the price given. The
+// paper is PENDING until the owner accepts the request with Accept or Transfer,
+// or until the request expires and is reclaimed. The buyer is bound to the
+// organization of the submitting client. The request date must be the day of
+// the transaction
+func (c *Contract) BuyRequest(ctx TransactionContextInterface, issuer string, paperNumber string, currentOwner string, newOwner string, price int, requestDateTime string, expiryDateTime string) (*CommercialPaper, error) {
+	_, err := checkTxDate(ctx, "request date", requestDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expiryDate, err := parseDateTime("expiry date", expiryDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if price <= 0 {
+		return nil, fmt.Errorf("Price must be positive")
+	}
+
+	paper, err := ctx.GetPaperList().GetPaper(issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.Owner != currentOwner {
+		return nil, fmt.Errorf("Paper %s:%s is not owned by %s", issuer, paperNumber, currentOwner)
+	}
+
+	if newOwner == paper.Owner {
+		return nil, fmt.Errorf("Paper %s:%s is already owned by %s", issuer, paperNumber, newOwner)
+	}
+
+	if !paper.IsIssued() && !paper.IsTrading() {
+		return nil, fmt.Errorf("Paper %s:%s cannot be requested. Current state = %s", issuer, paperNumber, paper.GetState())
+	}
+
+	matured, err := hasMatured(ctx, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if matured {
+		return nil, fmt.Errorf("Paper %s:%s matured on %s and can no longer be bought", issuer, paperNumber, paper.MaturityDateTime)
+	}
+
+	txTime, err := getTxTime(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !expiryDate.After(txTime) {
+		return nil, fmt.Errorf("Expiry date %s must be in the future", expiryDateTime)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper.Request = &PurchaseRequest{Buyer: newOwner, BuyerMSP: mspID, Price: price, RequestDateTime: requestDateTime, ExpiryDateTime: expiryDateTime, PreviousState: paper.GetState()}
+	paper.SetPending()
+
+	err = ctx.GetPaperList().UpdatePaper(paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, BuyRequestEvent, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return paper, nil
+}
+
+// Accept lets the owner of a PENDING paper accept the buy request. The paper
+// moves to the buyer at the requested price and starts trading. The accept
+// date must be the day of the transaction
+func (c *Contract) Accept(ctx TransactionContextInterface, issuer string, paperNumber string, acceptDateTime string) (*CommercialPaper, error) {
+	_, err := checkTxDate(ctx, "accept date", acceptDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := c.getPendingPaper(ctx, issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.transfer(ctx, paper)
+}
+
+// Transfer lets the owner of a PENDING paper confirm its transfer to the new
+// owner. It is the same as Accept, but the new owner and their MSP must match
+// the buyer of the request
+func (c *Contract) Transfer(ctx TransactionContextInterface, issuer string, paperNumber string, newOwner string, newOwnerMSP string, transferDateTime string) (*CommercialPaper, error) {
+	_, err := checkTxDate(ctx, "transfer date", transferDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := c.getPendingPaper(ctx, issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.Request.Buyer != newOwner || paper.Request.BuyerMSP != newOwnerMSP {
+		return nil, fmt.Errorf("Paper %s:%s was requested by %s of %s, not %s of %s", issuer, paperNumber, paper.Request.Buyer, paper.Request.BuyerMSP, newOwner, newOwnerMSP)
+	}
+
+	return c.transfer(ctx, paper)
+}
+
+// Reclaim returns a PENDING paper to the state it was in before the buy
+// request, once the request has expired. Either the owner or the buyer
+// can reclaim the paper
+func (c *Contract) Reclaim(ctx TransactionContextInterface, issuer string, paperNumber string) (*CommercialPaper, error) {
+	paper, err := ctx.GetPaperList().GetPaper(issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !paper.IsPending() {
+		return nil, fmt.Errorf("Paper %s:%s does not have a pending buy request", issuer, paperNumber)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if mspID != paper.OwnerMSP && mspID != paper.Request.BuyerMSP {
+		return nil, fmt.Errorf("Paper %s:%s cannot be reclaimed by %s", issuer, paperNumber, mspID)
+	}
+
+	expired, err := hasExpired(ctx, paper.Request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !expired {
+		return nil, fmt.Errorf("Buy request for paper %s:%s does not expire until %s", issuer, paperNumber, paper.Request.ExpiryDateTime)
+	}
+
+	if paper.Request.PreviousState == ISSUED {
+		paper.SetIssued()
+	} else {
+		paper.SetTrading()
+	}
+	paper.Request = nil
+
+	err = ctx.GetPaperList().UpdatePaper(paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, ReclaimEvent, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return paper, nil
+}
+
+// getPendingPaper returns a paper with a buy request that has not expired,
+// after checking the client is of the organization that owns it
+func (c *Contract) getPendingPaper(ctx TransactionContextInterface, issuer string, paperNumber string) (*CommercialPaper, error) {
+	paper, err := ctx.GetPaperList().GetPaper(issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !paper.IsPending() {
+		return nil, fmt.Errorf("Paper %s:%s does not have a pending buy request", issuer, paperNumber)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.OwnerMSP != mspID {
+		return nil, fmt.Errorf("Paper %s:%s cannot be transferred by %s as it is owned by %s", issuer, paperNumber, mspID, paper.OwnerMSP)
+	}
+
+	expired, err := hasExpired(ctx, paper.Request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expired {
+		return nil, fmt.Errorf("Buy request for paper %s:%s expired on %s", issuer, paperNumber, paper.Request.ExpiryDateTime)
+	}
+
+	return paper, nil
+}
+
+// transfer moves a pending paper to the buyer of its request
+func (c *Contract) transfer(ctx TransactionContextInterface, paper *CommercialPaper) (*CommercialPaper, error) {
+	request := paper.Request
+
+	err := c.Hooks.runBuy(ctx, paper, request.Buyer, request.Price)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper.Owner = request.Buyer
+	paper.OwnerMSP = request.BuyerMSP
+	paper.Price = request.Price
+	paper.Request = nil
+	paper.SetTrading()
+
+	err = ctx.GetPaperList().UpdatePaper(paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, TransferEvent, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return paper, nil
+}
+
+// hasExpired returns true if the transaction was created at or after
+// the expiry date of the request
+func hasExpired(ctx TransactionContextInterface, request *PurchaseRequest) (bool, error) {
+	expiryDate, err := parseDateTime("expiry date", request.ExpiryDateTime)
+
+	if err != nil {
+		return false, err
+	}
+
+	txTime, err := getTxTime(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return !txTime.Before(expiryDate), nil
+}
@@ -0,0 +1,219 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func pendingPaper() *CommercialPaper {
+	paper := new(CommercialPaper)
+	resetPaper(paper)
+	paper.Request = &PurchaseRequest{Buyer: "someotherowner", BuyerMSP: "someotherownermsp", Price: 950, RequestDateTime: "2020-06-01", ExpiryDateTime: "2020-06-10", PreviousState: TRADING}
+	paper.SetPending()
+
+	return paper
+}
+
+func TestBuyRequest(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	var sentPaper *CommercialPaper
+	var emptyPaper *CommercialPaper
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("GetPaper", "someotherissuer", "someotherpaper").Return(emptyPaper, errors.New("GetPaper error"))
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return true })).Return(nil)
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-05-30", "2020-06-10")
+	assert.EqualError(t, err, "Invalid request date 2020-05-30. The transaction was created on 2020-06-01", "should error when request date is not the transaction date")
+	assert.Nil(t, paper, "should not return paper for request date other than the transaction date")
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-06-01", "someexpiry")
+	assert.EqualError(t, err, "Invalid expiry date someexpiry. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", "should error when expiry date invalid")
+	assert.Nil(t, paper, "should not return paper for invalid expiry date")
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 0, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "Price must be positive", "should error when price not positive")
+	assert.Nil(t, paper, "should not return paper when price not positive")
+
+	paper, err = contract.BuyRequest(ctx, "someotherissuer", "someotherpaper", "someowner", "someotherowner", 950, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "GetPaper error", "should return error when GetPaper errors")
+	assert.Nil(t, paper, "should not return paper when GetPaper errors")
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someotherowner", "someowner", 950, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is not owned by someotherowner", "should error when sent owner not correct")
+	assert.Nil(t, paper, "should not return paper for bad owner error")
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someowner", 950, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is already owned by someowner", "should error when new owner is the current owner")
+	assert.Nil(t, paper, "should not return paper when new owner is the current owner")
+
+	wsPaper.SetRedeemed()
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be requested. Current state = REDEEMED", "should error when paper not issued or trading")
+	assert.Nil(t, paper, "should not return paper for bad state error")
+
+	resetPaper(wsPaper)
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-06-01", "2020-06-01")
+	assert.EqualError(t, err, "Expiry date 2020-06-01 must be in the future", "should error when request already expired")
+	assert.Nil(t, paper, "should not return paper for expired request")
+
+	mpl.AssertNotCalled(t, "UpdatePaper", mock.Anything)
+
+	wsPaper.SetIssued()
+	setClientMSPID(ctx, "someotherownermsp")
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-06-01", "2020-06-10")
+	assert.Nil(t, err, "should not error for good request")
+	assert.True(t, paper.IsPending(), "should mark paper as pending")
+	assert.Equal(t, "someowner", paper.Owner, "should not change the owner of the paper")
+	assert.Equal(t, &PurchaseRequest{Buyer: "someotherowner", BuyerMSP: "someotherownermsp", Price: 950, RequestDateTime: "2020-06-01", ExpiryDateTime: "2020-06-10", PreviousState: ISSUED}, paper.Request, "should record the request against the paper")
+	assert.Equal(t, sentPaper, paper, "should update same paper as it returns in the world state")
+	assertPaperEvent(t, stub, BuyRequestEvent, paper)
+
+	paper, err = contract.BuyRequest(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 960, "2020-06-01", "2020-06-10")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be requested. Current state = PENDING", "should error when paper already has a request")
+	assert.Nil(t, paper, "should not return paper when already pending")
+}
+
+func TestAccept(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	var sentPaper *CommercialPaper
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return true })).Return(nil)
+
+	paper, err = contract.Accept(ctx, "someissuer", "somepaper", "2020-06-02")
+	assert.EqualError(t, err, "Invalid accept date 2020-06-02. The transaction was created on 2020-06-01", "should error when accept date is not the transaction date")
+	assert.Nil(t, paper, "should not return paper for accept date other than the transaction date")
+
+	paper, err = contract.Accept(ctx, "someissuer", "somepaper", "2020-06-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper does not have a pending buy request", "should error when paper not pending")
+	assert.Nil(t, paper, "should not return paper when not pending")
+
+	*wsPaper = *pendingPaper()
+	setClientMSPID(ctx, "someotherownermsp")
+	paper, err = contract.Accept(ctx, "someissuer", "somepaper", "2020-06-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be transferred by someotherownermsp as it is owned by someownermsp", "should error when client not of owning organization")
+	assert.Nil(t, paper, "should not return paper when client not of owning organization")
+
+	setClientMSPID(ctx, "someownermsp")
+	setTxTime(stub, "2020-06-10")
+	paper, err = contract.Accept(ctx, "someissuer", "somepaper", "2020-06-10")
+	assert.EqualError(t, err, "Buy request for paper someissuer:somepaper expired on 2020-06-10", "should error when request expired")
+	assert.Nil(t, paper, "should not return paper when request expired")
+
+	mpl.AssertNotCalled(t, "UpdatePaper", mock.Anything)
+
+	setTxTime(stub, "2020-06-02")
+	paper, err = contract.Accept(ctx, "someissuer", "somepaper", "2020-06-02")
+	assert.Nil(t, err, "should not error when owner accepts request")
+	assert.Equal(t, "someotherowner", paper.Owner, "should update the owner of the paper")
+	assert.Equal(t, "someotherownermsp", paper.OwnerMSP, "should bind the owner to the MSP of the buyer")
+	assert.Equal(t, 950, paper.Price, "should record the requested price")
+	assert.Nil(t, paper.Request, "should clear the request")
+	assert.True(t, paper.IsTrading(), "should mark paper as trading")
+	assert.Equal(t, sentPaper, paper, "should update same paper as it returns in the world state")
+	assertPaperEvent(t, stub, TransferEvent, paper)
+}
+
+func TestTransfer(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	var hookedPrice int
+	contract.Hooks.OnBuy(func(ctx TransactionContextInterface, paper *CommercialPaper, newOwner string, price int) error {
+		hookedPrice = price
+		return nil
+	})
+
+	wsPaper := pendingPaper()
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("UpdatePaper", mock.Anything).Return(nil)
+
+	paper, err = contract.Transfer(ctx, "someissuer", "somepaper", "someotherowner", "someotherownermsp", "2020-06-02T10:00:00Z")
+	assert.EqualError(t, err, "Invalid transfer date 2020-06-02T10:00:00Z. The transaction was created on 2020-06-01", "should error when transfer date is not the transaction date")
+	assert.Nil(t, paper, "should not return paper for transfer date other than the transaction date")
+
+	paper, err = contract.Transfer(ctx, "someissuer", "somepaper", "someotherowner", "somemsp", "2020-06-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper was requested by someotherowner of someotherownermsp, not someotherowner of somemsp", "should error when new owner does not match request")
+	assert.Nil(t, paper, "should not return paper when new owner does not match request")
+
+	paper, err = contract.Transfer(ctx, "someissuer", "somepaper", "someotherowner", "someotherownermsp", "2020-06-01")
+	assert.Nil(t, err, "should not error when new owner matches request")
+	assert.Equal(t, "someotherowner", paper.Owner, "should update the owner of the paper")
+	assert.Equal(t, 950, hookedPrice, "should run buy hooks with the requested price")
+	assertPaperEvent(t, stub, TransferEvent, paper)
+}
+
+func TestReclaim(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("UpdatePaper", mock.Anything).Return(nil)
+
+	paper, err = contract.Reclaim(ctx, "someissuer", "somepaper")
+	assert.EqualError(t, err, "Paper someissuer:somepaper does not have a pending buy request", "should error when paper not pending")
+	assert.Nil(t, paper, "should not return paper when not pending")
+
+	*wsPaper = *pendingPaper()
+	paper, err = contract.Reclaim(ctx, "someissuer", "somepaper")
+	assert.EqualError(t, err, "Buy request for paper someissuer:somepaper does not expire until 2020-06-10", "should error when request not expired")
+	assert.Nil(t, paper, "should not return paper when request not expired")
+
+	setTxTime(stub, "2020-06-10")
+	setClientMSPID(ctx, "someothermsp")
+	paper, err = contract.Reclaim(ctx, "someissuer", "somepaper")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be reclaimed by someothermsp", "should error when client neither owner nor buyer")
+	assert.Nil(t, paper, "should not return paper when client neither owner nor buyer")
+
+	mpl.AssertNotCalled(t, "UpdatePaper", mock.Anything)
+
+	setClientMSPID(ctx, "someotherownermsp")
+	paper, err = contract.Reclaim(ctx, "someissuer", "somepaper")
+	assert.Nil(t, err, "should not error when buyer reclaims expired request")
+	assert.True(t, paper.IsTrading(), "should return paper to its previous state")
+	assert.Equal(t, "someowner", paper.Owner, "should not change the owner of the paper")
+	assert.Nil(t, paper.Request, "should clear the request")
+	assertPaperEvent(t, stub, ReclaimEvent, paper)
+}
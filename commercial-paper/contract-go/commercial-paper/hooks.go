@@ -0,0 +1,79 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+// IssueHook is called before a new paper is added to
+// the world state
+type IssueHook func(ctx TransactionContextInterface, paper *CommercialPaper) error
+
+// BuyHook is called before a paper is updated in the
+// world state with its new owner, by Buy or when a buy
+// request is accepted. The paper passed still has its
+// current owner
+type BuyHook func(ctx TransactionContextInterface, paper *CommercialPaper, newOwner string, price int) error
+
+// RedeemHook is called before a paper is updated in the
+// world state as redeemed. The paper passed still has its
+// current owner and state
+type RedeemHook func(ctx TransactionContextInterface, paper *CommercialPaper) error
+
+// Hooks lets an organization add its own checks to the
+// contract without forking it. Hooks are run in the order
+// they were registered and the transaction fails with the
+// error of the first hook that returns one.
+//
+// Hooks must only read from the world state. Every endorsing
+// organization has to produce the same write set, so an
+// organization can reject a transaction but not change it
+type Hooks struct {
+	issue  []IssueHook
+	buy    []BuyHook
+	redeem []RedeemHook
+}
+
+// OnIssue registers a hook to run when a paper is issued
+func (h *Hooks) OnIssue(hook IssueHook) {
+	h.issue = append(h.issue, hook)
+}
+
+// OnBuy registers a hook to run when a paper is bought
+func (h *Hooks) OnBuy(hook BuyHook) {
+	h.buy = append(h.buy, hook)
+}
+
+// OnRedeem registers a hook to run when a paper is redeemed
+func (h *Hooks) OnRedeem(hook RedeemHook) {
+	h.redeem = append(h.redeem, hook)
+}
+
+func (h *Hooks) runIssue(ctx TransactionContextInterface, paper *CommercialPaper) error {
+	for _, hook := range h.issue {
+		if err := hook(ctx, paper); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) runBuy(ctx TransactionContextInterface, paper *CommercialPaper, newOwner string, price int) error {
+	for _, hook := range h.buy {
+		if err := hook(ctx, paper, newOwner, price); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) runRedeem(ctx TransactionContextInterface, paper *CommercialPaper) error {
+	for _, hook := range h.redeem {
+		if err := hook(ctx, paper); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
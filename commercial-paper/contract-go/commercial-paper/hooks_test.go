@@ -0,0 +1,105 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIssueHooks(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	var calls []string
+	contract.Hooks.OnIssue(func(ctx TransactionContextInterface, paper *CommercialPaper) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	contract.Hooks.OnIssue(func(ctx TransactionContextInterface, paper *CommercialPaper) error {
+		calls = append(calls, "second")
+		if paper.FaceValue > 1000 {
+			return errors.New("issue limit exceeded")
+		}
+		return nil
+	})
+
+	mpl.On("AddPaper", mock.Anything).Return(nil)
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "2020-11-30", 2000)
+	assert.EqualError(t, err, "issue limit exceeded", "should return error of failing hook")
+	assert.Nil(t, paper, "should not return paper when hook fails")
+	mpl.AssertNotCalled(t, "AddPaper", mock.Anything)
+	assert.Empty(t, stub.ChaincodeEventsChannel, "should not set an event when hook fails")
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "2020-11-30", 1000)
+	assert.Nil(t, err, "should not error when hooks pass")
+	assert.Equal(t, "somepaper", paper.PaperNumber, "should return issued paper when hooks pass")
+	assert.Equal(t, []string{"first", "second", "first", "second"}, calls, "should run hooks in order they were registered")
+}
+
+func TestBuyHooks(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, _ := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	var hookedOwner string
+	contract.Hooks.OnBuy(func(ctx TransactionContextInterface, paper *CommercialPaper, newOwner string, price int) error {
+		hookedOwner = paper.Owner
+		if price < 900 {
+			return errors.New("price too low")
+		}
+		return nil
+	})
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("UpdatePaper", mock.Anything).Return(nil)
+
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-06-01")
+	assert.EqualError(t, err, "price too low", "should return error of failing hook")
+	assert.Nil(t, paper, "should not return paper when hook fails")
+	assert.Equal(t, "someowner", hookedOwner, "should pass paper with current owner to hook")
+	mpl.AssertNotCalled(t, "UpdatePaper", mock.Anything)
+
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 950, "2020-06-01")
+	assert.Nil(t, err, "should not error when hooks pass")
+	assert.Equal(t, "someotherowner", paper.Owner, "should update owner when hooks pass")
+}
+
+func TestRedeemHooks(t *testing.T) {
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+	contract.Hooks.OnRedeem(func(ctx TransactionContextInterface, paper *CommercialPaper) error {
+		return errors.New("redeem not allowed")
+	})
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	setTxTime(stub, "2020-12-01")
+
+	paper, err := contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "redeem not allowed", "should return error of failing hook")
+	assert.Nil(t, paper, "should not return paper when hook fails")
+	assert.True(t, wsPaper.IsTrading(), "should not redeem paper when hook fails")
+}
@@ -7,8 +7,9 @@ package commercialpaper
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
-	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/organization/magnetocorp/contract-go/ledger-api"
+	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/ledger-api"
 )
 
 // State enum for commercial paper state property
@@ -21,18 +22,35 @@ const (
 	TRADING
 	// REDEEMED state for when a paper has been redeemed
 	REDEEMED
+	// PENDING state for when a paper has a buy request waiting
+	// for its owner. Added last so stored states keep their values
+	PENDING
 )
 
 func (state State) String() string {
-	names := []string{"ISSUED", "TRADING", "REDEEMED"}
+	names := []string{"ISSUED", "TRADING", "REDEEMED", "PENDING"}
 
-	if state < ISSUED || state > REDEEMED {
+	if state < ISSUED || state > PENDING {
 		return "UNKNOWN"
 	}
 
 	return names[state-1]
 }
 
+// ParseState returns the state with the passed name
+func ParseState(name string) (State, error) {
+	for state := ISSUED; state <= PENDING; state++ {
+		if state.String() == name {
+			return state, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown commercial paper state %s", name)
+}
+
+// paperClass the class of commercial papers in world state
+const paperClass = "org.papernet.commercialpaper"
+
 // CreateCommercialPaperKey creates a key for commercial papers
 func CreateCommercialPaperKey(issuer string, paperNumber string) string {
 	return ledgerapi.MakeKey(issuer, paperNumber)
@@ -49,15 +67,40 @@ type jsonCommercialPaper struct {
 
 // CommercialPaper defines a commercial paper
 type CommercialPaper struct {
-	PaperNumber      string `json:"paperNumber"`
-	Issuer           string `json:"issuer"`
-	IssueDateTime    string `json:"issueDateTime"`
-	FaceValue        int    `json:"faceValue"`
-	MaturityDateTime string `json:"maturityDateTime"`
-	Owner            string `json:"owner"`
-	state            State  `metadata:"currentState"`
-	class            string `metadata:"class"`
-	key              string `metadata:"key"`
+	PaperNumber      string           `json:"paperNumber"`
+	Issuer           string           `json:"issuer"`
+	IssueDateTime    string           `json:"issueDateTime"`
+	FaceValue        int              `json:"faceValue"`
+	MaturityDateTime string           `json:"maturityDateTime"`
+	Owner            string           `json:"owner"`
+	OwnerMSP         string           `json:"mspid"`
+	IssuerMSP        string           `json:"issuerMSP"`
+	Price            int              `json:"price"`
+	RedeemDateTime   string           `json:"redeemDateTime,omitempty" metadata:",optional"`
+	Request          *PurchaseRequest `json:"request,omitempty" metadata:",optional"`
+	state            State            `metadata:"currentState"`
+	class            string           `metadata:"class"`
+	key              string           `metadata:"key"`
+}
+
+// PurchaseRequest defines a request to buy a commercial
+// paper that is waiting for the owner to accept it
+type PurchaseRequest struct {
+	Buyer           string `json:"buyer"`
+	BuyerMSP        string `json:"buyerMSP"`
+	Price           int    `json:"price"`
+	RequestDateTime string `json:"requestDateTime"`
+	ExpiryDateTime  string `json:"expiryDateTime"`
+	PreviousState   State  `json:"previousState"`
+}
+
+// PaperHistory defines a value a commercial paper
+// held at some point in its lifetime
+type PaperHistory struct {
+	TxID      string           `json:"txId"`
+	Timestamp time.Time        `json:"timestamp"`
+	IsDelete  bool             `json:"isDelete"`
+	Paper     *CommercialPaper `json:"paper" metadata:",optional"`
 }
 
 // UnmarshalJSON special handler for managing JSON marshalling
@@ -77,7 +120,7 @@ func (cp *CommercialPaper) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON special handler for managing JSON marshalling
 func (cp CommercialPaper) MarshalJSON() ([]byte, error) {
-	jcp := jsonCommercialPaper{commercialPaperAlias: (*commercialPaperAlias)(&cp), State: cp.state, Class: "org.papernet.commercialpaper", Key: ledgerapi.MakeKey(cp.Issuer, cp.PaperNumber)}
+	jcp := jsonCommercialPaper{commercialPaperAlias: (*commercialPaperAlias)(&cp), State: cp.state, Class: paperClass, Key: ledgerapi.MakeKey(cp.Issuer, cp.PaperNumber)}
 
 	return json.Marshal(&jcp)
 }
@@ -97,6 +140,11 @@ func (cp *CommercialPaper) SetTrading() {
 	cp.state = TRADING
 }
 
+// SetPending sets the state to pending
+func (cp *CommercialPaper) SetPending() {
+	cp.state = PENDING
+}
+
 // SetRedeemed sets the state to redeemed
 func (cp *CommercialPaper) SetRedeemed() {
 	cp.state = REDEEMED
@@ -112,6 +160,11 @@ func (cp *CommercialPaper) IsTrading() bool {
 	return cp.state == TRADING
 }
 
+// IsPending returns true if state is pending
+func (cp *CommercialPaper) IsPending() bool {
+	return cp.state == PENDING
+}
+
 // IsRedeemed returns true if state is redeemed
 func (cp *CommercialPaper) IsRedeemed() bool {
 	return cp.state == REDEEMED
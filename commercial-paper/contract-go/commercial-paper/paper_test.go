@@ -7,7 +7,7 @@ package commercialpaper
 import (
 	"testing"
 
-	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/organization/magnetocorp/contract-go/ledger-api"
+	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/ledger-api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,7 +15,21 @@ func TestString(t *testing.T) {
 	assert.Equal(t, "ISSUED", ISSUED.String(), "should return string for issued")
 	assert.Equal(t, "TRADING", TRADING.String(), "should return string for issued")
 	assert.Equal(t, "REDEEMED", REDEEMED.String(), "should return string for issued")
-	assert.Equal(t, "UNKNOWN", State(REDEEMED+1).String(), "should return unknown when not one of constants")
+	assert.Equal(t, "PENDING", PENDING.String(), "should return string for pending")
+	assert.Equal(t, "UNKNOWN", State(PENDING+1).String(), "should return unknown when not one of constants")
+}
+
+func TestParseState(t *testing.T) {
+	var state State
+	var err error
+
+	state, err = ParseState("TRADING")
+	assert.Nil(t, err, "should not error for known state name")
+	assert.Equal(t, TRADING, state, "should return state for name")
+
+	state, err = ParseState("UNKNOWN")
+	assert.EqualError(t, err, "Unknown commercial paper state UNKNOWN", "should error for unknown state name")
+	assert.Equal(t, State(0), state, "should return zero state on error")
 }
 
 func TestCreateCommercialPaperKey(t *testing.T) {
@@ -67,6 +81,22 @@ func TestIsTrading(t *testing.T) {
 	assert.False(t, cp.IsTrading(), "should be false when status not set to trading")
 }
 
+func TestSetPending(t *testing.T) {
+	cp := new(CommercialPaper)
+	cp.SetPending()
+	assert.Equal(t, PENDING, cp.state, "should set state to pending")
+}
+
+func TestIsPending(t *testing.T) {
+	cp := new(CommercialPaper)
+
+	cp.SetPending()
+	assert.True(t, cp.IsPending(), "should be true when status set to pending")
+
+	cp.SetTrading()
+	assert.False(t, cp.IsPending(), "should be false when status not set to pending")
+}
+
 func TestIsRedeemed(t *testing.T) {
 	cp := new(CommercialPaper)
 
@@ -93,18 +123,21 @@ func TestSerialize(t *testing.T) {
 	cp.FaceValue = 1000
 	cp.MaturityDateTime = "somelatertime"
 	cp.Owner = "someowner"
+	cp.OwnerMSP = "someownermsp"
+	cp.IssuerMSP = "someissuermsp"
+	cp.Price = 950
 	cp.state = TRADING
 
 	bytes, err := cp.Serialize()
 	assert.Nil(t, err, "should not error on serialize")
-	assert.Equal(t, `{"paperNumber":"somepaper","issuer":"someissuer","issueDateTime":"sometime","faceValue":1000,"maturityDateTime":"somelatertime","owner":"someowner","currentState":2,"class":"org.papernet.commercialpaper","key":"someissuer:somepaper"}`, string(bytes), "should return JSON formatted value")
+	assert.Equal(t, `{"paperNumber":"somepaper","issuer":"someissuer","issueDateTime":"sometime","faceValue":1000,"maturityDateTime":"somelatertime","owner":"someowner","mspid":"someownermsp","issuerMSP":"someissuermsp","price":950,"currentState":2,"class":"org.papernet.commercialpaper","key":"someissuer:somepaper"}`, string(bytes), "should return JSON formatted value")
 }
 
 func TestDeserialize(t *testing.T) {
 	var cp *CommercialPaper
 	var err error
 
-	goodJSON := `{"paperNumber":"somepaper","issuer":"someissuer","issueDateTime":"sometime","faceValue":1000,"maturityDateTime":"somelatertime","owner":"someowner","currentState":2,"class":"org.papernet.commercialpaper","key":"someissuer:somepaper"}`
+	goodJSON := `{"paperNumber":"somepaper","issuer":"someissuer","issueDateTime":"sometime","faceValue":1000,"maturityDateTime":"somelatertime","owner":"someowner","mspid":"someownermsp","issuerMSP":"someissuermsp","price":950,"redeemDateTime":"someredeemtime","currentState":2,"class":"org.papernet.commercialpaper","key":"someissuer:somepaper"}`
 	expectedCp := new(CommercialPaper)
 	expectedCp.PaperNumber = "somepaper"
 	expectedCp.Issuer = "someissuer"
@@ -112,6 +145,10 @@ func TestDeserialize(t *testing.T) {
 	expectedCp.FaceValue = 1000
 	expectedCp.MaturityDateTime = "somelatertime"
 	expectedCp.Owner = "someowner"
+	expectedCp.OwnerMSP = "someownermsp"
+	expectedCp.IssuerMSP = "someissuermsp"
+	expectedCp.Price = 950
+	expectedCp.RedeemDateTime = "someredeemtime"
 	expectedCp.state = TRADING
 	cp = new(CommercialPaper)
 	err = Deserialize([]byte(goodJSON), cp)
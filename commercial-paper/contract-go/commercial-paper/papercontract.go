@@ -0,0 +1,354 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Contract chaincode that defines
+// the business logic for managing commercial
+// paper
+type Contract struct {
+	contractapi.Contract
+	Hooks Hooks
+}
+
+// Names of the events emitted when the state of a paper changes.
+// The payload of each event is the paper as stored in world state
+const (
+	IssueEvent      = "PaperIssued"
+	BuyEvent        = "PaperBought"
+	RedeemEvent     = "PaperRedeemed"
+	BuyRequestEvent = "PaperBuyRequested"
+	TransferEvent   = "PaperTransferred"
+	ReclaimEvent    = "PaperReclaimed"
+)
+
+// dateLayout the layout of dates given as a day
+const dateLayout = "2006-01-02"
+
+// Instantiate does nothing
+func (c *Contract) Instantiate() {
+	fmt.Println("Instantiated")
+}
+
+// Issue creates a new commercial paper and stores it in the world state.
+// The paper is owned by the issuer and the organization of the submitting
+// client
+func (c *Contract) Issue(ctx TransactionContextInterface, issuer string, paperNumber string, issueDateTime string, maturityDateTime string, faceValue int) (*CommercialPaper, error) {
+	issueDate, err := parseDateTime("issue date", issueDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maturityDate, err := parseDateTime("maturity date", maturityDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !maturityDate.After(issueDate) {
+		return nil, fmt.Errorf("Maturity date %s must be after issue date %s", maturityDateTime, issueDateTime)
+	}
+
+	if faceValue <= 0 {
+		return nil, fmt.Errorf("Face value must be positive")
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper := CommercialPaper{PaperNumber: paperNumber, Issuer: issuer, IssueDateTime: issueDateTime, FaceValue: faceValue, MaturityDateTime: maturityDateTime, Owner: issuer, OwnerMSP: mspID, IssuerMSP: mspID}
+	paper.SetIssued()
+
+	err = c.Hooks.runIssue(ctx, &paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetPaperList().AddPaper(&paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, IssueEvent, &paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &paper, nil
+}
+
+// Buy updates a commercial paper to be in trading status and sets the new owner.
+// The new owner is bound to the organization of the submitting client, and the
+// price paid is recorded on the paper. Papers cannot be bought once they mature,
+// and the purchase date must be the day of the transaction
+func (c *Contract) Buy(ctx TransactionContextInterface, issuer string, paperNumber string, currentOwner string, newOwner string, price int, purchaseDateTime string) (*CommercialPaper, error) {
+	_, err := checkTxDate(ctx, "purchase date", purchaseDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := ctx.GetPaperList().GetPaper(issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.Owner != currentOwner {
+		return nil, fmt.Errorf("Paper %s:%s is not owned by %s", issuer, paperNumber, currentOwner)
+	}
+
+	if paper.IsIssued() {
+		paper.SetTrading()
+	}
+
+	if !paper.IsTrading() {
+		return nil, fmt.Errorf("Paper %s:%s is not trading. Current state = %s", issuer, paperNumber, paper.GetState())
+	}
+
+	matured, err := hasMatured(ctx, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if matured {
+		return nil, fmt.Errorf("Paper %s:%s matured on %s and can no longer be bought", issuer, paperNumber, paper.MaturityDateTime)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Hooks.runBuy(ctx, paper, newOwner, price)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper.Owner = newOwner
+	paper.OwnerMSP = mspID
+	paper.Price = price
+
+	err = ctx.GetPaperList().UpdatePaper(paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, BuyEvent, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return paper, nil
+}
+
+// Redeem updates a commercial paper status to be redeemed. Only a client of
+// the owning organization can redeem a paper, and only once it has matured. The
+// redeem date must be the day of the transaction, and the time of the
+// transaction is recorded as the redeem date of the paper
+func (c *Contract) Redeem(ctx TransactionContextInterface, issuer string, paperNumber string, redeemingOwner string, redeemDateTime string) (*CommercialPaper, error) {
+	txTime, err := checkTxDate(ctx, "redeem date", redeemDateTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := ctx.GetPaperList().GetPaper(issuer, paperNumber)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.Owner != redeemingOwner {
+		return nil, fmt.Errorf("Paper %s:%s is not owned by %s", issuer, paperNumber, redeemingOwner)
+	}
+
+	if paper.IsRedeemed() {
+		return nil, fmt.Errorf("Paper %s:%s is already redeemed", issuer, paperNumber)
+	}
+
+	if paper.IsPending() {
+		return nil, fmt.Errorf("Paper %s:%s has a pending buy request and cannot be redeemed", issuer, paperNumber)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if paper.OwnerMSP != mspID {
+		return nil, fmt.Errorf("Paper %s:%s cannot be redeemed by %s as it is owned by %s", issuer, paperNumber, mspID, paper.OwnerMSP)
+	}
+
+	matured, err := hasMatured(ctx, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !matured {
+		return nil, fmt.Errorf("Paper %s:%s cannot be redeemed before it matures on %s", issuer, paperNumber, paper.MaturityDateTime)
+	}
+
+	err = c.Hooks.runRedeem(ctx, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paper.Owner = paper.Issuer
+	paper.OwnerMSP = paper.IssuerMSP
+	paper.RedeemDateTime = txTime.UTC().Format(time.RFC3339)
+	paper.SetRedeemed()
+
+	err = ctx.GetPaperList().UpdatePaper(paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitPaperEvent(ctx, RedeemEvent, paper)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return paper, nil
+}
+
+// QueryPapersByIssuer returns all commercial papers issued by the issuer
+func (c *Contract) QueryPapersByIssuer(ctx TransactionContextInterface, issuer string) ([]*CommercialPaper, error) {
+	return ctx.GetPaperList().GetPapersByIssuer(issuer)
+}
+
+// QueryPapersByOwner returns all commercial papers currently owned by the owner.
+// Requires a state database that supports rich queries
+func (c *Contract) QueryPapersByOwner(ctx TransactionContextInterface, owner string) ([]*CommercialPaper, error) {
+	return ctx.GetPaperList().GetPapersByOwner(owner)
+}
+
+// QueryPapersByState returns all commercial papers in the named state (ISSUED,
+// TRADING or REDEEMED). Requires a state database that supports rich queries
+func (c *Contract) QueryPapersByState(ctx TransactionContextInterface, state string) ([]*CommercialPaper, error) {
+	paperState, err := ParseState(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.GetPaperList().GetPapersByState(paperState)
+}
+
+// GetPaperHistory returns every value a commercial paper has held, most recent first
+func (c *Contract) GetPaperHistory(ctx TransactionContextInterface, issuer string, paperNumber string) ([]PaperHistory, error) {
+	return ctx.GetPaperList().GetPaperHistory(issuer, paperNumber)
+}
+
+// getClientMSPID returns the MSP ID of the client that submitted the transaction
+func getClientMSPID(ctx TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to get client MSP ID. %s", err.Error())
+	}
+
+	return mspID, nil
+}
+
+// hasMatured returns true if the transaction was created at or after
+// the maturity date of the paper
+func hasMatured(ctx TransactionContextInterface, paper *CommercialPaper) (bool, error) {
+	maturityDate, err := parseDateTime("maturity date", paper.MaturityDateTime)
+
+	if err != nil {
+		return false, err
+	}
+
+	txTime, err := getTxTime(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return !txTime.Before(maturityDate), nil
+}
+
+// getTxTime returns the time the transaction was created by the client
+func getTxTime(ctx TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get transaction timestamp. %s", err.Error())
+	}
+
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)), nil
+}
+
+// checkTxDate parses a date passed to a transaction and checks that it is
+// the day, in UTC, on which the transaction was created. It returns the
+// time of the transaction, which is what the ledger records
+func checkTxDate(ctx TransactionContextInterface, field string, value string) (time.Time, error) {
+	date, err := parseDateTime(field, value)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	txTime, err := getTxTime(ctx)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if date.UTC().Format(dateLayout) != txTime.UTC().Format(dateLayout) {
+		return time.Time{}, fmt.Errorf("Invalid %s %s. The transaction was created on %s", field, value, txTime.UTC().Format(dateLayout))
+	}
+
+	return txTime, nil
+}
+
+// parseDateTime parses a date passed to a transaction. Dates may be given
+// as a day, such as 2020-05-31, or as an RFC 3339 date and time, such as
+// 2020-05-31T10:00:00Z. Days start at midnight UTC
+func parseDateTime(field string, value string) (time.Time, error) {
+	for _, layout := range []string{dateLayout, time.RFC3339} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid %s %s. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", field, value)
+}
+
+// emitPaperEvent sets a chaincode event carrying the paper so that
+// applications can follow changes to commercial papers
+func emitPaperEvent(ctx TransactionContextInterface, name string, paper *CommercialPaper) error {
+	payload, err := paper.Serialize()
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent(name, payload)
+}
@@ -0,0 +1,385 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// #########
+// HELPERS
+// #########
+type MockPaperList struct {
+	mock.Mock
+}
+
+func (mpl *MockPaperList) AddPaper(paper *CommercialPaper) error {
+	args := mpl.Called(paper)
+
+	return args.Error(0)
+}
+
+func (mpl *MockPaperList) GetPaper(issuer string, papernumber string) (*CommercialPaper, error) {
+	args := mpl.Called(issuer, papernumber)
+
+	return args.Get(0).(*CommercialPaper), args.Error(1)
+}
+
+func (mpl *MockPaperList) UpdatePaper(paper *CommercialPaper) error {
+	args := mpl.Called(paper)
+
+	return args.Error(0)
+}
+
+func (mpl *MockPaperList) GetPapersByIssuer(issuer string) ([]*CommercialPaper, error) {
+	args := mpl.Called(issuer)
+
+	return args.Get(0).([]*CommercialPaper), args.Error(1)
+}
+
+func (mpl *MockPaperList) GetPapersByOwner(owner string) ([]*CommercialPaper, error) {
+	args := mpl.Called(owner)
+
+	return args.Get(0).([]*CommercialPaper), args.Error(1)
+}
+
+func (mpl *MockPaperList) GetPapersByState(state State) ([]*CommercialPaper, error) {
+	args := mpl.Called(state)
+
+	return args.Get(0).([]*CommercialPaper), args.Error(1)
+}
+
+func (mpl *MockPaperList) GetPaperHistory(issuer string, papernumber string) ([]PaperHistory, error) {
+	args := mpl.Called(issuer, papernumber)
+
+	return args.Get(0).([]PaperHistory), args.Error(1)
+}
+
+type MockClientIdentity struct {
+	cid.ClientIdentity
+	mspID string
+}
+
+func (mci *MockClientIdentity) GetMSPID() (string, error) {
+	return mci.mspID, nil
+}
+
+type MockTransactionContext struct {
+	contractapi.TransactionContext
+	paperList *MockPaperList
+}
+
+func (mtc *MockTransactionContext) GetPaperList() ListInterface {
+	return mtc.paperList
+}
+
+func newMockTransactionContext() (*MockTransactionContext, *shimtest.MockStub) {
+	stub := shimtest.NewMockStub("commercialpaper", nil)
+
+	setTxTime(stub, "2020-06-01")
+
+	ctx := new(MockTransactionContext)
+	ctx.paperList = new(MockPaperList)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&MockClientIdentity{mspID: "someownermsp"})
+
+	return ctx, stub
+}
+
+func setTxTime(stub *shimtest.MockStub, date string) {
+	txTime, _ := time.Parse(dateLayout, date)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: txTime.Unix()}
+}
+
+func setClientMSPID(ctx *MockTransactionContext, mspID string) {
+	ctx.SetClientIdentity(&MockClientIdentity{mspID: mspID})
+}
+
+func resetPaper(paper *CommercialPaper) {
+	paper.Issuer = "someissuer"
+	paper.Owner = "someowner"
+	paper.OwnerMSP = "someownermsp"
+	paper.IssuerMSP = "someissuermsp"
+	paper.MaturityDateTime = "2020-11-30"
+	paper.SetTrading()
+}
+
+// #########
+// TESTS
+// #########
+
+func TestIssue(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	var sentPaper *CommercialPaper
+
+	mpl.On("AddPaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return paper.Issuer == "someissuer" })).Return(nil)
+	mpl.On("AddPaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return paper.Issuer == "someotherissuer" })).Return(errors.New("AddPaper error"))
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "someissuedate", "2020-11-30", 1000)
+	assert.EqualError(t, err, "Invalid issue date someissuedate. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", "should error when issue date invalid")
+	assert.Nil(t, paper, "should not return paper for invalid issue date")
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "somematuritydate", 1000)
+	assert.EqualError(t, err, "Invalid maturity date somematuritydate. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", "should error when maturity date invalid")
+	assert.Nil(t, paper, "should not return paper for invalid maturity date")
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "2020-05-31T00:00:00Z", 1000)
+	assert.EqualError(t, err, "Maturity date 2020-05-31T00:00:00Z must be after issue date 2020-05-31", "should error when paper matures on issue")
+	assert.Nil(t, paper, "should not return paper when paper matures on issue")
+
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "2020-11-30", 0)
+	assert.EqualError(t, err, "Face value must be positive", "should error when face value not positive")
+	assert.Nil(t, paper, "should not return paper when face value not positive")
+
+	mpl.AssertNotCalled(t, "AddPaper", mock.Anything)
+
+	setClientMSPID(ctx, "someissuermsp")
+	expectedPaper := CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer", IssueDateTime: "2020-05-31", FaceValue: 1000, MaturityDateTime: "2020-11-30", Owner: "someissuer", OwnerMSP: "someissuermsp", IssuerMSP: "someissuermsp", state: 1}
+	paper, err = contract.Issue(ctx, "someissuer", "somepaper", "2020-05-31", "2020-11-30", 1000)
+	assert.Nil(t, err, "should not error when add paper does not error")
+	assert.Equal(t, sentPaper, paper, "should send the same paper as it returns to add paper")
+	assert.Equal(t, expectedPaper, *paper, "should correctly configure paper")
+	assertPaperEvent(t, stub, IssueEvent, paper)
+
+	paper, err = contract.Issue(ctx, "someotherissuer", "somepaper", "2020-05-31", "2020-11-30", 1000)
+	assert.EqualError(t, err, "AddPaper error", "should return error when add paper fails")
+	assert.Nil(t, paper, "should not return paper when fails")
+}
+
+func TestBuy(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	var sentPaper *CommercialPaper
+	var emptyPaper *CommercialPaper
+	shouldError := false
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("GetPaper", "someotherissuer", "someotherpaper").Return(emptyPaper, errors.New("GetPaper error"))
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { return shouldError })).Return(errors.New("UpdatePaper error"))
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return !shouldError })).Return(nil)
+
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2019-12-10:10:00")
+	assert.EqualError(t, err, "Invalid purchase date 2019-12-10:10:00. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", "should error when purchase date invalid")
+	assert.Nil(t, paper, "should not return paper for invalid purchase date")
+
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-05-31")
+	assert.EqualError(t, err, "Invalid purchase date 2020-05-31. The transaction was created on 2020-06-01", "should error when purchase date is not the transaction date")
+	assert.Nil(t, paper, "should not return paper for purchase date other than the transaction date")
+
+	paper, err = contract.Buy(ctx, "someotherissuer", "someotherpaper", "someowner", "someotherowner", 100, "2020-06-01")
+	assert.EqualError(t, err, "GetPaper error", "should return error when GetPaper errors")
+	assert.Nil(t, paper, "should return nil for paper when GetPaper errors")
+
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someotherowner", "someowner", 100, "2020-06-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is not owned by someotherowner", "should error when sent owner not correct")
+	assert.Nil(t, paper, "should not return paper for bad owner error")
+
+	resetPaper(wsPaper)
+	wsPaper.SetRedeemed()
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-06-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is not trading. Current state = REDEEMED")
+	assert.Nil(t, paper, "should not return paper for bad state error")
+
+	resetPaper(wsPaper)
+	setTxTime(stub, "2020-11-30")
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-11-30")
+	assert.EqualError(t, err, "Paper someissuer:somepaper matured on 2020-11-30 and can no longer be bought", "should error when paper has matured")
+	assert.Nil(t, paper, "should not return paper for matured paper")
+	setTxTime(stub, "2020-06-01")
+
+	resetPaper(wsPaper)
+	shouldError = true
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-06-01")
+	assert.EqualError(t, err, "UpdatePaper error", "should error when update paper fails")
+	assert.Nil(t, paper, "should not return paper for bad state error")
+	shouldError = false
+
+	resetPaper(wsPaper)
+	wsPaper.SetIssued()
+	setClientMSPID(ctx, "someotherownermsp")
+	paper, err = contract.Buy(ctx, "someissuer", "somepaper", "someowner", "someotherowner", 100, "2020-06-01")
+	assert.Nil(t, err, "should not error when good paper and owner")
+	assert.Equal(t, "someotherowner", paper.Owner, "should update the owner of the paper")
+	assert.Equal(t, "someotherownermsp", paper.OwnerMSP, "should bind the owner to the MSP of the buyer")
+	assert.Equal(t, 100, paper.Price, "should record the price paid")
+	assert.True(t, paper.IsTrading(), "should mark issued paper as trading")
+	assert.Equal(t, sentPaper, paper, "should update same paper as it returns in the world state")
+	assertPaperEvent(t, stub, BuyEvent, paper)
+}
+
+func TestRedeem(t *testing.T) {
+	var paper *CommercialPaper
+	var err error
+
+	ctx, stub := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	var sentPaper *CommercialPaper
+	wsPaper := new(CommercialPaper)
+	resetPaper(wsPaper)
+
+	var emptyPaper *CommercialPaper
+	shouldError := false
+
+	mpl.On("GetPaper", "someissuer", "somepaper").Return(wsPaper, nil)
+	mpl.On("GetPaper", "someotherissuer", "someotherpaper").Return(emptyPaper, errors.New("GetPaper error"))
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { return shouldError })).Return(errors.New("UpdatePaper error"))
+	mpl.On("UpdatePaper", mock.MatchedBy(func(paper *CommercialPaper) bool { sentPaper = paper; return !shouldError })).Return(nil)
+
+	setTxTime(stub, "2020-12-01")
+
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020/12/01")
+	assert.EqualError(t, err, "Invalid redeem date 2020/12/01. Use a date such as 2020-05-31 or 2020-05-31T10:00:00Z", "should error when redeem date invalid")
+	assert.Nil(t, paper, "should not return paper for invalid redeem date")
+
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-02T09:00:00Z")
+	assert.EqualError(t, err, "Invalid redeem date 2020-12-02T09:00:00Z. The transaction was created on 2020-12-01", "should error when redeem date is not the transaction date")
+	assert.Nil(t, paper, "should not return paper for redeem date other than the transaction date")
+
+	paper, err = contract.Redeem(ctx, "someotherissuer", "someotherpaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "GetPaper error", "should error when GetPaper errors")
+	assert.Nil(t, paper, "should not return paper when GetPaper errors")
+
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someotherowner", "2020-12-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is not owned by someotherowner", "should error when paper owned by someone else")
+	assert.Nil(t, paper, "should not return paper when errors as owned by someone else")
+
+	resetPaper(wsPaper)
+	wsPaper.SetRedeemed()
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper is already redeemed", "should error when paper already redeemed")
+	assert.Nil(t, paper, "should not return paper when errors as already redeemed")
+
+	resetPaper(wsPaper)
+	wsPaper.SetPending()
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper has a pending buy request and cannot be redeemed", "should error when paper has pending request")
+	assert.Nil(t, paper, "should not return paper when paper has pending request")
+
+	resetPaper(wsPaper)
+	setClientMSPID(ctx, "someothermsp")
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be redeemed by someothermsp as it is owned by someownermsp", "should error when client not of owning organization")
+	assert.Nil(t, paper, "should not return paper when client not of owning organization")
+	setClientMSPID(ctx, "someownermsp")
+
+	resetPaper(wsPaper)
+	setTxTime(stub, "2020-11-29")
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-11-29")
+	assert.EqualError(t, err, "Paper someissuer:somepaper cannot be redeemed before it matures on 2020-11-30", "should error when paper has not matured")
+	assert.Nil(t, paper, "should not return paper when paper has not matured")
+	setTxTime(stub, "2020-12-01")
+
+	shouldError = true
+	resetPaper(wsPaper)
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.EqualError(t, err, "UpdatePaper error", "should error when update paper errors")
+	assert.Nil(t, paper, "should not return paper when UpdatePaper errors")
+	shouldError = false
+
+	resetPaper(wsPaper)
+	paper, err = contract.Redeem(ctx, "someissuer", "somepaper", "someowner", "2020-12-01")
+	assert.Nil(t, err, "should not error on good redeem")
+	assert.True(t, paper.IsRedeemed(), "should return redeemed paper")
+	assert.Equal(t, "someissuer", paper.Owner, "should return paper to the issuer")
+	assert.Equal(t, "someissuermsp", paper.OwnerMSP, "should return paper to the MSP of the issuer")
+	assert.Equal(t, "2020-12-01T00:00:00Z", paper.RedeemDateTime, "should record the time of the transaction as the redeem date")
+	assert.Equal(t, sentPaper, paper, "should update same paper as it returns in the world state")
+	assertPaperEvent(t, stub, RedeemEvent, paper)
+}
+
+func TestQueryPapers(t *testing.T) {
+	var papers []*CommercialPaper
+	var err error
+
+	ctx, _ := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	expectedPapers := []*CommercialPaper{{PaperNumber: "somepaper", Issuer: "someissuer", Owner: "someowner"}}
+
+	mpl.On("GetPapersByIssuer", "someissuer").Return(expectedPapers, nil)
+	mpl.On("GetPapersByOwner", "someowner").Return(expectedPapers, nil)
+	mpl.On("GetPapersByState", TRADING).Return(expectedPapers, nil)
+	mpl.On("GetPapersByState", REDEEMED).Return([]*CommercialPaper{}, errors.New("GetPapersByState error"))
+
+	papers, err = contract.QueryPapersByIssuer(ctx, "someissuer")
+	assert.Nil(t, err, "should not error when GetPapersByIssuer does not error")
+	assert.Equal(t, expectedPapers, papers, "should return papers of the issuer")
+
+	papers, err = contract.QueryPapersByOwner(ctx, "someowner")
+	assert.Nil(t, err, "should not error when GetPapersByOwner does not error")
+	assert.Equal(t, expectedPapers, papers, "should return papers of the owner")
+
+	papers, err = contract.QueryPapersByState(ctx, "TRADING")
+	assert.Nil(t, err, "should not error when GetPapersByState does not error")
+	assert.Equal(t, expectedPapers, papers, "should return papers in the state")
+
+	_, err = contract.QueryPapersByState(ctx, "REDEEMED")
+	assert.EqualError(t, err, "GetPapersByState error", "should return error when GetPapersByState errors")
+
+	papers, err = contract.QueryPapersByState(ctx, "LOST")
+	assert.EqualError(t, err, "Unknown commercial paper state LOST", "should error for unknown state name")
+	assert.Nil(t, papers, "should not return papers for unknown state name")
+}
+
+func TestGetPaperHistory(t *testing.T) {
+	ctx, _ := newMockTransactionContext()
+	mpl := ctx.paperList
+
+	contract := new(Contract)
+
+	expectedHistory := []PaperHistory{{TxID: "sometx", Paper: &CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer"}}}
+
+	mpl.On("GetPaperHistory", "someissuer", "somepaper").Return(expectedHistory, nil)
+
+	history, err := contract.GetPaperHistory(ctx, "someissuer", "somepaper")
+	assert.Nil(t, err, "should not error when GetPaperHistory does not error")
+	assert.Equal(t, expectedHistory, history, "should return history from the paper list")
+}
+
+func assertPaperEvent(t *testing.T, stub *shimtest.MockStub, name string, paper *CommercialPaper) {
+	t.Helper()
+
+	expectedPayload, _ := paper.Serialize()
+
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		assert.Equal(t, name, event.EventName, "should set event with correct name")
+		assert.Equal(t, expectedPayload, event.Payload, "should set the paper as event payload")
+	default:
+		t.Errorf("should set an event")
+	}
+
+	assert.Empty(t, stub.ChaincodeEventsChannel, "should only set one event")
+}
@@ -0,0 +1,136 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"encoding/json"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/ledger-api"
+)
+
+// ListInterface defines functionality needed
+// to interact with the world state on behalf
+// of a commercial paper
+type ListInterface interface {
+	AddPaper(*CommercialPaper) error
+	GetPaper(string, string) (*CommercialPaper, error)
+	UpdatePaper(*CommercialPaper) error
+	GetPapersByIssuer(string) ([]*CommercialPaper, error)
+	GetPapersByOwner(string) ([]*CommercialPaper, error)
+	GetPapersByState(State) ([]*CommercialPaper, error)
+	GetPaperHistory(string, string) ([]PaperHistory, error)
+}
+
+type list struct {
+	stateList ledgerapi.StateListInterface
+}
+
+func (cpl *list) AddPaper(paper *CommercialPaper) error {
+	return cpl.stateList.AddState(paper)
+}
+
+func (cpl *list) GetPaper(issuer string, paperNumber string) (*CommercialPaper, error) {
+	cp := new(CommercialPaper)
+
+	err := cpl.stateList.GetState(CreateCommercialPaperKey(issuer, paperNumber), cp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+func (cpl *list) UpdatePaper(paper *CommercialPaper) error {
+	return cpl.stateList.UpdateState(paper)
+}
+
+func (cpl *list) GetPapersByIssuer(issuer string) ([]*CommercialPaper, error) {
+	states, err := cpl.stateList.GetStatesByPartialKey([]string{issuer})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toPapers(states), nil
+}
+
+func (cpl *list) GetPapersByOwner(owner string) ([]*CommercialPaper, error) {
+	return cpl.queryPapers(map[string]interface{}{"owner": owner})
+}
+
+func (cpl *list) GetPapersByState(state State) ([]*CommercialPaper, error) {
+	return cpl.queryPapers(map[string]interface{}{"currentState": state})
+}
+
+func (cpl *list) GetPaperHistory(issuer string, paperNumber string) ([]PaperHistory, error) {
+	states, err := cpl.stateList.GetStateHistory(CreateCommercialPaperKey(issuer, paperNumber))
+
+	if err != nil {
+		return nil, err
+	}
+
+	history := []PaperHistory{}
+
+	for _, state := range states {
+		entry := PaperHistory{TxID: state.TxID, Timestamp: state.Timestamp, IsDelete: state.IsDelete}
+
+		if state.State != nil {
+			entry.Paper = state.State.(*CommercialPaper)
+		}
+
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// queryPapers runs a rich query for commercial papers with
+// fields matching the passed selector
+func (cpl *list) queryPapers(selector map[string]interface{}) ([]*CommercialPaper, error) {
+	selector["class"] = paperClass
+
+	query, err := json.Marshal(map[string]interface{}{"selector": selector})
+
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := cpl.stateList.QueryStates(string(query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toPapers(states), nil
+}
+
+func toPapers(states []ledgerapi.StateInterface) []*CommercialPaper {
+	papers := []*CommercialPaper{}
+
+	for _, state := range states {
+		papers = append(papers, state.(*CommercialPaper))
+	}
+
+	return papers
+}
+
+// NewList create a new list from context
+func newList(ctx TransactionContextInterface) *list {
+	stateList := new(ledgerapi.StateList)
+	stateList.Ctx = ctx
+	stateList.Name = "org.papernet.commercialpaperlist"
+	stateList.Deserialize = func(bytes []byte, state ledgerapi.StateInterface) error {
+		return Deserialize(bytes, state.(*CommercialPaper))
+	}
+	stateList.New = func() ledgerapi.StateInterface {
+		return new(CommercialPaper)
+	}
+
+	list := new(list)
+	list.stateList = stateList
+
+	return list
+}
@@ -0,0 +1,179 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commercialpaper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/ledger-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// #########
+// HELPERS
+// #########
+
+type MockStateList struct {
+	mock.Mock
+}
+
+func (msl *MockStateList) AddState(state ledgerapi.StateInterface) error {
+	args := msl.Called(state)
+
+	return args.Error(0)
+}
+
+func (msl *MockStateList) GetState(key string, state ledgerapi.StateInterface) error {
+	args := msl.Called(key, state)
+
+	state.(*CommercialPaper).PaperNumber = "somepaper"
+
+	return args.Error(0)
+}
+
+func (msl *MockStateList) UpdateState(state ledgerapi.StateInterface) error {
+	args := msl.Called(state)
+
+	return args.Error(0)
+}
+
+func (msl *MockStateList) GetStatesByPartialKey(keyParts []string) ([]ledgerapi.StateInterface, error) {
+	args := msl.Called(keyParts)
+
+	return args.Get(0).([]ledgerapi.StateInterface), args.Error(1)
+}
+
+func (msl *MockStateList) QueryStates(query string) ([]ledgerapi.StateInterface, error) {
+	args := msl.Called(query)
+
+	return args.Get(0).([]ledgerapi.StateInterface), args.Error(1)
+}
+
+func (msl *MockStateList) GetStateHistory(key string) ([]ledgerapi.StateHistory, error) {
+	args := msl.Called(key)
+
+	return args.Get(0).([]ledgerapi.StateHistory), args.Error(1)
+}
+
+// #########
+// TESTS
+// #########
+
+func TestAddPaper(t *testing.T) {
+	paper := new(CommercialPaper)
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("AddState", paper).Return(errors.New("Called add state correctly"))
+	list.stateList = msl
+
+	err := list.AddPaper(paper)
+	assert.EqualError(t, err, "Called add state correctly", "should call state list add state with paper")
+}
+
+func TestGetPaper(t *testing.T) {
+	var cp *CommercialPaper
+	var err error
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("GetState", CreateCommercialPaperKey("someissuer", "somepaper"), mock.MatchedBy(func(state ledgerapi.StateInterface) bool { _, ok := state.(*CommercialPaper); return ok })).Return(nil)
+	msl.On("GetState", CreateCommercialPaperKey("someotherissuer", "someotherpaper"), mock.MatchedBy(func(state ledgerapi.StateInterface) bool { _, ok := state.(*CommercialPaper); return ok })).Return(errors.New("GetState error"))
+	list.stateList = msl
+
+	cp, err = list.GetPaper("someissuer", "somepaper")
+	assert.Nil(t, err, "should not error when get state on state list does not error")
+	assert.Equal(t, cp.PaperNumber, "somepaper", "should use state list GetState to fill commercial paper")
+
+	cp, err = list.GetPaper("someotherissuer", "someotherpaper")
+	assert.EqualError(t, err, "GetState error", "should return error when state list get state errors")
+	assert.Nil(t, cp, "should not return commercial paper on error")
+}
+
+func TestUpdatePaper(t *testing.T) {
+	paper := new(CommercialPaper)
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("UpdateState", paper).Return(errors.New("Called update state correctly"))
+	list.stateList = msl
+
+	err := list.UpdatePaper(paper)
+	assert.EqualError(t, err, "Called update state correctly", "should call state list update state with paper")
+}
+
+func TestGetPapersByIssuer(t *testing.T) {
+	var papers []*CommercialPaper
+	var err error
+
+	paper := &CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer"}
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("GetStatesByPartialKey", []string{"someissuer"}).Return([]ledgerapi.StateInterface{paper}, nil)
+	msl.On("GetStatesByPartialKey", []string{"someotherissuer"}).Return([]ledgerapi.StateInterface{}, errors.New("GetStatesByPartialKey error"))
+	list.stateList = msl
+
+	papers, err = list.GetPapersByIssuer("someissuer")
+	assert.Nil(t, err, "should not error when state list does not error")
+	assert.Equal(t, []*CommercialPaper{paper}, papers, "should return papers found by partial key")
+
+	papers, err = list.GetPapersByIssuer("someotherissuer")
+	assert.EqualError(t, err, "GetStatesByPartialKey error", "should return error when state list errors")
+	assert.Nil(t, papers, "should not return papers on error")
+}
+
+func TestGetPapersByOwnerAndState(t *testing.T) {
+	var papers []*CommercialPaper
+	var err error
+
+	paper := &CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer", Owner: "someowner"}
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("QueryStates", `{"selector":{"class":"org.papernet.commercialpaper","owner":"someowner"}}`).Return([]ledgerapi.StateInterface{paper}, nil)
+	msl.On("QueryStates", `{"selector":{"class":"org.papernet.commercialpaper","currentState":2}}`).Return([]ledgerapi.StateInterface{}, errors.New("QueryStates error"))
+	list.stateList = msl
+
+	papers, err = list.GetPapersByOwner("someowner")
+	assert.Nil(t, err, "should not error when state list does not error")
+	assert.Equal(t, []*CommercialPaper{paper}, papers, "should query papers by owner")
+
+	papers, err = list.GetPapersByState(TRADING)
+	assert.EqualError(t, err, "QueryStates error", "should query papers by state and return error when state list errors")
+	assert.Nil(t, papers, "should not return papers on error")
+}
+
+func TestGetPaperHistoryFromList(t *testing.T) {
+	paper := &CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer"}
+	timestamp := time.Unix(1000, 0)
+
+	list := new(list)
+	msl := new(MockStateList)
+	msl.On("GetStateHistory", CreateCommercialPaperKey("someissuer", "somepaper")).Return([]ledgerapi.StateHistory{{TxID: "tx2", Timestamp: timestamp, IsDelete: true}, {TxID: "tx1", Timestamp: timestamp, State: paper}}, nil)
+	list.stateList = msl
+
+	history, err := list.GetPaperHistory("someissuer", "somepaper")
+	assert.Nil(t, err, "should not error when state list does not error")
+	assert.Equal(t, []PaperHistory{{TxID: "tx2", Timestamp: timestamp, IsDelete: true}, {TxID: "tx1", Timestamp: timestamp, Paper: paper}}, history, "should convert state history to paper history")
+}
+
+func TestNewStateList(t *testing.T) {
+	ctx := new(TransactionContext)
+	list := newList(ctx)
+	stateList, ok := list.stateList.(*ledgerapi.StateList)
+
+	assert.True(t, ok, "should make statelist of type ledgerapi.StateList")
+	assert.Equal(t, ctx, stateList.Ctx, "should set the context to passed context")
+	assert.Equal(t, "org.papernet.commercialpaperlist", stateList.Name, "should set the name for the list")
+
+	expectedErr := Deserialize([]byte("bad json"), new(CommercialPaper))
+	err := stateList.Deserialize([]byte("bad json"), new(CommercialPaper))
+	assert.EqualError(t, err, expectedErr.Error(), "should call Deserialize when stateList.Deserialize called")
+	assert.Equal(t, new(CommercialPaper), stateList.New(), "should create empty commercial papers for the list")
+}
@@ -0,0 +1,10 @@
+module github.com/hyperledger/fabric-samples/commercial-paper/contract-go
+
+go 1.13
+
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
+	github.com/hyperledger/fabric-contract-api-go v1.2.0
+	github.com/stretchr/testify v1.8.0
+)
@@ -0,0 +1,187 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package ledgerapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// StateListInterface functions that a state list
+// should have
+type StateListInterface interface {
+	AddState(StateInterface) error
+	GetState(string, StateInterface) error
+	UpdateState(StateInterface) error
+	GetStatesByPartialKey([]string) ([]StateInterface, error)
+	QueryStates(string) ([]StateInterface, error)
+	GetStateHistory(string) ([]StateHistory, error)
+}
+
+// StateHistory a value a state held at some point
+// in its lifetime. State is nil when the value was
+// a delete
+type StateHistory struct {
+	TxID      string
+	Timestamp time.Time
+	IsDelete  bool
+	State     StateInterface
+}
+
+// StateList useful for managing putting data in and out
+// of the ledger. Implementation of StateListInterface
+type StateList struct {
+	Ctx         contractapi.TransactionContextInterface
+	Name        string
+	Deserialize func([]byte, StateInterface) error
+	New         func() StateInterface
+}
+
+// AddState puts state into world state
+func (sl *StateList) AddState(state StateInterface) error {
+	key, err := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, state.GetSplitKey())
+
+	if err != nil {
+		return err
+	}
+
+	data, err := state.Serialize()
+
+	if err != nil {
+		return err
+	}
+
+	return sl.Ctx.GetStub().PutState(key, data)
+}
+
+// GetState returns state from world state. Unmarshalls the JSON
+// into passed state. Key is the split key value used in Add/Update
+// joined using a colon
+func (sl *StateList) GetState(key string, state StateInterface) error {
+	ledgerKey, err := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
+
+	if err != nil {
+		return err
+	}
+
+	data, err := sl.Ctx.GetStub().GetState(ledgerKey)
+
+	if err != nil {
+		return err
+	} else if data == nil {
+		return fmt.Errorf("No state found for %s", key)
+	}
+
+	return sl.Deserialize(data, state)
+}
+
+// UpdateState puts state into world state. Same as AddState but
+// separate as semantically different
+func (sl *StateList) UpdateState(state StateInterface) error {
+	return sl.AddState(state)
+}
+
+// GetStatesByPartialKey returns all states in the list whose split
+// key starts with the passed key parts
+func (sl *StateList) GetStatesByPartialKey(keyParts []string) ([]StateInterface, error) {
+	iterator, err := sl.Ctx.GetStub().GetStateByPartialCompositeKey(sl.Name, keyParts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sl.readStates(iterator)
+}
+
+// QueryStates returns the states matching the passed rich query.
+// Only available on state databases that support rich query (e.g. CouchDB).
+// The query should select on the class of the state as results are not
+// limited to the list
+func (sl *StateList) QueryStates(query string) ([]StateInterface, error) {
+	iterator, err := sl.Ctx.GetStub().GetQueryResult(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sl.readStates(iterator)
+}
+
+// GetStateHistory returns every value the state with the passed key
+// has held, most recent first. Key is the split key value used in
+// Add/Update joined using a colon
+func (sl *StateList) GetStateHistory(key string) ([]StateHistory, error) {
+	ledgerKey, err := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
+
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := sl.Ctx.GetStub().GetHistoryForKey(ledgerKey)
+
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	history := []StateHistory{}
+
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry := StateHistory{TxID: modification.TxId, IsDelete: modification.IsDelete}
+
+		if modification.Timestamp != nil {
+			entry.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)).UTC()
+		}
+
+		if !modification.IsDelete {
+			entry.State = sl.New()
+
+			err = sl.Deserialize(modification.Value, entry.State)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+func (sl *StateList) readStates(iterator shim.StateQueryIteratorInterface) ([]StateInterface, error) {
+	defer iterator.Close()
+
+	states := []StateInterface{}
+
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+
+		if err != nil {
+			return nil, err
+		}
+
+		state := sl.New()
+
+		err = sl.Deserialize(result.Value, state)
+
+		if err != nil {
+			return nil, err
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
@@ -0,0 +1,26 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"fmt"
+
+	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/commercial-paper"
+)
+
+// approvePurchase is DigiBank's check on every purchase it endorses.
+// Commercial paper is sold at a discount to its face value, so DigiBank
+// will not endorse a purchase at no cost or at a premium
+func approvePurchase(ctx commercialpaper.TransactionContextInterface, paper *commercialpaper.CommercialPaper, newOwner string, price int) error {
+	if price <= 0 {
+		return fmt.Errorf("Purchase of paper %s:%s not approved. Price must be positive", paper.Issuer, paper.PaperNumber)
+	}
+
+	if price > paper.FaceValue {
+		return fmt.Errorf("Purchase of paper %s:%s not approved. Price %d exceeds face value %d", paper.Issuer, paper.PaperNumber, price, paper.FaceValue)
+	}
+
+	return nil
+}
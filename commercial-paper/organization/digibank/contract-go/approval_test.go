@@ -0,0 +1,25 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/commercial-paper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovePurchase(t *testing.T) {
+	paper := &commercialpaper.CommercialPaper{PaperNumber: "somepaper", Issuer: "someissuer", FaceValue: 1000}
+
+	assert.Nil(t, approvePurchase(nil, paper, "someowner", 950), "should approve purchase at a discount")
+	assert.Nil(t, approvePurchase(nil, paper, "someowner", 1000), "should approve purchase at face value")
+
+	err := approvePurchase(nil, paper, "someowner", 0)
+	assert.EqualError(t, err, "Purchase of paper someissuer:somepaper not approved. Price must be positive", "should not approve purchase at no cost")
+
+	err = approvePurchase(nil, paper, "someowner", 1100)
+	assert.EqualError(t, err, "Purchase of paper someissuer:somepaper not approved. Price 1100 exceeds face value 1000", "should not approve purchase at a premium")
+}
@@ -4,5 +4,8 @@ go 1.13
 
 require (
 	github.com/hyperledger/fabric-contract-api-go v1.2.0
+	github.com/hyperledger/fabric-samples/commercial-paper/contract-go v0.0.0
 	github.com/stretchr/testify v1.8.0
 )
+
+replace github.com/hyperledger/fabric-samples/commercial-paper/contract-go => ../../../contract-go
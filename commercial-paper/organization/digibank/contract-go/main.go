@@ -8,7 +8,7 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
-	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/organization/digibank/contract-go/commercial-paper"
+	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/commercial-paper"
 )
 
 func main() {
@@ -17,6 +17,7 @@ func main() {
 	contract.TransactionContextHandler = new(commercialpaper.TransactionContext)
 	contract.Name = "org.papernet.commercialpaper"
 	contract.Info.Version = "0.0.1"
+	contract.Hooks.OnBuy(approvePurchase)
 
 	chaincode, err := contractapi.NewChaincode(contract)
 
@@ -0,0 +1,40 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"fmt"
+
+	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/commercial-paper"
+)
+
+// issuanceLimit the most an issuer may have outstanding, as the
+// sum of the face values of its papers that are not yet redeemed
+const issuanceLimit = 50000000
+
+// limitIssuance is MagnetoCorp's check on every paper it endorses
+// the issue of. A paper is rejected if it would take the issuer
+// over the issuance limit
+func limitIssuance(ctx commercialpaper.TransactionContextInterface, paper *commercialpaper.CommercialPaper) error {
+	papers, err := ctx.GetPaperList().GetPapersByIssuer(paper.Issuer)
+
+	if err != nil {
+		return err
+	}
+
+	outstanding := 0
+
+	for _, issued := range papers {
+		if !issued.IsRedeemed() {
+			outstanding += issued.FaceValue
+		}
+	}
+
+	if outstanding+paper.FaceValue > issuanceLimit {
+		return fmt.Errorf("Issue of paper %s:%s not allowed. %s has %d outstanding and the limit is %d", paper.Issuer, paper.PaperNumber, paper.Issuer, outstanding, issuanceLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	commercialpaper "github.com/hyperledger/fabric-samples/commercial-paper/contract-go/commercial-paper"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPaperList struct {
+	commercialpaper.ListInterface
+	papers map[string][]*commercialpaper.CommercialPaper
+}
+
+func (mpl *mockPaperList) GetPapersByIssuer(issuer string) ([]*commercialpaper.CommercialPaper, error) {
+	papers, ok := mpl.papers[issuer]
+
+	if !ok {
+		return nil, errors.New("GetPapersByIssuer error")
+	}
+
+	return papers, nil
+}
+
+type mockTransactionContext struct {
+	contractapi.TransactionContext
+	paperList *mockPaperList
+}
+
+func (mtc *mockTransactionContext) GetPaperList() commercialpaper.ListInterface {
+	return mtc.paperList
+}
+
+func newPaper(issuer string, faceValue int, redeemed bool) *commercialpaper.CommercialPaper {
+	paper := &commercialpaper.CommercialPaper{PaperNumber: "somepaper", Issuer: issuer, FaceValue: faceValue}
+	paper.SetTrading()
+
+	if redeemed {
+		paper.SetRedeemed()
+	}
+
+	return paper
+}
+
+func TestLimitIssuance(t *testing.T) {
+	ctx := new(mockTransactionContext)
+	ctx.paperList = &mockPaperList{papers: map[string][]*commercialpaper.CommercialPaper{
+		"someissuer": {newPaper("someissuer", 30000000, false), newPaper("someissuer", 40000000, true)},
+	}}
+
+	err := limitIssuance(ctx, newPaper("someissuer", 20000000, false))
+	assert.Nil(t, err, "should allow issue up to the limit, ignoring redeemed papers")
+
+	err = limitIssuance(ctx, newPaper("someissuer", 20000001, false))
+	assert.EqualError(t, err, "Issue of paper someissuer:somepaper not allowed. someissuer has 30000000 outstanding and the limit is 50000000", "should not allow issue over the limit")
+
+	err = limitIssuance(ctx, newPaper("someotherissuer", 1000, false))
+	assert.EqualError(t, err, "GetPapersByIssuer error", "should return error when papers of issuer cannot be read")
+}
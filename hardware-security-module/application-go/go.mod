@@ -4,7 +4,7 @@ go 1.18
 
 require (
 	github.com/hyperledger/fabric-gateway v1.1.0
-	google.golang.org/grpc v1.47.0
+	github.com/hyperledger/fabric-samples/client-go v0.0.0
 )
 
 require (
@@ -15,5 +15,9 @@ require (
 	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/genproto v0.0.0-20220527130721-00d5c0f3be58 // indirect
+	google.golang.org/grpc v1.47.0 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/hyperledger/fabric-samples/client-go => ../../client-go
@@ -11,31 +11,18 @@ package main
 
 import (
 	"bytes"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/sha256"
 	"encoding/json"
-	"encoding/pem"
-	"errors"
-	"os"
-
-	"crypto/x509"
 	"fmt"
-	"io/ioutil"
+	"log"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
-	"github.com/hyperledger/fabric-gateway/pkg/identity"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
 )
 
-const (
-	mspID        = "Org1MSP"
-	certPath     = "../crypto-material/hsm/HSMUser/signcerts/cert.pem"
-	tlsCertPath  = "../../test-network/organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt"
-	peerEndpoint = "localhost:7051"
-)
+// clientProfile names the HSM client identity and its PKCS#11 token, and the gateway peer. The settings can be
+// overridden by HLF_* environment variables, and the PKCS#11 library by PKCS11_LIB.
+const clientProfile = "clientProfile.yaml"
 
 var now = time.Now()
 var assetId = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
@@ -43,24 +30,27 @@ var assetId = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
 func main() {
 	fmt.Println("Running the GO HSM Sample")
 
+	p, err := profile.Load(clientProfile)
+	if err != nil {
+		panic(err)
+	}
+
 	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	clientConnection := newGrpcConnection()
+	clientConnection, err := p.NewConnection()
+	if err != nil {
+		panic(err)
+	}
 	defer clientConnection.Close()
 
-	hsmSignerFactory, err := identity.NewHSMSignerFactory(findSoftHSMLibrary())
+	id, err := p.NewIdentity()
 	if err != nil {
 		panic(err)
 	}
-	defer hsmSignerFactory.Dispose()
-
-	certificatePEM, err := ioutil.ReadFile(certPath)
+	// The signer finds the private key in the HSM by the subject key identifier of the client certificate
+	hsmSign, hsmSignClose, err := p.NewSign(profile.WithAuditLog(log.Default()))
 	if err != nil {
 		panic(err)
 	}
-
-	id := newIdentity(certificatePEM)
-	ski := getSKI(certificatePEM)
-	hsmSign, hsmSignClose := newHSMSign(hsmSignerFactory, ski)
 	defer hsmSignClose()
 
 	// Create a Gateway connection for a specific client identity
@@ -100,99 +90,6 @@ func exampleTransaction(gateway *client.Gateway) {
 	fmt.Printf("*** Result:%s\n", result)
 }
 
-// newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection() *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
-	if err != nil {
-		panic(fmt.Errorf("failed to obtain commit status: %w", err))
-	}
-
-	certPool := x509.NewCertPool()
-	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, "peer0.org1.example.com")
-
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
-	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
-	}
-
-	return connection
-}
-
-// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity(certificatePEM []byte) *identity.X509Identity {
-	cert, err := identity.CertificateFromPEM(certificatePEM)
-	if err != nil {
-		panic(err)
-	}
-	id, err := identity.NewX509Identity(mspID, cert)
-	if err != nil {
-		panic(err)
-	}
-
-	return id
-}
-
-// newHSMSign creates a function that generates a digital signature from a message digest using a private key.
-func newHSMSign(h *identity.HSMSignerFactory, certPEM []byte) (identity.Sign, identity.HSMSignClose) {
-	opt := identity.HSMSignerOptions{
-		Label:      "ForFabric",
-		Pin:        "98765432",
-		Identifier: string(certPEM),
-	}
-
-	sign, close, err := h.NewHSMSigner(opt)
-	if err != nil {
-		panic(err)
-	}
-
-	return sign, close
-}
-
-func loadCertificate(filename string) (*x509.Certificate, error) {
-	certificatePEM, err := ioutil.ReadFile(filename) //#nosec G304
-	if err != nil {
-		return nil, err
-	}
-
-	return identity.CertificateFromPEM(certificatePEM)
-}
-
-func getSKI(certPEM []byte) []byte {
-	block, _ := pem.Decode(certPEM)
-
-	x590cert, _ := x509.ParseCertificate(block.Bytes)
-	pk := x590cert.PublicKey
-
-	return skiForKey(pk.(*ecdsa.PublicKey))
-}
-
-func skiForKey(pk *ecdsa.PublicKey) []byte {
-	ski := sha256.Sum256(elliptic.Marshal(pk.Curve, pk.X, pk.Y))
-	return ski[:]
-}
-
-func findSoftHSMLibrary() string {
-
-	libraryLocations := []string{
-		"/usr/lib/softhsm/libsofthsm2.so",
-		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
-		"/usr/local/lib/softhsm/libsofthsm2.so",
-		"/usr/lib/libacsp-pkcs11.so",
-	}
-	pkcs11lib := os.Getenv("PKCS11_LIB")
-	if pkcs11lib != "" {
-		libraryLocations = append(libraryLocations, pkcs11lib)
-	}
-	for _, libraryLocation := range libraryLocations {
-		if _, err := os.Stat(libraryLocation); !errors.Is(err, os.ErrNotExist) {
-			return libraryLocation
-		}
-	}
-
-	panic("No SoftHSM library can be found. The Sample requires SoftHSM to be installed")
-}
-
 // Format JSON data
 func formatJSON(data []byte) string {
 	var prettyJSON bytes.Buffer
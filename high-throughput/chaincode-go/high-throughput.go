@@ -28,13 +28,17 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"sync"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-samples/chaincode/router"
 )
 
 //SmartContract is the data structure which represents this contract and on which  various contract lifecycle functions are attached
 type SmartContract struct {
+	routerOnce sync.Once
+	routes     *router.Router
 }
 
 // Define Status codes for the response
@@ -55,27 +59,29 @@ func (s *SmartContract) Init(APIstub shim.ChaincodeStubInterface) pb.Response {
 //	- prune, deletes all rows associated with the variable and replaces them with a single row containing the aggregate value
 //	- delete, removes all rows associated with the variable
 func (s *SmartContract) Invoke(APIstub shim.ChaincodeStubInterface) pb.Response {
-	// Retrieve the requested Smart Contract function and arguments
-	function, args := APIstub.GetFunctionAndParameters()
-
 	// Route to the appropriate handler function to interact with the ledger appropriately
-	if function == "update" {
-		return s.update(APIstub, args)
-	} else if function == "get" {
-		return s.get(APIstub, args)
-	} else if function == "prune" {
-		return s.prune(APIstub, args)
-	} else if function == "delete" {
-		return s.delete(APIstub, args)
-	} else if function == "putstandard" {
-		return s.putStandard(APIstub, args)
-	} else if function == "getstandard" {
-		return s.getStandard(APIstub, args)
-	} else if function == "delstandard" {
-		return s.delStandard(APIstub, args)
-	}
+	s.routerOnce.Do(func() { s.routes = s.newRouter() })
+	return s.routes.Invoke(APIstub)
+}
 
-	return shim.Error("Invalid Smart Contract function name.")
+// newRouter declares the functions of the contract and the arguments they expect
+func (s *SmartContract) newRouter() *router.Router {
+	r := router.New()
+	r.Handle("update", s.update, router.String("name"), router.Float("delta"), router.String("op").OneOf("+", "-")).
+		Describe("Add a delta to an aggregate variable")
+	r.Handle("get", s.get, router.String("name")).
+		Describe("Get the aggregate value of a variable")
+	r.Handle("prune", s.prune, router.String("name")).
+		Describe("Replace all rows of a variable with a single row holding its aggregate value")
+	r.Handle("delete", s.delete, router.String("name")).
+		Describe("Remove all rows of a variable")
+	r.Handle("putstandard", s.putStandard, router.String("name"), router.String("value")).
+		Describe("Put a single row value")
+	r.Handle("getstandard", s.getStandard, router.String("name")).
+		Describe("Get a single row value")
+	r.Handle("delstandard", s.delStandard, router.String("name")).
+		Describe("Delete a single row value")
+	return r
 }
 
 /**
@@ -92,23 +98,10 @@ func (s *SmartContract) Invoke(APIstub shim.ChaincodeStubInterface) pb.Response
  * @return A response structure indicating success or failure with a message
  */
 func (s *SmartContract) update(APIstub shim.ChaincodeStubInterface, args []string) pb.Response {
-	// Check we have a valid number of args
-	if len(args) != 3 {
-		return shim.Error("Incorrect number of arguments, expecting 3")
-	}
-
-	// Extract the args
+	// Extract the args, the router has made sure that the delta is a number and
+	// the operator is supported
 	name := args[0]
 	op := args[2]
-	_, err := strconv.ParseFloat(args[1], 64)
-	if err != nil {
-		return shim.Error("Provided value was not a number")
-	}
-
-	// Make sure a valid operator is provided
-	if op != "+" && op != "-" {
-		return shim.Error(fmt.Sprintf("Operator %s is unrecognized", op))
-	}
 
 	// Retrieve info needed for the update procedure
 	txid := APIstub.GetTxID()
@@ -141,11 +134,6 @@ func (s *SmartContract) update(APIstub shim.ChaincodeStubInterface, args []strin
  * @return A response structure indicating success or failure with a message
  */
 func (s *SmartContract) get(APIstub shim.ChaincodeStubInterface, args []string) pb.Response {
-	// Check we have a valid number of args
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments, expecting 1")
-	}
-
 	name := args[0]
 	// Get all deltas for the variable
 	deltaResultsIterator, deltaErr := APIstub.GetStateByPartialCompositeKey("varName~op~value~txID", []string{name})
@@ -210,11 +198,6 @@ func (s *SmartContract) get(APIstub shim.ChaincodeStubInterface, args []string)
  * @return A response structure indicating success or failure with a message
  */
 func (s *SmartContract) prune(APIstub shim.ChaincodeStubInterface, args []string) pb.Response {
-	// Check we have a valid number of ars
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments, expecting 1")
-	}
-
 	// Retrieve the name of the variable to prune
 	name := args[0]
 
@@ -293,11 +276,6 @@ func (s *SmartContract) prune(APIstub shim.ChaincodeStubInterface, args []string
  * @return A response structure indicating success or failure with a message
  */
 func (s *SmartContract) delete(APIstub shim.ChaincodeStubInterface, args []string) pb.Response {
-	// Check there are a correct number of arguments
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments, expecting 1")
-	}
-
 	// Retrieve the variable name
 	name := args[0]
 
@@ -19,20 +19,44 @@ import (
 
 /* InterestRateSwap represents an interest rate swap on the ledger
  * The swap is active between its start- and end-date.
- * At the specified interval, two parties A and B exchange the following payments:
- * A->B (PrincipalAmount * FixedRateBPS) / 100
- * B->A (PrincipalAmount * (ReferenceRateBPS + FloatingRateBPS)) / 100
+ * The term of the swap is split into periods of the payment interval, given as a
+ * number of months. At the end of every period, two parties A and B exchange the
+ * following payments:
+ * A->B PrincipalAmount * FixedRateBPS / 10000 * DayCountFraction
+ * B->A PrincipalAmount * (ReferenceRateBPS + FloatingRateBPS) / 10000 * DayCountFraction
  * We represent rates as basis points, with one basis point being equal to 1/100th
  * of 1% (see https://www.investopedia.com/terms/b/basispoint.asp)
+ * The day-count fraction of a period follows the DayCount convention of the swap,
+ * one of ACT/360 (the default), ACT/365 or 30/360.
+ * PartyA and PartyB are the MSP IDs of the participants, which are set when the
+ * swap is created. Status and the termination fields change over the lifecycle
+ * of the swap. PrincipalAmount is the current principal amount, the schedule
+ * records the principal amount of every period. PendingNovation is set while a
+ * novation waits for the new participant to accept it.
  */
 type InterestRateSwap struct {
-	StartDate       time.Time
-	EndDate         time.Time
-	PaymentInterval time.Duration
-	PrincipalAmount uint64
-	FixedRateBPS    uint64
-	FloatingRateBPS uint64
-	ReferenceRate   string
+	StartDate             time.Time
+	EndDate               time.Time
+	PaymentIntervalMonths int
+	PrincipalAmount       uint64
+	FixedRateBPS          uint64
+	FloatingRateBPS       uint64
+	ReferenceRate         string
+	DayCount              string
+	PartyA                string
+	PartyB                string
+	Status                string
+	TerminationDate       time.Time `json:",omitempty"`
+	TerminationAmount     int64     `json:",omitempty"`
+	PendingNovation       *Novation `json:",omitempty"`
+}
+
+// Novation is a proposed replacement of a participant of a swap. Its ID is the
+// ID of the transaction that proposed it
+type Novation struct {
+	ID           string
+	LeavingParty string
+	NewParty     string
 }
 
 /*
@@ -40,14 +64,32 @@ SwapManager is the chaincode that handles interest rate swaps.
 The chaincode endorsement policy includes an auditing organization.
 It provides the following functions:
 -) createSwap: create swap with participants
--) calculatePayment: calculate what needs to be paid
+-) calculatePayment: calculate what needs to be paid for the next period that is due
 -) settlePayment: mark payment done
--) setReferenceRate: for providers to set the reference rate
+-) setReferenceRate: for providers to submit the reference rate for a date
+-) configureReferenceRate: set the quorum and aggregation of a reference rate
+-) getReferenceRate: query the fixing of a reference rate for a date
+-) getPaymentSchedule: query the payment schedule of a swap
+-) terminateSwap: terminate a swap early with a termination payment
+-) novateSwap: propose to replace a participant of a swap
+-) acceptNovation: replace the participant as proposed, endorsed by the new one
+-) cancelNovation: withdraw a novation that has not been accepted
+-) amendNotional: change the principal amount of a swap
+-) getSwapEvents: query the event log of a swap
+-) getSwap: query a swap
+-) listSwapsByParticipant: query the swaps of a participant
+-) listUnsettledPayments: query the calculated payments that are not settled
+-) getExposureReport: query the notional and payments per counterparty
+-) getAuditThreshold: query the principal amount above which audits are needed
 
 The SwapManager stores three different kinds of information on the ledger:
 -) the actual swap data ("swap" + ID)
--) the payment information ("payment" + ID), if "none", the payment has been settled
--) the reference rate ("rr" + ID)
+-) the payment schedule ("payment" + ID), with the status of every period
+-) the event log of the swap ("events" + ID)
+-) an index of the swaps by participant (composite keys "participant~swap")
+-) the proposed novations of a swap (composite keys "novation~swap")
+-) the configuration of a reference rate (composite keys "rr")
+-) the submissions of a provider for a reference rate (composite keys "rr~provider")
 */
 type SwapManager struct {
 }
@@ -81,11 +123,22 @@ func (cc *SwapManager) Init(stub shim.ChaincodeStubInterface) pb.Response {
 		return shim.Error(err.Error())
 	}
 
-	// create the reference rates, require them to be endorsed by the provider
+	// create the reference rates. A rate may be listed with several providers,
+	// whose official fixing is the median of a majority of them by default. The
+	// configuration of a rate needs to be endorsed by the auditor, the
+	// submissions of a provider by the provider itself.
+	configs := map[string]*RateConfig{}
+	rrIDs := []string{}
 	for i := 3; i+1 < len(args); i += 2 {
 		org := string(args[i])
-		rrID := "rr" + string(args[i+1])
-		err = stub.PutState(rrID, []byte("0"))
+		rrID := string(args[i+1])
+		if _, ok := configs[rrID]; !ok {
+			configs[rrID] = &RateConfig{Method: AggregationMedian}
+			rrIDs = append(rrIDs, rrID)
+		}
+		configs[rrID].Providers = append(configs[rrID].Providers, org)
+
+		err = putSubmissions(stub, rrID, org, map[string]RateSubmission{})
 		if err != nil {
 			return shim.Error(err.Error())
 		}
@@ -101,7 +154,31 @@ func (cc *SwapManager) Init(stub shim.ChaincodeStubInterface) pb.Response {
 		if err != nil {
 			return shim.Error(err.Error())
 		}
-		err = stub.SetStateValidationParameter(rrID, epBytes)
+		providerKey, err := rateProviderKey(stub, rrID, org)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		err = stub.SetStateValidationParameter(providerKey, epBytes)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	auditorEPBytes, err := auditorEP.Policy()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, rrID := range rrIDs {
+		config := configs[rrID]
+		config.Quorum = len(config.Providers)/2 + 1
+		err = putRateConfig(stub, rrID, config)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		configKey, err := rateConfigKey(stub, rrID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		err = stub.SetStateValidationParameter(configKey, auditorEPBytes)
 		if err != nil {
 			return shim.Error(err.Error())
 		}
@@ -121,10 +198,24 @@ func (cc *SwapManager) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 }
 
 var functions = map[string]func(stub shim.ChaincodeStubInterface) pb.Response{
-	"createSwap":       createSwap,
-	"calculatePayment": calculatePayment,
-	"settlePayment":    settlePayment,
-	"setReferenceRate": setReferenceRate,
+	"createSwap":             createSwap,
+	"calculatePayment":       calculatePayment,
+	"settlePayment":          settlePayment,
+	"setReferenceRate":       setReferenceRate,
+	"getPaymentSchedule":     getPaymentSchedule,
+	"configureReferenceRate": configureReferenceRate,
+	"getReferenceRate":       getReferenceRate,
+	"terminateSwap":          terminateSwap,
+	"novateSwap":             novateSwap,
+	"acceptNovation":         acceptNovation,
+	"cancelNovation":         cancelNovation,
+	"amendNotional":          amendNotional,
+	"getSwapEvents":          getSwapEvents,
+	"getSwap":                querySwap,
+	"listSwapsByParticipant": listSwapsByParticipant,
+	"listUnsettledPayments":  listUnsettledPayments,
+	"getExposureReport":      getExposureReport,
+	"getAuditThreshold":      queryAuditThreshold,
 }
 
 // Create a new swap among participants.
@@ -134,7 +225,8 @@ var functions = map[string]func(stub shim.ChaincodeStubInterface) pb.Response{
 // This is enforced through the state-based endorsement policy that is set in this
 // function.
 // Parameters: swap ID, a JSONized InterestRateSwap, MSP ID of participant 1,
-//             MSP ID of participant 2
+//
+//	MSP ID of participant 2
 func createSwap(stub shim.ChaincodeStubInterface) pb.Response {
 	_, parameters := stub.GetFunctionAndParameters()
 	if len(parameters) != 4 {
@@ -143,23 +235,39 @@ func createSwap(stub shim.ChaincodeStubInterface) pb.Response {
 
 	// create the swap
 	swapID := "swap" + string(parameters[0])
-	irsJSON := []byte(parameters[1])
 	var irs InterestRateSwap
-	err := json.Unmarshal(irsJSON, &irs)
+	err := json.Unmarshal([]byte(parameters[1]), &irs)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	err = stub.PutState(swapID, irsJSON)
+	// the payment interval used to be given in days as PaymentInterval, which
+	// would otherwise be ignored silently
+	var legacy struct {
+		PaymentInterval *json.RawMessage
+	}
+	err = json.Unmarshal([]byte(parameters[1]), &legacy)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-
-	// get the auditing threshold
-	auditLimit, err := stub.GetState("audit_limit")
+	if legacy.PaymentInterval != nil {
+		return shim.Error("PaymentInterval is no longer supported. Use PaymentIntervalMonths to give the payment interval in months")
+	}
+	schedule, err := generateSchedule(irs)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	threshold, err := strconv.Atoi(string(auditLimit))
+	irs.PartyA = parameters[2]
+	irs.PartyB = parameters[3]
+	irs.Status = SwapActive
+	irs.TerminationDate = time.Time{}
+	irs.TerminationAmount = 0
+	err = putSwap(stub, parameters[0], &irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get the auditing threshold
+	threshold, err := getAuditThreshold(stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -174,8 +282,8 @@ func createSwap(stub shim.ChaincodeStubInterface) pb.Response {
 		return shim.Error(err.Error())
 	}
 	// if the swap principal amount exceeds the audit threshold set in init, the auditor needs to endorse as well
-	if irs.PrincipalAmount > uint64(threshold) {
-		fmt.Printf("Adding auditor for swap %s with prinicipal amount %v above threshold %v\n", parameters[0], irs.PrincipalAmount, uint64(threshold))
+	if irs.PrincipalAmount > threshold {
+		fmt.Printf("Adding auditor for swap %s with prinicipal amount %v above threshold %v\n", parameters[0], irs.PrincipalAmount, threshold)
 		err = ep.AddOrgs(statebased.RoleTypePeer, "auditor")
 		if err != nil {
 			return shim.Error(err.Error())
@@ -192,9 +300,9 @@ func createSwap(stub shim.ChaincodeStubInterface) pb.Response {
 		return shim.Error(err.Error())
 	}
 
-	// create and set the key for the payment
+	// create and set the key for the payment schedule
 	paymentID := "payment" + string(parameters[0])
-	err = stub.PutState(paymentID, []byte("none"))
+	err = putSchedule(stub, paymentID, schedule)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -203,10 +311,38 @@ func createSwap(stub shim.ChaincodeStubInterface) pb.Response {
 		return shim.Error(err.Error())
 	}
 
+	// start the event log of the swap
+	err = appendSwapEvent(stub, parameters[0], &SwapEvent{Type: SwapCreatedEvent})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.SetStateValidationParameter(swapEventsKey(parameters[0]), epBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// index the swap by its participants
+	for _, participant := range []string{irs.PartyA, irs.PartyB} {
+		indexKey, err := participantIndexKey(stub, participant, parameters[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		err = stub.PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		err = stub.SetStateValidationParameter(indexKey, epBytes)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
 	return shim.Success([]byte{})
 }
 
-// Calculate the payment due for a given swap
+// Calculate the payment due for the next period of a given swap. The period
+// needs to have ended according to the transaction timestamp, and the payment
+// of the previous period needs to have been settled
 func calculatePayment(stub shim.ChaincodeStubInterface) pb.Response {
 	_, parameters := stub.GetFunctionAndParameters()
 	if len(parameters) != 1 {
@@ -214,97 +350,296 @@ func calculatePayment(stub shim.ChaincodeStubInterface) pb.Response {
 	}
 
 	// retrieve swap
-	swapID := "swap" + parameters[0]
-	irsJSON, err := stub.GetState(swapID)
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-	if irsJSON == nil {
-		return shim.Error(fmt.Sprintf("Swap %s does not exist", parameters[0]))
-	}
-	var irs InterestRateSwap
-	err = json.Unmarshal(irsJSON, &irs)
+	irs, err := getSwap(stub, parameters[0])
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// check if the previous payment has been settled
 	paymentID := "payment" + parameters[0]
-	paid, err := stub.GetState(paymentID)
+	schedule, err := getSchedule(stub, paymentID)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	if paid == nil {
-		return shim.Error("Unexpected error: payment entry is nil. This should not happen.")
+	period := schedule.nextPeriod()
+	if period == nil {
+		return shim.Error(fmt.Sprintf("All payments of swap %s have been settled", parameters[0]))
 	}
-	if string(paid) != "none" {
+	if period.Status == PeriodCalculated {
 		return shim.Error("Previous payment has not been settled yet")
 	}
 
-	// get reference rate
-	referenceRateBytes, err := stub.GetState("rr" + irs.ReferenceRate)
+	// get the fixing of the reference rate for the fixing date of the period in
+	// the schedule, as known at the time of the calculation
+	txTime, err := getTxTime(stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	if referenceRateBytes == nil {
-		return shim.Error(fmt.Sprintf("Reference rate %s not found", irs.ReferenceRate))
+	fixingDate, err := time.Parse(fixingDateLayout, period.FixingDate)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid fixing date %s of payment period %d", period.FixingDate, period.Index))
 	}
-	referenceRate, err := strconv.Atoi(string(referenceRateBytes))
+	fixing, err := getLatestFixing(stub, irs.ReferenceRate, fixingDate, txTime)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// calculate payment
-	p1 := int((irs.PrincipalAmount * irs.FixedRateBPS) / 100)
-	p2 := int((irs.PrincipalAmount * (irs.FloatingRateBPS + uint64(referenceRate))) / 100)
-	payment := strconv.Itoa(p1 - p2)
-	err = stub.PutState(paymentID, []byte(payment))
+	err = calculatePeriod(*irs, period, fixing, txTime)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putSchedule(stub, paymentID, schedule)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	return shim.Success([]byte(payment))
+	periodJSON, err := json.Marshal(period)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(periodJSON)
 }
 
-// Settle the payment for a given swap
+// Settle the calculated payment for a given swap
 func settlePayment(stub shim.ChaincodeStubInterface) pb.Response {
 	_, parameters := stub.GetFunctionAndParameters()
 	if len(parameters) != 1 {
 		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
 	}
 	paymentID := "payment" + parameters[0]
-	paid, err := stub.GetState(paymentID)
+	schedule, err := getSchedule(stub, paymentID)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	if paid == nil {
-		return shim.Error("Unexpected error: payment entry is nil. This should not happen.")
-	}
-	if string(paid) == "none" {
+	period := schedule.nextPeriod()
+	if period == nil || period.Status != PeriodCalculated {
 		return shim.Error("Payment has already been settled.")
 	}
-	err = stub.PutState(paymentID, []byte("none"))
+	period.Status = PeriodSettled
+	err = putSchedule(stub, paymentID, schedule)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 	return shim.Success([]byte{})
 }
 
-// Set the reference rate for a given rate provider
+// Get the payment schedule of a given swap
+func getPaymentSchedule(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
+	}
+	scheduleJSON, err := stub.GetState("payment" + parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if scheduleJSON == nil {
+		return shim.Error(fmt.Sprintf("Swap %s does not exist", parameters[0]))
+	}
+	return shim.Success(scheduleJSON)
+}
+
+// getSwap reads the swap with the given ID from the ledger
+func getSwap(stub shim.ChaincodeStubInterface, swapID string) (*InterestRateSwap, error) {
+	irsJSON, err := stub.GetState("swap" + swapID)
+	if err != nil {
+		return nil, err
+	}
+	if irsJSON == nil {
+		return nil, fmt.Errorf("Swap %s does not exist", swapID)
+	}
+	var irs InterestRateSwap
+	err = json.Unmarshal(irsJSON, &irs)
+	if err != nil {
+		return nil, err
+	}
+	return &irs, nil
+}
+
+// putSwap writes the swap with the given ID to the ledger
+func putSwap(stub shim.ChaincodeStubInterface, swapID string, irs *InterestRateSwap) error {
+	irsJSON, err := json.Marshal(irs)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("swap"+swapID, irsJSON)
+}
+
+// getAuditThreshold reads the principal amount above which the auditor needs
+// to endorse a swap
+func getAuditThreshold(stub shim.ChaincodeStubInterface) (uint64, error) {
+	auditLimit, err := stub.GetState("audit_limit")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(auditLimit), 10, 64)
+}
+
+// getSchedule reads the payment schedule stored under the given payment key
+func getSchedule(stub shim.ChaincodeStubInterface, paymentID string) (*PaymentSchedule, error) {
+	scheduleJSON, err := stub.GetState(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if scheduleJSON == nil {
+		return nil, fmt.Errorf("Unexpected error: payment entry is nil. This should not happen.")
+	}
+	var schedule PaymentSchedule
+	err = json.Unmarshal(scheduleJSON, &schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// putSchedule writes the payment schedule under the given payment key
+func putSchedule(stub shim.ChaincodeStubInterface, paymentID string, schedule *PaymentSchedule) error {
+	scheduleJSON, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(paymentID, scheduleJSON)
+}
+
+// Submit the reference rate of the calling provider for a fixing date. The
+// fixing date defaults to the date of the transaction. A provider can submit
+// a rate only once per fixing date, so that past fixings cannot change.
 func setReferenceRate(stub shim.ChaincodeStubInterface) pb.Response {
 	_, parameters := stub.GetFunctionAndParameters()
-	if len(parameters) != 2 {
-		return shim.Error("Wrong number of arguments supplied. Expected: <reference_rate_ID> <reference_rate_BPS>")
+	if len(parameters) != 2 && len(parameters) != 3 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <reference_rate_ID> <reference_rate_BPS> [<fixing_date>]")
+	}
+
+	rrID := parameters[0]
+	rateBPS, err := strconv.ParseUint(parameters[1], 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid reference rate %s. Expected basis points", parameters[1]))
+	}
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	date := txTime.Format(fixingDateLayout)
+	if len(parameters) == 3 {
+		date, err = parseFixingDate(parameters[2])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// only the providers of the rate can submit it
+	provider, err := getCreatorMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	config, err := getRateConfig(stub, rrID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !contains(config.Providers, provider) {
+		return shim.Error(fmt.Sprintf("%s is not a provider of reference rate %s", provider, rrID))
+	}
+
+	submissions, err := getSubmissions(stub, rrID, provider)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if _, ok := submissions[date]; ok {
+		return shim.Error(fmt.Sprintf("%s has already submitted reference rate %s for %s", provider, rrID, date))
+	}
+	submissions[date] = RateSubmission{RateBPS: rateBPS, SubmittedAt: txTime}
+	err = putSubmissions(stub, rrID, provider, submissions)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// Set the quorum and the aggregation method of a reference rate. The change
+// needs to be endorsed by the auditor.
+// Parameters: reference rate ID, quorum, aggregation method and, for a trimmed
+// mean, the number of submissions trimmed from either end
+func configureReferenceRate(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 3 && len(parameters) != 4 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <reference_rate_ID> <quorum> <method> [<trim>]")
 	}
 
-	rrID := "rr" + parameters[0]
-	err := stub.PutState(rrID, []byte(parameters[1]))
+	config, err := getRateConfig(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	config.Quorum, err = strconv.Atoi(parameters[1])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid quorum %s", parameters[1]))
+	}
+	config.Method = parameters[2]
+	config.Trim = 0
+	if len(parameters) == 4 {
+		config.Trim, err = strconv.Atoi(parameters[3])
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Invalid trim %s", parameters[3]))
+		}
+	}
+	err = config.validate()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putRateConfig(stub, parameters[0], config)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 	return shim.Success([]byte{})
 }
 
+// Get the fixing of a reference rate for a date with the contributions it was
+// derived from. Passing the calculation time of a payment period reproduces
+// the fixing that was used for the payment.
+// Parameters: reference rate ID, fixing date and an optional RFC3339 time as of
+// which the fixing is derived, which defaults to the transaction time
+func getReferenceRate(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 2 && len(parameters) != 3 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <reference_rate_ID> <fixing_date> [<as_of>]")
+	}
+
+	date, err := parseFixingDate(parameters[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	asOf, err := getTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(parameters) == 3 {
+		asOf, err = time.Parse(time.RFC3339Nano, parameters[2])
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Invalid time %s. Expected RFC3339", parameters[2]))
+		}
+	}
+
+	fixing, err := getFixing(stub, parameters[0], date, asOf)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fixingJSON, err := json.Marshal(fixing)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(fixingJSON)
+}
+
+// contains reports whether value is one of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
 	err := shim.Start(new(SwapManager))
 	if err != nil {
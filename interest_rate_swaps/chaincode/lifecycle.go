@@ -0,0 +1,506 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Status of a swap
+const (
+	SwapActive     = "active"
+	SwapTerminated = "terminated"
+)
+
+// Types of the events in the event log of a swap. Lifecycle events are also
+// emitted as chaincode events under the same name.
+const (
+	SwapCreatedEvent    = "SwapCreated"
+	SwapTerminatedEvent = "SwapTerminated"
+	SwapNovatedEvent    = "SwapNovated"
+	SwapAmendedEvent    = "SwapAmended"
+)
+
+/* SwapEvent is an entry in the event log of a swap. Besides the transaction
+ * that caused it, an event records the details of the change:
+ * -) termination: the termination date and the termination payment from A to B
+ * -) novation: the participant that left the swap and the one that replaced it
+ * -) amendment: the principal amount before and after the change
+ */
+type SwapEvent struct {
+	Type              string
+	TxID              string
+	Timestamp         time.Time
+	TerminationDate   time.Time `json:",omitempty"`
+	TerminationAmount int64     `json:",omitempty"`
+	PreviousParty     string    `json:",omitempty"`
+	NewParty          string    `json:",omitempty"`
+	PreviousPrincipal uint64    `json:",omitempty"`
+	NewPrincipal      uint64    `json:",omitempty"`
+}
+
+// SwapEventLog holds all events of a swap, in the order they happened
+type SwapEventLog struct {
+	Events []SwapEvent
+}
+
+// swapEventsKey is the key of the event log of a swap
+func swapEventsKey(swapID string) string {
+	return "events" + swapID
+}
+
+// appendSwapEvent completes the event with the transaction details, appends it
+// to the event log of the swap and emits lifecycle events as chaincode events
+func appendSwapEvent(stub shim.ChaincodeStubInterface, swapID string, event *SwapEvent) error {
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	event.TxID = stub.GetTxID()
+	event.Timestamp = txTime
+
+	eventLog := &SwapEventLog{}
+	eventLogJSON, err := stub.GetState(swapEventsKey(swapID))
+	if err != nil {
+		return err
+	}
+	if eventLogJSON != nil {
+		err = json.Unmarshal(eventLogJSON, eventLog)
+		if err != nil {
+			return err
+		}
+	}
+	eventLog.Events = append(eventLog.Events, *event)
+	eventLogJSON, err = json.Marshal(eventLog)
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(swapEventsKey(swapID), eventLogJSON)
+	if err != nil {
+		return err
+	}
+
+	if event.Type == SwapCreatedEvent {
+		return nil
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(event.Type, eventJSON)
+}
+
+// getActiveSwap reads a swap that can still be changed
+func getActiveSwap(stub shim.ChaincodeStubInterface, swapID string) (*InterestRateSwap, error) {
+	irs, err := getSwap(stub, swapID)
+	if err != nil {
+		return nil, err
+	}
+	if irs.Status == SwapTerminated {
+		return nil, fmt.Errorf("Swap %s has been terminated", swapID)
+	}
+	return irs, nil
+}
+
+// setSwapPolicy sets the key-level endorsement policy of all entries of a swap,
+// including the index entries of its participants
+func setSwapPolicy(stub shim.ChaincodeStubInterface, swapID string, irs *InterestRateSwap, ep statebased.KeyEndorsementPolicy) error {
+	epBytes, err := ep.Policy()
+	if err != nil {
+		return err
+	}
+	keys := []string{"swap" + swapID, "payment" + swapID, swapEventsKey(swapID)}
+	for _, participant := range []string{irs.PartyA, irs.PartyB} {
+		indexKey, err := participantIndexKey(stub, participant, swapID)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, indexKey)
+	}
+	for _, key := range keys {
+		err = stub.SetStateValidationParameter(key, epBytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSwapPolicy reads the key-level endorsement policy of a swap
+func getSwapPolicy(stub shim.ChaincodeStubInterface, swapID string) (statebased.KeyEndorsementPolicy, error) {
+	epBytes, err := stub.GetStateValidationParameter("swap" + swapID)
+	if err != nil {
+		return nil, err
+	}
+	return statebased.NewStateEP(epBytes)
+}
+
+// Terminate a swap early. The participants agree on a termination date and a
+// termination payment from A to B, a negative amount is paid from B to A.
+// The termination date cannot be before the transaction time, so a swap cannot
+// be terminated retroactively. Periods after the termination date are
+// cancelled, the period that runs over the termination date ends at it. Like all changes to a swap, the termination
+// needs to be endorsed by the participants, and the auditor if required.
+// Parameters: swap ID, termination date (RFC3339), termination amount
+func terminateSwap(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 3 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID> <termination_date> <termination_amount>")
+	}
+	terminationDate, err := time.Parse(time.RFC3339, parameters[1])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid termination date %s. Expected RFC3339", parameters[1]))
+	}
+	terminationAmount, err := strconv.ParseInt(parameters[2], 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid termination amount %s", parameters[2]))
+	}
+
+	irs, err := getActiveSwap(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !terminationDate.After(irs.StartDate) || !terminationDate.Before(irs.EndDate) {
+		return shim.Error(fmt.Sprintf("Termination date must be between %s and %s", irs.StartDate.Format(time.RFC3339), irs.EndDate.Format(time.RFC3339)))
+	}
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if terminationDate.Before(txTime) {
+		return shim.Error(fmt.Sprintf("Termination date %s is before the transaction time %s", terminationDate.Format(time.RFC3339), txTime.Format(time.RFC3339)))
+	}
+
+	paymentID := "payment" + parameters[0]
+	schedule, err := getSchedule(stub, paymentID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = schedule.terminate(terminationDate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putSchedule(stub, paymentID, schedule)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	irs.Status = SwapTerminated
+	irs.TerminationDate = terminationDate
+	irs.TerminationAmount = terminationAmount
+	err = putSwap(stub, parameters[0], irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = appendSwapEvent(stub, parameters[0], &SwapEvent{
+		Type:              SwapTerminatedEvent,
+		TerminationDate:   terminationDate,
+		TerminationAmount: terminationAmount,
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// novationIndex is the object type of the composite keys of the novations of
+// a swap
+const novationIndex = "novation~swap"
+
+// novationKey is the key of a proposed novation of a swap. Every proposal has
+// its own key, which carries the endorsement policy of the proposal.
+func novationKey(stub shim.ChaincodeStubInterface, swapID string, novationID string) (string, error) {
+	return stub.CreateCompositeKey(novationIndex, []string{swapID, novationID})
+}
+
+// Propose to replace a participant of a swap with a new one. A key-level
+// endorsement policy only applies to the transactions after the one that sets
+// it, so the novation takes two transactions: this one, endorsed by the
+// current participants, records the pending novation in the swap and in a
+// novation entry whose endorsement policy adds the new participant to the
+// policy of the swap. acceptNovation then completes it by deleting the
+// novation entry, which requires the new participant to endorse as well. The
+// policy of the swap itself does not change until then, so the current
+// participants can withdraw the proposal with cancelNovation.
+// Parameters: swap ID, MSP ID of the leaving participant, MSP ID of the new one
+func novateSwap(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 3 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID> <leaving_MSPID> <new_MSPID>")
+	}
+	leaving, incoming := parameters[1], parameters[2]
+
+	irs, err := getActiveSwap(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if irs.PendingNovation != nil {
+		return shim.Error(fmt.Sprintf("Swap %s already has a pending novation of %s to %s", parameters[0], irs.PendingNovation.LeavingParty, irs.PendingNovation.NewParty))
+	}
+	if incoming == irs.PartyA || incoming == irs.PartyB {
+		return shim.Error(fmt.Sprintf("%s is already a participant of swap %s", incoming, parameters[0]))
+	}
+	if leaving != irs.PartyA && leaving != irs.PartyB {
+		return shim.Error(fmt.Sprintf("%s is not a participant of swap %s", leaving, parameters[0]))
+	}
+	irs.PendingNovation = &Novation{ID: stub.GetTxID(), LeavingParty: leaving, NewParty: incoming}
+	err = putSwap(stub, parameters[0], irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	key, err := novationKey(stub, parameters[0], irs.PendingNovation.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	novationJSON, err := json.Marshal(irs.PendingNovation)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.PutState(key, novationJSON)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	ep, err := getSwapPolicy(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = ep.AddOrgs(statebased.RoleTypePeer, incoming)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	epBytes, err := ep.Policy()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.SetStateValidationParameter(key, epBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// Complete the pending novation of a swap. Deleting the novation entry needs
+// the endorsement of the new participant, besides the current participants of
+// the swap. Afterwards the new participant replaces the leaving one in the
+// policy of the swap and in the participant index. The index entry of the new
+// participant has no key-level policy before the novation, so writing it also
+// needs to satisfy the chaincode-level endorsement policy.
+// Parameters: swap ID
+func acceptNovation(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
+	}
+
+	irs, err := getActiveSwap(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	novation := irs.PendingNovation
+	if novation == nil {
+		return shim.Error(fmt.Sprintf("Swap %s has no pending novation", parameters[0]))
+	}
+	key, err := novationKey(stub, parameters[0], novation.ID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	leaving, incoming := novation.LeavingParty, novation.NewParty
+	if leaving == irs.PartyA {
+		irs.PartyA = incoming
+	} else {
+		irs.PartyB = incoming
+	}
+	irs.PendingNovation = nil
+	err = putSwap(stub, parameters[0], irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move the swap in the participant index
+	leavingKey, err := participantIndexKey(stub, leaving, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.DelState(leavingKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	incomingKey, err := participantIndexKey(stub, incoming, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = stub.PutState(incomingKey, []byte{0x00})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	ep, err := getSwapPolicy(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	ep.DelOrgs(leaving)
+	err = ep.AddOrgs(statebased.RoleTypePeer, incoming)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = setSwapPolicy(stub, parameters[0], irs, ep)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = appendSwapEvent(stub, parameters[0], &SwapEvent{
+		Type:          SwapNovatedEvent,
+		PreviousParty: leaving,
+		NewParty:      incoming,
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// Withdraw the pending novation of a swap, for instance if the new participant
+// does not accept it. Only the swap entry is written, so the current
+// participants can cancel the novation without the new participant, whose
+// organization never became part of the policy of the swap. The novation entry
+// keeps the policy that includes the new participant, but acceptNovation only
+// completes the novation the swap refers to, so the entry cannot be accepted
+// any more.
+// Parameters: swap ID
+func cancelNovation(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
+	}
+
+	irs, err := getActiveSwap(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if irs.PendingNovation == nil {
+		return shim.Error(fmt.Sprintf("Swap %s has no pending novation", parameters[0]))
+	}
+	irs.PendingNovation = nil
+	err = putSwap(stub, parameters[0], irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// Amend the principal amount of a swap from an effective date. The new
+// principal amount applies to the periods that start on or after the effective
+// date, which must not have been calculated yet. Earlier periods, including
+// the one running over the effective date, keep their principal amount. If the
+// new amount exceeds the audit threshold, the auditor is added to the
+// endorsement policy of the swap.
+// Parameters: swap ID, new principal amount, effective date (RFC3339)
+func amendNotional(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 3 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID> <principal_amount> <effective_date>")
+	}
+	principal, err := strconv.ParseUint(parameters[1], 10, 64)
+	if err != nil || principal == 0 {
+		return shim.Error(fmt.Sprintf("Invalid principal amount %s", parameters[1]))
+	}
+	effectiveDate, err := time.Parse(time.RFC3339, parameters[2])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Invalid effective date %s. Expected RFC3339", parameters[2]))
+	}
+
+	irs, err := getActiveSwap(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if principal == irs.PrincipalAmount {
+		return shim.Error(fmt.Sprintf("Principal amount of swap %s is already %d", parameters[0], principal))
+	}
+
+	paymentID := "payment" + parameters[0]
+	schedule, err := getSchedule(stub, paymentID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = schedule.amend(principal, effectiveDate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putSchedule(stub, paymentID, schedule)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	previous := irs.PrincipalAmount
+	irs.PrincipalAmount = principal
+	err = putSwap(stub, parameters[0], irs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	threshold, err := getAuditThreshold(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if principal > threshold {
+		ep, err := getSwapPolicy(stub, parameters[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if !contains(ep.ListOrgs(), "auditor") {
+			fmt.Printf("Adding auditor for swap %s with prinicipal amount %v above threshold %v\n", parameters[0], principal, threshold)
+			err = ep.AddOrgs(statebased.RoleTypePeer, "auditor")
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+			err = setSwapPolicy(stub, parameters[0], irs, ep)
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+	}
+
+	err = appendSwapEvent(stub, parameters[0], &SwapEvent{
+		Type:              SwapAmendedEvent,
+		PreviousPrincipal: previous,
+		NewPrincipal:      principal,
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte{})
+}
+
+// Get the event log of a given swap
+func getSwapEvents(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
+	}
+	eventLogJSON, err := stub.GetState(swapEventsKey(parameters[0]))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if eventLogJSON == nil {
+		return shim.Error(fmt.Sprintf("Swap %s does not exist", parameters[0]))
+	}
+	return shim.Success(eventLogJSON)
+}
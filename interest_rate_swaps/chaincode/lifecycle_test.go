@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+const lifecycleSwap = `{"StartDate":"2018-09-27T15:04:05Z","EndDate":"2018-12-27T15:04:05Z","PaymentIntervalMonths":1,"PrincipalAmount":100000,"FixedRateBPS":400,"FloatingRateBPS":500,"ReferenceRate":"myrr","DayCount":"ACT/360"}`
+
+func newLifecycleSwap(t *testing.T) *shimtest.MockStub {
+	stub := newSwapManager(t)
+	_, err := invoke(stub, "createSwap", "myswap", lifecycleSwap, "partya", "partyb")
+	require.NoError(t, err)
+	return stub
+}
+
+// clockedSwapManager invokes the chaincode at a fixed transaction time instead
+// of the current time of the mock stub
+type clockedSwapManager struct {
+	SwapManager
+	now time.Time
+}
+
+func (cc *clockedSwapManager) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	txTimestamp, err := ptypes.TimestampProto(cc.now)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	stub.(*shimtest.MockStub).TxTimestamp = txTimestamp
+	return cc.SwapManager.Invoke(stub)
+}
+
+func newClockedLifecycleSwap(t *testing.T, now string) (*shimtest.MockStub, *clockedSwapManager) {
+	cc := &clockedSwapManager{now: date(now)}
+	stub := newSwapManagerStub(t, cc)
+	_, err := invoke(stub, "createSwap", "myswap", lifecycleSwap, "partya", "partyb")
+	require.NoError(t, err)
+	return stub, cc
+}
+
+func swapPolicyOrgs(t *testing.T, stub *shimtest.MockStub, key string) []string {
+	epBytes, err := stub.GetStateValidationParameter(key)
+	require.NoError(t, err)
+	ep, err := statebased.NewStateEP(epBytes)
+	require.NoError(t, err)
+	orgs := ep.ListOrgs()
+	sort.Strings(orgs)
+	return orgs
+}
+
+func swapEvents(t *testing.T, stub *shimtest.MockStub) []SwapEvent {
+	payload, err := invoke(stub, "getSwapEvents", "myswap")
+	require.NoError(t, err)
+	var eventLog SwapEventLog
+	require.NoError(t, json.Unmarshal(payload, &eventLog))
+	return eventLog.Events
+}
+
+func assertChaincodeEvent(t *testing.T, stub *shimtest.MockStub, name string) {
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		require.Equal(t, name, event.EventName)
+	default:
+		t.Fatalf("expected chaincode event %s", name)
+	}
+}
+
+func TestCreateSwapRecordsParticipants(t *testing.T) {
+	stub := newLifecycleSwap(t)
+
+	irs, err := getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, "partya", irs.PartyA)
+	require.Equal(t, "partyb", irs.PartyB)
+	require.Equal(t, SwapActive, irs.Status)
+
+	events := swapEvents(t, stub)
+	require.Len(t, events, 1)
+	require.Equal(t, SwapCreatedEvent, events[0].Type)
+	require.Equal(t, []string{"partya", "partyb"}, swapPolicyOrgs(t, stub, swapEventsKey("myswap")))
+}
+
+func TestTerminateSwap(t *testing.T) {
+	stub, clock := newClockedLifecycleSwap(t, "2018-11-01T00:00:00Z")
+	_, err := invoke(stub, "calculatePayment", "myswap")
+	require.NoError(t, err)
+
+	_, err = invoke(stub, "terminateSwap", "myswap", "2018-10-15T00:00:00Z", "-150")
+	require.EqualError(t, err, "Termination date 2018-10-15T00:00:00Z is before the transaction time 2018-11-01T00:00:00Z")
+	_, err = invoke(stub, "terminateSwap", "myswap", "2019-01-01T00:00:00Z", "-150")
+	require.EqualError(t, err, "Termination date must be between 2018-09-27T15:04:05Z and 2018-12-27T15:04:05Z")
+
+	_, err = invoke(stub, "terminateSwap", "myswap", "2018-11-15T00:00:00Z", "-150")
+	require.NoError(t, err)
+	assertChaincodeEvent(t, stub, SwapTerminatedEvent)
+
+	irs, err := getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, SwapTerminated, irs.Status)
+	require.Equal(t, date("2018-11-15T00:00:00Z"), irs.TerminationDate)
+	require.Equal(t, int64(-150), irs.TerminationAmount)
+
+	schedule, err := getSchedule(stub, "paymentmyswap")
+	require.NoError(t, err)
+	require.Equal(t, PeriodCalculated, schedule.Periods[0].Status)
+	require.Equal(t, PeriodScheduled, schedule.Periods[1].Status)
+	require.Equal(t, date("2018-11-15T00:00:00Z"), schedule.Periods[1].EndDate)
+	require.Equal(t, PeriodCancelled, schedule.Periods[2].Status)
+
+	// the periods up to the termination date are still paid
+	clock.now = date("2018-11-15T00:00:00Z")
+	for i := 0; i < 2; i++ {
+		_, err = invoke(stub, "settlePayment", "myswap")
+		require.NoError(t, err)
+		if i == 0 {
+			_, err = invoke(stub, "calculatePayment", "myswap")
+			require.NoError(t, err)
+		}
+	}
+	_, err = invoke(stub, "calculatePayment", "myswap")
+	require.EqualError(t, err, "All payments of swap myswap have been settled")
+
+	_, err = invoke(stub, "amendNotional", "myswap", "200000", "2018-11-27T15:04:05Z")
+	require.EqualError(t, err, "Swap myswap has been terminated")
+
+	events := swapEvents(t, stub)
+	require.Len(t, events, 2)
+	require.Equal(t, SwapTerminatedEvent, events[1].Type)
+	require.Equal(t, int64(-150), events[1].TerminationAmount)
+	require.Equal(t, "tx", events[1].TxID)
+}
+
+func TestNovateSwap(t *testing.T) {
+	stub := newLifecycleSwap(t)
+
+	_, err := invoke(stub, "acceptNovation", "myswap")
+	require.EqualError(t, err, "Swap myswap has no pending novation")
+	_, err = invoke(stub, "novateSwap", "myswap", "partyc", "partyd")
+	require.EqualError(t, err, "partyc is not a participant of swap myswap")
+	_, err = invoke(stub, "novateSwap", "myswap", "partya", "partyb")
+	require.EqualError(t, err, "partyb is already a participant of swap myswap")
+
+	// the proposal adds the new participant to the policy of the novation entry
+	// only, the swap entries keep the policy of the current participants
+	_, err = invoke(stub, "novateSwap", "myswap", "partya", "partyc")
+	require.NoError(t, err)
+	irs, err := getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, "partya", irs.PartyA)
+	require.Equal(t, &Novation{ID: "tx", LeavingParty: "partya", NewParty: "partyc"}, irs.PendingNovation)
+	for _, key := range []string{"swapmyswap", "paymentmyswap", swapEventsKey("myswap")} {
+		require.Equal(t, []string{"partya", "partyb"}, swapPolicyOrgs(t, stub, key), key)
+	}
+	key, err := novationKey(stub, "myswap", "tx")
+	require.NoError(t, err)
+	require.Equal(t, []string{"partya", "partyb", "partyc"}, swapPolicyOrgs(t, stub, key))
+	_, err = invoke(stub, "novateSwap", "myswap", "partyb", "partyd")
+	require.EqualError(t, err, "Swap myswap already has a pending novation of partya to partyc")
+
+	// accepting it, endorsed by the new participant, completes the novation
+	_, err = invoke(stub, "acceptNovation", "myswap")
+	require.NoError(t, err)
+	assertChaincodeEvent(t, stub, SwapNovatedEvent)
+
+	irs, err = getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, "partyc", irs.PartyA)
+	require.Equal(t, "partyb", irs.PartyB)
+	require.Nil(t, irs.PendingNovation)
+	novationJSON, err := stub.GetState(key)
+	require.NoError(t, err)
+	require.Nil(t, novationJSON)
+	for _, key := range []string{"swapmyswap", "paymentmyswap", swapEventsKey("myswap")} {
+		require.Equal(t, []string{"partyb", "partyc"}, swapPolicyOrgs(t, stub, key), key)
+	}
+
+	events := swapEvents(t, stub)
+	require.Len(t, events, 2)
+	require.Equal(t, SwapEvent{
+		Type:          SwapNovatedEvent,
+		TxID:          "tx",
+		Timestamp:     events[1].Timestamp,
+		PreviousParty: "partya",
+		NewParty:      "partyc",
+	}, events[1])
+}
+
+func TestCancelNovation(t *testing.T) {
+	stub := newLifecycleSwap(t)
+
+	_, err := invoke(stub, "cancelNovation", "myswap")
+	require.EqualError(t, err, "Swap myswap has no pending novation")
+
+	_, err = invoke(stub, "novateSwap", "myswap", "partya", "partyc")
+	require.NoError(t, err)
+
+	// cancelling only writes the swap entry, whose policy does not include
+	// the new participant
+	_, err = invoke(stub, "cancelNovation", "myswap")
+	require.NoError(t, err)
+	irs, err := getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, "partya", irs.PartyA)
+	require.Equal(t, "partyb", irs.PartyB)
+	require.Nil(t, irs.PendingNovation)
+	for _, key := range []string{"swapmyswap", "paymentmyswap", swapEventsKey("myswap")} {
+		require.Equal(t, []string{"partya", "partyb"}, swapPolicyOrgs(t, stub, key), key)
+	}
+
+	_, err = invoke(stub, "acceptNovation", "myswap")
+	require.EqualError(t, err, "Swap myswap has no pending novation")
+	_, err = invoke(stub, "cancelNovation", "myswap")
+	require.EqualError(t, err, "Swap myswap has no pending novation")
+
+	// the participants can propose another novation afterwards
+	_, err = invoke(stub, "novateSwap", "myswap", "partyb", "partyd")
+	require.NoError(t, err)
+	irs, err = getSwap(stub, "myswap")
+	require.NoError(t, err)
+	require.Equal(t, &Novation{ID: "tx", LeavingParty: "partyb", NewParty: "partyd"}, irs.PendingNovation)
+
+	require.Len(t, swapEvents(t, stub), 1)
+}
+
+func TestAmendNotional(t *testing.T) {
+	stub := newLifecycleSwap(t)
+
+	_, err := invoke(stub, "amendNotional", "myswap", "100000", "2018-10-27T15:04:05Z")
+	require.EqualError(t, err, "Principal amount of swap myswap is already 100000")
+	_, err = invoke(stub, "amendNotional", "myswap", "0", "2018-10-27T15:04:05Z")
+	require.EqualError(t, err, "Invalid principal amount 0")
+	_, err = invoke(stub, "amendNotional", "myswap", "500000", "2019-01-01T00:00:00Z")
+	require.EqualError(t, err, "No payment period starts on or after 2019-01-01T00:00:00Z")
+
+	_, err = invoke(stub, "calculatePayment", "myswap")
+	require.NoError(t, err)
+	_, err = invoke(stub, "amendNotional", "myswap", "500000", "2018-09-27T15:04:05Z")
+	require.EqualError(t, err, "Payment period 0 ending 2018-10-27T15:04:05Z has already been calculated")
+
+	_, err = invoke(stub, "amendNotional", "myswap", "500000", "2018-10-27T15:04:05Z")
+	require.NoError(t, err)
+	assertChaincodeEvent(t, stub, SwapAmendedEvent)
+	require.Equal(t, []string{"partya", "partyb"}, swapPolicyOrgs(t, stub, "swapmyswap"))
+
+	// above the audit threshold the auditor needs to endorse the swap as well
+	_, err = invoke(stub, "amendNotional", "myswap", "2000000", "2018-11-27T15:04:05Z")
+	require.NoError(t, err)
+	for _, key := range []string{"swapmyswap", "paymentmyswap", swapEventsKey("myswap")} {
+		require.Equal(t, []string{"auditor", "partya", "partyb"}, swapPolicyOrgs(t, stub, key), key)
+	}
+
+	// only the periods from the effective date on use the new principal amount
+	schedule, err := getSchedule(stub, "paymentmyswap")
+	require.NoError(t, err)
+	require.Equal(t, uint64(100000), schedule.Periods[0].PrincipalAmount)
+	require.Equal(t, int64(333), schedule.Periods[0].FixedAmount)
+	require.Equal(t, uint64(500000), schedule.Periods[1].PrincipalAmount)
+	require.Equal(t, uint64(2000000), schedule.Periods[2].PrincipalAmount)
+
+	_, err = invoke(stub, "settlePayment", "myswap")
+	require.NoError(t, err)
+	payload, err := invoke(stub, "calculatePayment", "myswap")
+	require.NoError(t, err)
+	var period PaymentPeriod
+	require.NoError(t, json.Unmarshal(payload, &period))
+	require.Equal(t, int64(1722), period.FixedAmount)
+
+	events := swapEvents(t, stub)
+	require.Len(t, events, 3)
+	require.Equal(t, uint64(500000), events[2].PreviousPrincipal)
+	require.Equal(t, uint64(2000000), events[2].NewPrincipal)
+}
@@ -0,0 +1,232 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// participantIndex is the object type of the composite keys that index swaps
+// by their participants
+const participantIndex = "participant~swap"
+
+// SwapEntry is a swap together with its ID, as returned by the list functions
+type SwapEntry struct {
+	SwapID string
+	Swap   InterestRateSwap
+}
+
+// UnsettledPayment is a calculated payment of a swap that has not been settled
+type UnsettledPayment struct {
+	SwapID string
+	PartyA string
+	PartyB string
+	Period PaymentPeriod
+}
+
+/* Exposure sums the swaps of a participant with one of its counterparties.
+ * Notional is the total principal amount of the active swaps with the
+ * counterparty. NetPayable is the total of the calculated but unsettled
+ * payments the participant owes the counterparty, a negative amount is owed
+ * by the counterparty.
+ */
+type Exposure struct {
+	Counterparty string
+	Swaps        int
+	Notional     uint64
+	NetPayable   int64
+}
+
+// participantIndexKey is the key of the index entry of a swap for a participant
+func participantIndexKey(stub shim.ChaincodeStubInterface, participant string, swapID string) (string, error) {
+	return stub.CreateCompositeKey(participantIndex, []string{participant, swapID})
+}
+
+// listSwapIDs returns the IDs of the swaps of a participant in key order, or
+// the IDs of all swaps if no participant is given
+func listSwapIDs(stub shim.ChaincodeStubInterface, participant string) ([]string, error) {
+	var iterator shim.StateQueryIteratorInterface
+	var err error
+	if participant == "" {
+		iterator, err = stub.GetStateByRange("swap", "swap\xff")
+	} else {
+		iterator, err = stub.GetStateByPartialCompositeKey(participantIndex, []string{participant})
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	swapIDs := []string{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if participant == "" {
+			swapIDs = append(swapIDs, entry.Key[len("swap"):])
+			continue
+		}
+		_, attributes, err := stub.SplitCompositeKey(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		swapIDs = append(swapIDs, attributes[1])
+	}
+	return swapIDs, nil
+}
+
+// Get a swap by its ID
+func querySwap(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <swap_ID>")
+	}
+	irsJSON, err := stub.GetState("swap" + parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if irsJSON == nil {
+		return shim.Error(fmt.Sprintf("Swap %s does not exist", parameters[0]))
+	}
+	return shim.Success(irsJSON)
+}
+
+// List the swaps a given participant is part of
+func listSwapsByParticipant(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <participant_MSPID>")
+	}
+	swapIDs, err := listSwapIDs(stub, parameters[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	entries := []SwapEntry{}
+	for _, swapID := range swapIDs {
+		irs, err := getSwap(stub, swapID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		entries = append(entries, SwapEntry{SwapID: swapID, Swap: *irs})
+	}
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(entriesJSON)
+}
+
+// List the calculated payments that have not been settled yet, of all swaps
+// or of the swaps of a given participant
+func listUnsettledPayments(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) > 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: [<participant_MSPID>]")
+	}
+	participant := ""
+	if len(parameters) == 1 {
+		participant = parameters[0]
+	}
+	swapIDs, err := listSwapIDs(stub, participant)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	payments := []UnsettledPayment{}
+	for _, swapID := range swapIDs {
+		irs, err := getSwap(stub, swapID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		schedule, err := getSchedule(stub, "payment"+swapID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		for _, period := range schedule.Periods {
+			if period.Status == PeriodCalculated {
+				payments = append(payments, UnsettledPayment{SwapID: swapID, PartyA: irs.PartyA, PartyB: irs.PartyB, Period: period})
+			}
+		}
+	}
+	paymentsJSON, err := json.Marshal(payments)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(paymentsJSON)
+}
+
+// Report the exposure of a given participant per counterparty, ordered by
+// counterparty
+func getExposureReport(stub shim.ChaincodeStubInterface) pb.Response {
+	_, parameters := stub.GetFunctionAndParameters()
+	if len(parameters) != 1 {
+		return shim.Error("Wrong number of arguments supplied. Expected: <participant_MSPID>")
+	}
+	participant := parameters[0]
+	swapIDs, err := listSwapIDs(stub, participant)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	exposures := map[string]*Exposure{}
+	for _, swapID := range swapIDs {
+		irs, err := getSwap(stub, swapID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		counterparty, sign := irs.PartyB, int64(1)
+		if participant == irs.PartyB {
+			counterparty, sign = irs.PartyA, -1
+		}
+		exposure, ok := exposures[counterparty]
+		if !ok {
+			exposure = &Exposure{Counterparty: counterparty}
+			exposures[counterparty] = exposure
+		}
+
+		schedule, err := getSchedule(stub, "payment"+swapID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		for _, period := range schedule.Periods {
+			if period.Status == PeriodCalculated {
+				exposure.NetPayable += sign * period.NetAmount
+			}
+		}
+		if irs.Status != SwapTerminated {
+			exposure.Swaps++
+			exposure.Notional += irs.PrincipalAmount
+		}
+	}
+
+	report := []Exposure{}
+	for _, exposure := range exposures {
+		report = append(report, *exposure)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Counterparty < report[j].Counterparty })
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(reportJSON)
+}
+
+// Get the principal amount above which the auditor needs to endorse a swap
+func queryAuditThreshold(stub shim.ChaincodeStubInterface) pb.Response {
+	threshold, err := getAuditThreshold(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success([]byte(fmt.Sprintf("%d", threshold)))
+}
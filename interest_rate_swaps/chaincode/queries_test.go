@@ -0,0 +1,144 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/require"
+)
+
+func newSwapPortfolio(t *testing.T) *shimtest.MockStub {
+	stub := newSwapManagerStub(t, &clockedSwapManager{now: date("2018-11-01T00:00:00Z")})
+	swaps := []struct {
+		id     string
+		amount string
+		partyA string
+		partyB string
+	}{
+		{"swap1", "100000", "partya", "partyb"},
+		{"swap2", "250000", "partyc", "partya"},
+		{"swap3", "50000", "partya", "partyb"},
+		{"swap4", "70000", "partyb", "partyc"},
+	}
+	for _, swap := range swaps {
+		irs := `{"StartDate":"2018-09-27T15:04:05Z","EndDate":"2018-12-27T15:04:05Z","PaymentIntervalMonths":1,"PrincipalAmount":` + swap.amount + `,"FixedRateBPS":400,"FloatingRateBPS":500,"ReferenceRate":"myrr"}`
+		_, err := invoke(stub, "createSwap", swap.id, irs, swap.partyA, swap.partyB)
+		require.NoError(t, err)
+	}
+	return stub
+}
+
+func TestQuerySwap(t *testing.T) {
+	stub := newSwapPortfolio(t)
+
+	payload, err := invoke(stub, "getSwap", "swap2")
+	require.NoError(t, err)
+	var irs InterestRateSwap
+	require.NoError(t, json.Unmarshal(payload, &irs))
+	require.Equal(t, uint64(250000), irs.PrincipalAmount)
+	require.Equal(t, "partyc", irs.PartyA)
+
+	_, err = invoke(stub, "getSwap", "swap9")
+	require.EqualError(t, err, "Swap swap9 does not exist")
+
+	payload, err = invoke(stub, "getAuditThreshold")
+	require.NoError(t, err)
+	require.Equal(t, "1000000", string(payload))
+}
+
+func TestListSwapsByParticipant(t *testing.T) {
+	stub := newSwapPortfolio(t)
+
+	listSwaps := func(participant string) []string {
+		payload, err := invoke(stub, "listSwapsByParticipant", participant)
+		require.NoError(t, err)
+		var entries []SwapEntry
+		require.NoError(t, json.Unmarshal(payload, &entries))
+		swapIDs := []string{}
+		for _, entry := range entries {
+			swapIDs = append(swapIDs, entry.SwapID)
+		}
+		return swapIDs
+	}
+
+	require.Equal(t, []string{"swap1", "swap2", "swap3"}, listSwaps("partya"))
+	require.Equal(t, []string{"swap2", "swap4"}, listSwaps("partyc"))
+	require.Equal(t, []string{}, listSwaps("partyd"))
+
+	// the index follows a novation
+	_, err := invoke(stub, "novateSwap", "swap1", "partya", "partyd")
+	require.NoError(t, err)
+	_, err = invoke(stub, "acceptNovation", "swap1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"swap2", "swap3"}, listSwaps("partya"))
+	require.Equal(t, []string{"swap1"}, listSwaps("partyd"))
+
+	key, err := participantIndexKey(stub, "partyd", "swap1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"partyb", "partyd"}, swapPolicyOrgs(t, stub, key))
+}
+
+func TestListUnsettledPayments(t *testing.T) {
+	stub := newSwapPortfolio(t)
+
+	for _, swapID := range []string{"swap1", "swap4"} {
+		_, err := invoke(stub, "calculatePayment", swapID)
+		require.NoError(t, err)
+	}
+
+	payload, err := invoke(stub, "listUnsettledPayments")
+	require.NoError(t, err)
+	var payments []UnsettledPayment
+	require.NoError(t, json.Unmarshal(payload, &payments))
+	require.Len(t, payments, 2)
+	require.Equal(t, "swap1", payments[0].SwapID)
+	require.Equal(t, "swap4", payments[1].SwapID)
+	require.Equal(t, "partyb", payments[1].PartyA)
+	require.Equal(t, PeriodCalculated, payments[1].Period.Status)
+
+	payload, err = invoke(stub, "listUnsettledPayments", "partya")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &payments))
+	require.Len(t, payments, 1)
+	require.Equal(t, "swap1", payments[0].SwapID)
+
+	_, err = invoke(stub, "settlePayment", "swap1")
+	require.NoError(t, err)
+	payload, err = invoke(stub, "listUnsettledPayments", "partya")
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(payload))
+}
+
+func TestExposureReport(t *testing.T) {
+	stub := newSwapPortfolio(t)
+
+	// swap1: fixed 333, floating 666, so partya receives 333 from partyb
+	_, err := invoke(stub, "calculatePayment", "swap1")
+	require.NoError(t, err)
+	_, err = invoke(stub, "terminateSwap", "swap3", "2018-11-15T00:00:00Z", "0")
+	require.NoError(t, err)
+
+	payload, err := invoke(stub, "getExposureReport", "partya")
+	require.NoError(t, err)
+	var report []Exposure
+	require.NoError(t, json.Unmarshal(payload, &report))
+	require.Equal(t, []Exposure{
+		{Counterparty: "partyb", Swaps: 1, Notional: 100000, NetPayable: -333},
+		{Counterparty: "partyc", Swaps: 1, Notional: 250000},
+	}, report)
+
+	payload, err = invoke(stub, "getExposureReport", "partyb")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &report))
+	require.Equal(t, []Exposure{
+		{Counterparty: "partya", Swaps: 1, Notional: 100000, NetPayable: 333},
+		{Counterparty: "partyc", Swaps: 1, Notional: 70000},
+	}, report)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Aggregation methods for the official fixing of a reference rate
+const (
+	AggregationMedian      = "median"
+	AggregationTrimmedMean = "trimmedmean"
+)
+
+// fixingDateLayout is the layout of the dates reference rates are fixed for
+const fixingDateLayout = "2006-01-02"
+
+/* RateConfig describes how the official fixing of a reference rate is derived
+ * from the submissions of its providers. A fixing exists for a date once at
+ * least Quorum providers have submitted a rate for it. The fixing is the median
+ * of the submitted rates or, for a trimmed mean, the mean of the submitted rates
+ * after dropping the Trim lowest and the Trim highest ones. Both are rounded down
+ * to the basis point.
+ */
+type RateConfig struct {
+	Providers []string
+	Quorum    int
+	Method    string
+	Trim      int
+}
+
+// RateSubmission is the rate a provider submitted for a fixing date
+type RateSubmission struct {
+	RateBPS     uint64
+	SubmittedAt time.Time
+}
+
+// Contribution is a provider's submission that went into a fixing
+type Contribution struct {
+	Provider    string
+	RateBPS     uint64
+	SubmittedAt time.Time
+}
+
+// Fixing is the official value of a reference rate for a date, together with
+// the contributions it was derived from
+type Fixing struct {
+	ReferenceRate string
+	Date          string
+	RateBPS       uint64
+	Method        string
+	Contributions []Contribution
+}
+
+// validate checks that the fixing of a rate can be derived with its configuration
+func (c *RateConfig) validate() error {
+	if c.Quorum < 1 || c.Quorum > len(c.Providers) {
+		return fmt.Errorf("Quorum must be between 1 and %d", len(c.Providers))
+	}
+	switch c.Method {
+	case AggregationMedian:
+		if c.Trim != 0 {
+			return fmt.Errorf("Trim is only supported by the %s aggregation", AggregationTrimmedMean)
+		}
+	case AggregationTrimmedMean:
+		if c.Trim < 0 || 2*c.Trim >= c.Quorum {
+			return fmt.Errorf("Trim must leave at least one of %d required submissions", c.Quorum)
+		}
+	default:
+		return fmt.Errorf("Unknown aggregation method %s. Expected one of %s, %s", c.Method, AggregationMedian, AggregationTrimmedMean)
+	}
+	return nil
+}
+
+// aggregate derives the fixing of a rate from the contributions for a date.
+// Contributions submitted after asOf are ignored so that a fixing used in the
+// past can be reproduced even if further providers submitted later on.
+func aggregate(rrID string, date string, config *RateConfig, contributions []Contribution, asOf time.Time) (*Fixing, error) {
+	fixing := &Fixing{ReferenceRate: rrID, Date: date, Method: config.Method}
+	values := []uint64{}
+	for _, contribution := range contributions {
+		if contribution.SubmittedAt.After(asOf) {
+			continue
+		}
+		fixing.Contributions = append(fixing.Contributions, contribution)
+		values = append(values, contribution.RateBPS)
+	}
+	if len(values) < config.Quorum {
+		return nil, fmt.Errorf("Reference rate %s has %d of %d required submissions for %s", rrID, len(values), config.Quorum, date)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	switch config.Method {
+	case AggregationMedian:
+		middle := len(values) / 2
+		if len(values)%2 == 1 {
+			fixing.RateBPS = values[middle]
+		} else {
+			fixing.RateBPS = (values[middle-1] + values[middle]) / 2
+		}
+	case AggregationTrimmedMean:
+		values = values[config.Trim : len(values)-config.Trim]
+		var sum uint64
+		for _, value := range values {
+			sum += value
+		}
+		fixing.RateBPS = sum / uint64(len(values))
+	default:
+		return nil, fmt.Errorf("Unknown aggregation method %s", config.Method)
+	}
+
+	return fixing, nil
+}
+
+// Object types of the composite keys of the reference rates. Composite keys
+// keep a rate ID from running into the MSP ID of a provider.
+const (
+	rateConfigType   = "rr"
+	rateProviderType = "rr~provider"
+)
+
+// rateConfigKey is the key of the configuration of a reference rate
+func rateConfigKey(stub shim.ChaincodeStubInterface, rrID string) (string, error) {
+	return stub.CreateCompositeKey(rateConfigType, []string{rrID})
+}
+
+// rateProviderKey is the key holding the submissions of a provider for a rate
+func rateProviderKey(stub shim.ChaincodeStubInterface, rrID string, provider string) (string, error) {
+	return stub.CreateCompositeKey(rateProviderType, []string{rrID, provider})
+}
+
+// getRateConfig reads the configuration of a reference rate from the ledger
+func getRateConfig(stub shim.ChaincodeStubInterface, rrID string) (*RateConfig, error) {
+	key, err := rateConfigKey(stub, rrID)
+	if err != nil {
+		return nil, err
+	}
+	configJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if configJSON == nil {
+		return nil, fmt.Errorf("Reference rate %s not found", rrID)
+	}
+	var config RateConfig
+	err = json.Unmarshal(configJSON, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// putRateConfig writes the configuration of a reference rate to the ledger
+func putRateConfig(stub shim.ChaincodeStubInterface, rrID string, config *RateConfig) error {
+	key, err := rateConfigKey(stub, rrID)
+	if err != nil {
+		return err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, configJSON)
+}
+
+// getSubmissions reads the submissions of a provider for a rate, by fixing date
+func getSubmissions(stub shim.ChaincodeStubInterface, rrID string, provider string) (map[string]RateSubmission, error) {
+	key, err := rateProviderKey(stub, rrID, provider)
+	if err != nil {
+		return nil, err
+	}
+	submissionsJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	submissions := map[string]RateSubmission{}
+	if submissionsJSON == nil {
+		return submissions, nil
+	}
+	err = json.Unmarshal(submissionsJSON, &submissions)
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// putSubmissions writes the submissions of a provider for a rate
+func putSubmissions(stub shim.ChaincodeStubInterface, rrID string, provider string, submissions map[string]RateSubmission) error {
+	key, err := rateProviderKey(stub, rrID, provider)
+	if err != nil {
+		return err
+	}
+	submissionsJSON, err := json.Marshal(submissions)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, submissionsJSON)
+}
+
+// getContributions collects the submissions of all providers of a rate, in the
+// order of the providers in the rate configuration, indexed by fixing date
+func getContributions(stub shim.ChaincodeStubInterface, rrID string, config *RateConfig) (map[string][]Contribution, error) {
+	contributions := map[string][]Contribution{}
+	for _, provider := range config.Providers {
+		submissions, err := getSubmissions(stub, rrID, provider)
+		if err != nil {
+			return nil, err
+		}
+		for date, submission := range submissions {
+			contributions[date] = append(contributions[date], Contribution{
+				Provider:    provider,
+				RateBPS:     submission.RateBPS,
+				SubmittedAt: submission.SubmittedAt,
+			})
+		}
+	}
+	return contributions, nil
+}
+
+// getFixing returns the fixing of a rate for a date as of the given time
+func getFixing(stub shim.ChaincodeStubInterface, rrID string, date string, asOf time.Time) (*Fixing, error) {
+	config, err := getRateConfig(stub, rrID)
+	if err != nil {
+		return nil, err
+	}
+	contributions, err := getContributions(stub, rrID, config)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate(rrID, date, config, contributions[date], asOf)
+}
+
+// getLatestFixing returns the fixing of a rate for the latest date on or before
+// the given date for which a quorum of providers had submitted as of asOf
+func getLatestFixing(stub shim.ChaincodeStubInterface, rrID string, onOrBefore time.Time, asOf time.Time) (*Fixing, error) {
+	config, err := getRateConfig(stub, rrID)
+	if err != nil {
+		return nil, err
+	}
+	contributions, err := getContributions(stub, rrID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := onOrBefore.UTC().Format(fixingDateLayout)
+	dates := []string{}
+	for date := range contributions {
+		if date <= latest {
+			dates = append(dates, date)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	for _, date := range dates {
+		fixing, err := aggregate(rrID, date, config, contributions[date], asOf)
+		if err == nil {
+			return fixing, nil
+		}
+	}
+	return nil, fmt.Errorf("No fixing of reference rate %s on or before %s", rrID, latest)
+}
+
+// getCreatorMSPID returns the MSP ID of the client that submitted the transaction
+func getCreatorMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return "", err
+	}
+	identity := &msp.SerializedIdentity{}
+	err = proto.Unmarshal(creator, identity)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read the identity of the client: %s", err)
+	}
+	return identity.Mspid, nil
+}
+
+// getTxTime returns the timestamp of the transaction
+func getTxTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC(), nil
+}
+
+// parseFixingDate checks that a fixing date is given as YYYY-MM-DD
+func parseFixingDate(value string) (string, error) {
+	date, err := time.Parse(fixingDateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("Invalid fixing date %s. Expected a date such as 2018-09-27", value)
+	}
+	return date.Format(fixingDateLayout), nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/require"
+)
+
+func contributions(rates ...uint64) []Contribution {
+	result := []Contribution{}
+	for i, rate := range rates {
+		result = append(result, Contribution{
+			Provider:    string(rune('a' + i)),
+			RateBPS:     rate,
+			SubmittedAt: date("2019-01-01T10:00:00Z").Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return result
+}
+
+func TestAggregate(t *testing.T) {
+	asOf := date("2019-01-02T00:00:00Z")
+	config := &RateConfig{Providers: []string{"a", "b", "c", "d", "e"}, Quorum: 3, Method: AggregationMedian}
+
+	fixing, err := aggregate("libor", "2019-01-01", config, contributions(310, 290, 300), asOf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(300), fixing.RateBPS)
+	require.Len(t, fixing.Contributions, 3)
+
+	fixing, err = aggregate("libor", "2019-01-01", config, contributions(310, 290, 300, 305), asOf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(302), fixing.RateBPS)
+
+	config.Method = AggregationTrimmedMean
+	config.Trim = 1
+	fixing, err = aggregate("libor", "2019-01-01", config, contributions(310, 290, 300, 306, 900), asOf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(305), fixing.RateBPS)
+
+	_, err = aggregate("libor", "2019-01-01", config, contributions(310, 290), asOf)
+	require.EqualError(t, err, "Reference rate libor has 2 of 3 required submissions for 2019-01-01")
+
+	// submissions after the as-of time do not count
+	_, err = aggregate("libor", "2019-01-01", config, contributions(310, 290, 300), date("2019-01-01T10:01:00Z"))
+	require.EqualError(t, err, "Reference rate libor has 2 of 3 required submissions for 2019-01-01")
+}
+
+func TestRateConfigValidate(t *testing.T) {
+	config := &RateConfig{Providers: []string{"a", "b", "c"}, Quorum: 3, Method: AggregationTrimmedMean, Trim: 1}
+	require.NoError(t, config.validate())
+
+	config.Quorum = 4
+	require.EqualError(t, config.validate(), "Quorum must be between 1 and 3")
+
+	config.Quorum = 2
+	require.EqualError(t, config.validate(), "Trim must leave at least one of 2 required submissions")
+
+	config.Method = AggregationMedian
+	require.EqualError(t, config.validate(), "Trim is only supported by the trimmedmean aggregation")
+
+	config.Method = "mean"
+	require.EqualError(t, config.validate(), "Unknown aggregation method mean. Expected one of median, trimmedmean")
+}
+
+func newMultiProviderSwapManager(t *testing.T) *shimtest.MockStub {
+	stub := shimtest.NewMockStub("irscc", new(SwapManager))
+	response := stub.MockInit("init", [][]byte{
+		[]byte("init"), []byte("auditor"), []byte("1000000"),
+		[]byte("provider1"), []byte("libor"),
+		[]byte("provider2"), []byte("libor"),
+		[]byte("provider3"), []byte("libor"),
+	})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+	return stub
+}
+
+func TestReferenceRateQuorum(t *testing.T) {
+	stub := newMultiProviderSwapManager(t)
+
+	config, err := getRateConfig(stub, "libor")
+	require.NoError(t, err)
+	require.Equal(t, &RateConfig{Providers: []string{"provider1", "provider2", "provider3"}, Quorum: 2, Method: AggregationMedian}, config)
+
+	setCreator(t, stub, "partya")
+	_, err = invoke(stub, "setReferenceRate", "libor", "300", "2019-01-01")
+	require.EqualError(t, err, "partya is not a provider of reference rate libor")
+
+	setCreator(t, stub, "provider1")
+	_, err = invoke(stub, "setReferenceRate", "libor", "300", "2019-01-01")
+	require.NoError(t, err)
+	_, err = invoke(stub, "setReferenceRate", "libor", "310", "2019-01-01")
+	require.EqualError(t, err, "provider1 has already submitted reference rate libor for 2019-01-01")
+
+	_, err = invoke(stub, "getReferenceRate", "libor", "2019-01-01")
+	require.EqualError(t, err, "Reference rate libor has 1 of 2 required submissions for 2019-01-01")
+
+	setCreator(t, stub, "provider2")
+	_, err = invoke(stub, "setReferenceRate", "libor", "320", "2019-01-01")
+	require.NoError(t, err)
+
+	payload, err := invoke(stub, "getReferenceRate", "libor", "2019-01-01")
+	require.NoError(t, err)
+	var fixing Fixing
+	require.NoError(t, json.Unmarshal(payload, &fixing))
+	require.Equal(t, uint64(310), fixing.RateBPS)
+	require.Equal(t, "provider1", fixing.Contributions[0].Provider)
+	require.Equal(t, "provider2", fixing.Contributions[1].Provider)
+
+	_, err = invoke(stub, "configureReferenceRate", "libor", "3", AggregationTrimmedMean, "1")
+	require.NoError(t, err)
+	_, err = invoke(stub, "configureReferenceRate", "libor", "2", AggregationTrimmedMean, "1")
+	require.EqualError(t, err, "Trim must leave at least one of 2 required submissions")
+
+	_, err = invoke(stub, "setReferenceRate", "libor", "abc", "2019-01-02")
+	require.EqualError(t, err, "Invalid reference rate abc. Expected basis points")
+	_, err = invoke(stub, "setReferenceRate", "libor", "300", "01/02/2019")
+	require.EqualError(t, err, "Invalid fixing date 01/02/2019. Expected a date such as 2018-09-27")
+}
+
+func TestRateKeysDoNotCollide(t *testing.T) {
+	// provider b_c of rate a and provider c of rate a_b joined the same keys
+	// when keys were built by concatenation
+	stub := shimtest.NewMockStub("irscc", new(SwapManager))
+	response := stub.MockInit("init", [][]byte{[]byte("init"), []byte("auditor"), []byte("1000000"), []byte("b_c"), []byte("a"), []byte("c"), []byte("a_b")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+
+	setCreator(t, stub, "b_c")
+	_, err := invoke(stub, "setReferenceRate", "a", "300", "2019-01-01")
+	require.NoError(t, err)
+
+	setCreator(t, stub, "c")
+	_, err = invoke(stub, "setReferenceRate", "a_b", "400", "2019-01-01")
+	require.NoError(t, err)
+
+	for rrID, rate := range map[string]uint64{"a": 300, "a_b": 400} {
+		payload, err := invoke(stub, "getReferenceRate", rrID, "2019-01-01")
+		require.NoError(t, err)
+		var fixing Fixing
+		require.NoError(t, json.Unmarshal(payload, &fixing))
+		require.Equal(t, rate, fixing.RateBPS, rrID)
+		require.Len(t, fixing.Contributions, 1, rrID)
+	}
+}
+
+func TestPaymentReproducibleFromFixing(t *testing.T) {
+	stub := newMultiProviderSwapManager(t)
+
+	for provider, rate := range map[string]string{"provider1": "300", "provider2": "340"} {
+		setCreator(t, stub, provider)
+		_, err := invoke(stub, "setReferenceRate", "libor", rate, "2018-12-31")
+		require.NoError(t, err)
+	}
+
+	swap := `{"StartDate":"2019-01-01T00:00:00Z","EndDate":"2019-04-01T00:00:00Z","PaymentIntervalMonths":3,"PrincipalAmount":1000000,"FixedRateBPS":400,"FloatingRateBPS":50,"ReferenceRate":"libor","DayCount":"ACT/365"}`
+	_, err := invoke(stub, "createSwap", "myswap", swap, "partya", "partyb")
+	require.NoError(t, err)
+
+	payload, err := invoke(stub, "calculatePayment", "myswap")
+	require.NoError(t, err)
+	var period PaymentPeriod
+	require.NoError(t, json.Unmarshal(payload, &period))
+	require.Equal(t, "2018-12-31", period.FixingDate)
+	require.Equal(t, uint64(320), period.ReferenceRateBPS)
+
+	// a late submission changes the current fixing, but not the one used for the payment
+	setCreator(t, stub, "provider3")
+	_, err = invoke(stub, "setReferenceRate", "libor", "500", "2018-12-31")
+	require.NoError(t, err)
+
+	var fixing Fixing
+	payload, err = invoke(stub, "getReferenceRate", "libor", "2018-12-31")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &fixing))
+	require.Equal(t, uint64(340), fixing.RateBPS)
+
+	payload, err = invoke(stub, "getReferenceRate", "libor", period.FixingDate, period.CalculatedAt.Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(payload, &fixing))
+	require.Equal(t, period.ReferenceRateBPS, fixing.RateBPS)
+
+	irs, err := getSwap(stub, "myswap")
+	require.NoError(t, err)
+	reproduced := PaymentPeriod{Index: period.Index, StartDate: period.StartDate, EndDate: period.EndDate, PrincipalAmount: period.PrincipalAmount}
+	require.NoError(t, calculatePeriod(*irs, &reproduced, &fixing, period.CalculatedAt))
+	require.Equal(t, period.NetAmount, reproduced.NetAmount)
+}
@@ -0,0 +1,253 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// Day-count conventions supported for the accrual of interest in a period
+const (
+	DayCountACT360 = "ACT/360"
+	DayCountACT365 = "ACT/365"
+	DayCount30360  = "30/360"
+)
+
+// maxPeriods limits the size of the payment schedule of a swap
+const maxPeriods = 1000
+
+// Status of a payment period
+const (
+	PeriodScheduled  = "scheduled"
+	PeriodCalculated = "calculated"
+	PeriodSettled    = "settled"
+	PeriodCancelled  = "cancelled"
+)
+
+/* PaymentPeriod is a single period in the payment schedule of a swap.
+ * Interest accrues on PrincipalAmount from StartDate to EndDate and the payment
+ * is due at EndDate.
+ * The reference rate of a period is fixed in advance, for the start date of the
+ * period, which the schedule records as its FixingDate. Once calculated, the
+ * period records the date and value of the fixing that was used, falling back
+ * to the latest earlier fixing if there was none for the start date, the time
+ * of the calculation and the amounts of both legs. NetAmount is paid from A to
+ * B, a negative amount is paid from B to A.
+ */
+type PaymentPeriod struct {
+	Index            int
+	StartDate        time.Time
+	EndDate          time.Time
+	Status           string
+	PrincipalAmount  uint64
+	ReferenceRateBPS uint64
+	FixingDate       string
+	CalculatedAt     time.Time
+	FixedAmount      int64
+	FloatingAmount   int64
+	NetAmount        int64
+}
+
+// PaymentSchedule holds all payment periods of a swap, in date order
+type PaymentSchedule struct {
+	Periods []PaymentPeriod
+}
+
+// generateSchedule splits the term of a swap into payment periods of the
+// swap's payment interval in months. Periods are counted from the start date
+// of the swap, so that they keep its day of the month, or end on the last day
+// of shorter months. The last period ends at the end date of the swap and may
+// be shorter than the payment interval.
+func generateSchedule(irs InterestRateSwap) (*PaymentSchedule, error) {
+	if !irs.EndDate.After(irs.StartDate) {
+		return nil, fmt.Errorf("End date %s must be after start date %s", irs.EndDate.Format(time.RFC3339), irs.StartDate.Format(time.RFC3339))
+	}
+	if irs.PaymentIntervalMonths <= 0 {
+		return nil, fmt.Errorf("Payment interval must be a positive number of months")
+	}
+	if _, _, err := dayCount(irs.DayCount, irs.StartDate, irs.EndDate); err != nil {
+		return nil, err
+	}
+
+	schedule := &PaymentSchedule{}
+	for start := irs.StartDate; start.Before(irs.EndDate); {
+		if len(schedule.Periods) == maxPeriods {
+			return nil, fmt.Errorf("Swap has more than %d payment periods", maxPeriods)
+		}
+		end := addMonths(irs.StartDate, (len(schedule.Periods)+1)*irs.PaymentIntervalMonths)
+		if end.After(irs.EndDate) {
+			end = irs.EndDate
+		}
+		schedule.Periods = append(schedule.Periods, PaymentPeriod{
+			Index:           len(schedule.Periods),
+			StartDate:       start,
+			EndDate:         end,
+			Status:          PeriodScheduled,
+			PrincipalAmount: irs.PrincipalAmount,
+			FixingDate:      start.UTC().Format(fixingDateLayout),
+		})
+		start = end
+	}
+
+	return schedule, nil
+}
+
+// addMonths adds a number of months to a date. If the day of the month does
+// not exist in the resulting month, the date moves to the last day of that
+// month instead of overflowing into the next one.
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := date.AddDate(0, 0, 1-date.Day()).AddDate(0, months, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// nextPeriod returns the first period of the schedule that has been neither
+// settled nor cancelled, or nil if there is no such period
+func (s *PaymentSchedule) nextPeriod() *PaymentPeriod {
+	for i := range s.Periods {
+		if s.Periods[i].Status != PeriodSettled && s.Periods[i].Status != PeriodCancelled {
+			return &s.Periods[i]
+		}
+	}
+	return nil
+}
+
+// terminate cancels the periods that start on or after the termination date
+// and ends the period running over it at the termination date. Periods that
+// have been calculated already cannot be changed.
+func (s *PaymentSchedule) terminate(terminationDate time.Time) error {
+	for i := range s.Periods {
+		period := &s.Periods[i]
+		if !period.EndDate.After(terminationDate) {
+			continue
+		}
+		if period.Status != PeriodScheduled {
+			return fmt.Errorf("Payment period %d ending %s has already been calculated", period.Index, period.EndDate.Format(time.RFC3339))
+		}
+	}
+	for i := range s.Periods {
+		period := &s.Periods[i]
+		if !period.StartDate.Before(terminationDate) {
+			period.Status = PeriodCancelled
+		} else if period.EndDate.After(terminationDate) {
+			period.EndDate = terminationDate
+		}
+	}
+	return nil
+}
+
+// amend changes the principal amount of the periods that start on or after the
+// effective date. Periods that have been calculated already cannot be changed.
+func (s *PaymentSchedule) amend(principal uint64, effectiveDate time.Time) error {
+	amended := false
+	for i := range s.Periods {
+		period := &s.Periods[i]
+		if period.StartDate.Before(effectiveDate) || period.Status == PeriodCancelled {
+			continue
+		}
+		if period.Status != PeriodScheduled {
+			return fmt.Errorf("Payment period %d ending %s has already been calculated", period.Index, period.EndDate.Format(time.RFC3339))
+		}
+		period.PrincipalAmount = principal
+		amended = true
+	}
+	if !amended {
+		return fmt.Errorf("No payment period starts on or after %s", effectiveDate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// calculatePeriod computes both legs of a period and their net amount using
+// the fixing of the reference rate for the period and the principal amount
+// of the period. The period must be due at txTime.
+func calculatePeriod(irs InterestRateSwap, period *PaymentPeriod, fixing *Fixing, txTime time.Time) error {
+	if txTime.Before(period.EndDate) {
+		return fmt.Errorf("Payment period %d is not due until %s", period.Index, period.EndDate.Format(time.RFC3339))
+	}
+
+	days, basis, err := dayCount(irs.DayCount, period.StartDate, period.EndDate)
+	if err != nil {
+		return err
+	}
+
+	fixedAmount, err := accrue(period.PrincipalAmount, irs.FixedRateBPS, days, basis)
+	if err != nil {
+		return fmt.Errorf("Fixed leg of payment period %d: %s", period.Index, err)
+	}
+	floatingAmount, err := accrue(period.PrincipalAmount, irs.FloatingRateBPS+fixing.RateBPS, days, basis)
+	if err != nil {
+		return fmt.Errorf("Floating leg of payment period %d: %s", period.Index, err)
+	}
+
+	period.ReferenceRateBPS = fixing.RateBPS
+	period.FixingDate = fixing.Date
+	period.CalculatedAt = txTime
+	period.FixedAmount = fixedAmount
+	period.FloatingAmount = floatingAmount
+	period.NetAmount = period.FixedAmount - period.FloatingAmount
+	period.Status = PeriodCalculated
+
+	return nil
+}
+
+// accrue returns the interest on principal at rateBPS basis points per year
+// for days out of a year of basis days, rounded down. It fails if the interest
+// does not fit into an int64.
+func accrue(principal uint64, rateBPS uint64, days int64, basis int64) (int64, error) {
+	amount := new(big.Int).SetUint64(principal)
+	amount.Mul(amount, new(big.Int).SetUint64(rateBPS))
+	amount.Mul(amount, big.NewInt(days))
+	amount.Quo(amount, big.NewInt(10000*basis))
+	if !amount.IsInt64() {
+		return 0, fmt.Errorf("Interest of %s exceeds the maximum amount of %d", amount.String(), int64(math.MaxInt64))
+	}
+	return amount.Int64(), nil
+}
+
+// dayCount returns the number of days between start and end and the number
+// of days in a year under the given day-count convention. An empty convention
+// defaults to ACT/360.
+func dayCount(convention string, start time.Time, end time.Time) (int64, int64, error) {
+	switch convention {
+	case DayCountACT360, "":
+		return actualDays(start, end), 360, nil
+	case DayCountACT365:
+		return actualDays(start, end), 365, nil
+	case DayCount30360:
+		return thirty360Days(start, end), 360, nil
+	default:
+		return 0, 0, fmt.Errorf("Unknown day-count convention %s. Expected one of %s, %s, %s", convention, DayCountACT360, DayCountACT365, DayCount30360)
+	}
+}
+
+// actualDays returns the number of calendar days between start and end
+func actualDays(start time.Time, end time.Time) int64 {
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	endDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int64(endDay.Sub(startDay).Hours() / 24)
+}
+
+// thirty360Days returns the number of days between start and end assuming
+// 30 days in every month, following the 30/360 bond basis
+func thirty360Days(start time.Time, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
@@ -0,0 +1,240 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/require"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDayCount(t *testing.T) {
+	tests := []struct {
+		convention string
+		start      string
+		end        string
+		days       int64
+		basis      int64
+	}{
+		{DayCountACT360, "2019-01-31T12:00:00Z", "2019-03-31T09:00:00Z", 59, 360},
+		{"", "2020-02-01T00:00:00Z", "2020-03-01T00:00:00Z", 29, 360},
+		{DayCountACT365, "2019-01-31T00:00:00Z", "2019-03-31T00:00:00Z", 59, 365},
+		{DayCount30360, "2019-01-31T00:00:00Z", "2019-03-31T00:00:00Z", 60, 360},
+		{DayCount30360, "2019-01-15T00:00:00Z", "2019-03-31T00:00:00Z", 76, 360},
+		{DayCount30360, "2019-02-28T00:00:00Z", "2020-02-28T00:00:00Z", 360, 360},
+	}
+
+	for _, test := range tests {
+		days, basis, err := dayCount(test.convention, date(test.start), date(test.end))
+		require.NoError(t, err)
+		require.Equal(t, test.days, days, "%s from %s to %s", test.convention, test.start, test.end)
+		require.Equal(t, test.basis, basis, test.convention)
+	}
+
+	_, _, err := dayCount("ACT/ACT", date("2019-01-01T00:00:00Z"), date("2019-02-01T00:00:00Z"))
+	require.EqualError(t, err, "Unknown day-count convention ACT/ACT. Expected one of ACT/360, ACT/365, 30/360")
+}
+
+func TestGenerateSchedule(t *testing.T) {
+	irs := InterestRateSwap{
+		StartDate:             date("2019-01-31T00:00:00Z"),
+		EndDate:               date("2019-06-15T00:00:00Z"),
+		PaymentIntervalMonths: 1,
+	}
+
+	// periods keep the day of the start date, or end on the last day of shorter months
+	schedule, err := generateSchedule(irs)
+	require.NoError(t, err)
+	require.Equal(t, []PaymentPeriod{
+		{Index: 0, StartDate: date("2019-01-31T00:00:00Z"), EndDate: date("2019-02-28T00:00:00Z"), Status: PeriodScheduled, FixingDate: "2019-01-31"},
+		{Index: 1, StartDate: date("2019-02-28T00:00:00Z"), EndDate: date("2019-03-31T00:00:00Z"), Status: PeriodScheduled, FixingDate: "2019-02-28"},
+		{Index: 2, StartDate: date("2019-03-31T00:00:00Z"), EndDate: date("2019-04-30T00:00:00Z"), Status: PeriodScheduled, FixingDate: "2019-03-31"},
+		{Index: 3, StartDate: date("2019-04-30T00:00:00Z"), EndDate: date("2019-05-31T00:00:00Z"), Status: PeriodScheduled, FixingDate: "2019-04-30"},
+		{Index: 4, StartDate: date("2019-05-31T00:00:00Z"), EndDate: date("2019-06-15T00:00:00Z"), Status: PeriodScheduled, FixingDate: "2019-05-31"},
+	}, schedule.Periods)
+
+	irs.PaymentIntervalMonths = 3
+	schedule, err = generateSchedule(irs)
+	require.NoError(t, err)
+	require.Len(t, schedule.Periods, 2)
+	require.Equal(t, date("2019-04-30T00:00:00Z"), schedule.Periods[0].EndDate)
+
+	irs.PaymentIntervalMonths = 0
+	_, err = generateSchedule(irs)
+	require.EqualError(t, err, "Payment interval must be a positive number of months")
+
+	irs.PaymentIntervalMonths = 1
+	irs.EndDate = date("2119-01-31T00:00:00Z")
+	_, err = generateSchedule(irs)
+	require.EqualError(t, err, "Swap has more than 1000 payment periods")
+
+	irs.EndDate = irs.StartDate
+	_, err = generateSchedule(irs)
+	require.EqualError(t, err, "End date 2019-01-31T00:00:00Z must be after start date 2019-01-31T00:00:00Z")
+}
+
+func TestCalculatePeriod(t *testing.T) {
+	irs := InterestRateSwap{
+		FixedRateBPS:    400,
+		FloatingRateBPS: 50,
+		DayCount:        DayCountACT365,
+	}
+	period := PaymentPeriod{Index: 1, StartDate: date("2019-01-01T00:00:00Z"), EndDate: date("2019-04-01T00:00:00Z"), Status: PeriodScheduled, PrincipalAmount: 1000000}
+
+	fixing := &Fixing{ReferenceRate: "libor", Date: "2018-12-31", RateBPS: 300}
+
+	err := calculatePeriod(irs, &period, fixing, date("2019-03-31T23:59:59Z"))
+	require.EqualError(t, err, "Payment period 1 is not due until 2019-04-01T00:00:00Z")
+	require.Equal(t, PeriodScheduled, period.Status)
+
+	// 90 days of ACT/365 at 4% fixed against 3.5% floating
+	err = calculatePeriod(irs, &period, fixing, date("2019-04-01T00:00:00Z"))
+	require.NoError(t, err)
+	require.Equal(t, PaymentPeriod{
+		Index:            1,
+		StartDate:        date("2019-01-01T00:00:00Z"),
+		EndDate:          date("2019-04-01T00:00:00Z"),
+		Status:           PeriodCalculated,
+		PrincipalAmount:  1000000,
+		ReferenceRateBPS: 300,
+		FixingDate:       "2018-12-31",
+		CalculatedAt:     date("2019-04-01T00:00:00Z"),
+		FixedAmount:      9863,
+		FloatingAmount:   8630,
+		NetAmount:        1233,
+	}, period)
+
+	// 1000% on the largest principal amount does not fit into the amounts of a period
+	irs.FixedRateBPS = 100000
+	overflowing := PaymentPeriod{Index: 2, StartDate: date("2019-01-01T00:00:00Z"), EndDate: date("2019-04-01T00:00:00Z"), Status: PeriodScheduled, PrincipalAmount: math.MaxUint64}
+	err = calculatePeriod(irs, &overflowing, fixing, date("2019-04-01T00:00:00Z"))
+	require.EqualError(t, err, "Fixed leg of payment period 2: Interest of 45485122373530401242 exceeds the maximum amount of 9223372036854775807")
+	require.Equal(t, PeriodScheduled, overflowing.Status)
+	require.Zero(t, overflowing.FixedAmount)
+}
+
+func invoke(stub *shimtest.MockStub, args ...string) ([]byte, error) {
+	byteArgs := [][]byte{}
+	for _, arg := range args {
+		byteArgs = append(byteArgs, []byte(arg))
+	}
+	response := stub.MockInvoke("tx", byteArgs)
+	if response.Status != shim.OK {
+		return nil, &responseError{response.Message}
+	}
+	return response.Payload, nil
+}
+
+type responseError struct {
+	message string
+}
+
+func (e *responseError) Error() string {
+	return e.message
+}
+
+func setCreator(t *testing.T, stub *shimtest.MockStub, mspID string) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+	require.NoError(t, err)
+	stub.Creator = creator
+}
+
+func newSwapManager(t *testing.T) *shimtest.MockStub {
+	return newSwapManagerStub(t, new(SwapManager))
+}
+
+func newSwapManagerStub(t *testing.T, cc shim.Chaincode) *shimtest.MockStub {
+	stub := shimtest.NewMockStub("irscc", cc)
+	response := stub.MockInit("init", [][]byte{[]byte("init"), []byte("auditor"), []byte("1000000"), []byte("rrprovider"), []byte("myrr")})
+	require.Equal(t, int32(shim.OK), response.Status, response.Message)
+
+	setCreator(t, stub, "rrprovider")
+	_, err := invoke(stub, "setReferenceRate", "myrr", "300", "2018-09-27")
+	require.NoError(t, err)
+
+	return stub
+}
+
+func TestPaymentFlow(t *testing.T) {
+	stub := newSwapManager(t)
+
+	swap := `{"StartDate":"2018-09-27T15:04:05Z","EndDate":"2018-12-27T15:04:05Z","PaymentIntervalMonths":1,"PrincipalAmount":100000,"FixedRateBPS":400,"FloatingRateBPS":500,"ReferenceRate":"myrr","DayCount":"ACT/360"}`
+	_, err := invoke(stub, "createSwap", "myswap", swap, "partya", "partyb")
+	require.NoError(t, err)
+
+	_, err = invoke(stub, "settlePayment", "myswap")
+	require.EqualError(t, err, "Payment has already been settled.")
+
+	payload, err := invoke(stub, "calculatePayment", "myswap")
+	require.NoError(t, err)
+	var period PaymentPeriod
+	require.NoError(t, json.Unmarshal(payload, &period))
+	require.Equal(t, 0, period.Index)
+	require.Equal(t, uint64(300), period.ReferenceRateBPS)
+	require.Equal(t, "2018-09-27", period.FixingDate)
+	require.Equal(t, int64(333), period.FixedAmount)
+	require.Equal(t, int64(666), period.FloatingAmount)
+	require.Equal(t, int64(-333), period.NetAmount)
+
+	_, err = invoke(stub, "calculatePayment", "myswap")
+	require.EqualError(t, err, "Previous payment has not been settled yet")
+
+	for i := 0; i < 2; i++ {
+		_, err = invoke(stub, "settlePayment", "myswap")
+		require.NoError(t, err)
+		_, err = invoke(stub, "calculatePayment", "myswap")
+		require.NoError(t, err)
+	}
+	_, err = invoke(stub, "settlePayment", "myswap")
+	require.NoError(t, err)
+
+	_, err = invoke(stub, "calculatePayment", "myswap")
+	require.EqualError(t, err, "All payments of swap myswap have been settled")
+
+	payload, err = invoke(stub, "getPaymentSchedule", "myswap")
+	require.NoError(t, err)
+	var schedule PaymentSchedule
+	require.NoError(t, json.Unmarshal(payload, &schedule))
+	require.Len(t, schedule.Periods, 3)
+	for _, period := range schedule.Periods {
+		require.Equal(t, PeriodSettled, period.Status)
+	}
+}
+
+func TestCalculatePaymentNotDue(t *testing.T) {
+	stub := newSwapManager(t)
+
+	swap := `{"StartDate":"2090-01-01T00:00:00Z","EndDate":"2091-01-01T00:00:00Z","PaymentIntervalMonths":1,"PrincipalAmount":100000,"FixedRateBPS":400,"FloatingRateBPS":500,"ReferenceRate":"myrr","DayCount":"30/360"}`
+	_, err := invoke(stub, "createSwap", "futureswap", swap, "partya", "partyb")
+	require.NoError(t, err)
+
+	_, err = invoke(stub, "calculatePayment", "futureswap")
+	require.EqualError(t, err, "Payment period 0 is not due until 2090-02-01T00:00:00Z")
+
+	swap = `{"StartDate":"2018-01-01T00:00:00Z","EndDate":"2019-01-01T00:00:00Z","PaymentIntervalMonths":1,"ReferenceRate":"myrr","DayCount":"ACT/ACT"}`
+	_, err = invoke(stub, "createSwap", "badswap", swap, "partya", "partyb")
+	require.EqualError(t, err, "Unknown day-count convention ACT/ACT. Expected one of ACT/360, ACT/365, 30/360")
+
+	swap = `{"StartDate":"2018-01-01T00:00:00Z","EndDate":"2019-01-01T00:00:00Z","PaymentInterval":30,"ReferenceRate":"myrr"}`
+	_, err = invoke(stub, "createSwap", "legacyswap", swap, "partya", "partyb")
+	require.EqualError(t, err, "PaymentInterval is no longer supported. Use PaymentIntervalMonths to give the payment interval in months")
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+var allCommands = map[string]func(gateway *client.Gateway) error{
+	"getAllAssets": getAllAssets,
+	"listen":       listen,
+	"transact":     transact,
+}
+
+// expectedError is an error that is part of the normal running of the sample, such as a simulated failure, and is
+// reported without failing the application.
+type expectedError struct {
+	message string
+}
+
+func (e *expectedError) Error() string {
+	return e.message
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		var expected *expectedError
+		if errors.As(err, &expected) {
+			fmt.Println(err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nUnexpected application error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	commands := []func(*client.Gateway) error{}
+	for _, name := range args {
+		command, ok := allCommands[name]
+		if !ok {
+			printUsage()
+			return fmt.Errorf("unknown command: %s", name)
+		}
+		commands = append(commands, command)
+	}
+	if len(commands) == 0 {
+		printUsage()
+		return errors.New("missing command")
+	}
+
+	gateway, closeGateway, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	for _, command := range commands {
+		if err := command(gateway); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	names := []string{}
+	for name := range allCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Arguments: <command1> [<command2> ...]")
+	fmt.Println("Available commands:", strings.Join(names, ", "))
+}
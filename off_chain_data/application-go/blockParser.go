@@ -0,0 +1,189 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// block is a parsed block containing its endorser transactions.
+type block struct {
+	number       uint64
+	transactions []*transaction
+}
+
+// transaction is a parsed endorser transaction within a block.
+type transaction struct {
+	channelHeader          *common.ChannelHeader
+	creator                []byte
+	validationCode         peer.TxValidationCode
+	namespaceReadWriteSets []*namespaceReadWriteSet
+}
+
+// namespaceReadWriteSet is the key-value read/write set of a transaction within one namespace.
+type namespaceReadWriteSet struct {
+	namespace    string
+	readWriteSet *kvrwset.KVRWSet
+}
+
+func (t *transaction) transactionID() string {
+	return t.channelHeader.GetTxId()
+}
+
+func (t *transaction) isValid() bool {
+	return t.validationCode == peer.TxValidationCode_VALID
+}
+
+// parseBlock extracts the endorser transactions and their read/write sets from a block. Other transaction types,
+// such as configuration updates, are ignored.
+func parseBlock(b *common.Block) (*block, error) {
+	if b.GetHeader() == nil {
+		return nil, errors.New("missing block header")
+	}
+
+	validationCodes, err := transactionValidationCodes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := []*transaction{}
+	for i, envelopeBytes := range b.GetData().GetData() {
+		if i >= len(validationCodes) {
+			return nil, fmt.Errorf("missing validation code for transaction %d in block %d", i, b.GetHeader().GetNumber())
+		}
+
+		t, err := parseEnvelope(envelopeBytes, peer.TxValidationCode(validationCodes[i]))
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			transactions = append(transactions, t)
+		}
+	}
+
+	return &block{
+		number:       b.GetHeader().GetNumber(),
+		transactions: transactions,
+	}, nil
+}
+
+func transactionValidationCodes(b *common.Block) ([]byte, error) {
+	metadata := b.GetMetadata().GetMetadata()
+	if len(metadata) <= int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		return nil, errors.New("missing block metadata")
+	}
+	return metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER], nil
+}
+
+// parseEnvelope returns the transaction of an envelope, or nil if it does not contain an endorser transaction.
+func parseEnvelope(envelopeBytes []byte, validationCode peer.TxValidationCode) (*transaction, error) {
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+		return nil, err
+	}
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.GetPayload(), payload); err != nil {
+		return nil, err
+	}
+	if payload.GetHeader() == nil {
+		return nil, errors.New("missing payload header")
+	}
+
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, err
+	}
+	if channelHeader.GetType() != int32(common.HeaderType_ENDORSER_TRANSACTION) {
+		return nil, nil
+	}
+
+	signatureHeader := &common.SignatureHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetSignatureHeader(), signatureHeader); err != nil {
+		return nil, err
+	}
+
+	namespaceReadWriteSets, err := parseEndorserTransaction(payload.GetData())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transaction %s: %w", channelHeader.GetTxId(), err)
+	}
+
+	return &transaction{
+		channelHeader:          channelHeader,
+		creator:                signatureHeader.GetCreator(),
+		validationCode:         validationCode,
+		namespaceReadWriteSets: namespaceReadWriteSets,
+	}, nil
+}
+
+// parseEndorserTransaction returns the read/write sets of all the chaincode actions of a transaction.
+func parseEndorserTransaction(transactionBytes []byte) ([]*namespaceReadWriteSet, error) {
+	endorserTransaction := &peer.Transaction{}
+	if err := proto.Unmarshal(transactionBytes, endorserTransaction); err != nil {
+		return nil, err
+	}
+
+	results := []*namespaceReadWriteSet{}
+	for _, transactionAction := range endorserTransaction.GetActions() {
+		actionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(transactionAction.GetPayload(), actionPayload); err != nil {
+			return nil, err
+		}
+		if actionPayload.GetAction() == nil {
+			return nil, errors.New("missing chaincode endorsed action")
+		}
+
+		responsePayload := &peer.ProposalResponsePayload{}
+		if err := proto.Unmarshal(actionPayload.GetAction().GetProposalResponsePayload(), responsePayload); err != nil {
+			return nil, err
+		}
+
+		chaincodeAction := &peer.ChaincodeAction{}
+		if err := proto.Unmarshal(responsePayload.GetExtension(), chaincodeAction); err != nil {
+			return nil, err
+		}
+
+		readWriteSets, err := parseReadWriteSet(chaincodeAction.GetResults())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, readWriteSets...)
+	}
+
+	return results, nil
+}
+
+func parseReadWriteSet(readWriteSetBytes []byte) ([]*namespaceReadWriteSet, error) {
+	readWriteSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(readWriteSetBytes, readWriteSet); err != nil {
+		return nil, err
+	}
+	if readWriteSet.GetDataModel() != rwset.TxReadWriteSet_KV {
+		return nil, fmt.Errorf("unexpected read/write set data model: %v", readWriteSet.GetDataModel())
+	}
+
+	results := []*namespaceReadWriteSet{}
+	for _, nsReadWriteSet := range readWriteSet.GetNsRwset() {
+		kvReadWriteSet := &kvrwset.KVRWSet{}
+		if err := proto.Unmarshal(nsReadWriteSet.GetRwset(), kvReadWriteSet); err != nil {
+			return nil, err
+		}
+		results = append(results, &namespaceReadWriteSet{
+			namespace:    nsReadWriteSet.GetNamespace(),
+			readWriteSet: kvReadWriteSet,
+		})
+	}
+
+	return results, nil
+}
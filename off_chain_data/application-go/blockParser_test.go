@@ -0,0 +1,117 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// newEnvelope creates a transaction envelope with the writes of a chaincode action by namespace.
+func newEnvelope(t *testing.T, headerType common.HeaderType, transactionID string, writes map[string][]*kvrwset.KVWrite) []byte {
+	nsReadWriteSets := []*rwset.NsReadWriteSet{}
+	for namespace, kvWrites := range writes {
+		nsReadWriteSets = append(nsReadWriteSets, &rwset.NsReadWriteSet{
+			Namespace: namespace,
+			Rwset:     mustMarshal(t, &kvrwset.KVRWSet{Writes: kvWrites}),
+		})
+	}
+	readWriteSet := &rwset.TxReadWriteSet{DataModel: rwset.TxReadWriteSet_KV, NsRwset: nsReadWriteSets}
+	chaincodeAction := &peer.ChaincodeAction{Results: mustMarshal(t, readWriteSet)}
+	responsePayload := &peer.ProposalResponsePayload{Extension: mustMarshal(t, chaincodeAction)}
+	actionPayload := &peer.ChaincodeActionPayload{
+		Action: &peer.ChaincodeEndorsedAction{ProposalResponsePayload: mustMarshal(t, responsePayload)},
+	}
+	endorserTransaction := &peer.Transaction{
+		Actions: []*peer.TransactionAction{{Payload: mustMarshal(t, actionPayload)}},
+	}
+
+	channelHeader := &common.ChannelHeader{Type: int32(headerType), ChannelId: "mychannel", TxId: transactionID}
+	signatureHeader := &common.SignatureHeader{Creator: []byte("creator")}
+	payload := &common.Payload{
+		Header: &common.Header{
+			ChannelHeader:   mustMarshal(t, channelHeader),
+			SignatureHeader: mustMarshal(t, signatureHeader),
+		},
+		Data: mustMarshal(t, endorserTransaction),
+	}
+	return mustMarshal(t, &common.Envelope{Payload: mustMarshal(t, payload)})
+}
+
+func newBlock(number uint64, envelopes [][]byte, validationCodes []peer.TxValidationCode) *common.Block {
+	transactionsFilter := []byte{}
+	for _, code := range validationCodes {
+		transactionsFilter = append(transactionsFilter, byte(code))
+	}
+	metadata := make([][]byte, len(common.BlockMetadataIndex_name))
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = transactionsFilter
+
+	return &common.Block{
+		Header:   &common.BlockHeader{Number: number},
+		Data:     &common.BlockData{Data: envelopes},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}
+}
+
+func TestParseBlock(t *testing.T) {
+	blockProto := newBlock(5, [][]byte{
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx1", map[string][]*kvrwset.KVWrite{
+			"basic": {{Key: "asset1", Value: []byte(`{"ID":"asset1"}`)}, {Key: "asset2", IsDelete: true}},
+		}),
+		newEnvelope(t, common.HeaderType_CONFIG, "config", nil),
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx2", nil),
+	}, []peer.TxValidationCode{peer.TxValidationCode_VALID, peer.TxValidationCode_VALID, peer.TxValidationCode_MVCC_READ_CONFLICT})
+
+	b, err := parseBlock(blockProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.number != 5 {
+		t.Errorf("block number = %d, want 5", b.number)
+	}
+	if len(b.transactions) != 2 {
+		t.Fatalf("got %d transactions, want the 2 endorser transactions", len(b.transactions))
+	}
+
+	first := b.transactions[0]
+	if first.transactionID() != "tx1" || !first.isValid() || string(first.creator) != "creator" {
+		t.Errorf("unexpected first transaction %s, valid %v, creator %s", first.transactionID(), first.isValid(), first.creator)
+	}
+	if len(first.namespaceReadWriteSets) != 1 || first.namespaceReadWriteSets[0].namespace != "basic" {
+		t.Fatalf("unexpected read/write sets %v", first.namespaceReadWriteSets)
+	}
+	writes := first.namespaceReadWriteSets[0].readWriteSet.GetWrites()
+	if len(writes) != 2 || writes[0].GetKey() != "asset1" || !writes[1].GetIsDelete() {
+		t.Errorf("unexpected writes %v", writes)
+	}
+
+	second := b.transactions[1]
+	if second.transactionID() != "tx2" || second.isValid() {
+		t.Errorf("transaction %s should be invalid", second.transactionID())
+	}
+}
+
+func TestParseBlockRejectsMissingMetadata(t *testing.T) {
+	blockProto := &common.Block{Header: &common.BlockHeader{Number: 1}}
+	if _, err := parseBlock(blockProto); err == nil || err.Error() != "missing block metadata" {
+		t.Errorf("err = %v, want missing block metadata", err)
+	}
+}
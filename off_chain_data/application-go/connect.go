@@ -0,0 +1,80 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/client-go/profile"
+)
+
+// clientProfile names the client identity and gateway peer, which can be overridden by HLF_* environment variables.
+const clientProfile = "clientProfile.yaml"
+
+var (
+	channelName   = envOrDefault("CHANNEL_NAME", "mychannel")
+	chaincodeName = envOrDefault("CHAINCODE_NAME", "basic")
+)
+
+// connect creates a Gateway connection for the client identity and gateway peers of the client profile, with default
+// timeouts for the different gRPC calls. The returned function closes the Gateway connection and the gRPC connection
+// and signer it uses.
+func connect() (*client.Gateway, func(), error) {
+	p, err := profile.Load(clientProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientConnection, err := p.NewConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := p.NewIdentity()
+	if err != nil {
+		clientConnection.Close()
+		return nil, nil, err
+	}
+
+	sign, closeSign, err := p.NewSign(profile.WithAuditLog(log.Default()))
+	if err != nil {
+		clientConnection.Close()
+		return nil, nil, err
+	}
+
+	gateway, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		closeSign()
+		clientConnection.Close()
+		return nil, nil, err
+	}
+
+	closeGateway := func() {
+		gateway.Close()
+		closeSign()
+		clientConnection.Close()
+	}
+	return gateway, closeGateway, nil
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// asset is the asset of the asset-transfer-basic smart contract.
+type asset struct {
+	ID             string `json:"ID"`
+	Color          string `json:"Color"`
+	Size           int    `json:"Size"`
+	Owner          string `json:"Owner"`
+	AppraisedValue int    `json:"AppraisedValue"`
+}
+
+// assetTransferBasic invokes the transaction functions of the asset-transfer-basic smart contract.
+type assetTransferBasic struct {
+	contract *client.Contract
+}
+
+func (smartContract *assetTransferBasic) createAsset(asset *asset) error {
+	_, err := smartContract.contract.SubmitTransaction(
+		"CreateAsset",
+		asset.ID,
+		asset.Color,
+		strconv.Itoa(asset.Size),
+		asset.Owner,
+		strconv.Itoa(asset.AppraisedValue),
+	)
+	return err
+}
+
+func (smartContract *assetTransferBasic) transferAsset(id string, newOwner string) (string, error) {
+	result, err := smartContract.contract.SubmitTransaction("TransferAsset", id, newOwner)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func (smartContract *assetTransferBasic) deleteAsset(id string) error {
+	_, err := smartContract.contract.SubmitTransaction("DeleteAsset", id)
+	return err
+}
+
+func (smartContract *assetTransferBasic) getAllAssets() ([]*asset, error) {
+	result, err := smartContract.contract.EvaluateTransaction("GetAllAssets")
+	if err != nil {
+		return nil, err
+	}
+
+	assets := []*asset{}
+	if len(result) == 0 {
+		return assets, nil
+	}
+	if err := json.Unmarshal(result, &assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+func getAllAssets(gateway *client.Gateway) error {
+	contract := gateway.GetNetwork(channelName).GetContract(chaincodeName)
+	smartContract := &assetTransferBasic{contract: contract}
+
+	assets, err := smartContract.getAllAssets()
+	if err != nil {
+		return err
+	}
+
+	assetsJSON, err := json.MarshalIndent(assets, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(assetsJSON))
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// jsonStore is a store that keeps the ledger state in memory and saves it to a JSON file after each transaction.
+// It suits small amounts of data that are inspected by hand. Values are kept as bytes, which JSON encodes as base64,
+// since the value of a ledger key need not be JSON or even text.
+type jsonStore struct {
+	fileName string
+	state    *jsonStoreState
+}
+
+type jsonStoreState struct {
+	BlockNumber   uint64 `json:"blockNumber"`
+	TransactionID string `json:"transactionId"`
+	// Values of the keys by channel name, namespace and key.
+	Ledgers map[string]map[string]map[string][]byte `json:"ledgers"`
+}
+
+// newJSONStore creates a jsonStore, loading its state from the file if it exists.
+func newJSONStore(fileName string) (*jsonStore, error) {
+	s := &jsonStore{
+		fileName: fileName,
+		state:    &jsonStoreState{Ledgers: map[string]map[string]map[string][]byte{}},
+	}
+
+	data, err := os.ReadFile(fileName) //#nosec G304 -- Caller responsible for safe file name
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s.state); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *jsonStore) apply(update *ledgerUpdate) error {
+	if update.TransactionID == s.state.TransactionID {
+		return nil
+	}
+
+	for _, w := range update.Writes {
+		namespaces, ok := s.state.Ledgers[w.ChannelName]
+		if !ok {
+			namespaces = map[string]map[string][]byte{}
+			s.state.Ledgers[w.ChannelName] = namespaces
+		}
+		values, ok := namespaces[w.Namespace]
+		if !ok {
+			values = map[string][]byte{}
+			namespaces[w.Namespace] = values
+		}
+
+		if w.IsDelete {
+			delete(values, w.Key)
+		} else {
+			values[w.Key] = w.Value
+		}
+	}
+	s.state.BlockNumber = update.BlockNumber
+	s.state.TransactionID = update.TransactionID
+
+	return s.save()
+}
+
+// save replaces the file with the current state, writing to a temporary file first so that a failure never leaves
+// a partially written file behind.
+func (s *jsonStore) save() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(s.fileName), filepath.Base(s.fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.fileName)
+}
+
+func (s *jsonStore) close() error {
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+)
+
+const startBlock = 0
+
+var (
+	checkpointFile = envOrDefault("CHECKPOINT_FILE", "checkpoint.json")
+	storeType      = envOrDefault("STORE_TYPE", "json")
+	storeFile      = envOrDefault("STORE_FILE", "store."+storeType)
+)
+
+// Typically we should ignore read/write sets that apply to system chaincode namespaces.
+var systemChaincodeNames = map[string]bool{
+	"_lifecycle": true,
+	"cscc":       true,
+	"escc":       true,
+	"lscc":       true,
+	"qscc":       true,
+	"vscc":       true,
+}
+
+func listen(gateway *client.Gateway) error {
+	simulatedFailureCount, err := getSimulatedFailureCount()
+	if err != nil {
+		return err
+	}
+
+	offChainStore, err := newStore(storeType, storeFile)
+	if err != nil {
+		return err
+	}
+	defer offChainStore.close()
+
+	checkpointer, err := client.NewFileCheckpointer(checkpointFile)
+	if err != nil {
+		return err
+	}
+	defer checkpointer.Close()
+
+	network := gateway.GetNetwork(channelName)
+
+	fmt.Printf("Starting event listening from block %d\n", checkpointer.BlockNumber())
+	fmt.Println("Last processed transaction ID within block:", checkpointer.TransactionID())
+	fmt.Printf("Writing ledger updates to %s store %s\n", storeType, storeFile)
+	if simulatedFailureCount > 0 {
+		fmt.Printf("Simulating a write failure every %d transactions\n", simulatedFailureCount)
+	}
+
+	// Listen until interrupted using Control-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	blocks, err := network.BlockEvents(
+		ctx,
+		client.WithCheckpoint(checkpointer),
+		client.WithStartBlock(startBlock), // Used only if there is no checkpoint block number
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start block event listening: %w", err)
+	}
+
+	processor := &blockProcessor{
+		checkpointer: checkpointer,
+		store:        &failingStore{store: offChainStore, failureCount: simulatedFailureCount},
+	}
+	for blockProto := range blocks {
+		if err := processor.process(blockProto); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockProcessor applies the ledger updates of the valid transactions in a block to a store, checkpointing each
+// transaction and then the block once they have been stored.
+type blockProcessor struct {
+	checkpointer *client.FileCheckpointer
+	store        store
+}
+
+func (p *blockProcessor) process(blockProto *common.Block) error {
+	b, err := parseBlock(blockProto)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nReceived block %d\n", b.number)
+
+	transactions, err := p.newTransactions(b)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range transactions {
+		if !t.isValid() {
+			continue
+		}
+
+		if err := processTransaction(b.number, t, p.store); err != nil {
+			return err
+		}
+		if err := p.checkpointer.CheckpointTransaction(b.number, t.transactionID()); err != nil {
+			return err
+		}
+	}
+
+	return p.checkpointer.CheckpointBlock(b.number)
+}
+
+// newTransactions returns the transactions of a block following the last processed transaction.
+func (p *blockProcessor) newTransactions(b *block) ([]*transaction, error) {
+	lastTransactionID := p.checkpointer.TransactionID()
+	if lastTransactionID == "" || p.checkpointer.BlockNumber() != b.number {
+		// No previously processed transactions within this block so all are new
+		return b.transactions, nil
+	}
+
+	// Ignore transactions up to the last processed transaction ID
+	transactionIDs := []string{}
+	for i, t := range b.transactions {
+		if t.transactionID() == lastTransactionID {
+			return b.transactions[i+1:], nil
+		}
+		transactionIDs = append(transactionIDs, t.transactionID())
+	}
+
+	return nil, fmt.Errorf(
+		"checkpoint transaction ID %s not found in block %d containing transactions: %s",
+		lastTransactionID,
+		b.number,
+		strings.Join(transactionIDs, ", "),
+	)
+}
+
+// processTransaction applies the writes of a transaction outside the system chaincode namespaces to a store.
+func processTransaction(blockNumber uint64, t *transaction, s store) error {
+	channelName := t.channelHeader.GetChannelId()
+
+	writes := []*write{}
+	for _, readWriteSet := range t.namespaceReadWriteSets {
+		if systemChaincodeNames[readWriteSet.namespace] {
+			continue
+		}
+
+		for _, kvWrite := range readWriteSet.readWriteSet.GetWrites() {
+			writes = append(writes, &write{
+				ChannelName: channelName,
+				Namespace:   readWriteSet.namespace,
+				Key:         kvWrite.GetKey(),
+				IsDelete:    kvWrite.GetIsDelete(),
+				Value:       kvWrite.GetValue(),
+			})
+		}
+	}
+
+	if len(writes) == 0 {
+		fmt.Printf("Skipping read-only or system transaction %s\n", t.transactionID())
+		return nil
+	}
+
+	fmt.Printf("Process transaction %s\n", t.transactionID())
+
+	return s.apply(&ledgerUpdate{
+		BlockNumber:   blockNumber,
+		TransactionID: t.transactionID(),
+		Writes:        writes,
+	})
+}
+
+// failingStore wraps a store to simulate a write failure every failureCount transactions.
+type failingStore struct {
+	store
+	failureCount     int
+	transactionCount int
+}
+
+func (s *failingStore) apply(update *ledgerUpdate) error {
+	if s.failureCount > 0 {
+		if s.transactionCount >= s.failureCount {
+			s.transactionCount = 0
+			return &expectedError{message: "Simulated write failure"}
+		}
+		s.transactionCount++
+	}
+
+	return s.store.apply(update)
+}
+
+func getSimulatedFailureCount() (int, error) {
+	value := envOrDefault("SIMULATED_FAILURE_COUNT", "0")
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid SIMULATED_FAILURE_COUNT value: %s", value)
+	}
+
+	return count, nil
+}
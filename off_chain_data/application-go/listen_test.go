@@ -0,0 +1,122 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// recordingStore records the transaction IDs of the updates it applies.
+type recordingStore struct {
+	transactionIDs []string
+	keys           []string
+}
+
+func (s *recordingStore) apply(update *ledgerUpdate) error {
+	s.transactionIDs = append(s.transactionIDs, update.TransactionID)
+	for _, w := range update.Writes {
+		s.keys = append(s.keys, w.Namespace+"/"+w.Key)
+	}
+	return nil
+}
+
+func (s *recordingStore) close() error {
+	return nil
+}
+
+func newTestBlock(t *testing.T) *common.Block {
+	assetWrite := func(key string) map[string][]*kvrwset.KVWrite {
+		return map[string][]*kvrwset.KVWrite{"basic": {{Key: key, Value: []byte("{}")}}}
+	}
+	return newBlock(7, [][]byte{
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx1", assetWrite("asset1")),
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx2", assetWrite("asset2")),
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx3", assetWrite("asset3")),
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx4", map[string][]*kvrwset.KVWrite{
+			"_lifecycle": {{Key: "namespaces/fields/basic/Sequence"}},
+		}),
+		newEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, "tx5", assetWrite("asset5")),
+	}, []peer.TxValidationCode{
+		peer.TxValidationCode_VALID,
+		peer.TxValidationCode_VALID,
+		peer.TxValidationCode_MVCC_READ_CONFLICT,
+		peer.TxValidationCode_VALID,
+		peer.TxValidationCode_VALID,
+	})
+}
+
+func TestBlockProcessorResumesFromCheckpoint(t *testing.T) {
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpointer, err := client.NewFileCheckpointer(checkpointFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &recordingStore{}
+
+	// Fail on the second stored transaction
+	processor := &blockProcessor{checkpointer: checkpointer, store: &failingStore{store: recorder, failureCount: 1}}
+	err = processor.process(newTestBlock(t))
+	var expected *expectedError
+	if !errors.As(err, &expected) {
+		t.Fatalf("err = %v, want the simulated failure", err)
+	}
+	if err := checkpointer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a restart the block is delivered again
+	checkpointer, err = client.NewFileCheckpointer(checkpointFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpointer.Close()
+	if checkpointer.BlockNumber() != 7 || checkpointer.TransactionID() != "tx1" {
+		t.Fatalf("checkpoint is block %d transaction %s, want block 7 transaction tx1", checkpointer.BlockNumber(), checkpointer.TransactionID())
+	}
+
+	processor = &blockProcessor{checkpointer: checkpointer, store: recorder}
+	if err := processor.process(newTestBlock(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid transactions and writes to system chaincode namespaces are not stored
+	if want := []string{"tx1", "tx2", "tx5"}; !reflect.DeepEqual(recorder.transactionIDs, want) {
+		t.Errorf("stored transactions %v, want %v", recorder.transactionIDs, want)
+	}
+	if want := []string{"basic/asset1", "basic/asset2", "basic/asset5"}; !reflect.DeepEqual(recorder.keys, want) {
+		t.Errorf("stored keys %v, want %v", recorder.keys, want)
+	}
+	if checkpointer.BlockNumber() != 8 || checkpointer.TransactionID() != "" {
+		t.Errorf("checkpoint is block %d transaction %s, want block 8", checkpointer.BlockNumber(), checkpointer.TransactionID())
+	}
+}
+
+func TestBlockProcessorRejectsUnknownCheckpointTransaction(t *testing.T) {
+	checkpointer, err := client.NewFileCheckpointer(filepath.Join(t.TempDir(), "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpointer.Close()
+	if err := checkpointer.CheckpointTransaction(7, "unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := &blockProcessor{checkpointer: checkpointer, store: &recordingStore{}}
+	err = processor.process(newTestBlock(t))
+	want := "checkpoint transaction ID unknown not found in block 7 containing transactions: tx1, tx2, tx3, tx4, tx5"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+}
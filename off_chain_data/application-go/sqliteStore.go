@@ -0,0 +1,103 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS ledger_state (
+	channel_name   TEXT    NOT NULL,
+	namespace      TEXT    NOT NULL,
+	key            TEXT    NOT NULL,
+	value          BLOB,
+	block_number   INTEGER NOT NULL,
+	transaction_id TEXT    NOT NULL,
+	PRIMARY KEY (channel_name, namespace, key)
+);
+CREATE TABLE IF NOT EXISTS last_update (
+	id             INTEGER PRIMARY KEY CHECK (id = 0),
+	block_number   INTEGER NOT NULL,
+	transaction_id TEXT    NOT NULL
+);
+`
+
+// sqliteStore is a store backed by a SQLite database. The ledger_state table holds the current value of each key,
+// together with the block and transaction that last wrote it, and can be queried with SQL.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// newSQLiteStore opens the SQLite database in the file, creating the file and the tables if they do not exist.
+func newSQLiteStore(fileName string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite3", fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) apply(update *ledgerUpdate) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback fails after a successful commit
+
+	var lastTransactionID string
+	err = tx.QueryRow("SELECT transaction_id FROM last_update WHERE id = 0").Scan(&lastTransactionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if lastTransactionID == update.TransactionID {
+		return nil
+	}
+
+	for _, w := range update.Writes {
+		if w.IsDelete {
+			_, err = tx.Exec(
+				"DELETE FROM ledger_state WHERE channel_name = ? AND namespace = ? AND key = ?",
+				w.ChannelName, w.Namespace, w.Key,
+			)
+		} else {
+			_, err = tx.Exec(
+				`INSERT INTO ledger_state (channel_name, namespace, key, value, block_number, transaction_id)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (channel_name, namespace, key) DO UPDATE SET
+					value = excluded.value, block_number = excluded.block_number, transaction_id = excluded.transaction_id`,
+				w.ChannelName, w.Namespace, w.Key, w.Value, update.BlockNumber, update.TransactionID,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO last_update (id, block_number, transaction_id) VALUES (0, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET block_number = excluded.block_number, transaction_id = excluded.transaction_id`,
+		update.BlockNumber, update.TransactionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "fmt"
+
+// ledgerUpdate is the ledger update made by a specific transaction.
+type ledgerUpdate struct {
+	BlockNumber   uint64   `json:"blockNumber"`
+	TransactionID string   `json:"transactionId"`
+	Writes        []*write `json:"writes"`
+}
+
+// write describes a ledger write that can be applied to an off-chain data store.
+type write struct {
+	// Channel whose ledger is being updated.
+	ChannelName string `json:"channelName"`
+	// Namespace within the ledger.
+	Namespace string `json:"namespace"`
+	// Key name within the ledger namespace.
+	Key string `json:"key"`
+	// Whether the key and associated value are being deleted.
+	IsDelete bool `json:"isDelete"`
+	// If IsDelete is false, the value written to the key; otherwise ignored.
+	Value []byte `json:"value"`
+}
+
+// store is an off-chain data store holding the current value of the ledger keys.
+//
+// The listener checkpoints a transaction only after the store has applied its writes, so after a failure between
+// the two the same transaction is delivered again. Implementations therefore apply the writes of a transaction in a
+// single operation together with the ID of the transaction, and ignore an update whose transaction they applied last.
+type store interface {
+	// apply writes the updates of a transaction to the store.
+	apply(update *ledgerUpdate) error
+	// close releases the resources of the store.
+	close() error
+}
+
+// newStore opens the store of the given type, either json or sqlite, backed by the given file.
+func newStore(storeType string, fileName string) (store, error) {
+	switch storeType {
+	case "json":
+		return newJSONStore(fileName)
+	case "sqlite":
+		return newSQLiteStore(fileName)
+	default:
+		return nil, fmt.Errorf("invalid STORE_TYPE value: %s", storeType)
+	}
+}
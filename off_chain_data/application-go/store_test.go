@@ -0,0 +1,122 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// storeContents reads the values of the keys in the basic namespace of a store.
+var storeContents = map[string]func(t *testing.T, fileName string) map[string]string{
+	"json": func(t *testing.T, fileName string) map[string]string {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := &jsonStoreState{}
+		if err := json.Unmarshal(data, state); err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]string{}
+		for key, value := range state.Ledgers["mychannel"]["basic"] {
+			values[key] = string(value)
+		}
+		return values
+	},
+	"sqlite": func(t *testing.T, fileName string) map[string]string {
+		s, err := newSQLiteStore(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.close()
+
+		rows, err := s.db.Query("SELECT key, value FROM ledger_state WHERE channel_name = 'mychannel' AND namespace = 'basic'")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		values := map[string]string{}
+		for rows.Next() {
+			var key, value string
+			if err := rows.Scan(&key, &value); err != nil {
+				t.Fatal(err)
+			}
+			values[key] = value
+		}
+		return values
+	},
+}
+
+func newUpdate(blockNumber uint64, transactionID string, writes ...*write) *ledgerUpdate {
+	for _, w := range writes {
+		w.ChannelName = "mychannel"
+		w.Namespace = "basic"
+	}
+	return &ledgerUpdate{BlockNumber: blockNumber, TransactionID: transactionID, Writes: writes}
+}
+
+func TestStores(t *testing.T) {
+	for storeType, contents := range storeContents {
+		t.Run(storeType, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "store")
+
+			s, err := newStore(storeType, fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			updates := []*ledgerUpdate{
+				newUpdate(1, "tx1", &write{Key: "asset1", Value: []byte("red")}, &write{Key: "asset2", Value: []byte("blue")}),
+				newUpdate(2, "tx2", &write{Key: "asset1", Value: []byte("green")}),
+				newUpdate(3, "tx3", &write{Key: "asset4", Value: []byte{0xff, 0x00, 0xfe}}),
+			}
+			for _, update := range updates {
+				if err := s.apply(update); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// After a restart the store ignores the transaction it applied last, which is delivered again if
+			// the listener failed before checkpointing it
+			s, err = newStore(storeType, fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			updates = []*ledgerUpdate{
+				newUpdate(3, "tx3", &write{Key: "asset3", Value: []byte("red")}),
+				newUpdate(3, "tx4", &write{Key: "asset2", IsDelete: true}),
+			}
+			for _, update := range updates {
+				if err := s.apply(update); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Values are kept byte for byte, even if they are not valid UTF-8
+			want := map[string]string{"asset1": "green", "asset4": "\xff\x00\xfe"}
+			if got := contents(t, fileName); !reflect.DeepEqual(got, want) {
+				t.Errorf("store contains %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewStoreRejectsUnknownType(t *testing.T) {
+	if _, err := newStore("csv", "store.csv"); err == nil || err.Error() != "invalid STORE_TYPE value: csv" {
+		t.Errorf("err = %v, want invalid STORE_TYPE value: csv", err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+const (
+	batchSize       = 10
+	maxInitialValue = 1000
+	maxInitialSize  = 10
+)
+
+var (
+	colors = []string{"red", "green", "blue"}
+	owners = []string{"alice", "bob", "charlie"}
+)
+
+func transact(gateway *client.Gateway) error {
+	contract := gateway.GetNetwork(channelName).GetContract(chaincodeName)
+	smartContract := &assetTransferBasic{contract: contract}
+
+	// Run the transactions concurrently, then fail only once all of them have completed.
+	var wg sync.WaitGroup
+	errs := make([]error, batchSize)
+	for i := 0; i < batchSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = transactAsset(smartContract)
+		}(i)
+	}
+	wg.Wait()
+
+	failures := []string{}
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, " - "+err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d failures:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func transactAsset(smartContract *assetTransferBasic) error {
+	asset := newAsset()
+
+	if err := smartContract.createAsset(asset); err != nil {
+		return err
+	}
+	fmt.Printf("Created asset %s\n", asset.ID)
+
+	// Transfer randomly 1 in 2 assets to a new owner.
+	if randomInt(2) == 0 {
+		newOwner := differentElement(owners, asset.Owner)
+		oldOwner, err := smartContract.transferAsset(asset.ID, newOwner)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Transferred asset %s from %s to %s\n", asset.ID, oldOwner, newOwner)
+	}
+
+	// Delete randomly 1 in 4 created assets.
+	if randomInt(4) == 0 {
+		if err := smartContract.deleteAsset(asset.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted asset %s\n", asset.ID)
+	}
+
+	return nil
+}
+
+func newAsset() *asset {
+	return &asset{
+		ID:             newID(),
+		Color:          randomElement(colors),
+		Size:           randomInt(maxInitialSize) + 1,
+		Owner:          randomElement(owners),
+		AppraisedValue: randomInt(maxInitialValue) + 1,
+	}
+}
+
+// newID returns a random version 4 UUID.
+func newID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// randomElement picks a random element from a slice.
+func randomElement(values []string) string {
+	return values[randomInt(len(values))]
+}
+
+// randomInt generates a random integer in the range 0 to max - 1.
+func randomInt(max int) int {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		panic(err)
+	}
+	return int(n.Int64())
+}
+
+// differentElement picks a random element from a slice, excluding the current value.
+func differentElement(values []string, currentValue string) string {
+	candidateValues := []string{}
+	for _, value := range values {
+		if value != currentValue {
+			candidateValues = append(candidateValues, value)
+		}
+	}
+	return randomElement(candidateValues)
+}
@@ -0,0 +1,320 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Define objectType names for prefix
+const holdPrefix = "hold"
+
+// Hold describes tokens that have been taken out of an account and locked on behalf of
+// another chaincode. Only the chaincode that created the hold (the operator) can release
+// or refund it, which lets contracts such as an auction escrow funds on the same channel
+type Hold struct {
+	Owner    string `json:"owner"`
+	Operator string `json:"operator"`
+	Amount   int    `json:"amount"`
+}
+
+// HoldFunds moves amount tokens from the client account into a hold identified by holdID.
+// The function has to be called by another chaincode, which becomes the operator of the hold
+func (s *SmartContract) HoldFunds(ctx contractapi.TransactionContextInterface, holdID string, amount int) error {
+
+	//check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract ia already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	if amount <= 0 {
+		return fmt.Errorf("hold amount must be a positive integer")
+	}
+
+	operator, err := getCallingChaincode(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Get ID of submitting client identity
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client id: %v", err)
+	}
+
+	holdKey, err := ctx.GetStub().CreateCompositeKey(holdPrefix, []string{operator, holdID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", holdPrefix, err)
+	}
+
+	holdBytes, err := ctx.GetStub().GetState(holdKey)
+	if err != nil {
+		return fmt.Errorf("failed to read hold %s from world state: %v", holdID, err)
+	}
+	if holdBytes != nil {
+		return fmt.Errorf("hold %s already exists", holdID)
+	}
+
+	balanceBytes, err := ctx.GetStub().GetState(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to read client account %s from world state: %v", clientID, err)
+	}
+	if balanceBytes == nil {
+		return fmt.Errorf("client account %s has no balance", clientID)
+	}
+
+	balance, _ := strconv.Atoi(string(balanceBytes)) // Error handling not needed since Itoa() was used when setting the account balance, guaranteeing it was an integer.
+
+	if balance < amount {
+		return fmt.Errorf("client account %s has insufficient funds", clientID)
+	}
+
+	updatedBalance, err := sub(balance, amount)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(clientID, []byte(strconv.Itoa(updatedBalance)))
+	if err != nil {
+		return err
+	}
+
+	hold := Hold{
+		Owner:    clientID,
+		Operator: operator,
+		Amount:   amount,
+	}
+	holdJSON, err := json.Marshal(hold)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(holdKey, holdJSON)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("client %s placed hold %s of %d for operator %s", clientID, holdID, amount, operator)
+
+	return nil
+}
+
+// ReleaseHold pays amount tokens from the hold to the recipient account and returns the
+// remainder of the hold to its owner. Only the operator of the hold can release it
+func (s *SmartContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string, recipient string, amount int) error {
+
+	//check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract ia already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	if amount < 0 {
+		return fmt.Errorf("release amount cannot be negative")
+	}
+
+	return releaseHoldHelper(ctx, holdID, recipient, amount)
+}
+
+// RefundHold returns all tokens of the hold to its owner. Only the operator of the hold can refund it
+func (s *SmartContract) RefundHold(ctx contractapi.TransactionContextInterface, holdID string) error {
+
+	//check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if contract ia already initialized: %v", err)
+	}
+	if !initialized {
+		return fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	return releaseHoldHelper(ctx, holdID, "", 0)
+}
+
+// HoldOf returns the hold that the operator chaincode created under holdID
+func (s *SmartContract) HoldOf(ctx contractapi.TransactionContextInterface, operator string, holdID string) (*Hold, error) {
+
+	//check if contract has been intilized first
+	initialized, err := checkInitialized(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
+	}
+	if !initialized {
+		return nil, fmt.Errorf("Contract options need to be set before calling any function, call Initialize() to initialize contract")
+	}
+
+	holdKey, err := ctx.GetStub().CreateCompositeKey(holdPrefix, []string{operator, holdID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the composite key for prefix %s: %v", holdPrefix, err)
+	}
+
+	return readHold(ctx, holdKey, holdID)
+}
+
+// releaseHoldHelper pays amount from the hold to the recipient, refunds the rest to the
+// owner and removes the hold. Dependant functions include ReleaseHold and RefundHold
+func releaseHoldHelper(ctx contractapi.TransactionContextInterface, holdID string, recipient string, amount int) error {
+
+	operator, err := getCallingChaincode(ctx)
+	if err != nil {
+		return err
+	}
+
+	// holds are keyed by their operator, so a chaincode can only find the holds it created
+	holdKey, err := ctx.GetStub().CreateCompositeKey(holdPrefix, []string{operator, holdID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", holdPrefix, err)
+	}
+
+	hold, err := readHold(ctx, holdKey, holdID)
+	if err != nil {
+		return err
+	}
+
+	if amount > hold.Amount {
+		return fmt.Errorf("release amount %d exceeds hold %s of %d", amount, holdID, hold.Amount)
+	}
+
+	if amount > 0 {
+		err = creditAccount(ctx, recipient, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	remainder, err := sub(hold.Amount, amount)
+	if err != nil {
+		return err
+	}
+
+	if remainder > 0 {
+		err = creditAccount(ctx, hold.Owner, remainder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ctx.GetStub().DelState(holdKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete hold %s: %v", holdID, err)
+	}
+
+	log.Printf("operator %s released hold %s: %d to %s, %d back to %s", operator, holdID, amount, recipient, remainder, hold.Owner)
+
+	return nil
+}
+
+// readHold reads and unmarshals the hold stored under holdKey
+func readHold(ctx contractapi.TransactionContextInterface, holdKey string, holdID string) (*Hold, error) {
+
+	holdBytes, err := ctx.GetStub().GetState(holdKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hold %s from world state: %v", holdID, err)
+	}
+	if holdBytes == nil {
+		return nil, fmt.Errorf("hold %s does not exist", holdID)
+	}
+
+	var hold Hold
+	err = json.Unmarshal(holdBytes, &hold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal hold %s: %v", holdID, err)
+	}
+
+	return &hold, nil
+}
+
+// creditAccount adds value to the balance of account, creating the account if needed
+func creditAccount(ctx contractapi.TransactionContextInterface, account string, value int) error {
+
+	if account == "" {
+		return fmt.Errorf("recipient account must be a non-empty string")
+	}
+
+	currentBalanceBytes, err := ctx.GetStub().GetState(account)
+	if err != nil {
+		return fmt.Errorf("failed to read recipient account %s from world state: %v", account, err)
+	}
+
+	var currentBalance int
+	// If recipient current balance doesn't yet exist, we'll create it with a current balance of 0
+	if currentBalanceBytes != nil {
+		currentBalance, _ = strconv.Atoi(string(currentBalanceBytes)) // Error handling not needed since Itoa() was used when setting the account balance, guaranteeing it was an integer.
+	}
+
+	updatedBalance, err := add(currentBalance, value)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(account, []byte(strconv.Itoa(updatedBalance)))
+}
+
+// getCallingChaincode returns the name of the chaincode that the client invoked when this
+// contract is reached through a chaincode-to-chaincode call. A call coming straight from a
+// client carries the same arguments as the proposal and is rejected
+func getCallingChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	if signedProposal == nil {
+		return "", fmt.Errorf("signed proposal is not available")
+	}
+
+	proposal := &peer.Proposal{}
+	err = proto.Unmarshal(signedProposal.ProposalBytes, proposal)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal: %v", err)
+	}
+
+	payload := &peer.ChaincodeProposalPayload{}
+	err = proto.Unmarshal(proposal.Payload, payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal payload: %v", err)
+	}
+
+	invocationSpec := &peer.ChaincodeInvocationSpec{}
+	err = proto.Unmarshal(payload.Input, invocationSpec)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal chaincode invocation spec: %v", err)
+	}
+
+	spec := invocationSpec.GetChaincodeSpec()
+	if spec.GetChaincodeId().GetName() == "" {
+		return "", fmt.Errorf("proposal does not name the invoked chaincode")
+	}
+
+	if equalArgs(spec.GetInput().GetArgs(), ctx.GetStub().GetArgs()) {
+		return "", fmt.Errorf("holds can only be managed by another chaincode")
+	}
+
+	return spec.GetChaincodeId().GetName(), nil
+}
+
+// equalArgs reports whether two argument lists are identical
+func equalArgs(a [][]byte, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
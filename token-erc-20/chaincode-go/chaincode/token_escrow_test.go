@@ -0,0 +1,160 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-samples/token-erc-20/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/token-erc-20/chaincode-go/chaincode/mocks"
+)
+
+//go:generate counterfeiter -o mocks/transaction.go -fake-name TransactionContext . transactionContext
+type transactionContext interface {
+	contractapi.TransactionContextInterface
+}
+
+//go:generate counterfeiter -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+//go:generate counterfeiter -o mocks/clientIdentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+const bidder = "eDUwOTo6Q049YmlkZGVy"
+const seller = "eDUwOTo6Q049c2VsbGVy"
+const auctionChaincode = "auction"
+
+// prepMocks returns a transaction context backed by an in-memory world state in which
+// the client invoked callingChaincode with callingArgs
+func prepMocks(clientID string, callingChaincode string, callingArgs ...string) (*mocks.TransactionContext, *mocks.ChaincodeStub, map[string][]byte) {
+	worldState := map[string][]byte{"name": []byte("token")}
+
+	chaincodeStub := &mocks.ChaincodeStub{}
+	chaincodeStub.GetStateStub = func(key string) ([]byte, error) {
+		return worldState[key], nil
+	}
+	chaincodeStub.PutStateStub = func(key string, value []byte) error {
+		worldState[key] = value
+		return nil
+	}
+	chaincodeStub.DelStateStub = func(key string) error {
+		delete(worldState, key)
+		return nil
+	}
+	chaincodeStub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+		return objectType + "~" + strings.Join(attributes, "~"), nil
+	}
+
+	args := [][]byte{}
+	for _, arg := range callingArgs {
+		args = append(args, []byte(arg))
+	}
+	payloadInput, _ := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: callingChaincode},
+			Input:       &peer.ChaincodeInput{Args: args},
+		},
+	})
+	payload, _ := proto.Marshal(&peer.ChaincodeProposalPayload{Input: payloadInput})
+	proposalBytes, _ := proto.Marshal(&peer.Proposal{Payload: payload})
+	chaincodeStub.GetSignedProposalReturns(&peer.SignedProposal{ProposalBytes: proposalBytes}, nil)
+
+	clientIdentity := &mocks.ClientIdentity{}
+	clientIdentity.GetIDReturns(clientID, nil)
+
+	transactionContext := &mocks.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+	transactionContext.GetClientIdentityReturns(clientIdentity)
+
+	return transactionContext, chaincodeStub, worldState
+}
+
+func TestHoldFunds(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(bidder, auctionChaincode, "SubmitEscrowedBid", "auction1", "tx1", "50")
+	chaincodeStub.GetArgsReturns([][]byte{[]byte("HoldFunds"), []byte("auction1_tx1"), []byte("50")})
+	tokenCC := chaincode.SmartContract{}
+
+	err := tokenCC.HoldFunds(transactionContext, "auction1_tx1", 50)
+	require.EqualError(t, err, "client account "+bidder+" has no balance")
+
+	worldState[bidder] = []byte("40")
+	err = tokenCC.HoldFunds(transactionContext, "auction1_tx1", 50)
+	require.EqualError(t, err, "client account "+bidder+" has insufficient funds")
+
+	err = tokenCC.HoldFunds(transactionContext, "auction1_tx1", 0)
+	require.EqualError(t, err, "hold amount must be a positive integer")
+
+	worldState[bidder] = []byte("100")
+	err = tokenCC.HoldFunds(transactionContext, "auction1_tx1", 50)
+	require.NoError(t, err)
+	require.Equal(t, "50", string(worldState[bidder]))
+
+	hold, err := tokenCC.HoldOf(transactionContext, auctionChaincode, "auction1_tx1")
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.Hold{Owner: bidder, Operator: auctionChaincode, Amount: 50}, hold)
+
+	err = tokenCC.HoldFunds(transactionContext, "auction1_tx1", 50)
+	require.EqualError(t, err, "hold auction1_tx1 already exists")
+}
+
+func TestHoldFundsRejectsDirectInvocation(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(bidder, "token_erc20", "HoldFunds", "auction1_tx1", "50")
+	chaincodeStub.GetArgsReturns([][]byte{[]byte("HoldFunds"), []byte("auction1_tx1"), []byte("50")})
+	worldState[bidder] = []byte("100")
+	tokenCC := chaincode.SmartContract{}
+
+	err := tokenCC.HoldFunds(transactionContext, "auction1_tx1", 50)
+	require.EqualError(t, err, "holds can only be managed by another chaincode")
+	require.Equal(t, "100", string(worldState[bidder]))
+}
+
+func TestReleaseHold(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(seller, auctionChaincode, "EndAuction", "auction1")
+	chaincodeStub.GetArgsReturns([][]byte{[]byte("ReleaseHold")})
+	holdJSON, err := json.Marshal(chaincode.Hold{Owner: bidder, Operator: auctionChaincode, Amount: 50})
+	require.NoError(t, err)
+	worldState["hold~"+auctionChaincode+"~auction1_tx1"] = holdJSON
+	worldState["hold~otherChaincode~auction1_tx2"] = holdJSON
+	tokenCC := chaincode.SmartContract{}
+
+	err = tokenCC.ReleaseHold(transactionContext, "auction1_tx1", seller, 60)
+	require.EqualError(t, err, "release amount 60 exceeds hold auction1_tx1 of 50")
+
+	// holds created by another chaincode are not visible to the caller
+	err = tokenCC.ReleaseHold(transactionContext, "auction1_tx2", seller, 10)
+	require.EqualError(t, err, "hold auction1_tx2 does not exist")
+
+	err = tokenCC.ReleaseHold(transactionContext, "auction1_tx1", seller, 30)
+	require.NoError(t, err)
+	require.Equal(t, "30", string(worldState[seller]))
+	require.Equal(t, "20", string(worldState[bidder]))
+	require.NotContains(t, worldState, "hold~"+auctionChaincode+"~auction1_tx1")
+}
+
+func TestRefundHold(t *testing.T) {
+	transactionContext, chaincodeStub, worldState := prepMocks(seller, auctionChaincode, "EndAuction", "auction1")
+	chaincodeStub.GetArgsReturns([][]byte{[]byte("RefundHold")})
+	holdJSON, err := json.Marshal(chaincode.Hold{Owner: bidder, Operator: auctionChaincode, Amount: 50})
+	require.NoError(t, err)
+	worldState["hold~"+auctionChaincode+"~auction1_tx1"] = holdJSON
+	worldState[bidder] = []byte("5")
+	tokenCC := chaincode.SmartContract{}
+
+	err = tokenCC.RefundHold(transactionContext, "auction1_tx1")
+	require.NoError(t, err)
+	require.Equal(t, "55", string(worldState[bidder]))
+
+	err = tokenCC.RefundHold(transactionContext, "auction1_tx1")
+	require.EqualError(t, err, "hold auction1_tx1 does not exist")
+}